@@ -62,6 +62,10 @@ func streamCompletions(m model, val string) tea.Cmd {
 					newChunk += openAiCompletion.Choices[0].Delta.Content
 					m.completionChunkSub <- newChunk
 				}
+			} else if message, ok := (completion).(string); ok {
+				// node message, e.g. about the failover
+				newChunk += "\n" + message + "\n"
+				m.completionChunkSub <- newChunk
 			} else {
 				prodiaCompletion := (completion).(map[string]interface{})
 				newChunk += prodiaCompletion["imageUrl"].(string)
@@ -6,8 +6,10 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"math/big"
 	"net/http"
+	"net/url"
 	"strings"
 
 	"github.com/ethereum/go-ethereum/common"
@@ -29,32 +31,129 @@ func NewApiGatewayClient(baseURL string, httpClient *http.Client) *ApiGatewayCli
 type ApiGatewayClient struct {
 	BaseURL    string
 	HttpClient *http.Client
+	Account    string // named wallet account of the node used by the requests, node default if empty
+	Token      string // api token sent as bearer token, required when the node api auth is enabled
 }
 
-// Helper function to make GET requests
-func (c *ApiGatewayClient) getRequest(ctx context.Context, endpoint string, result interface{}) error {
-	req, err := http.NewRequestWithContext(ctx, "GET", c.BaseURL+endpoint, nil)
+// HeaderAccount selects the named wallet account of the node
+const HeaderAccount = "X-Account"
+
+// maxStreamLineSize limits the size of a single chunk of the completion stream, image results are large
+const maxStreamLineSize = 4 * 1024 * 1024
+
+func (c *ApiGatewayClient) setHeaders(req *http.Request) {
+	if c.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.Token)
+	}
+	if c.Account != "" {
+		req.Header.Set(HeaderAccount, c.Account)
+	}
+}
 
+// ApiError is returned when the node api responds with a non 2xx status
+type ApiError struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *ApiError) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("unexpected status code: %d", e.StatusCode)
+	}
+	return fmt.Sprintf("unexpected status code: %d, response: %s", e.StatusCode, e.Message)
+}
+
+func newApiError(resp *http.Response) *ApiError {
+	apiErr := &ApiError{StatusCode: resp.StatusCode}
+	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return err
+		return apiErr
+	}
+
+	var errResp struct {
+		Error json.RawMessage `json:"error"`
+	}
+	if json.Unmarshal(body, &errResp) == nil && len(errResp.Error) > 0 {
+		var msg string
+		if json.Unmarshal(errResp.Error, &msg) == nil {
+			apiErr.Message = msg
+			return apiErr
+		}
+		// openai style error object
+		var obj struct {
+			Message string `json:"message"`
+		}
+		if json.Unmarshal(errResp.Error, &obj) == nil && obj.Message != "" {
+			apiErr.Message = obj.Message
+			return apiErr
+		}
+	}
+	apiErr.Message = strings.TrimSpace(string(body))
+	return apiErr
+}
+
+// sendRequest sends the json encoded body, if not nil, and returns the response with a 2xx status
+func (c *ApiGatewayClient) sendRequest(ctx context.Context, method string, endpoint string, body interface{}) (*http.Response, error) {
+	var reader io.Reader
+	if body != nil {
+		reqBody, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+		reader = bytes.NewReader(reqBody)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.BaseURL+endpoint, reader)
+	if err != nil {
+		return nil, err
 	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	c.setHeaders(req)
 
 	resp, err := c.HttpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		defer resp.Body.Close()
+		return nil, newApiError(resp)
+	}
+	return resp, nil
+}
 
+// doRequest decodes the json response into result, if not nil. Numbers are decoded as json.Number
+// to keep the wei amounts precise
+func (c *ApiGatewayClient) doRequest(ctx context.Context, method string, endpoint string, body interface{}, result interface{}) error {
+	resp, err := c.sendRequest(ctx, method, endpoint, body)
 	if err != nil {
-		fmt.Println("http client error: ", err)
 		return err
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		fmt.Printf("unexpected status code: %d", resp.StatusCode)
-		return fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	if result == nil {
+		return nil
 	}
-	return json.NewDecoder(resp.Body).Decode(result)
+
+	decoder := json.NewDecoder(resp.Body)
+	decoder.UseNumber()
+	return decoder.Decode(result)
 }
 
-func (c *ApiGatewayClient) requestChatCompletionStream(ctx context.Context, endpoint string, request *openai.ChatCompletionRequest, callback CompletionCallback, modelId string, sessionId string) (*openai.ChatCompletionStreamResponse, error) {
+// Helper function to make GET requests
+func (c *ApiGatewayClient) getRequest(ctx context.Context, endpoint string, result interface{}) error {
+	return c.doRequest(ctx, http.MethodGet, endpoint, nil, result)
+}
+
+// Helper function to make DELETE requests
+func (c *ApiGatewayClient) deleteRequest(ctx context.Context, endpoint string, result interface{}) error {
+	return c.doRequest(ctx, http.MethodDelete, endpoint, nil, result)
+}
+
+// requestChatCompletionStream passes the stream chunks to the callback: *openai.ChatCompletionStreamResponse
+// for completions, string for node messages, e.g. about the failover, and map[string]interface{} for other chunks
+func (c *ApiGatewayClient) requestChatCompletionStream(ctx context.Context, endpoint string, request *openai.ChatCompletionRequest, callback CompletionCallback, modelId string, sessionId string, chatId string) (*openai.ChatCompletionStreamResponse, error) {
 	requestBody, err := json.Marshal(request)
 	if err != nil {
 		return nil, fmt.Errorf("failed to encode request: %v", err)
@@ -64,12 +163,16 @@ func (c *ApiGatewayClient) requestChatCompletionStream(ctx context.Context, endp
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %v", err)
 	}
+	c.setHeaders(req)
 
 	if sessionId != "" {
 		req.Header.Set("session_id", sessionId)
 	} else if modelId != "" {
 		req.Header.Set("model_id", modelId)
 	}
+	if chatId != "" {
+		req.Header.Set("chat_id", chatId)
+	}
 
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("Accept", "application/json")
@@ -82,7 +185,12 @@ func (c *ApiGatewayClient) requestChatCompletionStream(ctx context.Context, endp
 	}
 	defer resp.Body.Close()
 
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, newApiError(resp)
+	}
+
 	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxStreamLineSize)
 	for scanner.Scan() {
 		line := scanner.Text()
 		// Handle the completion of the stream
@@ -100,23 +208,44 @@ func (c *ApiGatewayClient) requestChatCompletionStream(ctx context.Context, endp
 			return completion, nil
 		}
 
-		if strings.HasPrefix(line, "data: ") {
-			data := line[6:] // Skip the "data: " prefix
-			var completion *openai.ChatCompletionStreamResponse
-			if err := json.Unmarshal([]byte(data), &completion); err != nil {
-				fmt.Printf("Error decoding response: %v\n", err)
+		// the node replies with the error after the stream chunks if the prompt fails midway
+		if strings.HasPrefix(line, `{"error"`) {
+			apiErr := &ApiError{StatusCode: http.StatusInternalServerError}
+			var errResp struct {
+				Error string `json:"error"`
+			}
+			if json.Unmarshal([]byte(line), &errResp) == nil {
+				apiErr.Message = errResp.Error
+			}
+			return nil, apiErr
+		}
+
+		if !strings.HasPrefix(line, "data: ") {
+			continue
+		}
+
+		data := []byte(line[6:]) // Skip the "data: " prefix
+		var message string
+		if json.Unmarshal(data, &message) == nil {
+			err = callback(message)
+		} else {
+			var completion openai.ChatCompletionStreamResponse
+			if err := json.Unmarshal(data, &completion); err != nil {
+				return nil, fmt.Errorf("error decoding response: %v", err)
 			}
 
 			if completion.ID != "" {
-				callback(completion)
+				err = callback(&completion)
 			} else {
 				var completion map[string]interface{}
-				if err := json.Unmarshal([]byte(data), &completion); err != nil {
-					fmt.Printf("Error decoding response: %v\n", err)
+				if err := json.Unmarshal(data, &completion); err != nil {
+					return nil, fmt.Errorf("error decoding response: %v", err)
 				}
-				callback(completion)
+				err = callback(completion)
 			}
-
+		}
+		if err != nil {
+			return nil, err
 		}
 	}
 
@@ -124,47 +253,12 @@ func (c *ApiGatewayClient) requestChatCompletionStream(ctx context.Context, endp
 		return nil, fmt.Errorf("error reading stream: %v", err)
 	}
 
-	return nil, err
+	return nil, nil
 }
 
 // Helper function to make POST requests
 func (c *ApiGatewayClient) postRequest(ctx context.Context, endpoint string, body interface{}, result interface{}) error {
-	reqBody, err := json.Marshal(body)
-	if err != nil {
-		return err
-	}
-
-	reader := bytes.NewReader(reqBody)
-
-	req, err := http.NewRequestWithContext(ctx, "POST", c.BaseURL+endpoint, reader)
-	if err != nil {
-		return err
-	}
-	resp, err := c.HttpClient.Do(req)
-	if err != nil {
-		return err
-	}
-
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		var errResp map[string]interface{}
-		err = json.NewDecoder(resp.Body).Decode(&errResp)
-		if err != nil {
-			return fmt.Errorf("unexpected status code: %d", resp.StatusCode)
-		}
-		return fmt.Errorf("unexpected status code: %d, response: %s", resp.StatusCode, errResp["error"])
-	}
-
-	if resp == nil {
-		return nil
-	}
-
-	if result != nil {
-		err = json.NewDecoder(resp.Body).Decode(result)
-	}
-
-	return err
+	return c.doRequest(ctx, http.MethodPost, endpoint, body, result)
 }
 
 func (c *ApiGatewayClient) GetProxyRouterConfig(ctx context.Context) (interface{}, error) {
@@ -173,13 +267,20 @@ func (c *ApiGatewayClient) GetProxyRouterConfig(ctx context.Context) (interface{
 	return result, err
 }
 
-func (c *ApiGatewayClient) GetProxyRouterFiles(ctx context.Context) (interface{}, error) {
-	var result map[string]interface{}
-	err := c.getRequest(ctx, "/files", &result)
+// GetProxyRouterFiles returns the open file descriptors of the node as plain text table
+func (c *ApiGatewayClient) GetProxyRouterFiles(ctx context.Context) (string, error) {
+	resp, err := c.sendRequest(ctx, http.MethodGet, "/files", nil)
 	if err != nil {
-		return nil, fmt.Errorf("internal error: %v", err)
+		return "", fmt.Errorf("internal error: %w", err)
 	}
-	return result, nil
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+	// the table is followed by an empty json object
+	return strings.TrimSuffix(strings.TrimSpace(string(body)), "{}"), nil
 }
 
 func (c *ApiGatewayClient) HealthCheck(ctx context.Context) (interface{}, error) {
@@ -189,12 +290,12 @@ func (c *ApiGatewayClient) HealthCheck(ctx context.Context) (interface{}, error)
 	return result, err
 }
 
-func (c *ApiGatewayClient) InitiateSession(ctx context.Context) (interface{}, error) {
+func (c *ApiGatewayClient) InitiateSession(ctx context.Context, req *InitiateSessionRequest) (interface{}, error) {
 	var result map[string]interface{}
-	err := c.postRequest(ctx, "/proxy/sessions/initiate", nil, &result)
+	err := c.postRequest(ctx, "/proxy/sessions/initiate", req, &result)
 
 	if err != nil {
-		return nil, fmt.Errorf("internal error: %v", err)
+		return nil, fmt.Errorf("internal error: %w", err)
 	}
 
 	return result, nil
@@ -211,11 +312,17 @@ func (c *ApiGatewayClient) PromptStream(ctx context.Context, message string, mes
 		Stream:   true,
 	}
 
-	return c.requestChatCompletionStream(ctx, "/v1/chat/completions", request, flush, modelId, sessionId)
+	return c.PromptStreamRequest(ctx, request, modelId, sessionId, "", flush)
 }
 
-func (c *ApiGatewayClient) GetLatestBlock(ctx context.Context) (result uint64, err error) {
+// PromptStreamRequest streams the completion of the request from the model or session, chatId continues the
+// chat stored by the node, a new chat is started if it is empty
+func (c *ApiGatewayClient) PromptStreamRequest(ctx context.Context, request *openai.ChatCompletionRequest, modelId string, sessionId string, chatId string, flush CompletionCallback) (interface{}, error) {
+	request.Stream = true
+	return c.requestChatCompletionStream(ctx, "/v1/chat/completions", request, flush, modelId, sessionId, chatId)
+}
 
+func (c *ApiGatewayClient) GetLatestBlock(ctx context.Context) (result map[string]interface{}, err error) {
 	err = c.getRequest(ctx, "/blockchain/latestBlock", &result)
 	return result, err
 }
@@ -225,7 +332,7 @@ func (c *ApiGatewayClient) GetAllProviders(ctx context.Context) (result map[stri
 	err = c.getRequest(ctx, "/blockchain/providers", &result)
 
 	if err != nil {
-		return nil, fmt.Errorf("internal error: %v", err)
+		return nil, fmt.Errorf("internal error: %w", err)
 	}
 
 	return result, nil
@@ -240,7 +347,7 @@ func (c *ApiGatewayClient) CreateNewProvider(ctx context.Context, addStake uint6
 	err = c.postRequest(ctx, "/blockchain/providers", &request, &result)
 
 	if err != nil {
-		return nil, fmt.Errorf("internal error: %v", err)
+		return nil, fmt.Errorf("internal error: %w", err)
 	}
 
 	return result, nil
@@ -258,7 +365,7 @@ func (c *ApiGatewayClient) CreateNewModel(ctx context.Context, name string, ipfs
 	err = c.postRequest(ctx, "/blockchain/models", &request, &result)
 
 	if err != nil {
-		return nil, fmt.Errorf("internal error: %v", err)
+		return nil, fmt.Errorf("internal error: %w", err)
 	}
 
 	return result, nil
@@ -273,7 +380,7 @@ func (c *ApiGatewayClient) CreateNewProviderBid(ctx context.Context, model strin
 	err = c.postRequest(ctx, "/blockchain/bids", &request, &result)
 
 	if err != nil {
-		return nil, fmt.Errorf("internal error: %v", err)
+		return nil, fmt.Errorf("internal error: %w", err)
 	}
 
 	return result, nil
@@ -284,7 +391,7 @@ func (c *ApiGatewayClient) GetAllModels(ctx context.Context) (result map[string]
 	err = c.getRequest(ctx, "/blockchain/models", &result)
 
 	if err != nil {
-		return nil, fmt.Errorf("internal error: %v", err)
+		return nil, fmt.Errorf("internal error: %w", err)
 	}
 
 	return result, nil
@@ -294,7 +401,7 @@ func (c *ApiGatewayClient) GetBidsByProvider(ctx context.Context, providerAddr s
 	endpoint := fmt.Sprintf("/blockchain/providers/%s/bids?offset=%s&limit=%d", providerAddr, offset.String(), limit)
 	err = c.getRequest(ctx, endpoint, &bids)
 	if err != nil {
-		return nil, fmt.Errorf("internal error: %v", err)
+		return nil, fmt.Errorf("internal error: %w", err)
 	}
 	return bids, err
 }
@@ -305,7 +412,7 @@ func (c *ApiGatewayClient) GetBidsByModelAgent(ctx context.Context, modelAgentId
 	err = c.getRequest(ctx, endpoint, &result)
 
 	if err != nil {
-		return nil, fmt.Errorf("internal error: %v", err)
+		return nil, fmt.Errorf("internal error: %w", err)
 	}
 
 	return result, err
@@ -316,7 +423,7 @@ func (c *ApiGatewayClient) ListUserSessions(ctx context.Context, user string) (r
 
 	err = c.getRequest(ctx, fmt.Sprintf("/blockchain/sessions/user?user=%s", user), &response)
 	if err != nil {
-		return nil, fmt.Errorf("internal error: %v", err)
+		return nil, fmt.Errorf("internal error: %w", err)
 	}
 
 	return response["sessions"], nil
@@ -327,18 +434,18 @@ func (c *ApiGatewayClient) ListProviderSessions(ctx context.Context, provider st
 
 	err = c.getRequest(ctx, fmt.Sprintf("/blockchain/sessions/provider?provider=%s", provider), &response)
 	if err != nil {
-		return nil, fmt.Errorf("internal error: %v", err)
+		return nil, fmt.Errorf("internal error: %w", err)
 	}
 
 	return response["sessions"], nil
 }
 
 func (c *ApiGatewayClient) OpenStakeSession(ctx context.Context, req *SessionStakeRequest) (session *Session, err error) {
-
+	session = &Session{}
 	err = c.postRequest(ctx, "/blockchain/sessions", req, session)
 
 	if err != nil {
-		return nil, fmt.Errorf("internal error: %v", err)
+		return nil, fmt.Errorf("internal error: %w", err)
 	}
 
 	return session, nil
@@ -350,7 +457,7 @@ func (c *ApiGatewayClient) OpenSession(ctx context.Context, req *SessionRequest)
 	err = c.postRequest(ctx, fmt.Sprintf("/blockchain/models/%s/session", req.ModelId), req, session)
 
 	if err != nil {
-		return nil, fmt.Errorf("internal error: %v", err)
+		return nil, fmt.Errorf("internal error: %w", err)
 	}
 
 	return session, nil
@@ -360,21 +467,18 @@ func (c *ApiGatewayClient) GetLocalModels(ctx context.Context) (models *[]interf
 	err = c.getRequest(ctx, "/v1/models", &models)
 
 	if err != nil {
-		return nil, fmt.Errorf("internal error: %v", err)
+		return nil, fmt.Errorf("internal error: %w", err)
 	}
 
 	return models, nil
 }
 
-func (c *ApiGatewayClient) CloseSession(ctx context.Context, sessionId string) error {
-
-	err := c.postRequest(ctx, fmt.Sprintf("/blockchain/sessions/%s/close", sessionId), nil, nil)
-
+func (c *ApiGatewayClient) CloseSession(ctx context.Context, sessionId string) (result map[string]interface{}, err error) {
+	err = c.postRequest(ctx, fmt.Sprintf("/blockchain/sessions/%s/close", sessionId), nil, &result)
 	if err != nil {
-		return fmt.Errorf("internal error: %v", err)
+		return nil, fmt.Errorf("internal error: %w", err)
 	}
-
-	return nil
+	return result, nil
 }
 
 func (c *ApiGatewayClient) GetAllowance(ctx context.Context, spender string) (map[string]interface{}, error) {
@@ -391,21 +495,70 @@ func (c *ApiGatewayClient) ApproveAllowance(ctx context.Context, spender string,
 	return result, err
 }
 
-func (c *ApiGatewayClient) CreateWallet(ctx context.Context, privateKey string) error {
-	return c.postRequest(ctx, "/wallet", &WalletRequest{PrivateKey: privateKey}, nil)
+func (c *ApiGatewayClient) CreateWallet(ctx context.Context, privateKey string) (*WalletResponse, error) {
+	response := &WalletResponse{}
+	err := c.postRequest(ctx, "/wallet/privateKey", &WalletRequest{PrivateKey: privateKey}, response)
+	if err != nil {
+		return nil, err
+	}
+	return response, nil
+}
+
+type KeystoreRequest struct {
+	Keystore   json.RawMessage `json:"keystore,omitempty"`
+	Passphrase string          `json:"passphrase"`
+}
+
+// ImportKeystore sets the node wallet to the key of the V3 keystore json
+func (c *ApiGatewayClient) ImportKeystore(ctx context.Context, keystore []byte, passphrase string) (*WalletResponse, error) {
+	response := &WalletResponse{}
+	err := c.postRequest(ctx, "/wallet/keystore/import", &KeystoreRequest{Keystore: keystore, Passphrase: passphrase}, response)
+	if err != nil {
+		return nil, err
+	}
+	return response, nil
+}
+
+// ExportKeystore returns the node wallet key as V3 keystore json encrypted with the passphrase
+func (c *ApiGatewayClient) ExportKeystore(ctx context.Context, passphrase string) (json.RawMessage, error) {
+	var response json.RawMessage
+	err := c.postRequest(ctx, "/wallet/keystore/export", &KeystoreRequest{Passphrase: passphrase}, &response)
+	if err != nil {
+		return nil, err
+	}
+	return response, nil
 }
 
 type WalletResponse struct {
 	Address string `json:"address"`
 }
 
+type WalletAccountsResponse struct {
+	Accounts []struct {
+		Name    string `json:"name"`
+		Address string `json:"address"`
+	} `json:"accounts"`
+}
+
 func (c *ApiGatewayClient) GetWallet(ctx context.Context) (*WalletResponse, error) {
 	response := &WalletResponse{}
 
 	err := c.getRequest(ctx, "/wallet", response)
 
 	if err != nil {
-		return nil, fmt.Errorf("internal error: %v", err)
+		return nil, fmt.Errorf("internal error: %w", err)
+	}
+
+	return response, nil
+}
+
+func (c *ApiGatewayClient) GetWalletAccounts(ctx context.Context) (*WalletAccountsResponse, error) {
+	response := &WalletAccountsResponse{}
+
+	err := c.getRequest(ctx, "/wallet/accounts", response)
+
+	if err != nil {
+		return nil, fmt.Errorf("internal error: %w", err)
 	}
 
 	return response, nil
@@ -416,7 +569,7 @@ func (c *ApiGatewayClient) GetBalance(ctx context.Context) (eth string, mor stri
 
 	err = c.getRequest(ctx, "/blockchain/balance", &response)
 	if err != nil {
-		return "", "", fmt.Errorf("internal error: %v", err)
+		return "", "", fmt.Errorf("internal error: %w", err)
 	}
 
 	return response["eth"], response["mor"], nil
@@ -433,8 +586,178 @@ func (c *ApiGatewayClient) GetDiamondAddress(ctx context.Context) (common.Addres
 
 	err := c.getRequest(ctx, "/config", &response)
 	if err != nil {
-		return common.Address{}, fmt.Errorf("internal error: %v", err)
+		return common.Address{}, fmt.Errorf("internal error: %w", err)
 	}
 
 	return common.HexToAddress(response.Config.Marketplace.DiamondContractAddress), nil
 }
+
+func (c *ApiGatewayClient) SetMnemonic(ctx context.Context, mnemonic string, derivationPath string) (*WalletResponse, error) {
+	response := &WalletResponse{}
+	err := c.postRequest(ctx, "/wallet/mnemonic", &MnemonicRequest{Mnemonic: mnemonic, DerivationPath: derivationPath}, response)
+	if err != nil {
+		return nil, err
+	}
+	return response, nil
+}
+
+func (c *ApiGatewayClient) DeleteWallet(ctx context.Context) (result map[string]interface{}, err error) {
+	err = c.deleteRequest(ctx, "/wallet", &result)
+	return result, err
+}
+
+func (c *ApiGatewayClient) GetTransactions(ctx context.Context, page uint64, limit uint) (result map[string]interface{}, err error) {
+	err = c.getRequest(ctx, fmt.Sprintf("/blockchain/transactions?page=%d&limit=%d", page, limit), &result)
+	return result, err
+}
+
+func (c *ApiGatewayClient) SendETH(ctx context.Context, to string, amount string) (result map[string]interface{}, err error) {
+	err = c.postRequest(ctx, "/blockchain/send/eth", &SendRequest{To: to, Amount: amount}, &result)
+	return result, err
+}
+
+func (c *ApiGatewayClient) SendMOR(ctx context.Context, to string, amount string) (result map[string]interface{}, err error) {
+	err = c.postRequest(ctx, "/blockchain/send/mor", &SendRequest{To: to, Amount: amount}, &result)
+	return result, err
+}
+
+func (c *ApiGatewayClient) GetTxQueue(ctx context.Context) (result map[string]interface{}, err error) {
+	err = c.getRequest(ctx, "/blockchain/txqueue", &result)
+	return result, err
+}
+
+func (c *ApiGatewayClient) GetBudget(ctx context.Context) (result map[string]interface{}, err error) {
+	err = c.getRequest(ctx, "/blockchain/sessions/budget", &result)
+	return result, err
+}
+
+func (c *ApiGatewayClient) GetSupply(ctx context.Context) (result map[string]interface{}, err error) {
+	err = c.getRequest(ctx, "/blockchain/token/supply", &result)
+	return result, err
+}
+
+func (c *ApiGatewayClient) DeregisterProvider(ctx context.Context, provider string) (result map[string]interface{}, err error) {
+	err = c.deleteRequest(ctx, fmt.Sprintf("/blockchain/providers/%s", provider), &result)
+	return result, err
+}
+
+func (c *ApiGatewayClient) DeregisterModel(ctx context.Context, modelID string) (result map[string]interface{}, err error) {
+	err = c.deleteRequest(ctx, fmt.Sprintf("/blockchain/models/%s", modelID), &result)
+	return result, err
+}
+
+func (c *ApiGatewayClient) GetBid(ctx context.Context, bidID string) (result map[string]interface{}, err error) {
+	err = c.getRequest(ctx, fmt.Sprintf("/blockchain/bids/%s", bidID), &result)
+	return result, err
+}
+
+func (c *ApiGatewayClient) DeleteBid(ctx context.Context, bidID string) (result map[string]interface{}, err error) {
+	err = c.deleteRequest(ctx, fmt.Sprintf("/blockchain/bids/%s", bidID), &result)
+	return result, err
+}
+
+func (c *ApiGatewayClient) GetRatedBids(ctx context.Context, modelID string) (result map[string]interface{}, err error) {
+	err = c.getRequest(ctx, fmt.Sprintf("/blockchain/models/%s/bids/rated", modelID), &result)
+	return result, err
+}
+
+func (c *ApiGatewayClient) GetActiveBidsByModel(ctx context.Context, modelID string, offset *big.Int, limit uint8) (result map[string]interface{}, err error) {
+	err = c.getRequest(ctx, fmt.Sprintf("/blockchain/models/%s/bids/active?offset=%s&limit=%d", modelID, offset.String(), limit), &result)
+	return result, err
+}
+
+func (c *ApiGatewayClient) GetActiveBidsByProvider(ctx context.Context, provider string, offset *big.Int, limit uint8) (result map[string]interface{}, err error) {
+	err = c.getRequest(ctx, fmt.Sprintf("/blockchain/providers/%s/bids/active?offset=%s&limit=%d", provider, offset.String(), limit), &result)
+	return result, err
+}
+
+func (c *ApiGatewayClient) GetSession(ctx context.Context, sessionID string) (result map[string]interface{}, err error) {
+	err = c.getRequest(ctx, fmt.Sprintf("/blockchain/sessions/%s", sessionID), &result)
+	return result, err
+}
+
+func (c *ApiGatewayClient) GetUserSessionIDs(ctx context.Context, user string) (result []interface{}, err error) {
+	err = c.getRequest(ctx, fmt.Sprintf("/blockchain/sessions/user/ids?user=%s", user), &result)
+	return result, err
+}
+
+func (c *ApiGatewayClient) GetVirtualSessions(ctx context.Context) (result map[string]interface{}, err error) {
+	err = c.getRequest(ctx, "/blockchain/sessions/virtual", &result)
+	return result, err
+}
+
+func (c *ApiGatewayClient) OpenSessionByBid(ctx context.Context, bidID string, req *BidSessionRequest) (*Session, error) {
+	session := &Session{}
+	err := c.postRequest(ctx, fmt.Sprintf("/blockchain/bids/%s/session", bidID), req, session)
+	if err != nil {
+		return nil, err
+	}
+	return session, nil
+}
+
+func (c *ApiGatewayClient) GetProviderClaimableBalance(ctx context.Context, sessionID string) (result map[string]interface{}, err error) {
+	err = c.getRequest(ctx, fmt.Sprintf("/proxy/sessions/%s/providerClaimableBalance", sessionID), &result)
+	return result, err
+}
+
+func (c *ApiGatewayClient) ClaimProviderBalance(ctx context.Context, sessionID string) (result map[string]interface{}, err error) {
+	err = c.postRequest(ctx, fmt.Sprintf("/proxy/sessions/%s/providerClaim", sessionID), nil, &result)
+	return result, err
+}
+
+// GetIndexed lists the entities of the local marketplace indexer, kind is one of providers, models, bids and sessions
+func (c *ApiGatewayClient) GetIndexed(ctx context.Context, kind string, query url.Values) (result map[string]interface{}, err error) {
+	err = c.getRequest(ctx, fmt.Sprintf("/blockchain/indexer/%s?%s", kind, query.Encode()), &result)
+	return result, err
+}
+
+func (c *ApiGatewayClient) GetIndexerStatus(ctx context.Context) (result map[string]interface{}, err error) {
+	err = c.getRequest(ctx, "/blockchain/indexer/status", &result)
+	return result, err
+}
+
+func (c *ApiGatewayClient) PingProvider(ctx context.Context, providerAddr string, providerURL string) (result map[string]interface{}, err error) {
+	err = c.postRequest(ctx, "/proxy/provider/ping", &PingRequest{ProviderAddr: providerAddr, ProviderURL: providerURL}, &result)
+	return result, err
+}
+
+func (c *ApiGatewayClient) GetChats(ctx context.Context) (result []interface{}, err error) {
+	err = c.getRequest(ctx, "/v1/chats", &result)
+	return result, err
+}
+
+func (c *ApiGatewayClient) GetChat(ctx context.Context, chatID string) (result map[string]interface{}, err error) {
+	err = c.getRequest(ctx, fmt.Sprintf("/v1/chats/%s", chatID), &result)
+	return result, err
+}
+
+func (c *ApiGatewayClient) DeleteChat(ctx context.Context, chatID string) (result map[string]interface{}, err error) {
+	err = c.deleteRequest(ctx, fmt.Sprintf("/v1/chats/%s", chatID), &result)
+	return result, err
+}
+
+func (c *ApiGatewayClient) UpdateChatTitle(ctx context.Context, chatID string, title string) (result map[string]interface{}, err error) {
+	request := struct {
+		Title string `json:"title"`
+	}{title}
+	err = c.postRequest(ctx, fmt.Sprintf("/v1/chats/%s", chatID), &request, &result)
+	return result, err
+}
+
+func (c *ApiGatewayClient) SetEthNode(ctx context.Context, urls []string) (result map[string]interface{}, err error) {
+	request := struct {
+		URLs []string `json:"urls"`
+	}{urls}
+	err = c.postRequest(ctx, "/config/ethNode", &request, &result)
+	return result, err
+}
+
+func (c *ApiGatewayClient) GetEthNodeStatus(ctx context.Context) (result []interface{}, err error) {
+	err = c.getRequest(ctx, "/config/ethNode/status", &result)
+	return result, err
+}
+
+func (c *ApiGatewayClient) SendTestWebhook(ctx context.Context) (result map[string]interface{}, err error) {
+	err = c.postRequest(ctx, "/webhooks/test", nil, &result)
+	return result, err
+}
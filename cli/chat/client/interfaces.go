@@ -9,6 +9,14 @@ import (
 type SessionRequest struct {
 	ModelId         string   `json:"modelId" validate:"required"`
 	SessionDuration *big.Int `json:"sessionDuration" validate:"required"`
+	DirectPayment   bool     `json:"directPayment,omitempty"`
+	Failover        bool     `json:"failover,omitempty"`
+	AutoRenew       bool     `json:"autoRenew,omitempty"`
+}
+
+type BidSessionRequest struct {
+	SessionDuration *big.Int `json:"sessionDuration" validate:"required"`
+	AutoRenew       bool     `json:"autoRenew,omitempty"`
 }
 
 type SessionStakeRequest struct {
@@ -18,7 +26,8 @@ type SessionStakeRequest struct {
 }
 
 type Session struct {
-	SessionId string `json:"sessionId"`
+	SessionId        string `json:"sessionId"`
+	VirtualSessionId string `json:"virtualSessionId,omitempty"`
 }
 
 type CloseSessionRequest struct {
@@ -42,4 +51,28 @@ type WalletRequest struct {
 	PrivateKey string `json:"privateKey" validate:"required"`
 }
 
+type MnemonicRequest struct {
+	Mnemonic       string `json:"mnemonic" validate:"required"`
+	DerivationPath string `json:"derivationPath" validate:"required"`
+}
+
+// SendRequest is the body of the send ETH and MOR requests, amount is in wei
+type SendRequest struct {
+	To     string `json:"to" validate:"required"`
+	Amount string `json:"amount" validate:"required"`
+}
+
+type PingRequest struct {
+	ProviderAddr string `json:"providerAddr" validate:"required"`
+	ProviderURL  string `json:"providerUrl" validate:"required"`
+}
+
+type InitiateSessionRequest struct {
+	User        string `json:"user" validate:"required"`
+	Provider    string `json:"provider" validate:"required"`
+	Spend       string `json:"spend" validate:"required"`
+	ProviderUrl string `json:"providerUrl" validate:"required"`
+	BidID       string `json:"bidId" validate:"required"`
+}
+
 type ChatCompletionMessage = openai.ChatCompletionMessage
@@ -13,6 +13,8 @@ require (
 	github.com/urfave/cli/v2 v2.27.2
 )
 
+require gopkg.in/yaml.v3 v3.0.1
+
 require (
 	github.com/atotto/clipboard v0.1.4 // indirect
 	github.com/aymanbagabas/go-osc52/v2 v2.0.1 // indirect
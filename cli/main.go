@@ -1,14 +1,13 @@
 package main
 
 import (
-	"encoding/json"
+	"errors"
 	"fmt"
-	"log"
 	"math/big"
 	"net/http"
+	"net/url"
 	"os"
 	"strings"
-	"time"
 
 	chat "github.com/MorpheusAIs/Morpheus-Lumerin-Node/cli/chat"
 	"github.com/MorpheusAIs/Morpheus-Lumerin-Node/cli/chat/client"
@@ -21,17 +20,85 @@ import (
 
 const httpErrorMessage string = "internal error: %v; http status: %v"
 
+const (
+	ExitCodeError    = 1 // invalid arguments or the node is not reachable
+	ExitCodeAPIError = 2 // the node api responded with an error
+)
+
 func main() {
-	api_host := "http://localhost:8082"
 	dotenv.Load(".env")
 
-	if v := os.Getenv("API_HOST"); v != "" {
-		api_host = v
+	app := newApp()
+	if err := app.Run(os.Args); err != nil {
+		fmt.Fprintln(os.Stderr, "Error:", err)
+		os.Exit(exitCode(err))
 	}
+}
 
-	actions := NewActions(client.NewApiGatewayClient(api_host, http.DefaultClient))
-	app := &cli.App{
+// exitCode maps the command error to the exit code of the process
+func exitCode(err error) int {
+	var apiErr *client.ApiError
+	if errors.As(err, &apiErr) {
+		return ExitCodeAPIError
+	}
+	return ExitCodeError
+}
+
+// newApp returns the CLI app, the commands use the Reader, Writer and ErrWriter of the app
+func newApp() *cli.App {
+	actions := NewActions(nil)
+	return &cli.App{
 		Usage: "A client to call the Morpheus Lumerin API",
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:    "profile",
+				Aliases: []string{"p"},
+				Usage:   "named profile of the config file to use, the current profile if not set",
+				EnvVars: []string{"API_PROFILE"},
+			},
+			&cli.StringFlag{
+				Name:    "config",
+				Usage:   "path of the config file with the profiles",
+				Value:   defaultConfigPath(),
+				EnvVars: []string{"MOR_CLI_CONFIG"},
+			},
+			&cli.StringFlag{
+				Name:    "account",
+				Usage:   "named wallet account of the node to use, node default if not set",
+				EnvVars: []string{"API_ACCOUNT"},
+			},
+			&cli.StringFlag{
+				Name:    "output",
+				Aliases: []string{"o"},
+				Usage:   "output format: " + strings.Join(outputFormats, ", "),
+				Value:   OutputTable,
+				EnvVars: []string{"API_OUTPUT"},
+			},
+		},
+		Before: func(cCtx *cli.Context) error {
+			config, err := loadConfig(cCtx.String("config"))
+			if err != nil {
+				return err
+			}
+			profile, err := config.resolveProfile(cCtx.String("profile"))
+			if err != nil {
+				return err
+			}
+
+			apiClient := client.NewApiGatewayClient(profile.URL, http.DefaultClient)
+			apiClient.Token = profile.Token
+			apiClient.Account = cCtx.String("account")
+
+			printer, err := newPrinter(cCtx.String("output"), cCtx.App.Writer)
+			if err != nil {
+				return err
+			}
+			actions.client = apiClient
+			actions.config = config
+			actions.defaultModel = profile.Model
+			actions.printer = printer
+			return nil
+		},
 		Commands: []*cli.Command{
 			{
 				Name:    "healthcheck",
@@ -58,12 +125,73 @@ func main() {
 							},
 						},
 					},
+					{
+						Name:   "mnemonic",
+						Usage:  "morpheus wallet mnemonic --mnemonic <words> --derivationPath <path>",
+						Action: actions.setupWalletMnemonic,
+						Flags: []cli.Flag{
+							&cli.StringFlag{
+								Name:     "mnemonic",
+								EnvVars:  []string{"WALLET_MNEMONIC"},
+								Required: true,
+							},
+							&cli.StringFlag{
+								Name:  "derivationPath",
+								Value: "m/44'/60'/0'/0/0",
+							},
+						},
+					},
 					{
 						Name:    "balance",
 						Aliases: []string{"b"},
 						Usage:   "morpheus wallet balance",
 						Action:  actions.getBalance,
 					},
+					{
+						Name:   "import",
+						Usage:  "morpheus wallet import --keystore <file> --passphrase <passphrase>",
+						Action: actions.importKeystore,
+						Flags: []cli.Flag{
+							&cli.PathFlag{
+								Name:     "keystore",
+								Usage:    "V3 keystore json file",
+								Required: true,
+							},
+							&cli.StringFlag{
+								Name:     "passphrase",
+								EnvVars:  []string{"KEYSTORE_PASSPHRASE"},
+								Required: true,
+							},
+						},
+					},
+					{
+						Name:   "export",
+						Usage:  "morpheus wallet export --passphrase <passphrase> --out <file>",
+						Action: actions.exportKeystore,
+						Flags: []cli.Flag{
+							&cli.StringFlag{
+								Name:     "passphrase",
+								Usage:    "passphrase to encrypt the exported keystore with",
+								EnvVars:  []string{"KEYSTORE_PASSPHRASE"},
+								Required: true,
+							},
+							&cli.PathFlag{
+								Name:  "out",
+								Usage: "file to write the keystore to, printed if not set",
+							},
+						},
+					},
+					{
+						Name:    "accounts",
+						Aliases: []string{"a"},
+						Usage:   "morpheus wallet accounts, select one with --account <name>",
+						Action:  actions.getWalletAccounts,
+					},
+					{
+						Name:   "delete",
+						Usage:  "morpheus wallet delete",
+						Action: actions.deleteWallet,
+					},
 				},
 			},
 
@@ -89,6 +217,66 @@ func main() {
 					},
 				},
 			},
+			{
+				Name:      "prompt",
+				Aliases:   []string{"p"},
+				Usage:     "send a prompt to a model or session and stream the answer",
+				ArgsUsage: "<prompt>, read from stdin if not set or -",
+				Action:    actions.prompt,
+				Flags:     promptFlags(),
+			},
+			profileCommand(actions),
+			{
+				Name:    "chats",
+				Aliases: []string{"ch"},
+				Usage:   "list stored chats",
+				Action:  actions.listChats,
+				Subcommands: []*cli.Command{
+					{
+						Name:   "history",
+						Usage:  "morpheus chats history --chat <chat-id>",
+						Action: actions.getChat,
+						Flags: []cli.Flag{
+							&cli.StringFlag{
+								Name:     "chat",
+								Required: true,
+							},
+						},
+					},
+					{
+						Name:   "rename",
+						Usage:  "morpheus chats rename --chat <chat-id> --title <title>",
+						Action: actions.renameChat,
+						Flags: []cli.Flag{
+							&cli.StringFlag{
+								Name:     "chat",
+								Required: true,
+							},
+							&cli.StringFlag{
+								Name:     "title",
+								Required: true,
+							},
+						},
+					},
+					{
+						Name:   "delete",
+						Usage:  "morpheus chats delete --chat <chat-id>",
+						Action: actions.deleteChat,
+						Flags: []cli.Flag{
+							&cli.StringFlag{
+								Name:     "chat",
+								Required: true,
+							},
+						},
+					},
+				},
+			},
+			{
+				Name:    "localModels",
+				Aliases: []string{"lm"},
+				Usage:   "list models available for chat",
+				Action:  actions.localModels,
+			},
 			{
 				Name:    "listBlockchainSession",
 				Aliases: []string{"lbs"},
@@ -105,6 +293,105 @@ func main() {
 					},
 				},
 			},
+			{
+				Name:    "getBlockchainSession",
+				Aliases: []string{"gbs"},
+				Usage:   "get a blockchain session by id or virtual session id",
+				Action:  actions.getBlockchainSession,
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:     "session",
+						Required: true,
+					},
+				},
+			},
+			{
+				Name:    "userSessionIds",
+				Aliases: []string{"usi"},
+				Usage:   "list session ids of a user",
+				Action:  actions.userSessionIds,
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:     "user",
+						Required: true,
+					},
+				},
+			},
+			{
+				Name:    "virtualSessions",
+				Aliases: []string{"vs"},
+				Usage:   "list auto-renewed sessions",
+				Action:  actions.virtualSessions,
+			},
+			{
+				Name:    "openBlockchainSession",
+				Aliases: []string{"obs"},
+				Usage:   "open a blockchain session with a provider approval",
+				Action:  actions.openBlockchainSession,
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:     "approval",
+						Required: true,
+					},
+					&cli.StringFlag{
+						Name:     "approvalSig",
+						Required: true,
+					},
+					&cli.Uint64Flag{
+						Name:     "stake",
+						Required: true,
+					},
+				},
+			},
+			{
+				Name:    "openSessionByModel",
+				Aliases: []string{"osm"},
+				Usage:   "open a blockchain session with the best bid of the model",
+				Action:  actions.openSessionByModel,
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:  "model",
+						Usage: "model id, the default model of the profile if not set",
+					},
+					&cli.DurationFlag{
+						Name:     "duration",
+						Usage:    "session duration, ex. 10m",
+						Required: true,
+					},
+					&cli.BoolFlag{
+						Name: "directPayment",
+					},
+					&cli.BoolFlag{
+						Name:  "failover",
+						Usage: "open a session with another provider if the provider fails",
+					},
+					&cli.BoolFlag{
+						Name:  "autoRenew",
+						Usage: "renew the session before it ends, returns a virtual session id",
+					},
+				},
+			},
+			{
+				Name:    "openSessionByBid",
+				Aliases: []string{"osb"},
+				Usage:   "open a blockchain session with the bid",
+				Action:  actions.openSessionByBid,
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:     "bid",
+						Required: true,
+					},
+					&cli.DurationFlag{
+						Name:     "duration",
+						Usage:    "session duration, ex. 10m",
+						Required: true,
+					},
+					&cli.BoolFlag{
+						Name:  "autoRenew",
+						Usage: "renew the session before it ends, returns a virtual session id",
+					},
+				},
+			},
 			{
 				Name:    "closeBlockchainSession",
 				Aliases: []string{"cbs"},
@@ -117,6 +404,36 @@ func main() {
 					},
 				},
 			},
+			{
+				Name:    "sessionBudget",
+				Aliases: []string{"sb"},
+				Usage:   "get today's budget for sessions",
+				Action:  actions.sessionBudget,
+			},
+			{
+				Name:    "providerClaimableBalance",
+				Aliases: []string{"pcb"},
+				Usage:   "get the balance the provider can claim from the session",
+				Action:  actions.providerClaimableBalance,
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:     "session",
+						Required: true,
+					},
+				},
+			},
+			{
+				Name:    "claimProviderBalance",
+				Aliases: []string{"cpb"},
+				Usage:   "claim the provider balance from the session",
+				Action:  actions.claimProviderBalance,
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:     "session",
+						Required: true,
+					},
+				},
+			},
 			{
 				Name:    "blockchainModels",
 				Aliases: []string{"bm"},
@@ -151,12 +468,91 @@ func main() {
 					},
 				},
 			},
+			{
+				Name:    "sendEth",
+				Aliases: []string{"se"},
+				Usage:   "send ETH from the node wallet",
+				Action:  actions.sendETH,
+				Flags:   sendFlags(),
+			},
+			{
+				Name:    "sendMor",
+				Aliases: []string{"sm"},
+				Usage:   "send MOR from the node wallet",
+				Action:  actions.sendMOR,
+				Flags:   sendFlags(),
+			},
+			{
+				Name:    "transactions",
+				Aliases: []string{"tx"},
+				Usage:   "list transactions of the node wallet",
+				Action:  actions.transactions,
+				Flags: []cli.Flag{
+					&cli.Uint64Flag{
+						Name:  "page",
+						Value: 1,
+					},
+					&cli.UintFlag{
+						Name:  "limit",
+						Value: 10,
+					},
+				},
+			},
+			{
+				Name:    "txQueue",
+				Aliases: []string{"txq"},
+				Usage:   "view pending transactions of the node",
+				Action:  actions.txQueue,
+			},
+			{
+				Name:    "latestBlock",
+				Aliases: []string{"lb"},
+				Usage:   "get the latest block number",
+				Action:  actions.latestBlock,
+			},
+			{
+				Name:    "tokenSupply",
+				Aliases: []string{"ts"},
+				Usage:   "get the MOR token supply",
+				Action:  actions.tokenSupply,
+			},
 			{
 				Name:    "proxyRouterConfig",
 				Aliases: []string{"prc"},
 				Usage:   "view proxy router config",
 				Action:  actions.proxyRouterConfig,
 			},
+			{
+				Name:    "proxyRouterFiles",
+				Aliases: []string{"prf"},
+				Usage:   "view files opened by the proxy router",
+				Action:  actions.proxyRouterFiles,
+			},
+			{
+				Name:    "ethNode",
+				Aliases: []string{"en"},
+				Usage:   "view eth node endpoints status",
+				Action:  actions.ethNodeStatus,
+				Subcommands: []*cli.Command{
+					{
+						Name:   "set",
+						Usage:  "morpheus ethNode set --url <url> [--url <url>]",
+						Action: actions.setEthNode,
+						Flags: []cli.Flag{
+							&cli.StringSliceFlag{
+								Name:     "url",
+								Required: true,
+							},
+						},
+					},
+				},
+			},
+			{
+				Name:    "testWebhook",
+				Aliases: []string{"tw"},
+				Usage:   "send a test event to the configured webhooks",
+				Action:  actions.testWebhook,
+			},
 			{
 				Name:    "blockchainProviders",
 				Aliases: []string{"bp"},
@@ -179,6 +575,63 @@ func main() {
 					},
 				},
 			},
+			{
+				Name:    "deregisterProvider",
+				Aliases: []string{"dp"},
+				Usage:   "deregister the provider",
+				Action:  actions.deregisterProvider,
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:     "address",
+						Required: true,
+					},
+				},
+			},
+			{
+				Name:    "pingProvider",
+				Aliases: []string{"pp"},
+				Usage:   "ping the provider endpoint",
+				Action:  actions.pingProvider,
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:     "address",
+						Required: true,
+					},
+					&cli.StringFlag{
+						Name:     "endpoint",
+						Required: true,
+					},
+				},
+			},
+			{
+				Name:    "initiateSession",
+				Aliases: []string{"is"},
+				Usage:   "request a session approval from the provider",
+				Action:  actions.initiateProxySession,
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:     "user",
+						Required: true,
+					},
+					&cli.StringFlag{
+						Name:     "provider",
+						Required: true,
+					},
+					&cli.StringFlag{
+						Name:     "endpoint",
+						Required: true,
+					},
+					&cli.StringFlag{
+						Name:     "bid",
+						Required: true,
+					},
+					&cli.StringFlag{
+						Name:     "spend",
+						Usage:    "amount of MOR in wei",
+						Required: true,
+					},
+				},
+			},
 			{
 				Name:    "createBlockchainModel",
 				Aliases: []string{"bmc"},
@@ -210,6 +663,18 @@ func main() {
 					},
 				},
 			},
+			{
+				Name:    "deregisterModel",
+				Aliases: []string{"dm"},
+				Usage:   "deregister the model",
+				Action:  actions.deregisterModel,
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:     "model",
+						Required: true,
+					},
+				},
+			},
 			{
 				Name:    "blockchainProviderBid",
 				Aliases: []string{"bpb"},
@@ -228,6 +693,58 @@ func main() {
 						Name:  "limit",
 						Value: 10,
 					},
+					&cli.BoolFlag{
+						Name:  "active",
+						Usage: "list only active bids",
+					},
+				},
+			},
+			{
+				Name:    "blockchainModelBids",
+				Aliases: []string{"bmb"},
+				Usage:   "list model bids",
+				Action:  actions.blockchainModelBids,
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:     "model",
+						Required: true,
+					},
+					&cli.Int64Flag{
+						Name:  "offset",
+						Value: 0,
+					},
+					&cli.UintFlag{
+						Name:  "limit",
+						Value: 10,
+					},
+					&cli.BoolFlag{
+						Name:  "active",
+						Usage: "list only active bids",
+					},
+				},
+			},
+			{
+				Name:    "ratedBids",
+				Aliases: []string{"rb"},
+				Usage:   "list model bids sorted by the provider rating",
+				Action:  actions.ratedBids,
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:     "model",
+						Required: true,
+					},
+				},
+			},
+			{
+				Name:    "getBid",
+				Aliases: []string{"gb"},
+				Usage:   "get a bid by id",
+				Action:  actions.getBid,
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:     "bid",
+						Required: true,
+					},
 				},
 			},
 			{
@@ -246,42 +763,201 @@ func main() {
 					},
 				},
 			},
+			{
+				Name:    "deleteBid",
+				Aliases: []string{"db"},
+				Usage:   "delete the bid",
+				Action:  actions.deleteBid,
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:     "bid",
+						Required: true,
+					},
+				},
+			},
+			{
+				Name:    "indexer",
+				Aliases: []string{"idx"},
+				Usage:   "view local marketplace indexer status",
+				Action:  actions.indexerStatus,
+				Subcommands: []*cli.Command{
+					{
+						Name:   "providers",
+						Usage:  "morpheus indexer providers [--includeDeleted]",
+						Action: actions.indexed("providers"),
+						Flags: append(indexerFlags(),
+							&cli.BoolFlag{Name: "includeDeleted"},
+						),
+					},
+					{
+						Name:   "models",
+						Usage:  "morpheus indexer models [--owner <address>] [--tag <tag>] [--includeDeleted]",
+						Action: actions.indexed("models"),
+						Flags: append(indexerFlags(),
+							&cli.StringFlag{Name: "owner"},
+							&cli.StringFlag{Name: "tag"},
+							&cli.BoolFlag{Name: "includeDeleted"},
+						),
+					},
+					{
+						Name:   "bids",
+						Usage:  "morpheus indexer bids [--provider <address>] [--modelId <id>] [--active]",
+						Action: actions.indexed("bids"),
+						Flags: append(indexerFlags(),
+							&cli.StringFlag{Name: "provider"},
+							&cli.StringFlag{Name: "modelId"},
+							&cli.BoolFlag{Name: "active"},
+						),
+					},
+					{
+						Name:   "sessions",
+						Usage:  "morpheus indexer sessions [--user <address>] [--provider <address>] [--modelId <id>] [--open]",
+						Action: actions.indexed("sessions"),
+						Flags: append(indexerFlags(),
+							&cli.StringFlag{Name: "user"},
+							&cli.StringFlag{Name: "provider"},
+							&cli.StringFlag{Name: "modelId"},
+							&cli.BoolFlag{Name: "open"},
+						),
+					},
+				},
+			},
+		},
+	}
+}
+
+func sendFlags() []cli.Flag {
+	return []cli.Flag{
+		&cli.StringFlag{
+			Name:     "to",
+			Required: true,
+		},
+		&cli.StringFlag{
+			Name:     "amount",
+			Usage:    "amount in wei",
+			Required: true,
+		},
+	}
+}
+
+func indexerFlags() []cli.Flag {
+	return []cli.Flag{
+		&cli.Int64Flag{
+			Name: "offset",
+		},
+		&cli.UintFlag{
+			Name:  "limit",
+			Value: 10,
+		},
+		&cli.StringFlag{
+			Name:  "order",
+			Usage: "asc or desc",
+		},
+		&cli.StringFlag{
+			Name:  "sortBy",
+			Usage: "field to sort by, ex. createdAt",
 		},
 	}
+}
+
+type actions struct {
+	client       *client.ApiGatewayClient
+	config       *Config
+	defaultModel string // model of the selected profile
+	printer      *printer
+}
+
+func NewActions(c *client.ApiGatewayClient) *actions {
+	return &actions{client: c, printer: &printer{format: OutputTable, out: os.Stdout}}
+}
+
+// model returns the --model flag, the default model of the profile if not set
+func (a *actions) model(cCtx *cli.Context) string {
+	if model := cCtx.String("model"); model != "" {
+		return model
+	}
+	return a.defaultModel
+}
+
+func (a *actions) setupWallet(cCtx *cli.Context) error {
+	result, err := a.client.CreateWallet(cCtx.Context, cCtx.String("privateKey"))
+	if err != nil {
+		return err
+	}
+
+	return a.printer.print(result)
+}
+
+func (a *actions) setupWalletMnemonic(cCtx *cli.Context) error {
+	result, err := a.client.SetMnemonic(cCtx.Context, cCtx.String("mnemonic"), cCtx.String("derivationPath"))
+	if err != nil {
+		return err
+	}
+
+	return a.printer.print(result)
+}
+
+func (a *actions) deleteWallet(cCtx *cli.Context) error {
+	result, err := a.client.DeleteWallet(cCtx.Context)
+	if err != nil {
+		return err
+	}
+
+	return a.printer.print(result)
+}
+
+func (a *actions) getWallet(cCtx *cli.Context) error {
+	result, err := a.client.GetWallet(cCtx.Context)
 
-	if err := app.Run(os.Args); err != nil {
-		log.Fatal(err)
+	if err != nil {
+		return err
 	}
-}
 
-type actions struct {
-	client *client.ApiGatewayClient
+	return a.printer.print(result)
 }
 
-func NewActions(c *client.ApiGatewayClient) *actions {
-	return &actions{client: c}
-}
+func (a *actions) importKeystore(cCtx *cli.Context) error {
+	keystore, err := os.ReadFile(cCtx.Path("keystore"))
+	if err != nil {
+		return err
+	}
 
-func (a *actions) setupWallet(cCtx *cli.Context) error {
-	err := a.client.CreateWallet(cCtx.Context, cCtx.String("privateKey"))
+	result, err := a.client.ImportKeystore(cCtx.Context, keystore, cCtx.String("passphrase"))
 	if err != nil {
 		return err
 	}
 
-	fmt.Println("Wallet setup successfully")
-	return nil
+	return a.printer.print(result)
 }
 
-func (a *actions) getWallet(cCtx *cli.Context) error {
-	result, err := a.client.GetWallet(cCtx.Context)
+func (a *actions) exportKeystore(cCtx *cli.Context) error {
+	keystore, err := a.client.ExportKeystore(cCtx.Context, cCtx.String("passphrase"))
+	if err != nil {
+		return err
+	}
 
+	out := cCtx.Path("out")
+	if out == "" {
+		// the keystore is json already, it is printed as is in any format
+		fmt.Println(string(keystore))
+		return nil
+	}
+	err = os.WriteFile(out, keystore, 0600)
 	if err != nil {
 		return err
 	}
 
-	fmt.Println("Wallet Address: ", result.Address)
+	return a.printer.print(map[string]string{"file": out})
+}
 
-	return nil
+func (a *actions) getWalletAccounts(cCtx *cli.Context) error {
+	result, err := a.client.GetWalletAccounts(cCtx.Context)
+
+	if err != nil {
+		return err
+	}
+
+	return a.printer.print(result)
 }
 
 func (a *actions) getBalance(cCtx *cli.Context) error {
@@ -291,10 +967,7 @@ func (a *actions) getBalance(cCtx *cli.Context) error {
 		return err
 	}
 
-	fmt.Println("ETH Balance: ", eth)
-	fmt.Println("MOR Balance: ", mor)
-
-	return nil
+	return a.printer.print(map[string]string{"eth": eth, "mor": mor})
 }
 
 func (a *actions) startChatLocal(cCtx *cli.Context) error {
@@ -375,6 +1048,51 @@ func (a *actions) startRemoteChat(cCtx *cli.Context) error {
 	return nil
 }
 
+func (a *actions) listChats(cCtx *cli.Context) error {
+	chats, err := a.client.GetChats(cCtx.Context)
+	if err != nil {
+		return err
+	}
+
+	return a.printer.print(chats)
+}
+
+func (a *actions) getChat(cCtx *cli.Context) error {
+	history, err := a.client.GetChat(cCtx.Context, cCtx.String("chat"))
+	if err != nil {
+		return err
+	}
+
+	return a.printer.print(history)
+}
+
+func (a *actions) renameChat(cCtx *cli.Context) error {
+	res, err := a.client.UpdateChatTitle(cCtx.Context, cCtx.String("chat"), cCtx.String("title"))
+	if err != nil {
+		return err
+	}
+
+	return a.printer.print(res)
+}
+
+func (a *actions) deleteChat(cCtx *cli.Context) error {
+	res, err := a.client.DeleteChat(cCtx.Context, cCtx.String("chat"))
+	if err != nil {
+		return err
+	}
+
+	return a.printer.print(res)
+}
+
+func (a *actions) localModels(cCtx *cli.Context) error {
+	models, err := a.client.GetLocalModels(cCtx.Context)
+	if err != nil {
+		return err
+	}
+
+	return a.printer.print(models)
+}
+
 func (a *actions) getAllowance(cCtx *cli.Context) error {
 	spender := cCtx.String("spender")
 	res, err := a.client.GetAllowance(cCtx.Context, spender)
@@ -382,8 +1100,7 @@ func (a *actions) getAllowance(cCtx *cli.Context) error {
 		return err
 	}
 
-	fmt.Println("Allowance =", res["allowance"], "MOR")
-	return nil
+	return a.printer.print(res)
 }
 
 func (a *actions) approveAllowance(cCtx *cli.Context) error {
@@ -393,8 +1110,90 @@ func (a *actions) approveAllowance(cCtx *cli.Context) error {
 	if err != nil {
 		return err
 	}
-	fmt.Println("Allowance approved, tx:", res["tx"])
-	return nil
+
+	return a.printer.print(res)
+}
+
+func (a *actions) sendETH(cCtx *cli.Context) error {
+	to, amount, err := sendArgs(cCtx)
+	if err != nil {
+		return err
+	}
+
+	res, err := a.client.SendETH(cCtx.Context, to, amount)
+	if err != nil {
+		return err
+	}
+
+	return a.printer.print(res)
+}
+
+func (a *actions) sendMOR(cCtx *cli.Context) error {
+	to, amount, err := sendArgs(cCtx)
+	if err != nil {
+		return err
+	}
+
+	res, err := a.client.SendMOR(cCtx.Context, to, amount)
+	if err != nil {
+		return err
+	}
+
+	return a.printer.print(res)
+}
+
+func sendArgs(cCtx *cli.Context) (to string, amount string, err error) {
+	to = cCtx.String("to")
+	if !common.IsHexAddress(to) {
+		return "", "", fmt.Errorf("invalid address: %s", to)
+	}
+	amount, err = parseWei(cCtx.String("amount"))
+	return to, amount, err
+}
+
+// parseWei checks the amount is a positive integer, wei amounts don't fit into uint64
+func parseWei(amount string) (string, error) {
+	value, ok := new(big.Int).SetString(amount, 10)
+	if !ok || value.Sign() <= 0 {
+		return "", fmt.Errorf("invalid amount: %s, expected a positive integer in wei", amount)
+	}
+	return value.String(), nil
+}
+
+func (a *actions) transactions(cCtx *cli.Context) error {
+	res, err := a.client.GetTransactions(cCtx.Context, cCtx.Uint64("page"), cCtx.Uint("limit"))
+	if err != nil {
+		return err
+	}
+
+	return a.printer.print(res)
+}
+
+func (a *actions) txQueue(cCtx *cli.Context) error {
+	res, err := a.client.GetTxQueue(cCtx.Context)
+	if err != nil {
+		return err
+	}
+
+	return a.printer.print(res)
+}
+
+func (a *actions) latestBlock(cCtx *cli.Context) error {
+	res, err := a.client.GetLatestBlock(cCtx.Context)
+	if err != nil {
+		return err
+	}
+
+	return a.printer.print(res)
+}
+
+func (a *actions) tokenSupply(cCtx *cli.Context) error {
+	res, err := a.client.GetSupply(cCtx.Context)
+	if err != nil {
+		return err
+	}
+
+	return a.printer.print(res)
 }
 
 func (a *actions) healthcheck(cCtx *cli.Context) error {
@@ -402,10 +1201,8 @@ func (a *actions) healthcheck(cCtx *cli.Context) error {
 	if err != nil {
 		return err
 	}
-	// Output the result of the healthcheck
-	jsonData, err := json.Marshal(res)
-	fmt.Println(string(jsonData))
-	return nil
+
+	return a.printer.print(res)
 }
 
 func (a *actions) proxyRouterConfig(cCtx *cli.Context) error {
@@ -413,9 +1210,8 @@ func (a *actions) proxyRouterConfig(cCtx *cli.Context) error {
 	if err != nil {
 		return err
 	}
-	jsonData, err := json.Marshal(config)
-	fmt.Println(string(jsonData))
-	return nil
+
+	return a.printer.print(config)
 }
 
 func (a *actions) proxyRouterFiles(cCtx *cli.Context) error {
@@ -425,19 +1221,63 @@ func (a *actions) proxyRouterFiles(cCtx *cli.Context) error {
 		return err
 	}
 
-	jsonData, err := json.Marshal(files)
-	fmt.Println(string(jsonData))
-	return nil
+	return a.printer.printText("files", files)
+}
+
+func (a *actions) ethNodeStatus(cCtx *cli.Context) error {
+	res, err := a.client.GetEthNodeStatus(cCtx.Context)
+	if err != nil {
+		return err
+	}
+
+	return a.printer.print(res)
+}
+
+func (a *actions) setEthNode(cCtx *cli.Context) error {
+	res, err := a.client.SetEthNode(cCtx.Context, cCtx.StringSlice("url"))
+	if err != nil {
+		return err
+	}
+
+	return a.printer.print(res)
+}
+
+func (a *actions) testWebhook(cCtx *cli.Context) error {
+	res, err := a.client.SendTestWebhook(cCtx.Context)
+	if err != nil {
+		return err
+	}
+
+	return a.printer.print(res)
+}
+
+func (a *actions) pingProvider(cCtx *cli.Context) error {
+	res, err := a.client.PingProvider(cCtx.Context, cCtx.String("address"), cCtx.String("endpoint"))
+	if err != nil {
+		return err
+	}
+
+	return a.printer.print(res)
 }
 
 func (a *actions) initiateProxySession(cCtx *cli.Context) error {
-	session, err := a.client.InitiateSession(cCtx.Context)
+	spend, err := parseWei(cCtx.String("spend"))
 	if err != nil {
 		return err
 	}
-	jsonData, err := json.Marshal(session)
-	fmt.Println(string(jsonData))
-	return nil
+
+	session, err := a.client.InitiateSession(cCtx.Context, &client.InitiateSessionRequest{
+		User:        cCtx.String("user"),
+		Provider:    cCtx.String("provider"),
+		Spend:       spend,
+		ProviderUrl: cCtx.String("endpoint"),
+		BidID:       cCtx.String("bid"),
+	})
+	if err != nil {
+		return err
+	}
+
+	return a.printer.print(session)
 }
 
 func (a *actions) blockchainProviders(cCtx *cli.Context) error {
@@ -447,12 +1287,7 @@ func (a *actions) blockchainProviders(cCtx *cli.Context) error {
 		return err
 	}
 
-	for _, item := range providers["providers"].([]interface{}) {
-		provider := item.(map[string]interface{})
-		fmt.Println(provider["Address"], " - ", provider["Endpoint"])
-	}
-
-	return nil
+	return a.printer.print(providers)
 }
 
 func (a *actions) createBlockchainProvider(cCtx *cli.Context) error {
@@ -473,9 +1308,16 @@ func (a *actions) createBlockchainProvider(cCtx *cli.Context) error {
 		return err
 	}
 
-	jsonData, err := json.Marshal(providers)
-	fmt.Println(string(jsonData))
-	return nil
+	return a.printer.print(providers)
+}
+
+func (a *actions) deregisterProvider(cCtx *cli.Context) error {
+	res, err := a.client.DeregisterProvider(cCtx.Context, cCtx.String("address"))
+	if err != nil {
+		return err
+	}
+
+	return a.printer.print(res)
 }
 
 func (a *actions) createBlockchainProviderBid(cCtx *cli.Context) error {
@@ -496,12 +1338,34 @@ func (a *actions) createBlockchainProviderBid(cCtx *cli.Context) error {
 		return err
 	}
 
-	var bid map[string]interface{}
-	bid = result["bid"].(map[string]interface{})
+	return a.printer.print(result)
+}
+
+func (a *actions) getBid(cCtx *cli.Context) error {
+	res, err := a.client.GetBid(cCtx.Context, cCtx.String("bid"))
+	if err != nil {
+		return err
+	}
+
+	return a.printer.print(res)
+}
 
-	fmt.Println("Bid created for model ", model)
-	fmt.Println("Bid ID: ", bid["Id"])
-	return nil
+func (a *actions) deleteBid(cCtx *cli.Context) error {
+	res, err := a.client.DeleteBid(cCtx.Context, cCtx.String("bid"))
+	if err != nil {
+		return err
+	}
+
+	return a.printer.print(res)
+}
+
+func (a *actions) ratedBids(cCtx *cli.Context) error {
+	res, err := a.client.GetRatedBids(cCtx.Context, cCtx.String("model"))
+	if err != nil {
+		return err
+	}
+
+	return a.printer.print(res)
 }
 
 func (a *actions) createBlockchainModel(cCtx *cli.Context) error {
@@ -521,17 +1385,16 @@ func (a *actions) createBlockchainModel(cCtx *cli.Context) error {
 		return err
 	}
 
-	var model map[string]interface{}
-	model = result["model"].(map[string]interface{})
+	return a.printer.print(result)
+}
 
-	fmt.Println("Model created: ", model["Name"])
-	fmt.Println("Model ID: ", model["Id"])
-	fmt.Println("Model Stake: ", model["Stake"])
-	fmt.Println("Model Fee: ", model["Fee"])
-	fmt.Println("Model Tags: ", model["Tags"])
-	fmt.Println("Model IpfsCID: ", model["IpfsCID"])
+func (a *actions) deregisterModel(cCtx *cli.Context) error {
+	res, err := a.client.DeregisterModel(cCtx.Context, cCtx.String("model"))
+	if err != nil {
+		return err
+	}
 
-	return nil
+	return a.printer.print(res)
 }
 
 type Bid struct {
@@ -549,29 +1412,39 @@ func (a *actions) blockchainProvidersBids(cCtx *cli.Context) error {
 	offset := cCtx.Int64("offset")
 	limit := cCtx.Uint("limit")
 
-	bidsResult, err := a.client.GetBidsByProvider(cCtx.Context, address, big.NewInt(offset), uint8(limit))
+	var bidsResult interface{}
+	var err error
+	if cCtx.Bool("active") {
+		bidsResult, err = a.client.GetActiveBidsByProvider(cCtx.Context, address, big.NewInt(offset), uint8(limit))
+	} else {
+		bidsResult, err = a.client.GetBidsByProvider(cCtx.Context, address, big.NewInt(offset), uint8(limit))
+	}
 
 	if err != nil {
 		return err
 	}
 
-	bidsMap := bidsResult.(map[string]interface{})
+	return a.printer.print(bidsResult)
+}
 
-	bids := bidsMap["bids"].([]interface{})
+func (a *actions) blockchainModelBids(cCtx *cli.Context) error {
+	model := cCtx.String("model")
+	offset := cCtx.Int64("offset")
+	limit := cCtx.Uint("limit")
 
-	if len(bids) == 0 {
-		fmt.Println("No bids")
-		return nil
+	var bidsResult interface{}
+	var err error
+	if cCtx.Bool("active") {
+		bidsResult, err = a.client.GetActiveBidsByModel(cCtx.Context, model, big.NewInt(offset), uint8(limit))
+	} else {
+		bidsResult, err = a.client.GetBidsByModelAgent(cCtx.Context, model, fmt.Sprint(offset), fmt.Sprint(limit))
 	}
 
-	for _, item := range bids {
-		bid := item.(map[string]interface{})
-		fmt.Println("Bid: ", bid["Id"])
-		fmt.Println("\t- price per second: ", bid["PricePerSecond"])
-		fmt.Println("\t- model: ", bid["ModelAgentId"])
+	if err != nil {
+		return err
 	}
 
-	return nil
+	return a.printer.print(bidsResult)
 }
 
 func (a *actions) blockchainModels(cCtx *cli.Context) error {
@@ -580,17 +1453,36 @@ func (a *actions) blockchainModels(cCtx *cli.Context) error {
 		return err
 	}
 
-	models := modelsResult["models"].([]interface{})
+	return a.printer.print(modelsResult)
+}
 
-	for _, item := range models {
-		model := item.(map[string]interface{})
-		fmt.Println("\n", "Model: ", model["Name"], " - ", model["Id"])
-		fmt.Println("\t- provider: ", model["Owner"])
+func (a *actions) indexerStatus(cCtx *cli.Context) error {
+	res, err := a.client.GetIndexerStatus(cCtx.Context)
+	if err != nil {
+		return err
 	}
 
-	fmt.Println()
+	return a.printer.print(res)
+}
 
-	return nil
+// indexed lists the indexer entities of the kind, the set flags are passed as query params
+func (a *actions) indexed(kind string) cli.ActionFunc {
+	return func(cCtx *cli.Context) error {
+		query := url.Values{}
+		for _, name := range cCtx.LocalFlagNames() {
+			query.Set(name, fmt.Sprint(cCtx.Value(name)))
+		}
+		if !cCtx.IsSet("limit") {
+			query.Set("limit", fmt.Sprint(cCtx.Uint("limit")))
+		}
+
+		res, err := a.client.GetIndexed(cCtx.Context, kind, query)
+		if err != nil {
+			return err
+		}
+
+		return a.printer.print(res)
+	}
 }
 
 func (a *actions) openBlockchainSession(cCtx *cli.Context) error {
@@ -605,8 +1497,38 @@ func (a *actions) openBlockchainSession(cCtx *cli.Context) error {
 		return err
 	}
 
-	fmt.Println("session opened: ", session.SessionId)
-	return nil
+	return a.printer.print(session)
+}
+
+func (a *actions) openSessionByModel(cCtx *cli.Context) error {
+	modelID := a.model(cCtx)
+	if modelID == "" {
+		return fmt.Errorf("please provide the model id or set the default model of the profile")
+	}
+	session, err := a.client.OpenSession(cCtx.Context, &client.SessionRequest{
+		ModelId:         modelID,
+		SessionDuration: big.NewInt(int64(cCtx.Duration("duration").Seconds())),
+		DirectPayment:   cCtx.Bool("directPayment"),
+		Failover:        cCtx.Bool("failover"),
+		AutoRenew:       cCtx.Bool("autoRenew"),
+	})
+	if err != nil {
+		return err
+	}
+
+	return a.printer.print(session)
+}
+
+func (a *actions) openSessionByBid(cCtx *cli.Context) error {
+	session, err := a.client.OpenSessionByBid(cCtx.Context, cCtx.String("bid"), &client.BidSessionRequest{
+		SessionDuration: big.NewInt(int64(cCtx.Duration("duration").Seconds())),
+		AutoRenew:       cCtx.Bool("autoRenew"),
+	})
+	if err != nil {
+		return err
+	}
+
+	return a.printer.print(session)
 }
 
 func (a *actions) listBlockchainSessions(cCtx *cli.Context) error {
@@ -629,40 +1551,68 @@ func (a *actions) listBlockchainSessions(cCtx *cli.Context) error {
 		return err
 	}
 
-	if len(sessions) == 0 {
-		fmt.Println("No sessions")
-		return nil
+	return a.printer.print(sessions)
+}
+
+func (a *actions) getBlockchainSession(cCtx *cli.Context) error {
+	res, err := a.client.GetSession(cCtx.Context, cCtx.String("session"))
+	if err != nil {
+		return err
 	}
 
-	for _, item := range sessions {
-		var isActive bool
-		if item.CloseoutReceipt != "" {
-			isActive = false
-		} else {
-			isActive = true
-		}
+	return a.printer.print(res)
+}
+
+func (a *actions) userSessionIds(cCtx *cli.Context) error {
+	res, err := a.client.GetUserSessionIDs(cCtx.Context, cCtx.String("user"))
+	if err != nil {
+		return err
+	}
 
-		fmt.Println("\n", "Session: ", item.Sesssion)
-		fmt.Println("\t- provider: ", item.ModelORAgent)
-		fmt.Println("\t- price per second: ", item.PricePerSecond)
-		fmt.Println("\t- closed: ", !isActive)
-		fmt.Println("\t- expired: ", item.EndsAt < uint64(time.Now().Unix()))
+	return a.printer.print(res)
+}
 
+func (a *actions) virtualSessions(cCtx *cli.Context) error {
+	res, err := a.client.GetVirtualSessions(cCtx.Context)
+	if err != nil {
+		return err
 	}
 
-	return nil
+	return a.printer.print(res)
 }
 
 func (a *actions) closeBlockchainSession(cCtx *cli.Context) error {
+	res, err := a.client.CloseSession(cCtx.Context, cCtx.String("session"))
+	if err != nil {
+		return err
+	}
 
-	sessionId := cCtx.String("session")
+	return a.printer.print(res)
+}
+
+func (a *actions) sessionBudget(cCtx *cli.Context) error {
+	res, err := a.client.GetBudget(cCtx.Context)
+	if err != nil {
+		return err
+	}
 
-	err := a.client.CloseSession(cCtx.Context, sessionId)
+	return a.printer.print(res)
+}
 
+func (a *actions) providerClaimableBalance(cCtx *cli.Context) error {
+	res, err := a.client.GetProviderClaimableBalance(cCtx.Context, cCtx.String("session"))
 	if err != nil {
 		return err
 	}
 
-	fmt.Printf("Your request to close session %v was sent without issue.", sessionId)
-	return nil
+	return a.printer.print(res)
+}
+
+func (a *actions) claimProviderBalance(cCtx *cli.Context) error {
+	res, err := a.client.ClaimProviderBalance(cCtx.Context, cCtx.String("session"))
+	if err != nil {
+		return err
+	}
+
+	return a.printer.print(res)
 }
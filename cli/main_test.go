@@ -0,0 +1,94 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/MorpheusAIs/Morpheus-Lumerin-Node/cli/chat/client"
+)
+
+// unsetEnv unsets the env vars read by the CLI for the duration of the test
+func unsetEnv(t *testing.T) {
+	for _, env := range []string{"API_HOST", "API_TOKEN", "API_PROFILE", "API_ACCOUNT", "API_OUTPUT", "MOR_CLI_CONFIG"} {
+		t.Setenv(env, "")
+		os.Unsetenv(env)
+	}
+}
+
+// runApp runs the CLI with the args after the program name, the config file is in a temp dir
+// unless --config is passed
+func runApp(t *testing.T, stdin string, args ...string) (stdout string, stderr string, err error) {
+	t.Helper()
+	if !hasFlag(args, "--config") {
+		args = append([]string{"--config", filepath.Join(t.TempDir(), "config.yaml")}, args...)
+	}
+
+	var out, errOut bytes.Buffer
+	app := newApp()
+	app.Reader = strings.NewReader(stdin)
+	app.Writer = &out
+	app.ErrWriter = &errOut
+	err = app.Run(append([]string{"mor-cli"}, args...))
+	return out.String(), errOut.String(), err
+}
+
+func hasFlag(args []string, flag string) bool {
+	for _, arg := range args {
+		if arg == flag || strings.HasPrefix(arg, flag+"=") {
+			return true
+		}
+	}
+	return false
+}
+
+func TestExitCode(t *testing.T) {
+	apiErr := &client.ApiError{StatusCode: http.StatusBadRequest, Message: "invalid model id"}
+
+	tests := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{"api error", apiErr, ExitCodeAPIError},
+		{"wrapped api error", fmt.Errorf("internal error: %w", apiErr), ExitCodeAPIError},
+		{"node not reachable", errors.New("failed to send request: connection refused"), ExitCodeError},
+		{"invalid arguments", errors.New("please provide either a model or session id"), ExitCodeError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if code := exitCode(tt.err); code != tt.want {
+				t.Fatalf("expected exit code %d, got %d", tt.want, code)
+			}
+		})
+	}
+}
+
+func TestExitCodeOfCommand(t *testing.T) {
+	node := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		w.Write([]byte(`{"error":"node is starting"}`))
+	}))
+	defer node.Close()
+
+	unsetEnv(t)
+	t.Setenv("API_HOST", node.URL)
+	_, _, err := runApp(t, "", "healthcheck")
+	if code := exitCode(err); code != ExitCodeAPIError {
+		t.Fatalf("expected exit code %d, got %d: %v", ExitCodeAPIError, code, err)
+	}
+	if !strings.Contains(err.Error(), "node is starting") {
+		t.Fatalf("expected the node error message, got %v", err)
+	}
+
+	_, _, err = runApp(t, "", "--output", "xml", "healthcheck")
+	if code := exitCode(err); code != ExitCodeError {
+		t.Fatalf("expected exit code %d, got %d: %v", ExitCodeError, code, err)
+	}
+}
@@ -0,0 +1,228 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	OutputTable = "table"
+	OutputJSON  = "json"
+	OutputYAML  = "yaml"
+)
+
+var outputFormats = []string{OutputTable, OutputJSON, OutputYAML}
+
+// printer writes the command results in the format selected with the --output flag
+type printer struct {
+	format string
+	out    io.Writer
+}
+
+func newPrinter(format string, out io.Writer) (*printer, error) {
+	for _, f := range outputFormats {
+		if f == format {
+			return &printer{format: format, out: out}, nil
+		}
+	}
+	return nil, fmt.Errorf("unknown output format %q, use one of %s", format, strings.Join(outputFormats, ", "))
+}
+
+func (p *printer) print(v interface{}) error {
+	switch p.format {
+	case OutputJSON:
+		encoder := json.NewEncoder(p.out)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(v)
+	case OutputYAML:
+		value, err := toGeneric(v)
+		if err != nil {
+			return err
+		}
+		encoder := yaml.NewEncoder(p.out)
+		encoder.SetIndent(2)
+		defer encoder.Close()
+		return encoder.Encode(toYAMLValue(value))
+	default:
+		value, err := toGeneric(v)
+		if err != nil {
+			return err
+		}
+		return p.printTable(value)
+	}
+}
+
+// printText writes plain text as is in table format, other formats get it as {"<key>": text}
+func (p *printer) printText(key string, text string) error {
+	if p.format == OutputTable {
+		_, err := fmt.Fprintln(p.out, text)
+		return err
+	}
+	return p.print(map[string]string{key: text})
+}
+
+// printTable renders lists of objects as a table with a column per field and objects as key/value
+// rows. Responses wrapping a single list or object, like {"bids": [...]}, are unwrapped
+func (p *printer) printTable(value interface{}) error {
+	if obj, ok := value.(map[string]interface{}); ok && len(obj) == 1 {
+		for _, inner := range obj {
+			switch inner.(type) {
+			case []interface{}, map[string]interface{}:
+				value = inner
+			}
+		}
+	}
+
+	w := tabwriter.NewWriter(p.out, 0, 0, 2, ' ', 0)
+	switch v := value.(type) {
+	case []interface{}:
+		writeList(w, v)
+	case map[string]interface{}:
+		keys := sortedKeys(v)
+		if isSections(v) {
+			for i, key := range keys {
+				if i > 0 {
+					fmt.Fprintln(w)
+				}
+				fmt.Fprintf(w, "%s:\n", strings.ToUpper(key))
+				writeList(w, v[key].([]interface{}))
+			}
+			break
+		}
+		for _, key := range keys {
+			fmt.Fprintf(w, "%s\t%s\n", key, formatCell(v[key]))
+		}
+	default:
+		fmt.Fprintln(w, formatCell(v))
+	}
+	return w.Flush()
+}
+
+func writeList(w io.Writer, list []interface{}) {
+	if len(list) == 0 {
+		fmt.Fprintln(w, "No results")
+		return
+	}
+
+	columns := listColumns(list)
+	if len(columns) == 0 {
+		for _, item := range list {
+			fmt.Fprintln(w, formatCell(item))
+		}
+		return
+	}
+
+	header := make([]string, len(columns))
+	for i, column := range columns {
+		header[i] = strings.ToUpper(column)
+	}
+	fmt.Fprintln(w, strings.Join(header, "\t"))
+	for _, item := range list {
+		obj, _ := item.(map[string]interface{})
+		row := make([]string, len(columns))
+		for i, column := range columns {
+			row[i] = formatCell(obj[column])
+		}
+		fmt.Fprintln(w, strings.Join(row, "\t"))
+	}
+}
+
+// listColumns returns the fields of the listed objects, the id goes first
+func listColumns(list []interface{}) []string {
+	fields := map[string]interface{}{}
+	for _, item := range list {
+		obj, ok := item.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		for key := range obj {
+			fields[key] = nil
+		}
+	}
+	return sortedKeys(fields)
+}
+
+func sortedKeys(obj map[string]interface{}) []string {
+	keys := make([]string, 0, len(obj))
+	for key := range obj {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		iID, jID := strings.EqualFold(keys[i], "id"), strings.EqualFold(keys[j], "id")
+		if iID != jID {
+			return iID
+		}
+		return keys[i] < keys[j]
+	})
+	return keys
+}
+
+// isSections checks if all values of the object are lists, e.g. {"bids": [...], "excluded": [...]}
+func isSections(obj map[string]interface{}) bool {
+	for _, value := range obj {
+		if _, ok := value.([]interface{}); !ok {
+			return false
+		}
+	}
+	return len(obj) > 1
+}
+
+func formatCell(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case json.Number:
+		return v.String()
+	case bool:
+		return fmt.Sprint(v)
+	default:
+		data, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Sprint(v)
+		}
+		return string(data)
+	}
+}
+
+// toGeneric converts the value to maps, slices and scalars through json, so the json field names are used
+func toGeneric(v interface{}) (interface{}, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	var value interface{}
+	err = decoder.Decode(&value)
+	return value, err
+}
+
+// toYAMLValue replaces json numbers with integers, wei amounts too large for int64 and decimals are kept as strings
+func toYAMLValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, item := range v {
+			v[key] = toYAMLValue(item)
+		}
+	case []interface{}:
+		for i, item := range v {
+			v[i] = toYAMLValue(item)
+		}
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return i
+		}
+		return v.String()
+	}
+	return value
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"bytes"
+	"math/big"
+	"testing"
+)
+
+type testBid struct {
+	ID           string   `json:"id"`
+	Provider     string   `json:"provider"`
+	PricePerSec  *big.Int `json:"pricePerSecond"`
+	CreatedAt    int64    `json:"createdAt"`
+	DeletedAt    *int64   `json:"deletedAt"`
+	IsSubscribed bool     `json:"isSubscribed"`
+}
+
+func TestPrinter(t *testing.T) {
+	wei, _ := new(big.Int).SetString("100000000000000000000", 10)
+	bids := []testBid{
+		{ID: "0x01", Provider: "0xaa", PricePerSec: wei, CreatedAt: 1700000000},
+		{ID: "0x02", Provider: "0xbb", PricePerSec: big.NewInt(5), CreatedAt: 1700000001, IsSubscribed: true},
+	}
+
+	tests := []struct {
+		name   string
+		format string
+		value  interface{}
+		want   string
+	}{
+		{
+			name:   "json",
+			format: OutputJSON,
+			value:  map[string]interface{}{"bids": bids[:1]},
+			want: `{
+  "bids": [
+    {
+      "id": "0x01",
+      "provider": "0xaa",
+      "pricePerSecond": 100000000000000000000,
+      "createdAt": 1700000000,
+      "deletedAt": null,
+      "isSubscribed": false
+    }
+  ]
+}
+`,
+		},
+		{
+			name:   "yaml keeps large amounts precise",
+			format: OutputYAML,
+			value:  bids[:1],
+			want: `- createdAt: 1700000000
+  deletedAt: null
+  id: "0x01"
+  isSubscribed: false
+  pricePerSecond: "100000000000000000000"
+  provider: "0xaa"
+`,
+		},
+		{
+			name:   "table of a list unwraps the response and puts the id first",
+			format: OutputTable,
+			value:  map[string]interface{}{"bids": bids},
+			want: `ID    CREATEDAT   DELETEDAT  ISSUBSCRIBED  PRICEPERSECOND         PROVIDER
+0x01  1700000000             false         100000000000000000000  0xaa
+0x02  1700000001             true          5                      0xbb
+`,
+		},
+		{
+			name:   "table of an object",
+			format: OutputTable,
+			value:  map[string]interface{}{"status": "healthy", "uptime": "1h", "version": map[string]string{"commit": "abc"}},
+			want: `status   healthy
+uptime   1h
+version  {"commit":"abc"}
+`,
+		},
+		{
+			name:   "table of sections",
+			format: OutputTable,
+			value:  map[string]interface{}{"bids": []testBid{}, "excluded": []string{"0x03"}},
+			want: `BIDS:
+No results
+
+EXCLUDED:
+0x03
+`,
+		},
+		{
+			name:   "table of a scalar",
+			format: OutputTable,
+			value:  "0xhash",
+			want:   "0xhash\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			p, err := newPrinter(tt.format, &out)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := p.print(tt.value); err != nil {
+				t.Fatal(err)
+			}
+			if out.String() != tt.want {
+				t.Fatalf("unexpected output\n%s\nwant\n%s", out.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestPrinterText(t *testing.T) {
+	tests := []struct {
+		format string
+		want   string
+	}{
+		{OutputTable, "0xhash\n"},
+		{OutputJSON, "{\n  \"txHash\": \"0xhash\"\n}\n"},
+		{OutputYAML, "txHash: 0xhash\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			var out bytes.Buffer
+			p, err := newPrinter(tt.format, &out)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := p.printText("txHash", "0xhash"); err != nil {
+				t.Fatal(err)
+			}
+			if out.String() != tt.want {
+				t.Fatalf("unexpected output %q, want %q", out.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestNewPrinterUnknownFormat(t *testing.T) {
+	_, err := newPrinter("xml", &bytes.Buffer{})
+	if err == nil {
+		t.Fatal("expected an error for an unknown format")
+	}
+}
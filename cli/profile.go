@@ -0,0 +1,232 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/urfave/cli/v2"
+	"gopkg.in/yaml.v3"
+)
+
+const defaultAPIHost = "http://localhost:8082"
+
+// Profile is a proxy-router endpoint the CLI talks to
+type Profile struct {
+	URL   string `yaml:"url" json:"url"`
+	Token string `yaml:"token,omitempty" json:"token,omitempty"` // api token, see docs/api-auth.md
+	Model string `yaml:"model,omitempty" json:"model,omitempty"` // default model of the prompt and openSessionByModel commands
+}
+
+// Config is the CLI config file with the named profiles
+type Config struct {
+	Current  string              `yaml:"current,omitempty"`
+	Profiles map[string]*Profile `yaml:"profiles,omitempty"`
+
+	path string
+}
+
+// ProfileEntry is a profile as listed by the profile list command, the token is not printed
+type ProfileEntry struct {
+	Name    string `json:"name"`
+	URL     string `json:"url"`
+	Model   string `json:"model"`
+	Token   bool   `json:"token"`
+	Current bool   `json:"current"`
+}
+
+// defaultConfigPath returns mor-cli/config.yaml in the user config dir, e.g. ~/.config on linux
+func defaultConfigPath() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ".mor-cli.yaml"
+	}
+	return filepath.Join(dir, "mor-cli", "config.yaml")
+}
+
+// loadConfig reads the config file, a missing file is an empty config
+func loadConfig(path string) (*Config, error) {
+	config := &Config{path: path}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return config, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	err = yaml.Unmarshal(data, config)
+	if err != nil {
+		return nil, fmt.Errorf("invalid config file %s: %w", path, err)
+	}
+	return config, nil
+}
+
+// save writes the config file readable by the user only, as it holds the api tokens
+func (c *Config) save() error {
+	var data bytes.Buffer
+	encoder := yaml.NewEncoder(&data)
+	encoder.SetIndent(2)
+	err := encoder.Encode(c)
+	if err != nil {
+		return err
+	}
+	err = os.MkdirAll(filepath.Dir(c.path), 0o700)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(c.path, data.Bytes(), 0o600)
+}
+
+// resolveProfile selects the endpoint: the --profile flag, then the API_HOST and API_TOKEN env vars,
+// then the current profile of the config file and localhost if none is set
+func (c *Config) resolveProfile(name string) (*Profile, error) {
+	if name != "" {
+		profile, ok := c.Profiles[name]
+		if !ok {
+			return nil, fmt.Errorf("profile %q not found in %s", name, c.path)
+		}
+		return profile, nil
+	}
+	if host := os.Getenv("API_HOST"); host != "" {
+		return &Profile{URL: host, Token: os.Getenv("API_TOKEN")}, nil
+	}
+	if profile, ok := c.Profiles[c.Current]; ok {
+		return profile, nil
+	}
+	return &Profile{URL: defaultAPIHost, Token: os.Getenv("API_TOKEN")}, nil
+}
+
+func profileCommand(a *actions) *cli.Command {
+	return &cli.Command{
+		Name:    "profile",
+		Aliases: []string{"pr"},
+		Usage:   "manage the named profiles of the proxy-router endpoints",
+		Action:  a.listProfiles,
+		Subcommands: []*cli.Command{
+			{
+				Name:      "add",
+				Usage:     "mor-cli profile add --url <url> [--token <token>] [--model <model-id>] <name>",
+				ArgsUsage: "<name>",
+				Action:    a.addProfile,
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:     "url",
+						Usage:    "proxy-router api url, e.g. " + defaultAPIHost,
+						Required: true,
+					},
+					&cli.StringFlag{
+						Name:  "token",
+						Usage: "api token, required when the node api auth is enabled",
+					},
+					&cli.StringFlag{
+						Name:  "model",
+						Usage: "default model id",
+					},
+					&cli.BoolFlag{
+						Name:  "use",
+						Usage: "make it the current profile",
+					},
+				},
+			},
+			{
+				Name:   "list",
+				Usage:  "list the profiles",
+				Action: a.listProfiles,
+			},
+			{
+				Name:      "use",
+				Usage:     "set the current profile",
+				ArgsUsage: "<name>",
+				Action:    a.useProfile,
+			},
+			{
+				Name:      "remove",
+				Aliases:   []string{"rm"},
+				Usage:     "remove a profile",
+				ArgsUsage: "<name>",
+				Action:    a.removeProfile,
+			},
+		},
+	}
+}
+
+func (a *actions) addProfile(cCtx *cli.Context) error {
+	name := cCtx.Args().First()
+	if name == "" {
+		return fmt.Errorf("please provide the profile name")
+	}
+	if a.config.Profiles == nil {
+		a.config.Profiles = map[string]*Profile{}
+	}
+	a.config.Profiles[name] = &Profile{
+		URL:   cCtx.String("url"),
+		Token: cCtx.String("token"),
+		Model: cCtx.String("model"),
+	}
+	if cCtx.Bool("use") || a.config.Current == "" {
+		a.config.Current = name
+	}
+	err := a.config.save()
+	if err != nil {
+		return err
+	}
+	return a.printer.print(a.profileEntries())
+}
+
+func (a *actions) listProfiles(cCtx *cli.Context) error {
+	return a.printer.print(a.profileEntries())
+}
+
+func (a *actions) useProfile(cCtx *cli.Context) error {
+	name := cCtx.Args().First()
+	if _, ok := a.config.Profiles[name]; !ok {
+		return fmt.Errorf("profile %q not found in %s", name, a.config.path)
+	}
+	a.config.Current = name
+	err := a.config.save()
+	if err != nil {
+		return err
+	}
+	return a.printer.print(a.profileEntries())
+}
+
+func (a *actions) removeProfile(cCtx *cli.Context) error {
+	name := cCtx.Args().First()
+	if _, ok := a.config.Profiles[name]; !ok {
+		return fmt.Errorf("profile %q not found in %s", name, a.config.path)
+	}
+	delete(a.config.Profiles, name)
+	if a.config.Current == name {
+		a.config.Current = ""
+	}
+	err := a.config.save()
+	if err != nil {
+		return err
+	}
+	return a.printer.print(a.profileEntries())
+}
+
+func (a *actions) profileEntries() []ProfileEntry {
+	names := make([]string, 0, len(a.config.Profiles))
+	for name := range a.config.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	entries := make([]ProfileEntry, 0, len(names))
+	for _, name := range names {
+		profile := a.config.Profiles[name]
+		entries = append(entries, ProfileEntry{
+			Name:    name,
+			URL:     profile.URL,
+			Model:   profile.Model,
+			Token:   profile.Token != "",
+			Current: name == a.config.Current,
+		})
+	}
+	return entries
+}
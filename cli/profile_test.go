@@ -0,0 +1,234 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/MorpheusAIs/Morpheus-Lumerin-Node/cli/chat/client"
+)
+
+// headerNode is a node api answering the healthcheck with its name, it records the request headers
+type headerNode struct {
+	*httptest.Server
+	header http.Header
+}
+
+func newHeaderNode(t *testing.T, name string) *headerNode {
+	node := &headerNode{}
+	node.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		node.header = r.Header.Clone()
+		w.Write([]byte(`{"status":"healthy","node":"` + name + `"}`))
+	}))
+	t.Cleanup(node.Close)
+	return node
+}
+
+func listProfiles(t *testing.T, configPath string) []ProfileEntry {
+	t.Helper()
+	stdout, _, err := runApp(t, "", "--config", configPath, "--output", "json", "profile", "list")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var entries []ProfileEntry
+	if err := json.Unmarshal([]byte(stdout), &entries); err != nil {
+		t.Fatal(err)
+	}
+	return entries
+}
+
+func TestProfileSaveLoadUse(t *testing.T) {
+	unsetEnv(t)
+	configPath := filepath.Join(t.TempDir(), "mor-cli", "config.yaml")
+
+	_, _, err := runApp(t, "", "--config", configPath, "profile", "add", "--url", "http://local:8082", "local")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _, err = runApp(t, "", "--config", configPath, "profile", "add", "--url", "https://remote", "--token", "secret", "--model", "0x01", "remote")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the tokens are only readable by the user
+	info, err := os.Stat(configPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0o600 {
+		t.Fatalf("unexpected config file mode %s", info.Mode())
+	}
+	config, err := loadConfig(configPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.Current != "local" || *config.Profiles["remote"] != (Profile{URL: "https://remote", Token: "secret", Model: "0x01"}) {
+		t.Fatalf("unexpected config %+v", config)
+	}
+
+	// the first added profile is the current one, the token is not listed
+	want := []ProfileEntry{
+		{Name: "local", URL: "http://local:8082", Current: true},
+		{Name: "remote", URL: "https://remote", Model: "0x01", Token: true},
+	}
+	if entries := listProfiles(t, configPath); !equalEntries(entries, want) {
+		t.Fatalf("unexpected profiles %+v", entries)
+	}
+
+	_, _, err = runApp(t, "", "--config", configPath, "profile", "use", "remote")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want[0].Current, want[1].Current = false, true
+	if entries := listProfiles(t, configPath); !equalEntries(entries, want) {
+		t.Fatalf("unexpected profiles after use %+v", entries)
+	}
+
+	_, _, err = runApp(t, "", "--config", configPath, "profile", "use", "missing")
+	if err == nil {
+		t.Fatal("expected an error for a missing profile")
+	}
+
+	_, _, err = runApp(t, "", "--config", configPath, "profile", "rm", "remote")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if entries := listProfiles(t, configPath); !equalEntries(entries, []ProfileEntry{{Name: "local", URL: "http://local:8082"}}) {
+		t.Fatalf("unexpected profiles after remove %+v", entries)
+	}
+}
+
+func equalEntries(a, b []ProfileEntry) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestProfileWithFlagsAndEnv(t *testing.T) {
+	current := newHeaderNode(t, "current")
+	other := newHeaderNode(t, "other")
+	env := newHeaderNode(t, "env")
+
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+	config := &Config{
+		Current: "current",
+		Profiles: map[string]*Profile{
+			"current": {URL: current.URL, Token: "current-token"},
+			"other":   {URL: other.URL, Token: "other-token"},
+		},
+		path: configPath,
+	}
+	if err := config.save(); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		env     map[string]string
+		args    []string
+		node    *headerNode
+		token   string
+		account string
+	}{
+		{
+			name:  "current profile",
+			node:  current,
+			token: "current-token",
+		},
+		{
+			name:  "profile flag",
+			args:  []string{"--profile", "other"},
+			node:  other,
+			token: "other-token",
+		},
+		{
+			name:  "profile env var",
+			env:   map[string]string{"API_PROFILE": "other"},
+			node:  other,
+			token: "other-token",
+		},
+		{
+			name:  "host env vars override the current profile",
+			env:   map[string]string{"API_HOST": env.URL, "API_TOKEN": "env-token"},
+			node:  env,
+			token: "env-token",
+		},
+		{
+			name:  "profile flag overrides the host env vars",
+			env:   map[string]string{"API_HOST": env.URL, "API_TOKEN": "env-token"},
+			args:  []string{"--profile", "other"},
+			node:  other,
+			token: "other-token",
+		},
+		{
+			name:    "account flag overrides the account env var",
+			env:     map[string]string{"API_ACCOUNT": "ignored"},
+			args:    []string{"--account", "treasury"},
+			node:    current,
+			token:   "current-token",
+			account: "treasury",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			unsetEnv(t)
+			for key, value := range tt.env {
+				t.Setenv(key, value)
+			}
+			for _, node := range []*headerNode{current, other, env} {
+				node.header = nil
+			}
+
+			args := append([]string{"--config", configPath, "--output", "json"}, tt.args...)
+			stdout, _, err := runApp(t, "", append(args, "healthcheck")...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.node.header == nil {
+				t.Fatalf("the request was not sent to the expected node, got %s", stdout)
+			}
+			if got := tt.node.header.Get("Authorization"); got != "Bearer "+tt.token {
+				t.Fatalf("unexpected authorization %q", got)
+			}
+			if got := tt.node.header.Get(client.HeaderAccount); got != tt.account {
+				t.Fatalf("unexpected account %q", got)
+			}
+		})
+	}
+}
+
+func TestProfileDefaultModel(t *testing.T) {
+	node := newTestNode(t, chunkLine("ok", "stop"), "data: [DONE]")
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+	_, _, err := runApp(t, "", "--config", configPath, "profile", "add", "--url", node.URL, "--model", "0x01", "default")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the profile is used instead of API_HOST set by newTestNode
+	t.Setenv("API_PROFILE", "default")
+
+	_, _, err = runApp(t, "", "--config", configPath, "prompt", "hi")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := node.header.Get("model_id"); got != "0x01" {
+		t.Fatalf("expected the default model of the profile, got %q", got)
+	}
+
+	_, _, err = runApp(t, "", "--config", configPath, "prompt", "--model", "0x02", "hi")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := node.header.Get("model_id"); got != "0x02" {
+		t.Fatalf("expected the model flag to override the profile, got %q", got)
+	}
+}
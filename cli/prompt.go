@@ -0,0 +1,167 @@
+package main
+
+import (
+	"crypto/rand"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/sashabaranov/go-openai"
+	"github.com/urfave/cli/v2"
+)
+
+// PromptResult is the output of the prompt command in json and yaml formats
+type PromptResult struct {
+	ChatID       string        `json:"chatId"`
+	ModelID      string        `json:"modelId,omitempty"`
+	SessionID    string        `json:"sessionId,omitempty"`
+	Content      string        `json:"content"`
+	FinishReason string        `json:"finishReason,omitempty"`
+	Usage        *openai.Usage `json:"usage,omitempty"`
+	Messages     []string      `json:"messages,omitempty"` // node messages, e.g. about the failover
+}
+
+func promptFlags() []cli.Flag {
+	return []cli.Flag{
+		&cli.StringFlag{
+			Name:    "model",
+			Aliases: []string{"m"},
+			Usage:   "model id, the node opens a session with the model or uses the local model, the default model of the profile if not set",
+		},
+		&cli.StringFlag{
+			Name:    "session",
+			Aliases: []string{"s"},
+			Usage:   "session id or virtual session id",
+		},
+		&cli.StringFlag{
+			Name:  "system",
+			Usage: "system prompt",
+		},
+		&cli.StringFlag{
+			Name:  "chat-id",
+			Usage: "continue the chat stored by the node, the id of a new chat is in the json output",
+		},
+		&cli.Float64Flag{
+			Name:  "temperature",
+			Usage: "sampling temperature, model default if not set",
+		},
+		&cli.IntFlag{
+			Name:  "max-tokens",
+			Usage: "maximum number of tokens to generate, model default if not set",
+		},
+	}
+}
+
+// prompt sends a single prompt and streams the completion to stdout, node messages go to stderr.
+// With --output json or yaml the completion is printed when done
+func (a *actions) prompt(cCtx *cli.Context) error {
+	sessionID := cCtx.String("session")
+	modelID := cCtx.String("model")
+	if sessionID == "" {
+		modelID = a.model(cCtx)
+	}
+	if (modelID == "") == (sessionID == "") {
+		return fmt.Errorf("please provide either a model or session id")
+	}
+
+	prompt, err := readPrompt(cCtx.Args().Slice(), cCtx.App.Reader)
+	if err != nil {
+		return err
+	}
+
+	var messages []openai.ChatCompletionMessage
+	if system := cCtx.String("system"); system != "" {
+		messages = append(messages, openai.ChatCompletionMessage{Role: openai.ChatMessageRoleSystem, Content: system})
+	}
+	messages = append(messages, openai.ChatCompletionMessage{Role: openai.ChatMessageRoleUser, Content: prompt})
+
+	request := &openai.ChatCompletionRequest{
+		Messages:    messages,
+		Temperature: float32(cCtx.Float64("temperature")),
+		MaxTokens:   cCtx.Int("max-tokens"),
+	}
+
+	chatID := cCtx.String("chat-id")
+	if chatID == "" {
+		chatID, err = newChatID()
+		if err != nil {
+			return err
+		}
+	}
+
+	stream := a.printer.format == OutputTable
+	result := &PromptResult{ChatID: chatID, ModelID: modelID, SessionID: sessionID}
+	var content strings.Builder
+
+	_, err = a.client.PromptStreamRequest(cCtx.Context, request, modelID, sessionID, chatID, func(completion interface{}) error {
+		var chunk string
+		switch c := completion.(type) {
+		case *openai.ChatCompletionStreamResponse:
+			if c.Usage != nil {
+				result.Usage = c.Usage
+			}
+			if len(c.Choices) == 0 {
+				return nil
+			}
+			chunk = c.Choices[0].Delta.Content
+			if c.Choices[0].FinishReason != "" {
+				result.FinishReason = string(c.Choices[0].FinishReason)
+			}
+		case string:
+			result.Messages = append(result.Messages, c)
+			if stream {
+				fmt.Fprintln(cCtx.App.ErrWriter, c)
+			}
+			return nil
+		case map[string]interface{}:
+			// image generation result
+			chunk, _ = c["imageUrl"].(string)
+		}
+
+		content.WriteString(chunk)
+		if stream {
+			_, err := fmt.Fprint(a.printer.out, chunk)
+			return err
+		}
+		return nil
+	})
+	if stream && content.Len() > 0 {
+		fmt.Fprintln(a.printer.out)
+	}
+	if err != nil {
+		return err
+	}
+	if stream {
+		return nil
+	}
+
+	result.Content = content.String()
+	return a.printer.print(result)
+}
+
+// readPrompt joins the args, the prompt is read from stdin if there are no args or the only arg is "-"
+func readPrompt(args []string, stdin io.Reader) (string, error) {
+	if len(args) > 0 && !(len(args) == 1 && args[0] == "-") {
+		return strings.Join(args, " "), nil
+	}
+
+	data, err := io.ReadAll(stdin)
+	if err != nil {
+		return "", err
+	}
+	prompt := strings.TrimSpace(string(data))
+	if prompt == "" {
+		return "", fmt.Errorf("empty prompt, pass it as argument or to stdin")
+	}
+	return prompt, nil
+}
+
+func newChatID() (string, error) {
+	id := make([]byte, common.HashLength)
+	_, err := rand.Read(id)
+	if err != nil {
+		return "", err
+	}
+	return common.BytesToHash(id).Hex(), nil
+}
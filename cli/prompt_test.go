@@ -0,0 +1,227 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+// testNode is a node api serving /v1/chat/completions with the given stream lines
+type testNode struct {
+	*httptest.Server
+	lines   []string
+	pause   chan struct{} // if set, the stream waits for it after the first line
+	request openai.ChatCompletionRequest
+	header  http.Header
+}
+
+func newTestNode(t *testing.T, lines ...string) *testNode {
+	node := &testNode{lines: lines}
+	node.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/chat/completions" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		node.header = r.Header.Clone()
+		if err := json.NewDecoder(r.Body).Decode(&node.request); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		if r.Header.Get("model_id") == "unknown" {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"error":"model not found"}`))
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		for i, line := range node.lines {
+			fmt.Fprintf(w, "%s\n\n", line)
+			w.(http.Flusher).Flush()
+			if i == 0 && node.pause != nil {
+				select {
+				case <-node.pause:
+				case <-time.After(5 * time.Second):
+				}
+			}
+		}
+	}))
+	t.Cleanup(node.Close)
+	unsetEnv(t)
+	t.Setenv("API_HOST", node.URL)
+	return node
+}
+
+func chunkLine(content string, finishReason string) string {
+	chunk := openai.ChatCompletionStreamResponse{
+		ID:      "chatcmpl-1",
+		Choices: []openai.ChatCompletionStreamChoice{{Delta: openai.ChatCompletionStreamChoiceDelta{Content: content}, FinishReason: openai.FinishReason(finishReason)}},
+	}
+	data, _ := json.Marshal(chunk)
+	return "data: " + string(data)
+}
+
+// notifyWriter is a buffer safe for concurrent use, it closes written when the content contains want
+type notifyWriter struct {
+	mu      sync.Mutex
+	buf     bytes.Buffer
+	want    string
+	written chan struct{}
+}
+
+func (w *notifyWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	n, err := w.buf.Write(p)
+	if w.written != nil && strings.Contains(w.buf.String(), w.want) {
+		close(w.written)
+		w.written = nil
+	}
+	return n, err
+}
+
+func TestPromptFromArgs(t *testing.T) {
+	node := newTestNode(t,
+		chunkLine("4", ""),
+		`data: "session renewed"`,
+		chunkLine(", of course", "stop"),
+		"data: [DONE]",
+	)
+
+	stdout, stderr, err := runApp(t, "", "prompt", "--model", "0x01", "--system", "be brief", "what", "is", "2+2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stdout != "4, of course\n" {
+		t.Fatalf("unexpected output %q", stdout)
+	}
+	if stderr != "session renewed\n" {
+		t.Fatalf("expected the node message on stderr, got %q", stderr)
+	}
+
+	if node.header.Get("model_id") != "0x01" || node.header.Get("chat_id") == "" {
+		t.Fatalf("unexpected headers %v", node.header)
+	}
+	messages := node.request.Messages
+	if len(messages) != 2 || messages[0].Content != "be brief" || messages[1].Content != "what is 2+2" {
+		t.Fatalf("unexpected messages %+v", messages)
+	}
+	if !node.request.Stream {
+		t.Fatal("expected a streaming request")
+	}
+}
+
+func TestPromptFromStdin(t *testing.T) {
+	for _, args := range [][]string{{}, {"-"}} {
+		t.Run(fmt.Sprintf("args %v", args), func(t *testing.T) {
+			node := newTestNode(t,
+				chunkLine("hello", ""),
+				`data: "switched to another provider"`,
+				chunkLine(" there", "stop"),
+				"data: [DONE]",
+			)
+
+			stdout, stderr, err := runApp(t, "  say hello\n", append([]string{"--output", "json", "prompt", "--session", "0x02", "--chat-id", "0x03"}, args...)...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if stderr != "" {
+				t.Fatalf("node messages are in the json output, got %q on stderr", stderr)
+			}
+			if messages := node.request.Messages; len(messages) != 1 || messages[0].Content != "say hello" {
+				t.Fatalf("unexpected messages %+v", messages)
+			}
+			if node.header.Get("session_id") != "0x02" || node.header.Get("chat_id") != "0x03" {
+				t.Fatalf("unexpected headers %v", node.header)
+			}
+
+			var result PromptResult
+			if err := json.Unmarshal([]byte(stdout), &result); err != nil {
+				t.Fatal(err)
+			}
+			want := PromptResult{ChatID: "0x03", SessionID: "0x02", Content: "hello there", FinishReason: "stop", Messages: []string{"switched to another provider"}}
+			if fmt.Sprint(result) != fmt.Sprint(want) {
+				t.Fatalf("unexpected result %+v", result)
+			}
+		})
+	}
+
+	t.Run("empty stdin", func(t *testing.T) {
+		newTestNode(t)
+		_, _, err := runApp(t, " \n", "prompt", "--model", "0x01")
+		if err == nil || exitCode(err) != ExitCodeError {
+			t.Fatalf("expected an invalid arguments error, got %v", err)
+		}
+	})
+}
+
+func TestPromptStreamsOutput(t *testing.T) {
+	node := newTestNode(t, chunkLine("first", ""), chunkLine(" second", "stop"), "data: [DONE]")
+	node.pause = make(chan struct{})
+
+	// the node sends the rest of the stream only after the first chunk is printed
+	stdout := &notifyWriter{want: "first", written: node.pause}
+	app := newApp()
+	app.Reader = strings.NewReader("")
+	app.Writer = stdout
+	app.ErrWriter = &bytes.Buffer{}
+
+	start := time.Now()
+	err := app.Run([]string{"mor-cli", "--config", filepath.Join(t.TempDir(), "config.yaml"), "prompt", "--model", "0x01", "hi"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if time.Since(start) >= 5*time.Second {
+		t.Fatal("the first chunk was not printed before the end of the stream")
+	}
+	if stdout.buf.String() != "first second\n" {
+		t.Fatalf("unexpected output %q", stdout.buf.String())
+	}
+}
+
+func TestPromptError(t *testing.T) {
+	tests := []struct {
+		name   string
+		model  string
+		lines  []string
+		stdout string
+		errMsg string
+	}{
+		{
+			name:   "rejected by the node",
+			model:  "unknown",
+			errMsg: "model not found",
+		},
+		{
+			name:   "failed midway",
+			model:  "0x01",
+			lines:  []string{chunkLine("partial", ""), `{"error":"provider disconnected"}`},
+			stdout: "partial\n",
+			errMsg: "provider disconnected",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			newTestNode(t, tt.lines...)
+
+			stdout, _, err := runApp(t, "", "prompt", "--model", tt.model, "hi")
+			if err == nil || !strings.Contains(err.Error(), tt.errMsg) {
+				t.Fatalf("expected error %q, got %v", tt.errMsg, err)
+			}
+			if code := exitCode(err); code != ExitCodeAPIError {
+				t.Fatalf("expected exit code %d, got %d", ExitCodeAPIError, code)
+			}
+			if stdout != tt.stdout {
+				t.Fatalf("unexpected output %q", stdout)
+			}
+		})
+	}
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"text/tabwriter"
+	"time"
+)
+
+const usage = `Usage:
+  mor-launch                  start and supervise the processes of mor-launch.json
+  mor-launch status           show the state of the processes
+  mor-launch restart <name>   restart the process, e.g. the proxy-router after a config change
+  mor-launch logs [-n 100] <name>
+                              show the last output lines of the process
+  mor-launch stop             stop the processes and the launcher
+`
+
+// runCommand runs a subcommand against the control api of the running launcher
+func runCommand(conf *Config, args []string) error {
+	if conf.ControlAddr == "" {
+		return errors.New("the control api is disabled, set controlAddr in the config, e.g. 127.0.0.1:8099")
+	}
+	client := &controlClient{baseURL: "http://" + conf.ControlAddr, http: &http.Client{Timeout: time.Minute}}
+
+	switch args[0] {
+	case "status":
+		var statuses []ProcessStatus
+		err := client.do(http.MethodGet, "/processes", &statuses)
+		if err != nil {
+			return err
+		}
+		printStatus(statuses)
+		return nil
+	case "restart":
+		if len(args) < 2 {
+			return errors.New("please provide the process name")
+		}
+		var status ProcessStatus
+		err := client.do(http.MethodPost, "/processes/"+url.PathEscape(args[1])+"/restart", &status)
+		if err != nil {
+			return err
+		}
+		printStatus([]ProcessStatus{status})
+		return nil
+	case "logs":
+		flags := flag.NewFlagSet("logs", flag.ContinueOnError)
+		lines := flags.Int("n", defaultLogLines, "number of lines")
+		err := flags.Parse(args[1:])
+		if err != nil {
+			return err
+		}
+		if flags.NArg() < 1 {
+			return errors.New("please provide the process name")
+		}
+		var logs LogsRes
+		err = client.do(http.MethodGet, "/processes/"+url.PathEscape(flags.Arg(0))+"/logs?lines="+strconv.Itoa(*lines), &logs)
+		if err != nil {
+			return err
+		}
+		for _, line := range logs.Lines {
+			fmt.Println(line)
+		}
+		return nil
+	case "stop":
+		err := client.do(http.MethodPost, "/stop", nil)
+		if err != nil {
+			return err
+		}
+		fmt.Println("Stopping")
+		return nil
+	case "help", "-h", "--help":
+		fmt.Print(usage)
+		return nil
+	default:
+		fmt.Fprint(os.Stderr, usage)
+		return fmt.Errorf("unknown command %q", args[0])
+	}
+}
+
+type controlClient struct {
+	baseURL string
+	http    *http.Client
+}
+
+func (c *controlClient) do(method string, path string, result interface{}) error {
+	req, err := http.NewRequest(method, c.baseURL+path, nil)
+	if err != nil {
+		return err
+	}
+	res, err := c.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("launcher is not running or the control api is not reachable: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		var errRes ErrRes
+		if json.NewDecoder(res.Body).Decode(&errRes) == nil && errRes.Error != "" {
+			return errors.New(errRes.Error)
+		}
+		return fmt.Errorf("control api responded with status %d", res.StatusCode)
+	}
+	if result == nil {
+		return nil
+	}
+	return json.NewDecoder(res.Body).Decode(result)
+}
+
+func printStatus(statuses []ProcessStatus) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tSTATE\tPID\tUPTIME\tRESTARTS\tLAST EXIT CODE\tLAST ERROR")
+	for _, s := range statuses {
+		pid, uptime, exitCode := "-", "-", "-"
+		if s.PID != 0 {
+			pid = strconv.Itoa(s.PID)
+			uptime = (time.Duration(s.UptimeSeconds) * time.Second).String()
+		}
+		if s.LastExitCode != nil {
+			exitCode = strconv.Itoa(*s.LastExitCode)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%d\t%s\t%s\n", s.Name, s.State, pid, uptime, s.Restarts, exitCode, s.LastError)
+	}
+	w.Flush()
+}
@@ -0,0 +1,273 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"path"
+	"path/filepath"
+	"time"
+
+	"github.com/google/shlex"
+)
+
+type RestartPolicy string
+
+const (
+	RestartNo        RestartPolicy = "no"         // the process is started once
+	RestartOnFailure RestartPolicy = "on-failure" // restarted when it exits with an error
+	RestartAlways    RestartPolicy = "always"     // restarted whenever it exits
+)
+
+const (
+	defaultShutdownTimeout   = 10 * time.Second
+	defaultBackoffInitial    = time.Second
+	defaultBackoffMax        = time.Minute
+	defaultReadinessInterval = time.Second
+	defaultReadinessTimeout  = 2 * time.Minute
+)
+
+// Config is the mor-launch.json file
+type Config struct {
+	Run             []string        `json:"run"`             // commands started once without supervision, kept for the older configs
+	Processes       []ProcessConfig `json:"processes"`       // supervised processes
+	LogDir          string          `json:"logDir"`          // writes the output of each process to <logDir>/<name>.log
+	ShutdownTimeout Duration        `json:"shutdownTimeout"` // default time the processes get to exit on SIGTERM before they are killed
+	ControlAddr     string          `json:"controlAddr"`     // loopback address of the control api, disabled if empty
+}
+
+type ProcessConfig struct {
+	Name        string            `json:"name"`
+	Command     string            `json:"command"`
+	Dir         string            `json:"dir"` // working directory, relative to the launcher directory
+	Env         map[string]string `json:"env"` // added to the environment of the launcher
+	DependsOn   []string          `json:"dependsOn"`
+	Restart     RestartPolicy     `json:"restart"`
+	MaxRestarts int               `json:"maxRestarts"` // gives up after so many restarts in a row, 0 is unlimited
+	Backoff     Backoff           `json:"backoff"`
+	Readiness   *Readiness        `json:"readiness"`
+	LogFile     string            `json:"logFile"`   // overrides <logDir>/<name>.log
+	LogPrefix   string            `json:"logPrefix"` // prefix of the console output lines, the name by default
+	StopTimeout Duration          `json:"stopTimeout"`
+}
+
+// Backoff is the delay before a restart, it doubles with every restart in a row up to Max. A process
+// running longer than Max is considered recovered and the delay is reset
+type Backoff struct {
+	Initial Duration `json:"initial"`
+	Max     Duration `json:"max"`
+}
+
+// Readiness is the probe which tells that the process is ready and its dependents can start
+type Readiness struct {
+	HTTP     string   `json:"http"` // ready on a 2xx response to GET of the url
+	TCP      string   `json:"tcp"`  // ready when host:port accepts connections
+	Interval Duration `json:"interval"`
+	Timeout  Duration `json:"timeout"` // the process is stopped and handled as failed if not ready in time
+}
+
+// Duration is a time.Duration written as string in the config, e.g. "10s"
+type Duration time.Duration
+
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var s string
+	err := json.Unmarshal(data, &s)
+	if err != nil {
+		return fmt.Errorf("duration must be a string like \"10s\": %w", err)
+	}
+	value, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = Duration(value)
+	return nil
+}
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+func findConfig(fileName string, base string) (string, error) {
+	if _, err := os.Stat(fileName); err == nil {
+		return fileName, nil
+	}
+	homeFile := path.Join(os.Getenv("HOME"), fileName)
+	if _, err := os.Stat(homeFile); err == nil {
+		return homeFile, nil
+	}
+	siblingFile := path.Join(base, fileName)
+	if _, err := os.Stat(siblingFile); err == nil {
+		return siblingFile, nil
+	}
+	return "", os.ErrNotExist
+}
+
+func loadConfig(file string) (*Config, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	var conf Config
+	err = json.Unmarshal(data, &conf)
+	if err != nil {
+		return nil, err
+	}
+	err = conf.normalize()
+	if err != nil {
+		return nil, err
+	}
+	return &conf, nil
+}
+
+// normalize converts the run entries to processes, sets the defaults and validates the config
+func (c *Config) normalize() error {
+	if c.ShutdownTimeout == 0 {
+		c.ShutdownTimeout = Duration(defaultShutdownTimeout)
+	}
+	if c.ControlAddr != "" {
+		host, _, err := net.SplitHostPort(c.ControlAddr)
+		if err != nil {
+			return fmt.Errorf("controlAddr: %w", err)
+		}
+		if !isLoopback(host) {
+			return fmt.Errorf("controlAddr %s: the control api is not authenticated, only loopback addresses are allowed", c.ControlAddr)
+		}
+	}
+
+	names := map[string]bool{}
+	for _, p := range c.Processes {
+		names[p.Name] = true
+	}
+	for _, command := range c.Run {
+		args, err := shlex.Split(command)
+		if err != nil {
+			return fmt.Errorf("run %q: %w", command, err)
+		}
+		if len(args) == 0 {
+			continue
+		}
+		name := filepath.Base(args[0])
+		for i := 2; names[name]; i++ {
+			name = fmt.Sprintf("%s-%d", filepath.Base(args[0]), i)
+		}
+		names[name] = true
+		c.Processes = append(c.Processes, ProcessConfig{Name: name, Command: command, Restart: RestartNo})
+	}
+
+	byName := map[string]*ProcessConfig{}
+	for i := range c.Processes {
+		p := &c.Processes[i]
+		if p.Name == "" {
+			return fmt.Errorf("process %d: name is required", i)
+		}
+		if byName[p.Name] != nil {
+			return fmt.Errorf("process %s: duplicate name", p.Name)
+		}
+		byName[p.Name] = p
+
+		err := p.normalize(c)
+		if err != nil {
+			return fmt.Errorf("process %s: %w", p.Name, err)
+		}
+	}
+
+	for _, p := range c.Processes {
+		for _, dep := range p.DependsOn {
+			if byName[dep] == nil {
+				return fmt.Errorf("process %s: unknown dependency %s", p.Name, dep)
+			}
+		}
+	}
+	return checkCycles(byName)
+}
+
+func (p *ProcessConfig) normalize(c *Config) error {
+	args, err := shlex.Split(p.Command)
+	if err != nil {
+		return err
+	}
+	if len(args) == 0 {
+		return fmt.Errorf("command is required")
+	}
+
+	switch p.Restart {
+	case "":
+		p.Restart = RestartOnFailure
+	case RestartNo, RestartOnFailure, RestartAlways:
+	default:
+		return fmt.Errorf("unknown restart policy %q, use %s, %s or %s", p.Restart, RestartNo, RestartOnFailure, RestartAlways)
+	}
+	if p.MaxRestarts < 0 {
+		return fmt.Errorf("maxRestarts must not be negative")
+	}
+	if p.Backoff.Initial == 0 {
+		p.Backoff.Initial = Duration(defaultBackoffInitial)
+	}
+	if p.Backoff.Max == 0 {
+		p.Backoff.Max = Duration(defaultBackoffMax)
+	}
+	if p.Backoff.Max < p.Backoff.Initial {
+		p.Backoff.Max = p.Backoff.Initial
+	}
+
+	if r := p.Readiness; r != nil {
+		if (r.HTTP == "") == (r.TCP == "") {
+			return fmt.Errorf("readiness needs either http or tcp")
+		}
+		if r.Interval == 0 {
+			r.Interval = Duration(defaultReadinessInterval)
+		}
+		if r.Timeout == 0 {
+			r.Timeout = Duration(defaultReadinessTimeout)
+		}
+	}
+
+	if p.LogFile == "" && c.LogDir != "" {
+		p.LogFile = filepath.Join(c.LogDir, p.Name+".log")
+	}
+	if p.LogPrefix == "" {
+		p.LogPrefix = p.Name
+	}
+	if p.StopTimeout == 0 {
+		p.StopTimeout = c.ShutdownTimeout
+	}
+	return nil
+}
+
+// checkCycles makes sure the processes do not wait for each other
+func checkCycles(byName map[string]*ProcessConfig) error {
+	const (
+		visiting = 1
+		visited  = 2
+	)
+	state := map[string]int{}
+
+	var visit func(name string, chain []string) error
+	visit = func(name string, chain []string) error {
+		chain = append(chain, name)
+		switch state[name] {
+		case visiting:
+			return fmt.Errorf("dependency cycle: %v", chain)
+		case visited:
+			return nil
+		}
+		state[name] = visiting
+		for _, dep := range byName[name].DependsOn {
+			err := visit(dep, chain)
+			if err != nil {
+				return err
+			}
+		}
+		state[name] = visited
+		return nil
+	}
+
+	for name := range byName {
+		err := visit(name, nil)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// defaultLogLines is the number of lines returned by the logs endpoint if not set
+const defaultLogLines = 100
+
+// LogsRes is the response of the logs endpoint
+type LogsRes struct {
+	Name  string   `json:"name"`
+	Lines []string `json:"lines"`
+}
+
+type ErrRes struct {
+	Error string `json:"error"`
+}
+
+// startControlServer serves the control api, it is bound to a loopback address as it is not authenticated
+func startControlServer(addr string, s *Supervisor) error {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	log.Printf("Control api is listening on %s", listener.Addr())
+
+	go func() {
+		err := http.Serve(listener, localOnly(newControlHandler(s)))
+		log.Printf("Control api stopped: %s", err)
+	}()
+	return nil
+}
+
+// newControlHandler routes
+//
+//	GET  /processes                  status of all processes
+//	GET  /processes/{name}           status of the process
+//	POST /processes/{name}/restart   restarts the process
+//	GET  /processes/{name}/logs      last output lines of the process, ?lines=100
+//	POST /stop                       stops all processes and the launcher
+func newControlHandler(s *Supervisor) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/processes", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			writeError(w, http.StatusMethodNotAllowed, errors.New("method not allowed"))
+			return
+		}
+		writeJSON(w, http.StatusOK, s.Status())
+	})
+	mux.HandleFunc("/processes/", func(w http.ResponseWriter, r *http.Request) {
+		name, action, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/processes/"), "/")
+		switch {
+		case action == "" && r.Method == http.MethodGet:
+			status, err := s.ProcessStatus(name)
+			if err != nil {
+				writeError(w, http.StatusNotFound, err)
+				return
+			}
+			writeJSON(w, http.StatusOK, status)
+		case action == "restart" && r.Method == http.MethodPost:
+			err := s.Restart(name)
+			if errors.Is(err, ErrProcessNotFound) {
+				writeError(w, http.StatusNotFound, err)
+				return
+			}
+			if err != nil {
+				writeError(w, http.StatusConflict, err)
+				return
+			}
+			status, _ := s.ProcessStatus(name)
+			writeJSON(w, http.StatusOK, status)
+		case action == "logs" && r.Method == http.MethodGet:
+			lines := defaultLogLines
+			if value := r.URL.Query().Get("lines"); value != "" {
+				var err error
+				lines, err = strconv.Atoi(value)
+				if err != nil {
+					writeError(w, http.StatusBadRequest, errors.New("lines must be a number"))
+					return
+				}
+			}
+			logs, err := s.Logs(name, lines)
+			if err != nil {
+				writeError(w, http.StatusNotFound, err)
+				return
+			}
+			writeJSON(w, http.StatusOK, LogsRes{Name: name, Lines: logs})
+		default:
+			writeError(w, http.StatusNotFound, errors.New("not found"))
+		}
+	})
+	mux.HandleFunc("/stop", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			writeError(w, http.StatusMethodNotAllowed, errors.New("method not allowed"))
+			return
+		}
+		log.Print("Stop requested through the control api, stopping the processes")
+		go s.Shutdown()
+		writeJSON(w, http.StatusAccepted, map[string]string{"status": "stopping"})
+	})
+	return mux
+}
+
+// localOnly rejects the requests of web pages: cross-origin requests carry an Origin header and
+// DNS rebinding sends the name of the attacker's host
+func localOnly(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Origin") != "" {
+			writeError(w, http.StatusForbidden, errors.New("requests from browsers are not allowed"))
+			return
+		}
+		host, _, err := net.SplitHostPort(r.Host)
+		if err != nil {
+			host = r.Host
+		}
+		if !isLoopback(host) {
+			writeError(w, http.StatusForbidden, errors.New("host is not a loopback address"))
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// isLoopback is true for localhost and the loopback ips
+func isLoopback(host string) bool {
+	if strings.EqualFold(host, "localhost") {
+		return true
+	}
+	ip := net.ParseIP(strings.Trim(host, "[]"))
+	return ip != nil && ip.IsLoopback()
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, ErrRes{Error: err.Error()})
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestLocalOnly(t *testing.T) {
+	handler := localOnly(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	tests := []struct {
+		name   string
+		host   string
+		origin string
+		status int
+	}{
+		{"ipv4", "127.0.0.1:8099", "", http.StatusOK},
+		{"ipv6", "[::1]:8099", "", http.StatusOK},
+		{"localhost", "localhost:8099", "", http.StatusOK},
+		{"no port", "localhost", "", http.StatusOK},
+		{"rebinding", "attacker.example:8099", "", http.StatusForbidden},
+		{"lan ip", "192.168.1.10:8099", "", http.StatusForbidden},
+		{"web page", "127.0.0.1:8099", "https://attacker.example", http.StatusForbidden},
+		{"null origin", "127.0.0.1:8099", "null", http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/stop", nil)
+			req.Host = tt.host
+			if tt.origin != "" {
+				req.Header.Set("Origin", tt.origin)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+			if rec.Code != tt.status {
+				t.Fatalf("expected status %d, got %d", tt.status, rec.Code)
+			}
+		})
+	}
+}
+
+func TestControlAddr(t *testing.T) {
+	tests := []struct {
+		addr  string
+		valid bool
+	}{
+		{"", true},
+		{"127.0.0.1:8099", true},
+		{"localhost:8099", true},
+		{"[::1]:8099", true},
+		{"0.0.0.0:8099", false},
+		{":8099", false},
+		{"127.0.0.1", false},
+	}
+	for _, tt := range tests {
+		conf := Config{ControlAddr: tt.addr}
+		err := conf.normalize()
+		if tt.valid && err != nil {
+			t.Errorf("%q: unexpected error %s", tt.addr, err)
+		}
+		if !tt.valid && err == nil {
+			t.Errorf("%q: expected an error", tt.addr)
+		}
+	}
+}
@@ -1,92 +1,59 @@
 package main
 
 import (
-    "encoding/json"
-    "path"
-    "log"
-    "io"
-    "os"
-    "os/exec"
-    "path/filepath"
-    "sync"
-    "github.com/google/shlex"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
 )
 
-type Config struct {
-    Run []string `json:"run"`
-}
+func main() {
+	exepath, err := os.Executable()
+	if err != nil {
+		log.Panic(err)
+	}
+	base := filepath.Dir(exepath)
+	confName := "mor-launch.json"
+	confFile, err := findConfig(confName, base)
+	if err != nil {
+		log.Fatalf("Error finding %s: %v", confName, err)
+	}
+	conf, err := loadConfig(confFile)
+	if err != nil {
+		log.Fatalf("Error reading %s: %v", confName, err)
+	}
 
-func findConfig(fileName string, base string) (string, error) {
-    if _, err := os.Stat(fileName); err == nil {
-        return fileName, nil
-    }
-    homeFile := path.Join(os.Getenv("HOME"), fileName)
-    if _, err := os.Stat(homeFile); err == nil {
-        return homeFile, nil
-    }
-    siblingFile := path.Join(base, fileName)
-    if _, err := os.Stat(siblingFile); err == nil {
-        return siblingFile, nil
-    }
-    return "", os.ErrNotExist
-}
+	if len(os.Args) > 1 {
+		err := runCommand(conf, os.Args[1:])
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %s\n", err)
+			os.Exit(1)
+		}
+		return
+	}
 
-func main() {
-    exepath, err := os.Executable()
-    if err != nil {
-        log.Panic(err)
-    }
-    base := filepath.Dir(exepath)
-    confName := "mor-launch.json"
-    confFile, err := findConfig(confName, base)
-    if err != nil {
-        log.Fatalf("Error finding %s: %v", confName, err)
-    }
-    data, err := os.ReadFile(confFile)
-    if err != nil {
-        log.Fatalf("Error reading %s: %v", confName, err)
-    }
-    var conf Config
-    err = json.Unmarshal(data, &conf)
-    if err != nil {
-        log.Fatal("Error during Unmarshal(): ", err)
-    }
-    var wg sync.WaitGroup
-    for _, v := range conf.Run {
-        wg.Add(1)
-        go func(v string) {
-            defer wg.Done()
-            var args []string
-            var err error
-            args, err = shlex.Split(v)
-            if err != nil {
-                log.Print(err)
-                return
-            }
-            if len(args) == 0 {
-                log.Print("No args, bailing")
-                return
-            }
-            cmd := exec.Command(args[0], args[1:]...)
-            cmd.Dir = base
-            stdout, err := cmd.StdoutPipe()
-            if err != nil {
-                log.Print(err)
-                return
-            }
-            if err := cmd.Start(); err != nil {
-                log.Print(err)
-                return
-            }
-            if _, err := io.Copy(os.Stdout, stdout); err != nil {
-                log.Print(err)
-                return
-            }
-            if err := cmd.Wait(); err != nil {
-                log.Print(err)
-            }
-        }(v)
-    }
-    wg.Wait()
-}
+	supervisor, err := NewSupervisor(conf, base)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if conf.ControlAddr != "" {
+		err := startControlServer(conf.ControlAddr, supervisor)
+		if err != nil {
+			log.Printf("Control api is disabled: %s", err)
+		}
+	}
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		sig := <-signals
+		log.Printf("Received %s, stopping the processes", sig)
+		supervisor.Shutdown()
+	}()
 
+	supervisor.Run()
+	// waits for the shutdown started by a signal to complete
+	supervisor.Shutdown()
+}
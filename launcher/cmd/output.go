@@ -0,0 +1,103 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// consoleMutex keeps the lines of the processes from interleaving on the console
+var consoleMutex sync.Mutex
+
+// lineWriter writes the output of a process line by line to the console with the process prefix
+// and, without the prefix, to the log file and the history of the process
+type lineWriter struct {
+	prefix  []byte
+	console io.Writer
+	file    io.Writer // nil without a log file
+	history *lineBuffer
+	buf     []byte
+}
+
+func newLineWriter(prefix string, console io.Writer, file io.Writer, history *lineBuffer) *lineWriter {
+	return &lineWriter{prefix: []byte("[" + prefix + "] "), console: console, file: file, history: history}
+}
+
+func (w *lineWriter) Write(p []byte) (int, error) {
+	w.buf = append(w.buf, p...)
+	for {
+		i := bytes.IndexByte(w.buf, '\n')
+		if i < 0 {
+			break
+		}
+		w.writeLine(w.buf[:i+1])
+		w.buf = w.buf[i+1:]
+	}
+	return len(p), nil
+}
+
+// Flush writes the last line if the process exited without a newline
+func (w *lineWriter) Flush() {
+	if len(w.buf) > 0 {
+		w.writeLine(append(w.buf, '\n'))
+		w.buf = nil
+	}
+}
+
+func (w *lineWriter) writeLine(line []byte) {
+	consoleMutex.Lock()
+	w.console.Write(append(append([]byte{}, w.prefix...), line...))
+	consoleMutex.Unlock()
+
+	if w.file != nil {
+		w.file.Write(line)
+	}
+	w.history.add(string(bytes.TrimRight(line, "\r\n")))
+}
+
+// lineBuffer keeps the last lines of the output of a process for the logs command
+type lineBuffer struct {
+	mutex sync.Mutex
+	size  int
+	lines []string
+}
+
+func newLineBuffer(size int) *lineBuffer {
+	return &lineBuffer{size: size}
+}
+
+func (b *lineBuffer) add(line string) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	b.lines = append(b.lines, line)
+	// trims in batches to not copy the buffer on every line
+	if len(b.lines) >= 2*b.size {
+		b.lines = append([]string(nil), b.lines[len(b.lines)-b.size:]...)
+	}
+}
+
+// last returns up to n last lines, all kept lines if n is not positive
+func (b *lineBuffer) last(n int) []string {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if n <= 0 || n > b.size {
+		n = b.size
+	}
+	if n > len(b.lines) {
+		n = len(b.lines)
+	}
+	return append([]string{}, b.lines[len(b.lines)-n:]...)
+}
+
+// openLogFile opens the log file for appending, the output of the restarted processes is kept
+func openLogFile(name string) (*os.File, error) {
+	err := os.MkdirAll(filepath.Dir(name), 0o755)
+	if err != nil {
+		return nil, err
+	}
+	return os.OpenFile(name, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+)
+
+var (
+	errNotReady = errors.New("readiness probe timed out")
+	errExited   = errors.New("process exited before it was ready")
+)
+
+// waitReady polls the probe until it passes, the timeout is reached or the process exits
+func waitReady(r *Readiness, exited <-chan struct{}) error {
+	interval := time.Duration(r.Interval)
+	timeout := time.NewTimer(time.Duration(r.Timeout))
+	defer timeout.Stop()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if probe(r, interval) == nil {
+			return nil
+		}
+		select {
+		case <-exited:
+			return errExited
+		case <-timeout.C:
+			return errNotReady
+		case <-ticker.C:
+		}
+	}
+}
+
+func probe(r *Readiness, timeout time.Duration) error {
+	if r.TCP != "" {
+		conn, err := net.DialTimeout("tcp", r.TCP, timeout)
+		if err != nil {
+			return err
+		}
+		return conn.Close()
+	}
+
+	client := http.Client{Timeout: timeout}
+	res, err := client.Get(r.HTTP)
+	if err != nil {
+		return err
+	}
+	res.Body.Close()
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		return fmt.Errorf("status %d", res.StatusCode)
+	}
+	return nil
+}
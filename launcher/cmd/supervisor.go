@@ -0,0 +1,535 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/google/shlex"
+)
+
+// logHistorySize is the number of the last output lines of each process kept for the logs command
+const logHistorySize = 1000
+
+// restartWaitTimeout limits the wait of a restart request for the process to start again
+const restartWaitTimeout = 5 * time.Second
+
+var (
+	ErrProcessNotFound = errors.New("process not found")
+	ErrStopping        = errors.New("launcher is stopping")
+)
+
+type State string
+
+const (
+	StateWaiting  State = "waiting"  // for the dependencies to get ready
+	StateStarting State = "starting" // started, the readiness probe has not passed yet
+	StateRunning  State = "running"
+	StateBackoff  State = "backoff" // waiting to be restarted
+	StateStopped  State = "stopped" // not restarted anymore, can be restarted through the control api
+)
+
+// ProcessStatus is the state of a process reported by the control api
+type ProcessStatus struct {
+	Name          string     `json:"name"`
+	State         State      `json:"state"`
+	PID           int        `json:"pid,omitempty"`
+	StartedAt     *time.Time `json:"startedAt,omitempty"`
+	UptimeSeconds int64      `json:"uptimeSeconds"`
+	Restarts      int        `json:"restarts"`
+	LastExitCode  *int       `json:"lastExitCode,omitempty"` // -1 if killed by a signal
+	LastError     string     `json:"lastError,omitempty"`
+}
+
+// Supervisor starts the processes once their dependencies are ready, restarts them according to
+// their restart policy and stops them on shutdown
+type Supervisor struct {
+	processes []*process
+	byName    map[string]*process
+	changed   chan struct{} // signals that a process has stopped
+	stopping  chan struct{} // closed on shutdown
+	stopOnce  sync.Once
+}
+
+type process struct {
+	ProcessConfig
+	args    []string
+	dir     string
+	env     []string
+	deps    []*process
+	history *lineBuffer
+
+	ready     chan struct{} // closed when the process passes the readiness probe for the first time
+	readyOnce sync.Once
+	done      chan struct{} // closed when the process stops being restarted for the first time
+	doneOnce  sync.Once
+	restartCh chan struct{} // restart requests while the process is not running
+
+	mutex      sync.Mutex
+	running    *run // nil when the process is not running
+	state      State
+	startedAt  time.Time
+	restarts   int
+	lastExit   *int
+	lastError  string
+	restarting bool // the process is stopped by a restart request, the exit is not a failure
+}
+
+// run is a single start of a process
+type run struct {
+	cmd    *exec.Cmd
+	exited chan struct{}
+}
+
+func NewSupervisor(conf *Config, base string) (*Supervisor, error) {
+	s := &Supervisor{
+		byName:   map[string]*process{},
+		changed:  make(chan struct{}, 1),
+		stopping: make(chan struct{}),
+	}
+
+	for _, pc := range conf.Processes {
+		args, err := shlex.Split(pc.Command)
+		if err != nil {
+			return nil, err
+		}
+		p := &process{
+			ProcessConfig: pc,
+			args:          args,
+			dir:           resolvePath(base, pc.Dir),
+			env:           os.Environ(),
+			history:       newLineBuffer(logHistorySize),
+			ready:         make(chan struct{}),
+			done:          make(chan struct{}),
+			restartCh:     make(chan struct{}, 1),
+			state:         StateWaiting,
+		}
+		keys := make([]string, 0, len(pc.Env))
+		for key := range pc.Env {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			p.env = append(p.env, key+"="+pc.Env[key])
+		}
+		if pc.LogFile != "" {
+			p.LogFile = resolvePath(base, pc.LogFile)
+		}
+
+		s.byName[p.Name] = p
+		s.processes = append(s.processes, p)
+	}
+	for _, p := range s.processes {
+		for _, dep := range p.DependsOn {
+			p.deps = append(p.deps, s.byName[dep])
+		}
+	}
+	return s, nil
+}
+
+// Run supervises the processes until all of them have stopped or the supervisor shuts down
+func (s *Supervisor) Run() {
+	var wg sync.WaitGroup
+	for _, p := range s.processes {
+		wg.Add(1)
+		go func(p *process) {
+			defer wg.Done()
+			s.supervise(p)
+		}(p)
+	}
+
+	for !s.allStopped() && !s.isStopping() {
+		select {
+		case <-s.changed:
+		case <-s.stopping:
+		}
+	}
+	s.Shutdown()
+	wg.Wait()
+}
+
+// Shutdown sends SIGTERM to all running processes and kills the ones still running after their stop timeout
+func (s *Supervisor) Shutdown() {
+	s.stopOnce.Do(func() {
+		close(s.stopping)
+
+		var wg sync.WaitGroup
+		for _, p := range s.processes {
+			wg.Add(1)
+			go func(p *process) {
+				defer wg.Done()
+				p.stop()
+			}(p)
+		}
+		wg.Wait()
+	})
+}
+
+// Status returns the state of the processes in the config order
+func (s *Supervisor) Status() []ProcessStatus {
+	statuses := make([]ProcessStatus, 0, len(s.processes))
+	for _, p := range s.processes {
+		statuses = append(statuses, p.status())
+	}
+	return statuses
+}
+
+func (s *Supervisor) ProcessStatus(name string) (ProcessStatus, error) {
+	p, ok := s.byName[name]
+	if !ok {
+		return ProcessStatus{}, ErrProcessNotFound
+	}
+	return p.status(), nil
+}
+
+// Logs returns up to n last output lines of the process
+func (s *Supervisor) Logs(name string, n int) ([]string, error) {
+	p, ok := s.byName[name]
+	if !ok {
+		return nil, ErrProcessNotFound
+	}
+	return p.history.last(n), nil
+}
+
+// Restart stops the running process and starts it again right away, regardless of its restart policy.
+// A process which is not running is started, skipping the backoff delay
+func (s *Supervisor) Restart(name string) error {
+	p, ok := s.byName[name]
+	if !ok {
+		return ErrProcessNotFound
+	}
+	if s.isStopping() {
+		return ErrStopping
+	}
+
+	p.mutex.Lock()
+	r := p.running
+	if r != nil {
+		p.restarting = true
+	}
+	restarts := p.restarts
+	p.mutex.Unlock()
+
+	log.Printf("%s: restart requested", p.Name)
+	if r != nil {
+		p.stop()
+	} else {
+		select {
+		case p.restartCh <- struct{}{}:
+		default:
+		}
+	}
+
+	// waits for the supervisor to pick up the request, so that the status shows the new start
+	deadline := time.Now().Add(restartWaitTimeout)
+	for p.status().Restarts == restarts && time.Now().Before(deadline) && !s.isStopping() {
+		time.Sleep(50 * time.Millisecond)
+	}
+	return nil
+}
+
+func (s *Supervisor) isStopping() bool {
+	select {
+	case <-s.stopping:
+		return true
+	default:
+		return false
+	}
+}
+
+func (s *Supervisor) allStopped() bool {
+	for _, p := range s.processes {
+		if p.status().State != StateStopped {
+			return false
+		}
+	}
+	return true
+}
+
+// supervise runs the process until the shutdown, a stopped process waits for a restart request
+func (s *Supervisor) supervise(p *process) {
+	for {
+		s.superviseRuns(p)
+		p.doneOnce.Do(func() { close(p.done) })
+		p.setState(StateStopped)
+		select {
+		case s.changed <- struct{}{}:
+		default:
+		}
+
+		select {
+		case <-p.restartCh:
+			p.countRestart()
+		case <-s.stopping:
+			return
+		}
+	}
+}
+
+// superviseRuns waits for the dependencies and runs the process until its restart policy stops it
+func (s *Supervisor) superviseRuns(p *process) {
+	p.setState(StateWaiting)
+	for _, dep := range p.deps {
+		if !s.waitDependency(p, dep) {
+			return
+		}
+	}
+
+	delay := time.Duration(p.Backoff.Initial)
+	restarts := 0
+	for {
+		// the requests made while starting are outdated
+		select {
+		case <-p.restartCh:
+		default:
+		}
+
+		started := time.Now()
+		err := s.runOnce(p)
+		if s.isStopping() {
+			return
+		}
+		if p.takeRestartRequest() {
+			p.countRestart()
+			continue
+		}
+		if err != nil {
+			log.Printf("%s: %s", p.Name, err)
+		} else {
+			log.Printf("%s: exited", p.Name)
+		}
+		if p.Restart == RestartNo || (p.Restart == RestartOnFailure && err == nil) {
+			return
+		}
+
+		if time.Since(started) > time.Duration(p.Backoff.Max) {
+			delay, restarts = time.Duration(p.Backoff.Initial), 0
+		}
+		restarts++
+		if p.MaxRestarts > 0 && restarts > p.MaxRestarts {
+			log.Printf("%s: not restarted, failed %d times in a row", p.Name, restarts)
+			return
+		}
+
+		log.Printf("%s: restarting in %s", p.Name, delay)
+		p.setState(StateBackoff)
+		select {
+		case <-time.After(delay):
+		case <-p.restartCh:
+		case <-s.stopping:
+			return
+		}
+		p.countRestart()
+		delay *= 2
+		if delay > time.Duration(p.Backoff.Max) {
+			delay = time.Duration(p.Backoff.Max)
+		}
+	}
+}
+
+// waitDependency waits for the dependency to get ready, it fails if the dependency stops before
+func (s *Supervisor) waitDependency(p *process, dep *process) bool {
+	select {
+	case <-dep.ready:
+		return true
+	case <-dep.done:
+	case <-s.stopping:
+		return false
+	}
+
+	// the dependency could have got ready after a restart
+	select {
+	case <-dep.ready:
+		return true
+	default:
+		log.Printf("%s: not started, dependency %s stopped before it was ready", p.Name, dep.Name)
+		return false
+	}
+}
+
+// runOnce starts the process and waits until it exits, the process is stopped if it does not get ready in time
+func (s *Supervisor) runOnce(p *process) error {
+	var logFile *os.File
+	if p.LogFile != "" {
+		var err error
+		logFile, err = openLogFile(p.LogFile)
+		if err != nil {
+			return p.recordExit(nil, fmt.Errorf("cannot open log file: %w", err))
+		}
+		defer logFile.Close()
+	}
+
+	stdout := newLineWriter(p.LogPrefix, os.Stdout, nil, p.history)
+	stderr := newLineWriter(p.LogPrefix, os.Stderr, nil, p.history)
+	if logFile != nil {
+		stdout.file, stderr.file = logFile, logFile
+	}
+
+	cmd := exec.Command(p.args[0], p.args[1:]...)
+	cmd.Dir = p.dir
+	cmd.Env = p.env
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+
+	r, err := p.start(cmd, s.stopping)
+	if err != nil {
+		return p.recordExit(nil, err)
+	}
+	log.Printf("%s: started, pid %d", p.Name, cmd.Process.Pid)
+
+	var waitErr error
+	go func() {
+		waitErr = cmd.Wait()
+		stdout.Flush()
+		stderr.Flush()
+		close(r.exited)
+	}()
+
+	var readyErr error
+	if p.Readiness != nil {
+		readyErr = waitReady(p.Readiness, r.exited)
+		if readyErr == errNotReady {
+			log.Printf("%s: not ready after %s, stopping", p.Name, time.Duration(p.Readiness.Timeout))
+			p.stop()
+		}
+	}
+	select {
+	case <-r.exited:
+	default:
+		p.markReady()
+	}
+
+	<-r.exited
+	if readyErr == errNotReady {
+		return p.recordExit(cmd, readyErr)
+	}
+	return p.recordExit(cmd, waitErr)
+}
+
+// start starts the command unless the supervisor is stopping, so that a stop does not miss it
+func (p *process) start(cmd *exec.Cmd, stopping <-chan struct{}) (*run, error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	select {
+	case <-stopping:
+		return nil, fmt.Errorf("not started, stopping")
+	default:
+	}
+	err := cmd.Start()
+	if err != nil {
+		return nil, err
+	}
+	p.running = &run{cmd: cmd, exited: make(chan struct{})}
+	p.state = StateStarting
+	p.startedAt = time.Now()
+	return p.running, nil
+}
+
+// recordExit records the exit code and the error of the run
+func (p *process) recordExit(cmd *exec.Cmd, err error) error {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	p.running = nil
+	if cmd != nil && cmd.ProcessState != nil {
+		code := cmd.ProcessState.ExitCode()
+		p.lastExit = &code
+	}
+	p.lastError = ""
+	if err != nil {
+		p.lastError = err.Error()
+	}
+	return err
+}
+
+func (p *process) markReady() {
+	p.setState(StateRunning)
+	p.readyOnce.Do(func() {
+		log.Printf("%s: ready", p.Name)
+		close(p.ready)
+	})
+}
+
+func (p *process) setState(state State) {
+	p.mutex.Lock()
+	p.state = state
+	p.mutex.Unlock()
+}
+
+func (p *process) countRestart() {
+	p.mutex.Lock()
+	p.restarts++
+	p.mutex.Unlock()
+}
+
+func (p *process) takeRestartRequest() bool {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	requested := p.restarting
+	p.restarting = false
+	return requested
+}
+
+func (p *process) status() ProcessStatus {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	status := ProcessStatus{
+		Name:         p.Name,
+		State:        p.state,
+		Restarts:     p.restarts,
+		LastExitCode: p.lastExit,
+		LastError:    p.lastError,
+	}
+	if p.running != nil {
+		startedAt := p.startedAt
+		status.PID = p.running.cmd.Process.Pid
+		status.StartedAt = &startedAt
+		status.UptimeSeconds = int64(time.Since(startedAt).Seconds())
+	}
+	return status
+}
+
+// stop sends SIGTERM to the process and kills it if it is still running after the stop timeout
+func (p *process) stop() {
+	p.mutex.Lock()
+	r := p.running
+	p.mutex.Unlock()
+	if r == nil {
+		return
+	}
+
+	// windows does not support SIGTERM, the process is killed right away
+	err := r.cmd.Process.Signal(syscall.SIGTERM)
+	if err == nil {
+		select {
+		case <-r.exited:
+			log.Printf("%s: stopped", p.Name)
+			return
+		case <-time.After(time.Duration(p.StopTimeout)):
+			log.Printf("%s: still running after %s, killing", p.Name, time.Duration(p.StopTimeout))
+		}
+	}
+	r.cmd.Process.Kill()
+	<-r.exited
+	log.Printf("%s: stopped", p.Name)
+}
+
+func resolvePath(base string, path string) string {
+	if path == "" {
+		return base
+	}
+	if filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(base, path)
+}
@@ -0,0 +1,210 @@
+package main
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newTestSupervisor(t *testing.T, processes ...ProcessConfig) (*Supervisor, string) {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("the test processes need sh")
+	}
+	dir := t.TempDir()
+	conf := &Config{Processes: processes}
+	err := conf.normalize()
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := NewSupervisor(conf, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s, dir
+}
+
+// runSupervisor runs the supervisor in the background, the channel is closed when Run returns
+func runSupervisor(s *Supervisor) <-chan struct{} {
+	done := make(chan struct{})
+	go func() {
+		s.Run()
+		close(done)
+	}()
+	return done
+}
+
+func waitClosed(t *testing.T, ch <-chan struct{}, timeout time.Duration) {
+	t.Helper()
+	select {
+	case <-ch:
+	case <-time.After(timeout):
+		t.Fatalf("not done after %s", timeout)
+	}
+}
+
+func waitStatus(t *testing.T, s *Supervisor, name string, check func(ProcessStatus) bool) ProcessStatus {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		status, err := s.ProcessStatus(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if check(status) {
+			return status
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("unexpected status of %s: %+v", name, status)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// lines reads the lines the test processes appended to the file
+func lines(t *testing.T, dir, file string) []string {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join(dir, file))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	return strings.Fields(string(data))
+}
+
+func TestSupervisorRestartPolicy(t *testing.T) {
+	backoff := Backoff{Initial: Duration(50 * time.Millisecond), Max: Duration(time.Second)}
+
+	tests := []struct {
+		name       string
+		command    string
+		restart    RestartPolicy
+		maxRestart int
+		starts     int
+		exitCode   int
+		minElapsed time.Duration // sum of the backoff delays
+	}{
+		{"no restart", "sh -c 'echo x >> starts; exit 1'", RestartNo, 0, 1, 1, 0},
+		{"on-failure after success", "sh -c 'echo x >> starts'", RestartOnFailure, 3, 1, 0, 0},
+		{"on-failure up to max restarts", "sh -c 'echo x >> starts; exit 2'", RestartOnFailure, 3, 4, 2, 350 * time.Millisecond},
+		{"always up to max restarts", "sh -c 'echo x >> starts'", RestartAlways, 2, 3, 0, 150 * time.Millisecond},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, dir := newTestSupervisor(t, ProcessConfig{
+				Name:        "app",
+				Command:     tt.command,
+				Restart:     tt.restart,
+				MaxRestarts: tt.maxRestart,
+				Backoff:     backoff,
+			})
+
+			start := time.Now()
+			waitClosed(t, runSupervisor(s), 10*time.Second)
+			elapsed := time.Since(start)
+
+			if starts := len(lines(t, dir, "starts")); starts != tt.starts {
+				t.Fatalf("expected %d starts, got %d", tt.starts, starts)
+			}
+			status, _ := s.ProcessStatus("app")
+			if status.State != StateStopped || status.Restarts != tt.starts-1 {
+				t.Fatalf("unexpected status %+v", status)
+			}
+			if status.LastExitCode == nil || *status.LastExitCode != tt.exitCode {
+				t.Fatalf("expected exit code %d, got %+v", tt.exitCode, status.LastExitCode)
+			}
+			if elapsed < tt.minElapsed {
+				t.Fatalf("restarted without backoff, done after %s", elapsed)
+			}
+		})
+	}
+}
+
+func TestSupervisorDependencies(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := listener.Addr().String()
+	listener.Close()
+
+	readiness := &Readiness{TCP: addr, Interval: Duration(20 * time.Millisecond), Timeout: Duration(5 * time.Second)}
+
+	tests := []struct {
+		name       string
+		depCommand string
+		listen     bool // opens the readiness address of the dependency after a while
+		order      []string
+	}{
+		{"started when the dependency is ready", "sh -c 'echo db >> order; sleep 1'", true, []string{"db", "app"}},
+		{"not started when the dependency fails", "sh -c 'echo db >> order; exit 1'", false, []string{"db"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, dir := newTestSupervisor(t,
+				ProcessConfig{Name: "db", Command: tt.depCommand, Restart: RestartNo, Readiness: readiness},
+				ProcessConfig{Name: "app", Command: "sh -c 'echo app >> order'", Restart: RestartNo, DependsOn: []string{"db"}},
+			)
+			done := runSupervisor(s)
+
+			if tt.listen {
+				waitStatus(t, s, "db", func(status ProcessStatus) bool { return status.State == StateStarting })
+				time.Sleep(100 * time.Millisecond)
+				if status, _ := s.ProcessStatus("app"); status.State != StateWaiting {
+					t.Fatalf("app should wait for db, got %+v", status)
+				}
+
+				listener, err := net.Listen("tcp", addr)
+				if err != nil {
+					t.Fatal(err)
+				}
+				defer listener.Close()
+			}
+
+			waitClosed(t, done, 10*time.Second)
+			if order := lines(t, dir, "order"); strings.Join(order, ",") != strings.Join(tt.order, ",") {
+				t.Fatalf("expected start order %v, got %v", tt.order, order)
+			}
+		})
+	}
+}
+
+func TestSupervisorShutdown(t *testing.T) {
+	stopTimeout := Duration(300 * time.Millisecond)
+	s, _ := newTestSupervisor(t,
+		ProcessConfig{Name: "sleep", Command: "sleep 30", Restart: RestartAlways, StopTimeout: stopTimeout},
+		// ignores SIGTERM, so it is killed after the stop timeout
+		ProcessConfig{Name: "stubborn-1", Command: `sh -c 'trap "" TERM; while true; do sleep 0.05; done'`, Restart: RestartAlways, StopTimeout: stopTimeout},
+		ProcessConfig{Name: "stubborn-2", Command: `sh -c 'trap "" TERM; while true; do sleep 0.05; done'`, Restart: RestartAlways, StopTimeout: stopTimeout},
+	)
+	done := runSupervisor(s)
+	for _, name := range []string{"sleep", "stubborn-1", "stubborn-2"} {
+		waitStatus(t, s, name, func(status ProcessStatus) bool { return status.State == StateRunning })
+	}
+
+	start := time.Now()
+	s.Shutdown()
+	// the processes are stopped at once, not one after another
+	if elapsed := time.Since(start); elapsed >= 2*time.Duration(stopTimeout) {
+		t.Fatalf("shutdown took %s", elapsed)
+	}
+	waitClosed(t, done, 5*time.Second)
+
+	for _, status := range s.Status() {
+		if status.State != StateStopped || status.Restarts != 0 || status.PID != 0 {
+			t.Fatalf("unexpected status after shutdown %+v", status)
+		}
+		if status.LastExitCode == nil || *status.LastExitCode != -1 {
+			t.Fatalf("%s: expected to be stopped by a signal, got %+v", status.Name, status.LastExitCode)
+		}
+	}
+	if err := s.Restart("sleep"); err != ErrStopping {
+		t.Fatalf("expected ErrStopping, got %v", err)
+	}
+}
@@ -2,12 +2,14 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"math/big"
 	"net/url"
 	"os"
 	"os/signal"
 	"path/filepath"
+	"strings"
 	"syscall"
 	"time"
 
@@ -19,6 +21,7 @@ import (
 	"github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/handlers/httphandlers"
 	"github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/interfaces"
 	"github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/lib"
+	"github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/metrics"
 	"github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/proxyapi"
 	"github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/proxyctl"
 	"github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/repositories/contracts"
@@ -28,10 +31,14 @@ import (
 	"github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/repositories/registries"
 	sessionrepo "github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/repositories/session"
 	"github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/repositories/transport"
+	"github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/repositories/txmanager"
 	wlt "github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/repositories/wallet"
 	"github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/storages"
 	"github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/system"
+	"github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/tracing"
 	"github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/walletapi"
+	"github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/webhooks"
+	"github.com/ethereum/go-ethereum/common"
 
 	docs "github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/docs"
 )
@@ -172,16 +179,46 @@ func start() error {
 		os.Exit(1)
 	}()
 
+	shutdownTracing, err := tracing.Init(ctx, tracing.Config{
+		Endpoint:    cfg.Tracing.Endpoint,
+		ServiceName: cfg.Tracing.ServiceName,
+		SampleRatio: *cfg.Tracing.SampleRatio.Float64,
+		Propagate:   cfg.Tracing.Propagate,
+	}, appLog.Named("TRACING"))
+	if err != nil {
+		return err
+	}
+	defer func() {
+		// the app context is cancelled by now, spans are flushed with a separate timeout
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := shutdownTracing(shutdownCtx); err != nil {
+			appLog.Warnf("failed to flush traces: %s", err)
+		}
+	}()
+	if cfg.Tracing.Endpoint != "" {
+		appLog.Infof("tracing enabled, exporting spans to %s", cfg.Tracing.Endpoint)
+	}
+
 	keychainStorage := keychain.NewKeychain()
 
 	if cfg.App.ResetKeychain {
 		appLog.Warnf("Resetting keychain...")
-		wallet := wlt.NewKeychainWallet(keychainStorage)
-		err = wallet.DeleteWallet()
+		accounts := wlt.NewKeychainAccounts(keychainStorage)
+		names, err := accounts.ListAccounts()
 		if err != nil {
-			appLog.Warnf("Failed to delete wallet\n%s", err)
-		} else {
-			appLog.Info("Wallet deleted")
+			appLog.Warnf("Failed to list wallet accounts\n%s", err)
+		}
+		for _, name := range names {
+			wallet, err := accounts.GetAccount(name)
+			if err == nil {
+				err = wallet.DeleteWallet()
+			}
+			if err != nil {
+				appLog.Warnf("Failed to delete wallet account %s\n%s", name, err)
+			} else {
+				appLog.Infof("Wallet account %s deleted", name)
+			}
 		}
 
 		ethNodeStorage := ethclient.NewRPCClientStoreKeychain(keychainStorage, nil, rpcLog.Named("RPC"))
@@ -198,10 +235,25 @@ func start() error {
 	if cfg.Blockchain.EthNodeAddress != "" {
 		ethNodeAddresses = []string{cfg.Blockchain.EthNodeAddress}
 	}
-	rpcClientStore, err := ethclient.ConfigureRPCClientStore(keychainStorage, ethNodeAddresses, cfg.Blockchain.ChainID, rpcLog.Named("RPC"))
+	rpcStrategy, err := ethclient.ParseStrategy(cfg.Blockchain.RPCStrategy)
+	if err != nil {
+		return err
+	}
+	rpcClientCfg := ethclient.RPCClientConfig{
+		Strategy:            rpcStrategy,
+		MaxBlockLag:         cfg.Blockchain.MaxBlockLag,
+		HealthCheckInterval: cfg.Blockchain.HealthCheckInterval,
+	}
+	rpcClientStore, err := ethclient.ConfigureRPCClientStore(keychainStorage, ethNodeAddresses, cfg.Blockchain.ChainID, rpcClientCfg, rpcLog.Named("RPC"))
 	if err != nil {
 		return lib.WrapError(ErrConnectToEthNode, err)
 	}
+	go func() {
+		err := rpcClientStore.RunHealthCheck(ctx)
+		if err != nil && !errors.Is(err, context.Canceled) {
+			appLog.Errorf("eth node health check stopped: %s", err)
+		}
+	}()
 
 	ethClient := ethclient.NewClient(rpcClientStore.GetClient())
 	chainID, err := ethClient.ChainID(ctx)
@@ -216,13 +268,76 @@ func start() error {
 	storage := storages.NewStorage(storageLog, cfg.Proxy.StoragePath)
 	sessionStorage := storages.NewSessionStorage(storage)
 
-	var wallet interfaces.Wallet
-	if len(*cfg.Marketplace.WalletPrivateKey) > 0 {
+	err = sessionStorage.IndexUserSessions()
+	if err != nil {
+		return err
+	}
+
+	var (
+		wallet         interfaces.Wallet // consumer account, or the account selected by the request
+		providerWallet interfaces.Wallet
+		accounts       interfaces.Accounts
+	)
+	if cfg.Marketplace.RemoteSignerURL != "" {
+		if cfg.Marketplace.ConsumerAccount != lib.DefaultAccount || cfg.Marketplace.ProviderAccount != lib.DefaultAccount {
+			return wlt.ErrRemoteSignerAccounts
+		}
+		transportKey, err := storages.NewTransportKeyStorage(storage).GetOrCreateKey()
+		if err != nil {
+			return err
+		}
+		var signerAddr common.Address
+		if cfg.Marketplace.RemoteSignerAddress != nil {
+			signerAddr = *cfg.Marketplace.RemoteSignerAddress
+		}
+		remoteSigner, err := wlt.NewRemoteSigner(ctx, cfg.Marketplace.RemoteSignerURL, cfg.Marketplace.RemoteSignerAPI, signerAddr, transportKey)
+		if err != nil {
+			return err
+		}
+		signerAddr, err = remoteSigner.Address(ctx)
+		if err != nil {
+			return err
+		}
+		wallet = remoteSigner
+		providerWallet = wallet
+		appLog.Infof("Using remote signer, address: %s", signerAddr.Hex())
+	} else if len(*cfg.Marketplace.WalletPrivateKey) > 0 {
+		if cfg.Marketplace.ConsumerAccount != lib.DefaultAccount || cfg.Marketplace.ProviderAccount != lib.DefaultAccount {
+			return wlt.ErrEnvWalletAccounts
+		}
 		wallet = wlt.NewEnvWallet(*cfg.Marketplace.WalletPrivateKey)
+		providerWallet = wallet
 		appLog.Warnf("Using env wallet. Private key persistance unavailable")
 	} else {
-		wallet = wlt.NewKeychainWallet(keychainStorage)
-		appLog.Infof("Using keychain wallet")
+		if cfg.Marketplace.KeystoreDir != "" {
+			passphrase, err := wlt.ReadKeystorePassphrase(cfg.Marketplace.KeystorePassphrase, cfg.Marketplace.KeystorePassFile)
+			if err != nil {
+				return err
+			}
+			accounts, err = wlt.NewKeystoreAccounts(cfg.Marketplace.KeystoreDir, passphrase)
+			if err != nil {
+				return err
+			}
+			appLog.Infof("Using keystore wallet, folder: %s", cfg.Marketplace.KeystoreDir)
+		} else {
+			accounts = wlt.NewKeychainAccounts(keychainStorage)
+			appLog.Infof("Using keychain wallet")
+		}
+		wallet, err = wlt.NewAccountSelector(accounts, cfg.Marketplace.ConsumerAccount)
+		if err != nil {
+			return err
+		}
+		providerWallet, err = accounts.GetAccount(cfg.Marketplace.ProviderAccount)
+		if err != nil {
+			return err
+		}
+		// fail fast on a wrong keystore passphrase
+		for _, w := range []interfaces.PrKeyProvider{wallet, providerWallet} {
+			if _, err := w.GetPrivateKey(); errors.Is(err, wlt.ErrKeystore) {
+				return err
+			}
+		}
+		appLog.Infof("Consumer account: %s, provider account: %s", cfg.Marketplace.ConsumerAccount, cfg.Marketplace.ProviderAccount)
 	}
 
 	var logWatcher contracts.LogWatcher
@@ -230,7 +345,10 @@ func start() error {
 		logWatcher = contracts.NewLogWatcherSubscription(ethClient, cfg.Blockchain.MaxReconnects, rpcLog)
 		appLog.Infof("using websocket log subscription for blockchain events")
 	} else {
-		logWatcher = contracts.NewLogWatcherPolling(ethClient, cfg.Blockchain.PollingInterval, cfg.Blockchain.MaxReconnects, rpcLog)
+		checkpointStorage := storages.NewCheckpointStorage(storage)
+		pollingWatcher := contracts.NewLogWatcherPolling(ethClient, cfg.Blockchain.PollingInterval, cfg.Blockchain.MaxReconnects, cfg.Blockchain.Confirmations, checkpointStorage, rpcLog)
+		pollingWatcher.SetMaxBlockRange(cfg.Blockchain.MaxBlockRange)
+		logWatcher = pollingWatcher
 		appLog.Infof("using polling for blockchain events")
 	}
 
@@ -239,17 +357,68 @@ func start() error {
 		return err
 	}
 
+	webhooksCfg, err := config.LoadWebhooks(cfg.Webhooks.ConfigPath, appLog)
+	if err != nil {
+		return err
+	}
+
+	var webhookDispatcher *webhooks.Dispatcher
+	if webhooksCfg != nil {
+		webhookDispatcher = webhooks.NewDispatcher(webhooksCfg, appLog)
+		go func() {
+			err := webhookDispatcher.Run(ctx)
+			if err != nil && !errors.Is(err, context.Canceled) {
+				appLog.Errorf("webhooks stopped: %s", err)
+			}
+		}()
+	}
+
 	chatStoragePath := filepath.Join(cfg.Proxy.StoragePath, "chats")
 	chatStorage := chatstorage.NewChatStorage(chatStoragePath)
 
+	txManager := txmanager.NewTxManager(ethClient, storages.NewTxQueueStorage(storage), wallet, chainID, cfg.Blockchain.TxStuckTimeout, cfg.Blockchain.TxMaxGasBumps, appLog)
+	if accounts != nil {
+		txManager.SetWalletAccounts(accounts)
+	}
+	err = txManager.Load()
+	if err != nil {
+		appLog.Warnf("failed to load pending transactions: %s", err)
+	}
+	go func() {
+		err := txManager.Run(ctx)
+		if err != nil && !errors.Is(err, context.Canceled) {
+			appLog.Errorf("tx manager stopped: %s", err)
+		}
+	}()
+
 	multicallBackend := multicall.NewMulticall3Custom(ethClient, *cfg.Blockchain.Multicall3Addr)
 	sessionRouter := registries.NewSessionRouter(*cfg.Marketplace.DiamondContractAddress, ethClient, multicallBackend, rpcLog)
 	marketplace := registries.NewMarketplace(*cfg.Marketplace.DiamondContractAddress, ethClient, multicallBackend, rpcLog)
 	sessionRepo := sessionrepo.NewSessionRepositoryCached(sessionStorage, sessionRouter, marketplace)
 	proxyRouterApi := proxyapi.NewProxySender(chainID, wallet, contractLogStorage, sessionStorage, sessionRepo, appLog)
 	explorer := blockchainapi.NewExplorerClient(cfg.Blockchain.ExplorerApiUrl, *cfg.Marketplace.MorTokenAddress, cfg.Blockchain.ExplorerRetryDelay, cfg.Blockchain.ExplorerMaxRetries)
-	blockchainApi := blockchainapi.NewBlockchainService(ethClient, multicallBackend, *cfg.Marketplace.DiamondContractAddress, *cfg.Marketplace.MorTokenAddress, explorer, wallet, proxyRouterApi, sessionRepo, scorer, appLog, rpcLog, cfg.Blockchain.EthLegacyTx)
+	blockchainApi := blockchainapi.NewBlockchainService(txManager.Client(), multicallBackend, *cfg.Marketplace.DiamondContractAddress, *cfg.Marketplace.MorTokenAddress, explorer, wallet, proxyRouterApi, sessionRepo, scorer, appLog, rpcLog, cfg.Blockchain.EthLegacyTx)
 	proxyRouterApi.SetSessionService(blockchainApi)
+	proxyRouterApi.SetWebhooks(webhookDispatcher)
+	proxyRouterApi.SetOpenAIStrict(cfg.Proxy.OpenAIStrict)
+	blockchainApi.SetWebhooks(webhookDispatcher)
+	blockchainApi.SetTxManager(txManager)
+	if accounts != nil {
+		blockchainApi.SetProviderAccount(cfg.Marketplace.ProviderAccount)
+	}
+
+	if cfg.Indexer.Enable {
+		indexerStorage := storages.NewIndexerStorage(storage)
+		indexer := blockchainapi.NewIndexer(ethClient, multicallBackend, *cfg.Marketplace.DiamondContractAddress, logWatcher, indexerStorage, cfg.Indexer.StartBlock, cfg.Indexer.BackfillRange, appLog.Named("INDEXER"))
+		blockchainApi.SetIndexer(indexer)
+		go func() {
+			err := indexer.Run(ctx)
+			if err != nil && !errors.Is(err, context.Canceled) {
+				appLog.Errorf("indexer stopped: %s", err)
+			}
+		}()
+		appLog.Infof("local marketplace indexer enabled, start block %d", cfg.Indexer.StartBlock)
+	}
 
 	modelConfigLoader := config.NewModelConfigLoader(cfg.Proxy.ModelsConfigPath, valid, blockchainApi, &aiengine.ConnectionChecker{}, appLog)
 	err = modelConfigLoader.Init()
@@ -259,18 +428,67 @@ func start() error {
 
 	aiEngine := aiengine.NewAiEngine(proxyRouterApi, chatStorage, modelConfigLoader, appLog)
 
-	eventListener := blockchainapi.NewEventsListener(sessionRepo, sessionRouter, wallet, logWatcher, appLog)
+	eventListener := blockchainapi.NewEventsListener(sessionRepo, sessionRouter, providerWallet, logWatcher, appLog)
+	eventListener.SetWebhooks(webhookDispatcher)
 
 	sessionExpiryHandler := blockchainapi.NewSessionExpiryHandler(blockchainApi, sessionStorage, wallet, appLog)
+	sessionExpiryHandler.SetWebhooks(webhookDispatcher)
+	if accounts != nil {
+		eventListener.SetAccounts(accounts)
+		sessionExpiryHandler.SetAccounts(accounts)
+	}
+	sessionRenewer := blockchainapi.NewSessionRenewer(blockchainApi, storages.NewVirtualSessionStorage(storage), cfg.Proxy.SessionRenewBefore, appLog)
+	sessionRenewer.SetWebhooks(webhookDispatcher)
+	proxyRouterApi.SetVirtualSessions(sessionRenewer)
+	go func() {
+		err := sessionRenewer.Run(ctx)
+		if err != nil && !errors.Is(err, context.Canceled) {
+			appLog.Errorf("session renewer stopped: %s", err)
+		}
+	}()
+
 	blockchainController := blockchainapi.NewBlockchainController(blockchainApi, appLog)
+	blockchainController.SetSessionRenewer(sessionRenewer)
 
 	ethConnectionValidator := system.NewEthConnectionValidator(*big.NewInt(int64(cfg.Blockchain.ChainID)))
 	proxyController := proxyapi.NewProxyController(proxyRouterApi, aiEngine, chatStorage, *cfg.Proxy.StoreChatContext.Bool, *cfg.Proxy.ForwardChatContext.Bool, appLog)
+	proxyController.SetOpenAIStrict(cfg.Proxy.OpenAIStrict)
+	proxyController.SetResponseSchemaRetries(cfg.Proxy.SchemaRetries)
 	walletController := walletapi.NewWalletController(wallet)
+	if accounts != nil {
+		walletController.SetAccounts(accounts)
+	}
 	systemController := system.NewSystemController(&cfg, wallet, rpcClientStore, sysConfig, appStartTime, chainID, appLog, ethConnectionValidator)
+	systemController.SetEthNodeHealth(rpcClientStore)
+	systemController.SetWebhooks(webhookDispatcher)
 
 	apiBus := apibus.NewApiBus(blockchainController, proxyController, walletController, systemController)
-	httpHandler := httphandlers.CreateHTTPServer(appLog, apiBus)
+	var authenticator *httphandlers.Authenticator
+	if cfg.Web.AuthConfigPath != "" {
+		authenticator, err = httphandlers.LoadAuthenticator(cfg.Web.AuthConfigPath, appLog)
+		if err != nil {
+			return err
+		}
+		authenticator.SetConsumerAccount(cfg.Marketplace.ConsumerAccount)
+	} else {
+		appLog.Warnf("api auth is disabled, set WEB_AUTH_CONFIG_PATH to protect the api")
+	}
+
+	var corsOrigins []string
+	if cfg.Web.CorsOrigins != "" {
+		for _, origin := range strings.Split(cfg.Web.CorsOrigins, ",") {
+			corsOrigins = append(corsOrigins, strings.TrimSpace(origin))
+		}
+	}
+
+	controllers := []httphandlers.Registrable{apiBus}
+	if cfg.Metrics.Enable {
+		metrics.Registry.MustRegister(metrics.NewSessionsCollector(sessionStorage, providerWallet))
+		controllers = append(controllers, metrics.NewMetricsController())
+		appLog.Infof("prometheus metrics enabled on /metrics")
+	}
+
+	httpHandler := httphandlers.CreateHTTPServer(appLog, corsOrigins, authenticator, controllers...)
 	httpServer := transport.NewServer(cfg.Web.Address, httpHandler, appLog.Named("HTTP"))
 
 	// http server should shut down latest to keep pprof running
@@ -283,7 +501,9 @@ func start() error {
 
 	appLog.Infof("API docs available at %s/swagger/index.html", cfg.Web.PublicUrl)
 
-	proxy := proxyctl.NewProxyCtl(eventListener, wallet, chainID, appLog, tcpLog, cfg.Proxy.Address, sessionStorage, modelConfigLoader, valid, aiEngine, blockchainApi, sessionRepo, sessionExpiryHandler)
+	proxy := proxyctl.NewProxyCtl(eventListener, providerWallet, chainID, appLog, tcpLog, cfg.Proxy.Address, sessionStorage, modelConfigLoader, valid, aiEngine, blockchainApi, sessionRepo, sessionExpiryHandler)
+	proxy.SetWebhooks(webhookDispatcher)
+	proxy.SetResponseCache(storages.NewResponseCacheStorage(storage))
 	err = proxy.Run(ctx)
 
 	cancelServer()
@@ -0,0 +1,51 @@
+// mockai serves an OpenAI compatible mock model for local testing, see internal/testlib/mockai
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+
+	"github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/testlib/mockai"
+)
+
+func main() {
+	err := start()
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+}
+
+func start() error {
+	var cfg mockai.Config
+	addr := flag.String("addr", "127.0.0.1:8090", "listen address, the model api url is http://<addr>/v1")
+	script := flag.String("script", "", "json file with the scripted responses, a list of {match, content, status, error, times}")
+	flag.DurationVar(&cfg.Latency, "latency", 0, "delay of the response or the first chunk")
+	flag.Float64Var(&cfg.TokensPerSecond, "tokens-per-second", 20, "rate of the streamed chunks, 0 sends them at once")
+	flag.Float64Var(&cfg.ErrorRate, "error-rate", 0, "share of the requests failed with an error, from 0 to 1")
+	flag.IntVar(&cfg.ErrorStatus, "error-status", mockai.DefaultErrorStatus, "http status of the injected errors")
+	flag.IntVar(&cfg.AbortAfterTokens, "abort-after-tokens", 0, "drops the connection after so many streamed tokens, 0 disables")
+	flag.StringVar(&cfg.DefaultResponse, "response", "", "response if no scripted one matches, echoes the prompt if empty")
+	flag.Parse()
+
+	if *script != "" {
+		data, err := os.ReadFile(*script)
+		if err != nil {
+			return err
+		}
+		err = json.Unmarshal(data, &cfg.Responses)
+		if err != nil {
+			return fmt.Errorf("invalid script %s: %w", *script, err)
+		}
+	}
+
+	server, err := mockai.NewServer(cfg)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("mock model %s is listening: http://%s/v1\n", mockai.DefaultModel, *addr)
+	return http.ListenAndServe(*addr, server)
+}
@@ -230,6 +230,300 @@ const docTemplate = `{
                 }
             }
         },
+        "/blockchain/indexer/bids": {
+            "get": {
+                "description": "Get bids from the local index, sortBy is one of createdAt, pricePerSecond",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "indexer"
+                ],
+                "summary": "Get Indexed Bids",
+                "parameters": [
+                    {
+                        "minimum": 1,
+                        "type": "integer",
+                        "example": 10,
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "minLength": 0,
+                        "type": "string",
+                        "example": "0",
+                        "name": "offset",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "asc",
+                            "desc"
+                        ],
+                        "type": "string",
+                        "example": "asc",
+                        "name": "order",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "createdAt",
+                        "name": "sortBy",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Provider address",
+                        "name": "provider",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Model ID",
+                        "name": "modelId",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Only active bids",
+                        "name": "active",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/structs.BidsRes"
+                        }
+                    }
+                }
+            }
+        },
+        "/blockchain/indexer/models": {
+            "get": {
+                "description": "Get models from the local index, sortBy is one of createdAt, fee, stake, name",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "indexer"
+                ],
+                "summary": "Get Indexed Models",
+                "parameters": [
+                    {
+                        "minimum": 1,
+                        "type": "integer",
+                        "example": 10,
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "minLength": 0,
+                        "type": "string",
+                        "example": "0",
+                        "name": "offset",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "asc",
+                            "desc"
+                        ],
+                        "type": "string",
+                        "example": "asc",
+                        "name": "order",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "createdAt",
+                        "name": "sortBy",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Model owner address",
+                        "name": "owner",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Model tag",
+                        "name": "tag",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Include deregistered models",
+                        "name": "includeDeleted",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/structs.ModelsRes"
+                        }
+                    }
+                }
+            }
+        },
+        "/blockchain/indexer/providers": {
+            "get": {
+                "description": "Get providers from the local index, sortBy is one of createdAt, stake",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "indexer"
+                ],
+                "summary": "Get Indexed Providers",
+                "parameters": [
+                    {
+                        "minimum": 1,
+                        "type": "integer",
+                        "example": 10,
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "minLength": 0,
+                        "type": "string",
+                        "example": "0",
+                        "name": "offset",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "asc",
+                            "desc"
+                        ],
+                        "type": "string",
+                        "example": "asc",
+                        "name": "order",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "createdAt",
+                        "name": "sortBy",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Include deregistered providers",
+                        "name": "includeDeleted",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/structs.ProvidersRes"
+                        }
+                    }
+                }
+            }
+        },
+        "/blockchain/indexer/sessions": {
+            "get": {
+                "description": "Get sessions from the local index, sortBy is one of openedAt, endsAt, stake",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "indexer"
+                ],
+                "summary": "Get Indexed Sessions",
+                "parameters": [
+                    {
+                        "minimum": 1,
+                        "type": "integer",
+                        "example": 10,
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "minLength": 0,
+                        "type": "string",
+                        "example": "0",
+                        "name": "offset",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "asc",
+                            "desc"
+                        ],
+                        "type": "string",
+                        "example": "asc",
+                        "name": "order",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "createdAt",
+                        "name": "sortBy",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "User address",
+                        "name": "user",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Provider address",
+                        "name": "provider",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Model ID",
+                        "name": "modelId",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Only open sessions",
+                        "name": "open",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/structs.SessionsRes"
+                        }
+                    }
+                }
+            }
+        },
+        "/blockchain/indexer/status": {
+            "get": {
+                "description": "Get the state of the local marketplace index",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "indexer"
+                ],
+                "summary": "Get Indexer Status",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/structs.IndexerStatusRes"
+                        }
+                    }
+                }
+            }
+        },
         "/blockchain/latestBlock": {
             "get": {
                 "description": "Get latest block number from blockchain",
@@ -411,7 +705,7 @@ const docTemplate = `{
         },
         "/blockchain/models/{id}/bids/rated": {
             "get": {
-                "description": "Get rated bids from blockchain by model",
+                "description": "Get rated bids from blockchain by model, bids excluded by rating filters are returned with the reason",
                 "produces": [
                     "application/json"
                 ],
@@ -965,6 +1259,26 @@ const docTemplate = `{
                 }
             }
         },
+        "/blockchain/sessions/virtual": {
+            "get": {
+                "description": "Returns the auto-renewed sessions of the selected account with their current session IDs",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "sessions"
+                ],
+                "summary": "Get virtual sessions",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/structs.VirtualSessionsRes"
+                        }
+                    }
+                }
+            }
+        },
         "/blockchain/sessions/{id}": {
             "get": {
                 "description": "Returns session by ID",
@@ -1077,6 +1391,26 @@ const docTemplate = `{
                 }
             }
         },
+        "/blockchain/txqueue": {
+            "get": {
+                "description": "Get the next nonces, pending transactions sent by the node and the recently mined or dropped ones",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "transactions"
+                ],
+                "summary": "Get Transaction Queue Status",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/txmanager.QueueStatus"
+                        }
+                    }
+                }
+            }
+        },
         "/config": {
             "get": {
                 "description": "Return the current config of proxy router",
@@ -1149,6 +1483,29 @@ const docTemplate = `{
                 }
             }
         },
+        "/config/ethNode/status": {
+            "get": {
+                "description": "Return the health of the eth node endpoints: state, score, latency, error rate and block lag",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "system"
+                ],
+                "summary": "Get Eth Node Status",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/ethclient.EndpointStatus"
+                            }
+                        }
+                    }
+                }
+            }
+        },
         "/files": {
             "get": {
                 "description": "Returns opened files",
@@ -1192,6 +1549,26 @@ const docTemplate = `{
                 }
             }
         },
+        "/metrics": {
+            "get": {
+                "description": "Node metrics in Prometheus exposition format",
+                "produces": [
+                    "text/plain"
+                ],
+                "tags": [
+                    "system"
+                ],
+                "summary": "Get Metrics",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
         "/proxy/provider/ping": {
             "post": {
                 "description": "sends a ping to the provider on the RPC level",
@@ -1248,7 +1625,7 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/morrpcmesssage.SessionRes"
+                            "$ref": "#/definitions/proxyapi.InitiateSessionRes"
                         }
                     }
                 }
@@ -1535,6 +1912,94 @@ const docTemplate = `{
                 }
             }
         },
+        "/wallet/accounts": {
+            "get": {
+                "description": "Get named wallet accounts with their addresses, an account is selected with the X-Account header",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "wallet"
+                ],
+                "summary": "Get Accounts",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/walletapi.AccountsRes"
+                        }
+                    }
+                }
+            }
+        },
+        "/wallet/keystore/export": {
+            "post": {
+                "description": "Export the wallet private key as an Ethereum V3 keystore (Web3 Secret Storage) json encrypted with the passphrase",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "wallet"
+                ],
+                "summary": "Export wallet as keystore",
+                "parameters": [
+                    {
+                        "description": "Passphrase of the exported keystore",
+                        "name": "passphrase",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/walletapi.ExportKeystoreReqBody"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object"
+                        }
+                    }
+                }
+            }
+        },
+        "/wallet/keystore/import": {
+            "post": {
+                "description": "Setup wallet with the private key of an Ethereum V3 keystore (Web3 Secret Storage) json",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "wallet"
+                ],
+                "summary": "Setup wallet with keystore",
+                "parameters": [
+                    {
+                        "description": "Keystore and its passphrase",
+                        "name": "keystore",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/walletapi.ImportKeystoreReqBody"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/walletapi.WalletRes"
+                        }
+                    }
+                }
+            }
+        },
         "/wallet/mnemonic": {
             "post": {
                 "description": "Setup wallet using mnemonic",
@@ -1603,6 +2068,26 @@ const docTemplate = `{
                     }
                 }
             }
+        },
+        "/webhooks/test": {
+            "post": {
+                "description": "Sends a test event to all configured webhook endpoints",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "system"
+                ],
+                "summary": "Send test webhook",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/system.ConfigResponse"
+                        }
+                    }
+                }
+            }
         }
     },
     "definitions": {
@@ -1632,6 +2117,65 @@ const docTemplate = `{
                 }
             }
         },
+        "ethclient.EndpointState": {
+            "type": "string",
+            "enum": [
+                "healthy",
+                "degraded",
+                "lagging",
+                "rate-limited",
+                "circuit-open"
+            ],
+            "x-enum-varnames": [
+                "EndpointHealthy",
+                "EndpointDegraded",
+                "EndpointLagging",
+                "EndpointRateLimited",
+                "EndpointOpen"
+            ]
+        },
+        "ethclient.EndpointStatus": {
+            "type": "object",
+            "properties": {
+                "blockLag": {
+                    "type": "integer"
+                },
+                "blockNumber": {
+                    "type": "integer"
+                },
+                "errorRate": {
+                    "description": "moving average, 0-1",
+                    "type": "number"
+                },
+                "failures": {
+                    "type": "integer"
+                },
+                "lastError": {
+                    "type": "string"
+                },
+                "latencyMs": {
+                    "description": "moving average",
+                    "type": "integer"
+                },
+                "requests": {
+                    "type": "integer"
+                },
+                "retryAt": {
+                    "description": "when the open or rate limited endpoint is tried again",
+                    "type": "string"
+                },
+                "score": {
+                    "description": "0-100, based on latency, error rate and block lag",
+                    "type": "integer"
+                },
+                "state": {
+                    "$ref": "#/definitions/ethclient.EndpointState"
+                },
+                "url": {
+                    "type": "string"
+                }
+            }
+        },
         "genericchatstorage.Chat": {
             "type": "object",
             "properties": {
@@ -1793,37 +2337,6 @@ const docTemplate = `{
                 }
             }
         },
-        "morrpcmesssage.SessionRes": {
-            "type": "object",
-            "required": [
-                "approval",
-                "approvalSig",
-                "message",
-                "signature",
-                "timestamp",
-                "user"
-            ],
-            "properties": {
-                "approval": {
-                    "type": "string"
-                },
-                "approvalSig": {
-                    "type": "string"
-                },
-                "message": {
-                    "type": "string"
-                },
-                "signature": {
-                    "type": "string"
-                },
-                "timestamp": {
-                    "type": "integer"
-                },
-                "user": {
-                    "type": "string"
-                }
-            }
-        },
         "proxyapi.ChatCompletionRequestSwaggerExample": {
             "type": "object",
             "properties": {
@@ -1875,6 +2388,43 @@ const docTemplate = `{
                 }
             }
         },
+        "proxyapi.InitiateSessionRes": {
+            "type": "object",
+            "required": [
+                "approval",
+                "approvalSig",
+                "message",
+                "signature",
+                "timestamp",
+                "user"
+            ],
+            "properties": {
+                "approval": {
+                    "type": "string"
+                },
+                "approvalSig": {
+                    "type": "string"
+                },
+                "capabilities": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "message": {
+                    "type": "string"
+                },
+                "signature": {
+                    "type": "string"
+                },
+                "timestamp": {
+                    "type": "integer"
+                },
+                "user": {
+                    "type": "string"
+                }
+            }
+        },
         "proxyapi.PingReq": {
             "type": "object",
             "required": [
@@ -2071,6 +2621,40 @@ const docTemplate = `{
                 }
             }
         },
+        "structs.ExcludedBid": {
+            "type": "object",
+            "properties": {
+                "bid": {
+                    "$ref": "#/definitions/structs.Bid"
+                },
+                "reason": {
+                    "type": "string"
+                }
+            }
+        },
+        "structs.IndexerStatusRes": {
+            "type": "object",
+            "properties": {
+                "block": {
+                    "type": "integer",
+                    "example": 1234
+                },
+                "blockHash": {
+                    "type": "string",
+                    "example": "0x1234"
+                },
+                "reorgs": {
+                    "type": "integer"
+                },
+                "startBlock": {
+                    "type": "integer",
+                    "example": 1234
+                },
+                "synced": {
+                    "type": "boolean"
+                }
+            }
+        },
         "structs.Model": {
             "type": "object",
             "properties": {
@@ -2158,12 +2742,19 @@ const docTemplate = `{
                 "sessionID": {
                     "type": "string",
                     "example": "0x1234"
+                },
+                "virtualSessionID": {
+                    "type": "string",
+                    "example": "0x1234"
                 }
             }
         },
         "structs.OpenSessionWithDurationRequest": {
             "type": "object",
             "properties": {
+                "autoRenew": {
+                    "type": "boolean"
+                },
                 "sessionDuration": {
                     "type": "string"
                 }
@@ -2172,6 +2763,9 @@ const docTemplate = `{
         "structs.OpenSessionWithFailover": {
             "type": "object",
             "properties": {
+                "autoRenew": {
+                    "type": "boolean"
+                },
                 "directPayment": {
                     "type": "boolean"
                 },
@@ -2312,6 +2906,12 @@ const docTemplate = `{
                     "items": {
                         "$ref": "#/definitions/structs.ScoredBid"
                     }
+                },
+                "excluded": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/structs.ExcludedBid"
+                    }
                 }
             }
         },
@@ -2435,6 +3035,46 @@ const docTemplate = `{
                 }
             }
         },
+        "structs.VirtualSession": {
+            "type": "object",
+            "properties": {
+                "bidID": {
+                    "type": "string"
+                },
+                "directPayment": {
+                    "type": "boolean"
+                },
+                "duration": {
+                    "type": "integer"
+                },
+                "failover": {
+                    "type": "boolean"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "modelID": {
+                    "type": "string"
+                },
+                "renewals": {
+                    "type": "integer"
+                },
+                "sessionID": {
+                    "type": "string"
+                }
+            }
+        },
+        "structs.VirtualSessionsRes": {
+            "type": "object",
+            "properties": {
+                "sessions": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/structs.VirtualSession"
+                    }
+                }
+            }
+        },
         "system.ConfigResponse": {
             "type": "object",
             "properties": {
@@ -2486,8 +3126,132 @@ const docTemplate = `{
                     }
                 }
             }
+        },
+        "txmanager.QueueStatus": {
+            "type": "object",
+            "properties": {
+                "nextNonces": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "integer"
+                    }
+                },
+                "pending": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/txmanager.TxStatus"
+                    }
+                },
+                "recent": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/txmanager.TxStatus"
+                    }
+                }
+            }
+        },
+        "txmanager.TxStatus": {
+            "type": "object",
+            "properties": {
+                "bumps": {
+                    "type": "integer"
+                },
+                "from": {
+                    "type": "string"
+                },
+                "hash": {
+                    "type": "string"
+                },
+                "nonce": {
+                    "type": "integer"
+                },
+                "prevHashes": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "sentAt": {
+                    "type": "integer"
+                },
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
+        "walletapi.AccountRes": {
+            "type": "object",
+            "properties": {
+                "address": {
+                    "type": "string",
+                    "example": "0x1234"
+                },
+                "name": {
+                    "type": "string",
+                    "example": "default"
+                }
+            }
+        },
+        "walletapi.AccountsRes": {
+            "type": "object",
+            "properties": {
+                "accounts": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/walletapi.AccountRes"
+                    }
+                }
+            }
+        },
+        "walletapi.ExportKeystoreReqBody": {
+            "type": "object",
+            "required": [
+                "passphrase"
+            ],
+            "properties": {
+                "passphrase": {
+                    "type": "string"
+                }
+            }
+        },
+        "walletapi.ImportKeystoreReqBody": {
+            "type": "object",
+            "required": [
+                "keystore",
+                "passphrase"
+            ],
+            "properties": {
+                "keystore": {
+                    "type": "object"
+                },
+                "passphrase": {
+                    "type": "string"
+                }
+            }
+        },
+        "walletapi.WalletRes": {
+            "type": "object",
+            "properties": {
+                "address": {
+                    "type": "string",
+                    "example": "0x1234"
+                }
+            }
+        }
+    },
+    "securityDefinitions": {
+        "BearerAuth": {
+            "description": "\"Bearer \u003ctoken\u003e\", required when API auth is enabled",
+            "type": "apiKey",
+            "name": "Authorization",
+            "in": "header"
         }
     },
+    "security": [
+        {
+            "BearerAuth": []
+        }
+    ],
     "externalDocs": {
         "description": "OpenAPI",
         "url": "https://swagger.io/resources/open-api/"
@@ -36,7 +36,9 @@ func NewAiEngine(service ProxyService, storage gcs.ChatStorageInterface, modelsC
 	}
 }
 
-func (a *AiEngine) GetAdapter(ctx context.Context, chatID, modelID, sessionID common.Hash, storeChatContext, forwardChatContext bool) (AIEngineStream, error) {
+// GetAdapter returns the engine of the local model or the remote session. The responses of the remote session
+// are validated if the schema is set
+func (a *AiEngine) GetAdapter(ctx context.Context, chatID, modelID, sessionID common.Hash, storeChatContext, forwardChatContext bool, schema *ResponseSchema) (AIEngineStream, error) {
 	var engine AIEngineStream
 	if sessionID == (common.Hash{}) {
 		// local model
@@ -52,6 +54,9 @@ func (a *AiEngine) GetAdapter(ctx context.Context, chatID, modelID, sessionID co
 	} else {
 		// remote model
 		engine = &RemoteModel{sessionID: sessionID, service: a.service}
+		if schema != nil {
+			engine = NewSchemaEngine(engine, schema, a.log)
+		}
 	}
 
 	if storeChatContext {
@@ -0,0 +1,39 @@
+package aiengine
+
+import (
+	"testing"
+
+	"github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/chatstorage"
+	"github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/lib"
+	"github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/testlib/mockai"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHistoryForwardsChatContext(t *testing.T) {
+	mock := mockai.NewTestServer(t, mockai.Config{})
+	storage := chatstorage.NewChatStorage(t.TempDir())
+	chatID := common.HexToHash("0x01")
+	log := lib.NewTestLogger()
+
+	history := NewHistory(NewOpenAIEngine(mockai.DefaultModel, mock.URL, "", log), storage, chatID, common.HexToHash("0x02"), true, log)
+
+	text, err := collect(t, history, newPrompt("my name is Alice", true))
+	require.NoError(t, err)
+	require.Equal(t, "You said: my name is Alice", text)
+
+	_, err = collect(t, history, newPrompt("what is my name?", false))
+	require.NoError(t, err)
+
+	requests := mock.Requests()
+	require.Len(t, requests, 2)
+	messages := requests[1].Messages
+	require.Len(t, messages, 3)
+	require.Equal(t, "my name is Alice", messages[0].Content)
+	require.Equal(t, "You said: my name is Alice", messages[1].Content)
+	require.Equal(t, "what is my name?", messages[2].Content)
+
+	chat, err := storage.LoadChatFromFile(chatID.Hex())
+	require.NoError(t, err)
+	require.Len(t, chat.Messages, 2)
+}
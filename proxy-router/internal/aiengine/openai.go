@@ -38,7 +38,7 @@ func NewOpenAIEngine(modelName, baseURL, apiKey string, log lib.ILogger) *OpenAI
 
 func (a *OpenAI) Prompt(ctx context.Context, compl *openai.ChatCompletionRequest, cb gcs.CompletionCallback) error {
 	compl.Model = a.modelName
-	requestBody, err := json.Marshal(compl)
+	requestBody, err := json.Marshal(PromptMessage(ctx, compl))
 	if err != nil {
 		return fmt.Errorf("failed to encode request: %v", err)
 	}
@@ -62,6 +62,11 @@ func (a *OpenAI) Prompt(ctx context.Context, compl *openai.ChatCompletionRequest
 	}
 	defer resp.Body.Close()
 
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBodySize))
+		return lib.WrapError(ErrChatCompletion, fmt.Errorf("status code: %d, response: %s", resp.StatusCode, strings.TrimSpace(string(body))))
+	}
+
 	if isContentTypeStream(resp.Header) {
 		return a.readStream(ctx, resp.Body, cb)
 	}
@@ -70,8 +75,13 @@ func (a *OpenAI) Prompt(ctx context.Context, compl *openai.ChatCompletionRequest
 }
 
 func (a *OpenAI) readResponse(ctx context.Context, body io.Reader, cb gcs.CompletionCallback) error {
+	raw, err := io.ReadAll(body)
+	if err != nil {
+		return fmt.Errorf("failed to read response: %v", err)
+	}
+
 	var compl openai.ChatCompletionResponse
-	if err := json.NewDecoder(body).Decode(&compl); err != nil {
+	if err := json.Unmarshal(raw, &compl); err != nil {
 		return fmt.Errorf("failed to decode response: %v", err)
 	}
 
@@ -80,8 +90,8 @@ func (a *OpenAI) readResponse(ctx context.Context, body io.Reader, cb gcs.Comple
 		text[i] = choice.Message.Content
 	}
 
-	chunk := gcs.NewChunkText(&compl)
-	err := cb(ctx, chunk)
+	chunk := gcs.NewChunkTextRaw(&compl, raw)
+	err = cb(ctx, chunk)
 	if err != nil {
 		return fmt.Errorf("callback failed: %v", err)
 	}
@@ -105,7 +115,7 @@ func (a *OpenAI) readStream(ctx context.Context, body io.Reader, cb gcs.Completi
 				}
 			}
 			// Call the callback function with the unmarshalled completion
-			chunk := gcs.NewChunkStreaming(&compl)
+			chunk := gcs.NewChunkStreamingRaw(&compl, []byte(data))
 			err := cb(ctx, chunk)
 			if err != nil {
 				return fmt.Errorf("callback failed: %v", err)
@@ -140,4 +150,7 @@ const (
 	StreamDataPrefix = "data: "
 )
 
+// maxErrorBodySize limits the part of an upstream error response included in the error
+const maxErrorBodySize = 1024
+
 var _ AIEngineStream = &OpenAI{}
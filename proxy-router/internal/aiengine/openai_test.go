@@ -0,0 +1,141 @@
+package aiengine
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	gcs "github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/chatstorage/genericchatstorage"
+	"github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/lib"
+	"github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/testlib/mockai"
+	"github.com/sashabaranov/go-openai"
+	"github.com/stretchr/testify/require"
+)
+
+func newPrompt(text string, stream bool) *openai.ChatCompletionRequest {
+	return &openai.ChatCompletionRequest{
+		Stream:   stream,
+		Messages: []openai.ChatCompletionMessage{{Role: openai.ChatMessageRoleUser, Content: text}},
+	}
+}
+
+// collect runs the prompt and returns the text of the chunks
+func collect(t *testing.T, engine AIEngineStream, prompt *openai.ChatCompletionRequest) (string, error) {
+	var text strings.Builder
+	err := engine.Prompt(context.Background(), prompt, func(ctx context.Context, chunk gcs.Chunk) error {
+		text.WriteString(chunk.String())
+		return nil
+	})
+	return text.String(), err
+}
+
+func TestOpenAIPrompt(t *testing.T) {
+	mock := mockai.NewTestServer(t, mockai.Config{DefaultResponse: "hello from the mock"})
+	engine := NewOpenAIEngine(mockai.DefaultModel, mock.URL, "", lib.NewTestLogger())
+
+	text, err := collect(t, engine, newPrompt("hi", false))
+	require.NoError(t, err)
+	require.Equal(t, "hello from the mock", text)
+
+	text, err = collect(t, engine, newPrompt("hi", true))
+	require.NoError(t, err)
+	require.Equal(t, "hello from the mock", text)
+	require.Equal(t, mockai.DefaultModel, mock.Requests()[1].Model)
+}
+
+func TestOpenAIPromptUpstreamError(t *testing.T) {
+	mock := mockai.NewTestServer(t, mockai.Config{ErrorRate: 1, ErrorStatus: http.StatusServiceUnavailable})
+	engine := NewOpenAIEngine(mockai.DefaultModel, mock.URL, "", lib.NewTestLogger())
+
+	_, err := collect(t, engine, newPrompt("hi", true))
+	require.ErrorIs(t, err, ErrChatCompletion)
+	require.ErrorContains(t, err, "503")
+}
+
+func TestOpenAIPromptErrorStatus(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+		body   string
+		stream bool
+	}{
+		// a json error body used to be passed to the callback as an empty completion
+		{"json error body", http.StatusBadRequest, `{"error":{"message":"invalid model"}}`, false},
+		{"json error body when streaming", http.StatusUnauthorized, `{"error":{"message":"invalid api key"}}`, true},
+		{"text error body", http.StatusBadGateway, "bad gateway", false},
+		{"large error body", http.StatusInternalServerError, strings.Repeat("x", 2*maxErrorBodySize), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(tt.status)
+				_, _ = w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+			engine := NewOpenAIEngine("model", server.URL, "", lib.NewTestLogger())
+
+			chunks := 0
+			err := engine.Prompt(context.Background(), newPrompt("hi", tt.stream), func(ctx context.Context, chunk gcs.Chunk) error {
+				chunks++
+				return nil
+			})
+			require.ErrorIs(t, err, ErrChatCompletion)
+			require.ErrorContains(t, err, fmt.Sprintf("status code: %d", tt.status))
+			require.Zero(t, chunks)
+
+			// the error includes the start of the response body only
+			if len(tt.body) > maxErrorBodySize {
+				require.ErrorContains(t, err, tt.body[:maxErrorBodySize])
+				require.NotContains(t, err.Error(), tt.body[:maxErrorBodySize+1])
+			} else {
+				require.ErrorContains(t, err, tt.body)
+			}
+		})
+	}
+}
+
+func TestPromptMessageZeroTemperature(t *testing.T) {
+	encode := func(ctx context.Context, temperature float32) map[string]interface{} {
+		prompt := newPrompt("hi", false)
+		prompt.Temperature = temperature
+		data, err := json.Marshal(PromptMessage(ctx, prompt))
+		require.NoError(t, err)
+		var fields map[string]interface{}
+		require.NoError(t, json.Unmarshal(data, &fields))
+		return fields
+	}
+
+	// openai.ChatCompletionRequest drops the zero temperature
+	require.NotContains(t, encode(context.Background(), 0), "temperature")
+
+	fields := encode(WithZeroTemperature(context.Background()), 0)
+	require.Equal(t, float64(0), fields["temperature"])
+	require.Equal(t, "hi", fields["messages"].([]interface{})[0].(map[string]interface{})["content"])
+
+	require.Equal(t, 0.5, encode(WithZeroTemperature(context.Background()), 0.5)["temperature"])
+}
+
+func TestOpenAIPromptStreamAborted(t *testing.T) {
+	mock := mockai.NewTestServer(t, mockai.Config{DefaultResponse: "one two three", AbortAfterTokens: 2})
+	engine := NewOpenAIEngine(mockai.DefaultModel, mock.URL, "", lib.NewTestLogger())
+
+	text, err := collect(t, engine, newPrompt("hi", true))
+	require.Error(t, err)
+	require.Equal(t, "one two", text)
+}
+
+func TestHyperbolicSDPrompt(t *testing.T) {
+	mock := mockai.NewTestServer(t, mockai.Config{})
+	cfg := mock.ImageModelConfig()
+	engine, ok := ApiAdapterFactory(cfg.ApiType, cfg.ModelName, cfg.ApiURL, cfg.ApiKey, cfg.Parameters, lib.NewTestLogger())
+	require.True(t, ok)
+
+	text, err := collect(t, engine, newPrompt("a cat", false))
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(text, "data:image/png;base64,"))
+}
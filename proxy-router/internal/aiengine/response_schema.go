@@ -0,0 +1,149 @@
+package aiengine
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	gcs "github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/chatstorage/genericchatstorage"
+	"github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/lib"
+	"github.com/santhosh-tekuri/jsonschema/v5"
+	"github.com/sashabaranov/go-openai"
+)
+
+var (
+	ErrResponseSchema        = errors.New("response does not match the requested schema")
+	ErrInvalidResponseSchema = errors.New("invalid response_format json schema")
+)
+
+const responseSchemaURL = "response_format.json"
+
+// ResponseSchema is the json schema the responses of a remote model are validated against
+type ResponseSchema struct {
+	Schema  *jsonschema.Schema
+	Retries int // how many times the prompt is repeated if the response doesn't match
+	// Warning is sent as a node message before the prompt and added to the validation error,
+	// e.g. if the provider doesn't advertise the schema support
+	Warning string
+}
+
+// CompileResponseSchema returns the schema of the json_schema response format, nil for other formats
+func CompileResponseSchema(format *openai.ChatCompletionResponseFormat) (*jsonschema.Schema, error) {
+	if format == nil || format.Type != openai.ChatCompletionResponseFormatTypeJSONSchema {
+		return nil, nil
+	}
+	if format.JSONSchema == nil || format.JSONSchema.Schema == nil {
+		return nil, lib.WrapError(ErrInvalidResponseSchema, fmt.Errorf("json_schema.schema is required"))
+	}
+
+	data, err := json.Marshal(format.JSONSchema.Schema)
+	if err != nil {
+		return nil, lib.WrapError(ErrInvalidResponseSchema, err)
+	}
+
+	compiler := jsonschema.NewCompiler()
+	err = compiler.AddResource(responseSchemaURL, bytes.NewReader(data))
+	if err != nil {
+		return nil, lib.WrapError(ErrInvalidResponseSchema, err)
+	}
+	schema, err := compiler.Compile(responseSchemaURL)
+	if err != nil {
+		return nil, lib.WrapError(ErrInvalidResponseSchema, err)
+	}
+	return schema, nil
+}
+
+// validateResponse checks that the response content is a json document matching the schema
+func validateResponse(schema *jsonschema.Schema, content string) error {
+	// the numbers are decoded as json.Number, as the validator expects
+	decoder := json.NewDecoder(strings.NewReader(content))
+	decoder.UseNumber()
+	var doc interface{}
+	err := decoder.Decode(&doc)
+	if err == nil && decoder.More() {
+		err = fmt.Errorf("unexpected data after the json document")
+	}
+	if err != nil {
+		return lib.WrapError(ErrResponseSchema, fmt.Errorf("invalid json: %w", err))
+	}
+	err = schema.Validate(doc)
+	if err != nil {
+		return lib.WrapError(ErrResponseSchema, err)
+	}
+	return nil
+}
+
+// SchemaEngine holds the response until it is validated against the requested schema, responses which
+// don't match are requested again on the same session. It wraps the remote model below the history,
+// so only the validated response is stored and forwarded as the chat context
+type SchemaEngine struct {
+	engine AIEngineStream
+	schema *ResponseSchema
+	log    lib.ILogger
+}
+
+func NewSchemaEngine(engine AIEngineStream, schema *ResponseSchema, log lib.ILogger) *SchemaEngine {
+	return &SchemaEngine{
+		engine: engine,
+		schema: schema,
+		log:    log,
+	}
+}
+
+func (e *SchemaEngine) Prompt(ctx context.Context, prompt *openai.ChatCompletionRequest, cb gcs.CompletionCallback) error {
+	if e.schema.Warning != "" {
+		e.log.Warn(e.schema.Warning)
+		err := cb(ctx, gcs.NewChunkControl(e.schema.Warning))
+		if err != nil {
+			return err
+		}
+	}
+
+	for attempt := 0; ; attempt++ {
+		var (
+			chunks  []gcs.Chunk
+			content strings.Builder
+		)
+		err := e.engine.Prompt(ctx, prompt, func(ctx context.Context, chunk gcs.Chunk) error {
+			if chunk.Type() == gcs.ChunkTypeControl {
+				return cb(ctx, chunk)
+			}
+			if chunk.Type() == gcs.ChunkTypeText {
+				content.WriteString(chunk.String())
+			}
+			chunks = append(chunks, chunk)
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		err = validateResponse(e.schema.Schema, content.String())
+		if err == nil {
+			for _, chunk := range chunks {
+				err = cb(ctx, chunk)
+				if err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+
+		e.log.Warnf("response attempt %d of %d: %s", attempt+1, e.schema.Retries+1, err)
+		if attempt >= e.schema.Retries {
+			if e.schema.Warning != "" {
+				return fmt.Errorf("%w (%s)", err, e.schema.Warning)
+			}
+			return err
+		}
+	}
+}
+
+func (e *SchemaEngine) ApiType() string {
+	return e.engine.ApiType()
+}
+
+var _ AIEngineStream = &SchemaEngine{}
@@ -0,0 +1,180 @@
+package aiengine
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/chatstorage"
+	gcs "github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/chatstorage/genericchatstorage"
+	"github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/lib"
+	"github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/testlib/mockai"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/sashabaranov/go-openai"
+	"github.com/stretchr/testify/require"
+)
+
+const testResponseSchema = `{"type":"object","properties":{"name":{"type":["string","null"]},"tags":{"type":"array","minItems":1}},"required":["name","tags"],"additionalProperties":false}`
+
+func compileTestSchema(t *testing.T) *ResponseSchema {
+	schema, err := CompileResponseSchema(&openai.ChatCompletionResponseFormat{
+		Type:       openai.ChatCompletionResponseFormatTypeJSONSchema,
+		JSONSchema: &openai.ChatCompletionResponseFormatJSONSchema{Schema: json.RawMessage(testResponseSchema)},
+	})
+	require.NoError(t, err)
+	return &ResponseSchema{Schema: schema}
+}
+
+func TestValidateResponse(t *testing.T) {
+	schema, err := CompileResponseSchema(&openai.ChatCompletionResponseFormat{
+		Type:       openai.ChatCompletionResponseFormatTypeJSONSchema,
+		JSONSchema: &openai.ChatCompletionResponseFormatJSONSchema{Schema: json.RawMessage(testResponseSchema)},
+	})
+	require.NoError(t, err)
+
+	require.NoError(t, validateResponse(schema, `{"name":"Bob","tags":["a"]}`))
+	require.NoError(t, validateResponse(schema, ` {"name":null,"tags":[1]} `))
+	for _, content := range []string{
+		`{"name":"Bob","tags":[]}`,
+		`{"name":"Bob"}`,
+		`{"name":"Bob","tags":["a"],"age":1}`,
+		`{"name":"Bob","tags":["a"]} {}`,
+		"```json\n{\"name\":\"Bob\",\"tags\":[\"a\"]}\n```",
+		``,
+	} {
+		require.ErrorIs(t, validateResponse(schema, content), ErrResponseSchema, content)
+	}
+
+	_, err = CompileResponseSchema(&openai.ChatCompletionResponseFormat{
+		Type:       openai.ChatCompletionResponseFormatTypeJSONSchema,
+		JSONSchema: &openai.ChatCompletionResponseFormatJSONSchema{Schema: json.RawMessage(`{"type":"unknown"}`)},
+	})
+	require.ErrorIs(t, err, ErrInvalidResponseSchema)
+	_, err = CompileResponseSchema(&openai.ChatCompletionResponseFormat{Type: openai.ChatCompletionResponseFormatTypeJSONSchema})
+	require.ErrorIs(t, err, ErrInvalidResponseSchema)
+}
+
+// engineStub streams the responses of the consecutive prompts
+type engineStub struct {
+	responses []string
+	prompts   int
+}
+
+func (e *engineStub) Prompt(ctx context.Context, prompt *openai.ChatCompletionRequest, cb gcs.CompletionCallback) error {
+	content := e.responses[e.prompts]
+	e.prompts++
+	err := cb(ctx, gcs.NewChunkControl("attempt"))
+	if err != nil {
+		return err
+	}
+	// the content is split to check it is validated as a whole
+	for _, part := range []string{content[:len(content)/2], content[len(content)/2:]} {
+		err := cb(ctx, gcs.NewChunkStreaming(&openai.ChatCompletionStreamResponse{
+			Choices: []openai.ChatCompletionStreamChoice{{Delta: openai.ChatCompletionStreamChoiceDelta{Content: part}}},
+		}))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (e *engineStub) ApiType() string {
+	return "remote"
+}
+
+func TestSchemaEngine(t *testing.T) {
+	schema, err := CompileResponseSchema(&openai.ChatCompletionResponseFormat{
+		Type:       openai.ChatCompletionResponseFormatTypeJSONSchema,
+		JSONSchema: &openai.ChatCompletionResponseFormatJSONSchema{Schema: json.RawMessage(testResponseSchema)},
+	})
+	require.NoError(t, err)
+
+	prompt := func(engine *SchemaEngine) (string, int, error) {
+		var content string
+		controls := 0
+		err := engine.Prompt(context.Background(), &openai.ChatCompletionRequest{}, func(ctx context.Context, chunk gcs.Chunk) error {
+			if chunk.Type() == gcs.ChunkTypeControl {
+				controls++
+			}
+			content += chunk.String()
+			return nil
+		})
+		return content, controls, err
+	}
+
+	// the invalid response is requested again and only the valid one is sent
+	stub := &engineStub{responses: []string{`{"name":"Bob"}`, `{"name":"Bob","tags":["a"]}`}}
+	content, controls, err := prompt(NewSchemaEngine(stub, &ResponseSchema{Schema: schema, Retries: 2}, lib.NewTestLogger()))
+	require.NoError(t, err)
+	require.Equal(t, `{"name":"Bob","tags":["a"]}`, content)
+	require.Equal(t, 2, controls)
+	require.Equal(t, 2, stub.prompts)
+
+	// the attempts are bounded
+	stub = &engineStub{responses: []string{`not json`, `{"name":"Bob"}`, `{"name":"Bob","tags":["a"]}`}}
+	content, _, err = prompt(NewSchemaEngine(stub, &ResponseSchema{Schema: schema, Retries: 1}, lib.NewTestLogger()))
+	require.ErrorIs(t, err, ErrResponseSchema)
+	require.Empty(t, content)
+	require.Equal(t, 2, stub.prompts)
+
+	// the warning is sent before the prompt and added to the error
+	warned := &ResponseSchema{Schema: schema, Warning: "schema support not advertised"}
+	stub = &engineStub{responses: []string{`{"name":"Bob"}`}}
+	var messages []interface{}
+	err = NewSchemaEngine(stub, warned, lib.NewTestLogger()).Prompt(context.Background(), &openai.ChatCompletionRequest{}, func(ctx context.Context, chunk gcs.Chunk) error {
+		if chunk.Type() == gcs.ChunkTypeControl {
+			messages = append(messages, chunk.Data())
+		}
+		return nil
+	})
+	require.ErrorIs(t, err, ErrResponseSchema)
+	require.ErrorContains(t, err, "schema support not advertised")
+	require.Equal(t, []interface{}{"schema support not advertised", "attempt"}, messages)
+	require.Equal(t, 1, stub.prompts)
+
+	stub = &engineStub{responses: []string{`{"name":"Bob","tags":["a"]}`}}
+	content, controls, err = prompt(NewSchemaEngine(stub, warned, lib.NewTestLogger()))
+	require.NoError(t, err)
+	require.Equal(t, `{"name":"Bob","tags":["a"]}`, content)
+	require.Equal(t, 2, controls)
+}
+
+func TestSchemaEngineHistory(t *testing.T) {
+	mock := mockai.NewTestServer(t, mockai.Config{
+		DefaultResponse: `{"name":"Alice","tags":["a"]}`,
+		Responses:       []mockai.Response{{Content: `{"name":"Alice"}`, Times: 1}},
+	})
+	storage := chatstorage.NewChatStorage(t.TempDir())
+	chatID := common.HexToHash("0x01")
+	log := lib.NewTestLogger()
+
+	schema := compileTestSchema(t)
+	schema.Retries = 1
+	engine := NewSchemaEngine(NewOpenAIEngine(mockai.DefaultModel, mock.URL, "", log), schema, log)
+	history := NewHistory(engine, storage, chatID, common.HexToHash("0x02"), true, log)
+
+	text, err := collect(t, history, newPrompt("who am I?", true))
+	require.NoError(t, err)
+	require.Equal(t, `{"name":"Alice","tags":["a"]}`, text)
+
+	// the retry doesn't carry the invalid response as the chat context
+	requests := mock.Requests()
+	require.Len(t, requests, 2)
+	require.Len(t, requests[1].Messages, 1)
+	require.Equal(t, "who am I?", requests[1].Messages[0].Content)
+
+	// only the validated response is stored
+	chat, err := storage.LoadChatFromFile(chatID.Hex())
+	require.NoError(t, err)
+	require.Len(t, chat.Messages, 1)
+	require.Equal(t, `{"name":"Alice","tags":["a"]}`, chat.Messages[0].Response)
+
+	// nothing is stored if all the attempts fail
+	require.NoError(t, mock.SetConfig(mockai.Config{DefaultResponse: "not json"}))
+	_, err = collect(t, history, newPrompt("who am I?", false))
+	require.ErrorIs(t, err, ErrResponseSchema)
+	chat, err = storage.LoadChatFromFile(chatID.Hex())
+	require.NoError(t, err)
+	require.Len(t, chat.Messages, 1)
+}
@@ -0,0 +1,35 @@
+package aiengine
+
+import (
+	"context"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+type zeroTemperatureCtxKey struct{}
+
+// WithZeroTemperature marks that the client set temperature 0 explicitly. openai.ChatCompletionRequest
+// omits a zero temperature when encoded, so without the mark the upstream would use its default
+func WithZeroTemperature(ctx context.Context) context.Context {
+	return context.WithValue(ctx, zeroTemperatureCtxKey{}, true)
+}
+
+func IsZeroTemperature(ctx context.Context) bool {
+	zero, _ := ctx.Value(zeroTemperatureCtxKey{}).(bool)
+	return zero
+}
+
+// zeroTemperaturePrompt encodes the request with "temperature": 0, the outer field hides the embedded one
+type zeroTemperaturePrompt struct {
+	*openai.ChatCompletionRequest
+	Temperature float32 `json:"temperature"`
+}
+
+// PromptMessage returns the request to be encoded for the provider or the upstream,
+// keeping the temperature 0 marked with WithZeroTemperature
+func PromptMessage(ctx context.Context, prompt *openai.ChatCompletionRequest) interface{} {
+	if prompt.Temperature == 0 && IsZeroTemperature(ctx) {
+		return &zeroTemperaturePrompt{ChatCompletionRequest: prompt}
+	}
+	return prompt
+}
@@ -0,0 +1,196 @@
+package blockchainapi
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"sync/atomic"
+	"testing"
+
+	"github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/interfaces/mocks"
+	"github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/lib"
+	m "github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/repositories/contracts/bindings/marketplace"
+	mc3 "github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/repositories/contracts/bindings/multicall3"
+	pr "github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/repositories/contracts/bindings/providerregistry"
+	s "github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/repositories/contracts/bindings/sessionrouter"
+	"github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/repositories/multicall"
+	r "github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/repositories/registries"
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+var testDiamondAddr = common.HexToAddress("0xdddd")
+
+// fakeDiamond answers eth_call requests to the diamond and multicall3 contracts with generated bids
+type fakeDiamond struct {
+	bidCount  int
+	modelID   common.Hash
+	abis      []*abi.ABI
+	mcABI     *abi.ABI
+	rpcCalls  atomic.Int64
+	providers int // number of unique providers, bids are distributed among them
+}
+
+func newFakeDiamond(bidCount, providers int) *fakeDiamond {
+	mpABI, _ := m.MarketplaceMetaData.GetAbi()
+	srABI, _ := s.SessionRouterMetaData.GetAbi()
+	prABI, _ := pr.ProviderRegistryMetaData.GetAbi()
+	mcABI, _ := mc3.Multicall3MetaData.GetAbi()
+
+	return &fakeDiamond{
+		bidCount:  bidCount,
+		providers: providers,
+		modelID:   common.HexToHash("0x01"),
+		abis:      []*abi.ABI{mpABI, srABI, prABI},
+		mcABI:     mcABI,
+	}
+}
+
+func (f *fakeDiamond) CallContract(ctx context.Context, msg ethereum.CallMsg, blockNumber *big.Int) ([]byte, error) {
+	f.rpcCalls.Add(1)
+	if *msg.To == multicall.MULTICALL3_ADDR {
+		return f.aggregate3(msg.Data)
+	}
+	return f.call(msg.Data)
+}
+
+func (f *fakeDiamond) aggregate3(data []byte) ([]byte, error) {
+	method := f.mcABI.Methods["aggregate3"]
+	args, err := method.Inputs.Unpack(data[4:])
+	if err != nil {
+		return nil, err
+	}
+	calls := *abi.ConvertType(args[0], new([]mc3.Multicall3Call3)).(*[]mc3.Multicall3Call3)
+
+	results := make([]mc3.Multicall3Result, len(calls))
+	for i, call := range calls {
+		res, err := f.call(call.CallData)
+		if err != nil {
+			return nil, err
+		}
+		results[i] = mc3.Multicall3Result{Success: true, ReturnData: res}
+	}
+	return method.Outputs.Pack(results)
+}
+
+func (f *fakeDiamond) call(data []byte) ([]byte, error) {
+	for _, contractABI := range f.abis {
+		method, err := contractABI.MethodById(data[:4])
+		if err != nil {
+			continue
+		}
+		args, err := method.Inputs.Unpack(data[4:])
+		if err != nil {
+			return nil, err
+		}
+
+		switch method.Name {
+		case "getModelActiveBids":
+			offset, limit := args[1].(*big.Int).Int64(), args[2].(*big.Int).Int64()
+			ids := make([][32]byte, 0)
+			for i := offset; i < offset+limit && i < int64(f.bidCount); i++ {
+				ids = append(ids, common.BigToHash(big.NewInt(i+1)))
+			}
+			return method.Outputs.Pack(ids, big.NewInt(int64(f.bidCount)))
+		case "getBid":
+			bidID := args[0].([32]byte)
+			return method.Outputs.Pack(m.IBidStorageBid{
+				Provider:       f.providerAddr(new(big.Int).SetBytes(bidID[:]).Int64()),
+				ModelId:        f.modelID,
+				PricePerSecond: big.NewInt(1),
+				Nonce:          big.NewInt(0),
+				CreatedAt:      big.NewInt(1),
+				DeletedAt:      big.NewInt(0),
+			})
+		case "getProviderModelStats":
+			return method.Outputs.Pack(s.IStatsStorageProviderModelStats{TotalCount: 1, SuccessCount: 1})
+		case "getProvider":
+			return method.Outputs.Pack(pr.IProviderStorageProvider{
+				Endpoint:          "localhost:3333",
+				Stake:             big.NewInt(1),
+				CreatedAt:         big.NewInt(1),
+				LimitPeriodEnd:    big.NewInt(0),
+				LimitPeriodEarned: big.NewInt(0),
+			})
+		}
+		return nil, fmt.Errorf("unexpected method %s", method.Name)
+	}
+	return nil, fmt.Errorf("unknown method selector %x", data[:4])
+}
+
+func (f *fakeDiamond) providerAddr(bidIndex int64) common.Address {
+	return common.BigToAddress(big.NewInt(bidIndex%int64(f.providers) + 1))
+}
+
+func newBidsTestService(t testing.TB, fake *fakeDiamond) *BlockchainService {
+	ethClientMock := mocks.NewEthClientMock(t)
+	ethClientMock.EXPECT().CallContract(mock.Anything, mock.Anything, mock.Anything).RunAndReturn(fake.CallContract)
+	mc := multicall.NewMulticall3(ethClientMock)
+
+	return NewBlockchainService(ethClientMock, mc, testDiamondAddr, common.Address{}, nil, nil, nil, nil, nil, lib.NewTestLogger(), lib.NewTestLogger(), false)
+}
+
+func TestGetAllBidsWithRatingBatched(t *testing.T) {
+	fake := newFakeDiamond(600, 50)
+	svc := newBidsTestService(t, fake)
+
+	ids, bids, stats, providers, err := svc.GetAllBidsWithRating(context.Background(), fake.modelID)
+	require.NoError(t, err)
+	require.Len(t, ids, 600)
+	require.Len(t, bids, 600)
+	require.Len(t, stats, 600)
+	require.Len(t, providers, 600)
+	require.Equal(t, "localhost:3333", providers[599].Endpoint)
+	require.Equal(t, uint32(1), stats[599].TotalCount)
+
+	// 3 pages x (count + ids + bids multicall) + 1 stats multicall + 1 providers multicall
+	require.Equal(t, int64(11), fake.rpcCalls.Load())
+
+	// providers are served from cache on the next call
+	fake.rpcCalls.Store(0)
+	_, _, _, _, err = svc.GetAllBidsWithRating(context.Background(), fake.modelID)
+	require.NoError(t, err)
+	require.Equal(t, int64(10), fake.rpcCalls.Load())
+}
+
+func BenchmarkGetAllBidsWithRating(b *testing.B) {
+	for _, bidCount := range []int{10, 100, 500} {
+		b.Run(fmt.Sprintf("multicall/bids=%d", bidCount), func(b *testing.B) {
+			fake := newFakeDiamond(bidCount, bidCount)
+			svc := newBidsTestService(b, fake)
+			b.ResetTimer()
+
+			for i := 0; i < b.N; i++ {
+				svc.providerCache = lib.NewTTLCache[common.Address, pr.IProviderStorageProvider](providerCacheTTL)
+				_, _, _, _, err := svc.GetAllBidsWithRating(context.Background(), fake.modelID)
+				require.NoError(b, err)
+			}
+			b.ReportMetric(float64(fake.rpcCalls.Load())/float64(b.N), "rpc/op")
+		})
+
+		// the previous implementation, requesting stats and provider for each bid sequentially
+		b.Run(fmt.Sprintf("sequential/bids=%d", bidCount), func(b *testing.B) {
+			fake := newFakeDiamond(bidCount, bidCount)
+			svc := newBidsTestService(b, fake)
+			ctx := context.Background()
+			b.ResetTimer()
+
+			for i := 0; i < b.N; i++ {
+				for offset := 0; offset < bidCount; offset += 255 {
+					_, bids, err := svc.marketplace.GetActiveBidsByModel(ctx, fake.modelID, big.NewInt(int64(offset)), 255, r.OrderASC)
+					require.NoError(b, err)
+					for _, bid := range bids {
+						_, err := svc.sessionRouter.GetProviderModelStats(ctx, fake.modelID, bid.Provider)
+						require.NoError(b, err)
+						_, err = svc.providerRegistry.GetProviderById(ctx, bid.Provider)
+						require.NoError(b, err)
+					}
+				}
+			}
+			b.ReportMetric(float64(fake.rpcCalls.Load())/float64(b.N), "rpc/op")
+		})
+	}
+}
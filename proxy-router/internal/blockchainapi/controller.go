@@ -1,7 +1,9 @@
 package blockchainapi
 
 import (
+	"context"
 	"crypto/rand"
+	"fmt"
 	"math/big"
 	"net/http"
 
@@ -15,6 +17,7 @@ import (
 
 type BlockchainController struct {
 	service *BlockchainService
+	renewer *SessionRenewer
 	log     lib.ILogger
 }
 
@@ -27,6 +30,11 @@ func NewBlockchainController(service *BlockchainService, log lib.ILogger) *Block
 	return c
 }
 
+// SetSessionRenewer enables the autoRenew option of the open session requests
+func (c *BlockchainController) SetSessionRenewer(renewer *SessionRenewer) {
+	c.renewer = renewer
+}
+
 func (c *BlockchainController) RegisterRoutes(r interfaces.Router) {
 	// transactions
 	r.GET("/blockchain/balance", c.getBalance)
@@ -36,6 +44,7 @@ func (c *BlockchainController) RegisterRoutes(r interfaces.Router) {
 	r.POST("/blockchain/approve", c.approve)
 	r.POST("/blockchain/send/eth", c.sendETH)
 	r.POST("/blockchain/send/mor", c.sendMOR)
+	r.GET("/blockchain/txqueue", c.getTxQueueStatus)
 
 	// providers
 	r.GET("/blockchain/providers", c.getAllProviders)
@@ -63,6 +72,7 @@ func (c *BlockchainController) RegisterRoutes(r interfaces.Router) {
 	r.GET("/blockchain/sessions/user", c.getSessionsForUser)
 	r.GET("/blockchain/sessions/user/ids", c.getSessionsIdsForUser)
 	r.GET("/blockchain/sessions/provider", c.getSessionsForProvider)
+	r.GET("/blockchain/sessions/virtual", c.getVirtualSessions)
 	r.GET("/blockchain/sessions/:id", c.getSession)
 	r.POST("/blockchain/sessions", c.openSession)
 	r.POST("/blockchain/bids/:id/session", c.openSessionByBid)
@@ -70,6 +80,13 @@ func (c *BlockchainController) RegisterRoutes(r interfaces.Router) {
 	r.POST("/blockchain/sessions/:id/close", c.closeSession)
 	r.GET("/blockchain/sessions/budget", c.getBudget)
 	r.GET("/blockchain/token/supply", c.getSupply)
+
+	// local index
+	r.GET("/blockchain/indexer/status", c.getIndexerStatus)
+	r.GET("/blockchain/indexer/providers", c.getIndexedProviders)
+	r.GET("/blockchain/indexer/models", c.getIndexedModels)
+	r.GET("/blockchain/indexer/bids", c.getIndexedBids)
+	r.GET("/blockchain/indexer/sessions", c.getIndexedSessions)
 }
 
 // GetProviderClaimableBalance godoc
@@ -569,13 +586,22 @@ func (s *BlockchainController) openSessionByBid(ctx *gin.Context) {
 		return
 	}
 
-	sessionId, err := s.service.openSessionByBid(ctx, params.ID.Hash, reqPayload.SessionDuration.Unpack())
+	sessionId, err := s.service.openSessionByBid(ctx, params.ID.Hash, reqPayload.SessionDuration.Unpack(), false, false)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, structs.ErrRes{Error: err.Error()})
 		return
 	}
 
-	ctx.JSON(http.StatusOK, structs.OpenSessionRes{SessionID: sessionId})
+	res := structs.OpenSessionRes{SessionID: sessionId}
+	if reqPayload.AutoRenew {
+		res.VirtualSessionID, err = s.createVirtualSession(ctx, sessionId, reqPayload.SessionDuration.Unpack(), false, false)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, structs.ErrRes{Error: err.Error()})
+			return
+		}
+	}
+
+	ctx.JSON(http.StatusOK, res)
 	return
 }
 
@@ -614,10 +640,32 @@ func (s *BlockchainController) openSessionByModelId(ctx *gin.Context) {
 		return
 	}
 
-	ctx.JSON(http.StatusOK, structs.OpenSessionRes{SessionID: sessionId})
+	res := structs.OpenSessionRes{SessionID: sessionId}
+	if reqPayload.AutoRenew {
+		res.VirtualSessionID, err = s.createVirtualSession(ctx, sessionId, reqPayload.SessionDuration.Unpack(), reqPayload.DirectPayment, isFailoverEnabled)
+		if err != nil {
+			s.log.Error(err)
+			ctx.JSON(http.StatusInternalServerError, structs.ErrRes{Error: err.Error()})
+			return
+		}
+	}
+
+	ctx.JSON(http.StatusOK, res)
 	return
 }
 
+// createVirtualSession makes the renewer keep the opened session alive
+func (s *BlockchainController) createVirtualSession(ctx context.Context, sessionID common.Hash, duration *big.Int, directPayment, failover bool) (*common.Hash, error) {
+	if s.renewer == nil {
+		return nil, ErrAutoRenewDisabled
+	}
+	id, err := s.renewer.Create(ctx, sessionID, duration, directPayment, failover)
+	if err != nil {
+		return nil, lib.WrapError(ErrAutoRenew, fmt.Errorf("session %s: %w", sessionID.Hex(), err))
+	}
+	return &id, nil
+}
+
 // CloseSession godoc
 //
 //	@Summary		Close Session with Provider
@@ -636,13 +684,23 @@ func (c *BlockchainController) closeSession(ctx *gin.Context) {
 		return
 	}
 
-	txHash, err := c.service.CloseSession(ctx, params.ID.Hash)
+	sessionID := params.ID.Hash
+	virtualID, isVirtual := c.resolveVirtualSession(&sessionID)
+
+	txHash, err := c.service.CloseSession(ctx, sessionID)
 	if err != nil {
 		c.log.Error(err)
 		ctx.JSON(http.StatusInternalServerError, structs.ErrRes{Error: err.Error()})
 		return
 	}
 
+	if isVirtual {
+		err = c.renewer.Remove(virtualID)
+		if err != nil {
+			c.log.Warnf("failed to remove virtual session %s: %s", virtualID.Hex(), err)
+		}
+	}
+
 	ctx.JSON(http.StatusOK, structs.TxRes{Tx: txHash})
 	return
 }
@@ -665,7 +723,10 @@ func (c *BlockchainController) getSession(ctx *gin.Context) {
 		return
 	}
 
-	session, err := c.service.GetSession(ctx, params.ID.Hash)
+	sessionID := params.ID.Hash
+	c.resolveVirtualSession(&sessionID)
+
+	session, err := c.service.GetSession(ctx, sessionID)
 	if err != nil {
 		c.log.Error(err)
 		ctx.JSON(http.StatusInternalServerError, structs.ErrRes{Error: err.Error()})
@@ -676,6 +737,44 @@ func (c *BlockchainController) getSession(ctx *gin.Context) {
 	return
 }
 
+// resolveVirtualSession replaces the virtual session ID with its current session ID
+func (c *BlockchainController) resolveVirtualSession(sessionID *common.Hash) (common.Hash, bool) {
+	if c.renewer == nil {
+		return common.Hash{}, false
+	}
+	current, ok := c.renewer.Resolve(*sessionID)
+	if !ok {
+		return common.Hash{}, false
+	}
+	virtualID := *sessionID
+	*sessionID = current
+	return virtualID, true
+}
+
+// GetVirtualSessions godoc
+//
+//	@Summary		Get virtual sessions
+//	@Description	Returns the auto-renewed sessions of the selected account with their current session IDs
+//	@Tags			sessions
+//	@Produce		json
+//	@Success		200	{object}	structs.VirtualSessionsRes
+//	@Router			/blockchain/sessions/virtual [get]
+func (c *BlockchainController) getVirtualSessions(ctx *gin.Context) {
+	if c.renewer == nil {
+		ctx.JSON(http.StatusOK, structs.VirtualSessionsRes{Sessions: []*structs.VirtualSession{}})
+		return
+	}
+
+	sessions, err := c.renewer.GetVirtualSessions(ctx)
+	if err != nil {
+		c.log.Error(err)
+		ctx.JSON(http.StatusInternalServerError, structs.ErrRes{Error: err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, structs.VirtualSessionsRes{Sessions: mapVirtualSessions(sessions)})
+}
+
 // GetSessions godoc
 //
 //	@Summary		Get Sessions for User
@@ -878,7 +977,7 @@ func (c *BlockchainController) getBidByID(ctx *gin.Context) {
 // GetRatedBids godoc
 //
 //	@Summary		Get Rated Bids
-//	@Description	Get rated bids from blockchain by model
+//	@Description	Get rated bids from blockchain by model, bids excluded by rating filters are returned with the reason
 //	@Tags			bids
 //	@Produce		json
 //	@Param			id	path		string	true	"Model ID"
@@ -893,14 +992,14 @@ func (c *BlockchainController) getRatedBids(ctx *gin.Context) {
 		return
 	}
 
-	bids, err := c.service.GetRatedBids(ctx, params.ID.Hash)
+	bids, excluded, err := c.service.GetRatedBids(ctx, params.ID.Hash)
 	if err != nil {
 		c.log.Error(err)
 		ctx.JSON(http.StatusInternalServerError, structs.ErrRes{Error: err.Error()})
 		return
 	}
 
-	ctx.JSON(http.StatusOK, structs.ScoredBidsRes{Bids: bids})
+	ctx.JSON(http.StatusOK, structs.ScoredBidsRes{Bids: bids, Excluded: excluded})
 	return
 }
 
@@ -1082,8 +1181,238 @@ func (c *BlockchainController) deleteBid(ctx *gin.Context) {
 	return
 }
 
+// GetTxQueueStatus godoc
+//
+//	@Summary		Get Transaction Queue Status
+//	@Description	Get the next nonces, pending transactions sent by the node and the recently mined or dropped ones
+//	@Tags			transactions
+//	@Produce		json
+//	@Success		200	{object}	txmanager.QueueStatus
+//	@Router			/blockchain/txqueue [get]
+func (c *BlockchainController) getTxQueueStatus(ctx *gin.Context) {
+	status, err := c.service.GetTxQueueStatus()
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, structs.ErrRes{Error: err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, status)
+	return
+}
+
+// GetIndexerStatus godoc
+//
+//	@Summary		Get Indexer Status
+//	@Description	Get the state of the local marketplace index
+//	@Tags			indexer
+//	@Produce		json
+//	@Success		200	{object}	structs.IndexerStatusRes
+//	@Router			/blockchain/indexer/status [get]
+func (c *BlockchainController) getIndexerStatus(ctx *gin.Context) {
+	indexer, err := c.service.GetIndexer()
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, structs.ErrRes{Error: err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, indexer.Status())
+	return
+}
+
+// GetIndexedProviders godoc
+//
+//	@Summary		Get Indexed Providers
+//	@Description	Get providers from the local index, sortBy is one of createdAt, stake
+//	@Tags			indexer
+//	@Produce		json
+//	@Param			request			query		structs.QueryOffsetLimitOrderNoDefault	true	"Query Params"
+//	@Param			sort			query		structs.QueryIndexerSort				false	"Sort"
+//	@Param			includeDeleted	query		bool									false	"Include deregistered providers"
+//	@Success		200				{object}	structs.ProvidersRes
+//	@Router			/blockchain/indexer/providers [get]
+func (c *BlockchainController) getIndexedProviders(ctx *gin.Context) {
+	indexer, query, ok := c.getIndexerQuery(ctx)
+	if !ok {
+		return
+	}
+
+	var filter structs.QueryIndexerProviders
+	err := ctx.ShouldBindQuery(&filter)
+	if err != nil {
+		c.log.Error(err)
+		ctx.JSON(http.StatusBadRequest, structs.ErrRes{Error: err.Error()})
+		return
+	}
+
+	providers, err := indexer.GetProviders(ProviderFilter{IncludeDeleted: filter.IncludeDeleted}, query)
+	if err != nil {
+		c.log.Error(err)
+		ctx.JSON(http.StatusBadRequest, structs.ErrRes{Error: err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, structs.ProvidersRes{Providers: providers})
+	return
+}
+
+// GetIndexedModels godoc
+//
+//	@Summary		Get Indexed Models
+//	@Description	Get models from the local index, sortBy is one of createdAt, fee, stake, name
+//	@Tags			indexer
+//	@Produce		json
+//	@Param			request			query		structs.QueryOffsetLimitOrderNoDefault	true	"Query Params"
+//	@Param			sort			query		structs.QueryIndexerSort				false	"Sort"
+//	@Param			owner			query		string									false	"Model owner address"
+//	@Param			tag				query		string									false	"Model tag"
+//	@Param			includeDeleted	query		bool									false	"Include deregistered models"
+//	@Success		200				{object}	structs.ModelsRes
+//	@Router			/blockchain/indexer/models [get]
+func (c *BlockchainController) getIndexedModels(ctx *gin.Context) {
+	indexer, query, ok := c.getIndexerQuery(ctx)
+	if !ok {
+		return
+	}
+
+	var filter structs.QueryIndexerModels
+	err := ctx.ShouldBindQuery(&filter)
+	if err != nil {
+		c.log.Error(err)
+		ctx.JSON(http.StatusBadRequest, structs.ErrRes{Error: err.Error()})
+		return
+	}
+
+	models, err := indexer.GetModels(ModelFilter{
+		Owner:          filter.Owner.Address,
+		Tag:            filter.Tag,
+		IncludeDeleted: filter.IncludeDeleted,
+	}, query)
+	if err != nil {
+		c.log.Error(err)
+		ctx.JSON(http.StatusBadRequest, structs.ErrRes{Error: err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, structs.ModelsRes{Models: models})
+	return
+}
+
+// GetIndexedBids godoc
+//
+//	@Summary		Get Indexed Bids
+//	@Description	Get bids from the local index, sortBy is one of createdAt, pricePerSecond
+//	@Tags			indexer
+//	@Produce		json
+//	@Param			request		query		structs.QueryOffsetLimitOrderNoDefault	true	"Query Params"
+//	@Param			sort		query		structs.QueryIndexerSort				false	"Sort"
+//	@Param			provider	query		string									false	"Provider address"
+//	@Param			modelId		query		string									false	"Model ID"
+//	@Param			active		query		bool									false	"Only active bids"
+//	@Success		200			{object}	structs.BidsRes
+//	@Router			/blockchain/indexer/bids [get]
+func (c *BlockchainController) getIndexedBids(ctx *gin.Context) {
+	indexer, query, ok := c.getIndexerQuery(ctx)
+	if !ok {
+		return
+	}
+
+	var filter structs.QueryIndexerBids
+	err := ctx.ShouldBindQuery(&filter)
+	if err != nil {
+		c.log.Error(err)
+		ctx.JSON(http.StatusBadRequest, structs.ErrRes{Error: err.Error()})
+		return
+	}
+
+	bids, err := indexer.GetBids(BidFilter{
+		Provider:   filter.Provider.Address,
+		ModelID:    filter.ModelID.Hash,
+		ActiveOnly: filter.Active,
+	}, query)
+	if err != nil {
+		c.log.Error(err)
+		ctx.JSON(http.StatusBadRequest, structs.ErrRes{Error: err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, structs.BidsRes{Bids: bids})
+	return
+}
+
+// GetIndexedSessions godoc
+//
+//	@Summary		Get Indexed Sessions
+//	@Description	Get sessions from the local index, sortBy is one of openedAt, endsAt, stake
+//	@Tags			indexer
+//	@Produce		json
+//	@Param			request		query		structs.QueryOffsetLimitOrderNoDefault	true	"Query Params"
+//	@Param			sort		query		structs.QueryIndexerSort				false	"Sort"
+//	@Param			user		query		string									false	"User address"
+//	@Param			provider	query		string									false	"Provider address"
+//	@Param			modelId		query		string									false	"Model ID"
+//	@Param			open		query		bool									false	"Only open sessions"
+//	@Success		200			{object}	structs.SessionsRes
+//	@Router			/blockchain/indexer/sessions [get]
+func (c *BlockchainController) getIndexedSessions(ctx *gin.Context) {
+	indexer, query, ok := c.getIndexerQuery(ctx)
+	if !ok {
+		return
+	}
+
+	var filter structs.QueryIndexerSessions
+	err := ctx.ShouldBindQuery(&filter)
+	if err != nil {
+		c.log.Error(err)
+		ctx.JSON(http.StatusBadRequest, structs.ErrRes{Error: err.Error()})
+		return
+	}
+
+	sessions, err := indexer.GetSessions(SessionFilter{
+		User:     filter.User.Address,
+		Provider: filter.Provider.Address,
+		ModelID:  filter.ModelID.Hash,
+		OpenOnly: filter.Open,
+	}, query)
+	if err != nil {
+		c.log.Error(err)
+		ctx.JSON(http.StatusBadRequest, structs.ErrRes{Error: err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, structs.SessionsRes{Sessions: sessions})
+	return
+}
+
 // helpers
 
+// getIndexerQuery writes the error response and returns false if the indexer is disabled or params are invalid
+func (c *BlockchainController) getIndexerQuery(ctx *gin.Context) (*Indexer, IndexerQuery, bool) {
+	indexer, err := c.service.GetIndexer()
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, structs.ErrRes{Error: err.Error()})
+		return nil, IndexerQuery{}, false
+	}
+
+	offset, limit, order, err := getOffsetLimitOrderNoDefault(ctx)
+	if err != nil {
+		c.log.Error(err)
+		ctx.JSON(http.StatusBadRequest, structs.ErrRes{Error: err.Error()})
+		return nil, IndexerQuery{}, false
+	}
+
+	var sort structs.QueryIndexerSort
+	err = ctx.ShouldBindQuery(&sort)
+	if err != nil {
+		c.log.Error(err)
+		ctx.JSON(http.StatusBadRequest, structs.ErrRes{Error: err.Error()})
+		return nil, IndexerQuery{}, false
+	}
+
+	query := indexerPage(offset, limit, order)
+	query.SortBy = sort.SortBy
+	return indexer, query, true
+}
+
 func (s *BlockchainController) getSendParams(ctx *gin.Context) (to common.Address, amount *big.Int, err error) {
 	var body structs.SendRequest
 	if err := ctx.ShouldBindJSON(&body); err != nil {
@@ -2,6 +2,7 @@ package blockchainapi
 
 import (
 	"context"
+	"time"
 
 	"github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/interfaces"
 	"github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/lib"
@@ -9,19 +10,30 @@ import (
 	"github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/repositories/contracts/bindings/sessionrouter"
 	"github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/repositories/registries"
 	sessionrepo "github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/repositories/session"
+	"github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/webhooks"
 	"github.com/ethereum/go-ethereum/common"
 )
 
+const (
+	eventsListenerCheckpointKey = "events-listener"
+	// accountsRefreshInterval is how often the addresses of the named accounts are reread from the keychain
+	accountsRefreshInterval = time.Minute
+)
+
 type EventsListener struct {
 	sessionRouter *registries.SessionRouter
 	sessionRepo   *sessionrepo.SessionRepositoryCached
 	tsk           *lib.Task
 	log           lib.ILogger
 	wallet        interfaces.Wallet
+	accounts      interfaces.Accounts
 	logWatcher    contracts.LogWatcher
+	webhooks      *webhooks.Dispatcher
 
 	//internal state
-	addr common.Address
+	addr           common.Address
+	accountAddrs   map[common.Address]bool
+	accountAddrsAt time.Time
 }
 
 func NewEventsListener(sessionRepo *sessionrepo.SessionRepositoryCached, sessionRouter *registries.SessionRouter, wallet interfaces.Wallet, logWatcher contracts.LogWatcher, log lib.ILogger) *EventsListener {
@@ -34,6 +46,15 @@ func NewEventsListener(sessionRepo *sessionrepo.SessionRepositoryCached, session
 	}
 }
 
+func (e *EventsListener) SetWebhooks(dispatcher *webhooks.Dispatcher) {
+	e.webhooks = dispatcher
+}
+
+// SetAccounts makes the listener handle the session events of all named accounts
+func (e *EventsListener) SetAccounts(accounts interfaces.Accounts) {
+	e.accounts = accounts
+}
+
 func (e *EventsListener) Run(ctx context.Context) error {
 	defer func() {
 		_ = e.log.Close()
@@ -46,12 +67,14 @@ func (e *EventsListener) Run(ctx context.Context) error {
 	e.addr = addr
 
 	//TODO: filter events by user/provider address
-	sub, err := e.logWatcher.Watch(
+	// resume from the last processed block, so session events that happened while offline are not lost
+	sub, err := e.logWatcher.WatchFromCheckpoint(
 		ctx,
+		eventsListenerCheckpointKey,
 		e.sessionRouter.GetContractAddress(),
 		contracts.CreateEventMapper(contracts.SessionRouterEventFactory,
 			e.sessionRouter.GetABI(),
-		), nil)
+		))
 	if err != nil {
 		return err
 	}
@@ -89,6 +112,7 @@ func (e *EventsListener) handleSessionOpened(event *sessionrouter.SessionRouterS
 		return nil
 	}
 	e.log.Debugf("received open session event, sessionId %s", lib.BytesToString(event.SessionId[:]))
+	e.webhooks.Emit(webhooks.EventSessionOpened, sessionEventData(event.SessionId, event.User, event.ProviderId, event.Raw.TxHash))
 	return e.sessionRepo.RefreshSession(context.Background(), event.SessionId)
 }
 
@@ -97,23 +121,65 @@ func (e *EventsListener) handleSessionClosed(event *sessionrouter.SessionRouterS
 		return nil
 	}
 	e.log.Debugf("received close session event, sessionId %s", lib.BytesToString(event.SessionId[:]))
+	e.webhooks.Emit(webhooks.EventSessionClosed, sessionEventData(event.SessionId, event.User, event.ProviderId, event.Raw.TxHash))
 	return e.sessionRepo.RemoveSession(context.Background(), event.SessionId)
 }
 
 // getWalletAddress returns the wallet address from the wallet
 func (e *EventsListener) getWalletAddress() (common.Address, error) {
-	prkey, err := e.wallet.GetPrivateKey()
+	return interfaces.GetAddressCtx(context.Background(), e.wallet)
+}
+
+// getAccountAddresses returns the addresses of the named accounts, cached for accountsRefreshInterval
+func (e *EventsListener) getAccountAddresses() map[common.Address]bool {
+	if e.accounts == nil || time.Since(e.accountAddrsAt) < accountsRefreshInterval {
+		return e.accountAddrs
+	}
+	e.accountAddrsAt = time.Now()
+
+	names, err := e.accounts.ListAccounts()
 	if err != nil {
-		return common.Address{}, err
+		e.log.Warnf("failed to list accounts: %s", err)
+		return e.accountAddrs
 	}
-	return lib.PrivKeyStringToAddr(prkey.Hex())
+	addrs := make(map[common.Address]bool, len(names))
+	for _, name := range names {
+		wallet, err := e.accounts.GetAccount(name)
+		if err != nil {
+			continue
+		}
+		prkey, err := wallet.GetPrivateKey()
+		if err != nil {
+			continue
+		}
+		addr, err := lib.PrivKeyBytesToAddr(prkey)
+		if err != nil {
+			continue
+		}
+		addrs[addr] = true
+	}
+	e.accountAddrs = addrs
+	return addrs
 }
 
 // filter returns true if the event is for the user or provider
 func (e *EventsListener) filter(provider, user common.Address) bool {
 	ret := provider.Hex() == e.addr.Hex() || user.Hex() == e.addr.Hex()
+	if !ret {
+		accountAddrs := e.getAccountAddresses()
+		ret = accountAddrs[provider] || accountAddrs[user]
+	}
 	if !ret {
 		e.log.Debugf("received event for another user/provider, skipping")
 	}
 	return ret
 }
+
+func sessionEventData(sessionID [32]byte, user, provider common.Address, txHash common.Hash) *webhooks.SessionEventData {
+	return &webhooks.SessionEventData{
+		SessionID: lib.BytesToString(sessionID[:]),
+		User:      user.Hex(),
+		Provider:  provider.Hex(),
+		TxHash:    txHash.Hex(),
+	}
+}
@@ -0,0 +1,483 @@
+package blockchainapi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"sync/atomic"
+	"time"
+
+	"github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/blockchainapi/structs"
+	i "github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/interfaces"
+	"github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/lib"
+	"github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/repositories/contracts"
+	m "github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/repositories/contracts/bindings/marketplace"
+	"github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/repositories/contracts/bindings/modelregistry"
+	pr "github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/repositories/contracts/bindings/providerregistry"
+	s "github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/repositories/contracts/bindings/sessionrouter"
+	"github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/repositories/multicall"
+	r "github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/repositories/registries"
+	"github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/storages"
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+const (
+	entityProvider = "provider"
+	entityModel    = "model"
+	entityBid      = "bid"
+	entitySession  = "session"
+
+	// indexerReorgDepth is the number of recent blocks which hashes are kept to find the common ancestor on reorg
+	indexerReorgDepth = 256
+	// indexerReorgCheckInterval is how often the last indexed block hash is compared with the chain
+	indexerReorgCheckInterval = 30 * time.Second
+	// indexerRetryInterval is the delay before restarting indexing after an error
+	indexerRetryInterval = 10 * time.Second
+)
+
+var (
+	ErrIndexerReorg    = errors.New("chain reorganization detected")
+	ErrIndexerDisabled = errors.New("indexer is disabled")
+	ErrIndexerSortBy   = errors.New("invalid sort field")
+)
+
+// indexedLog is emitted by the log watcher, keeping the block info of the event
+type indexedLog struct {
+	Log   types.Log
+	Event interface{}
+}
+
+// Indexer builds a local copy of providers, models, bids and sessions from diamond contract events.
+// Entities are refreshed from the chain when an event concerning them is received, and the hashes of
+// the blocks they were updated in are kept to roll back and reindex on chain reorganization
+type Indexer struct {
+	// config
+	diamondAddr   common.Address
+	startBlock    uint64
+	backfillRange uint64
+
+	// deps
+	ethClient        i.EthClient
+	logWatcher       contracts.LogWatcher
+	providerRegistry *r.ProviderRegistry
+	modelRegistry    *r.ModelRegistry
+	marketplace      *r.Marketplace
+	sessionRouter    *r.SessionRouter
+	storage          *storages.IndexerStorage
+	log              lib.ILogger
+
+	// state
+	mapper contracts.EventMapper
+	synced atomic.Bool
+	reorgs atomic.Uint64
+}
+
+func NewIndexer(ethClient i.EthClient, mc multicall.MulticallBackend, diamondAddr common.Address, logWatcher contracts.LogWatcher, storage *storages.IndexerStorage, startBlock uint64, backfillRange uint64, log lib.ILogger) *Indexer {
+	providerRegistry := r.NewProviderRegistry(diamondAddr, ethClient, mc, log)
+	modelRegistry := r.NewModelRegistry(diamondAddr, ethClient, mc, log)
+	marketplace := r.NewMarketplace(diamondAddr, ethClient, mc, log)
+	sessionRouter := r.NewSessionRouter(diamondAddr, ethClient, mc, log)
+
+	prABI, err := pr.ProviderRegistryMetaData.GetAbi()
+	if err != nil {
+		panic("invalid provider registry ABI: " + err.Error())
+	}
+	mrABI, err := modelregistry.ModelRegistryMetaData.GetAbi()
+	if err != nil {
+		panic("invalid model registry ABI: " + err.Error())
+	}
+
+	return &Indexer{
+		diamondAddr:      diamondAddr,
+		startBlock:       startBlock,
+		backfillRange:    backfillRange,
+		ethClient:        ethClient,
+		logWatcher:       logWatcher,
+		providerRegistry: providerRegistry,
+		modelRegistry:    modelRegistry,
+		marketplace:      marketplace,
+		sessionRouter:    sessionRouter,
+		storage:          storage,
+		log:              log,
+		mapper: contracts.CombineEventMappers(
+			contracts.CreateEventMapper(contracts.ProviderRegistryEventFactory, prABI),
+			contracts.CreateEventMapper(contracts.ModelRegistryEventFactory, mrABI),
+			contracts.CreateEventMapper(contracts.MarketplaceEventFactory, marketplace.GetABI()),
+			contracts.CreateEventMapper(contracts.SessionRouterEventFactory, sessionRouter.GetABI()),
+		),
+	}
+}
+
+func (idx *Indexer) Run(ctx context.Context) error {
+	for {
+		err := idx.sync(ctx)
+		if err == nil {
+			idx.synced.Store(true)
+			idx.log.Infof("index is synced, watching events")
+			err = idx.watch(ctx)
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		if errors.Is(err, ErrIndexerReorg) {
+			idx.log.Warnf("%s, reindexing", err)
+			idx.synced.Store(false)
+			continue
+		}
+
+		idx.log.Errorf("indexer error, retrying in %s: %s", indexerRetryInterval, err)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(indexerRetryInterval):
+		}
+	}
+}
+
+// IsSynced returns true if the index caught up with the chain and can serve queries
+func (idx *Indexer) IsSynced() bool {
+	return idx.synced.Load()
+}
+
+func (idx *Indexer) Status() structs.IndexerStatusRes {
+	status := structs.IndexerStatusRes{
+		Synced:     idx.IsSynced(),
+		StartBlock: idx.startBlock,
+		Reorgs:     idx.reorgs.Load(),
+	}
+	if checkpoint, ok := idx.storage.GetCheckpoint(); ok {
+		status.Block = checkpoint.Block
+		status.BlockHash = checkpoint.Hash
+	}
+	return status
+}
+
+// sync backfills the index from the last checkpoint to the current head, rolling back if the checkpoint was reorged
+func (idx *Indexer) sync(ctx context.Context) error {
+	from := idx.startBlock
+	if checkpoint, ok := idx.storage.GetCheckpoint(); ok {
+		match, err := idx.checkpointMatches(ctx, checkpoint)
+		if err != nil {
+			return err
+		}
+		from = checkpoint.Block + 1
+		if !match {
+			from, err = idx.rollback(ctx, checkpoint)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	head, err := idx.ethClient.HeaderByNumber(ctx, nil)
+	if err != nil {
+		return err
+	}
+	headNumber := head.Number.Uint64()
+
+	for from <= headNumber {
+		to := min(from+idx.backfillRange-1, headNumber)
+		idx.log.Debugf("indexing blocks from %d to %d", from, to)
+
+		logs, err := idx.ethClient.FilterLogs(ctx, ethereum.FilterQuery{
+			Addresses: []common.Address{idx.diamondAddr},
+			FromBlock: new(big.Int).SetUint64(from),
+			ToBlock:   new(big.Int).SetUint64(to),
+		})
+		if err != nil {
+			return err
+		}
+
+		for _, log := range logs {
+			event, err := idx.mapper(log)
+			if err != nil {
+				idx.log.Debugf("error mapping event, skipping: %s", err)
+				continue
+			}
+			err = idx.processEvent(ctx, log, event)
+			if err != nil {
+				return err
+			}
+		}
+
+		toHeader, err := idx.ethClient.HeaderByNumber(ctx, new(big.Int).SetUint64(to))
+		if err != nil {
+			return err
+		}
+		err = idx.storage.SetCheckpoint(&storages.IndexerCheckpoint{Block: to, Hash: toHeader.Hash().Hex()})
+		if err != nil {
+			return err
+		}
+		from = to + 1
+	}
+
+	if _, ok := idx.storage.GetCheckpoint(); !ok {
+		// start block is ahead of the chain head
+		err = idx.storage.SetCheckpoint(&storages.IndexerCheckpoint{Block: headNumber, Hash: head.Hash().Hex()})
+		if err != nil {
+			return err
+		}
+	}
+
+	return idx.prune(headNumber)
+}
+
+// watch processes live events until an error or a reorg is detected
+func (idx *Indexer) watch(ctx context.Context) error {
+	checkpoint, ok := idx.storage.GetCheckpoint()
+	if !ok {
+		return fmt.Errorf("no checkpoint after sync")
+	}
+
+	sub, err := idx.logWatcher.Watch(ctx, idx.diamondAddr, idx.watchMapper, new(big.Int).SetUint64(checkpoint.Block+1))
+	if err != nil {
+		return err
+	}
+	defer sub.Unsubscribe()
+
+	ticker := time.NewTicker(indexerReorgCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case event := <-sub.Events():
+			ev, ok := event.(*indexedLog)
+			if !ok {
+				continue
+			}
+			if ev.Log.Removed {
+				return lib.WrapError(ErrIndexerReorg, fmt.Errorf("log removed in block %d", ev.Log.BlockNumber))
+			}
+			if block, ok := idx.storage.GetBlock(ev.Log.BlockNumber); ok && block.Hash != ev.Log.BlockHash.Hex() {
+				return lib.WrapError(ErrIndexerReorg, fmt.Errorf("block %d hash changed", ev.Log.BlockNumber))
+			}
+
+			err := idx.processEvent(ctx, ev.Log, ev.Event)
+			if err != nil {
+				idx.log.Errorf("error indexing event: %s", err)
+				continue
+			}
+			if ev.Log.BlockNumber > checkpoint.Block {
+				checkpoint = &storages.IndexerCheckpoint{Block: ev.Log.BlockNumber, Hash: ev.Log.BlockHash.Hex()}
+				err = idx.storage.SetCheckpoint(checkpoint)
+				if err != nil {
+					return err
+				}
+			}
+		case err := <-sub.Err():
+			return err
+		case <-ticker.C:
+			match, err := idx.checkpointMatches(ctx, checkpoint)
+			if err != nil {
+				idx.log.Warnf("error checking checkpoint: %s", err)
+				continue
+			}
+			if !match {
+				return lib.WrapError(ErrIndexerReorg, fmt.Errorf("block %d hash changed", checkpoint.Block))
+			}
+			err = idx.prune(checkpoint.Block)
+			if err != nil {
+				idx.log.Warnf("error pruning block hashes: %s", err)
+			}
+		}
+	}
+}
+
+func (idx *Indexer) watchMapper(log types.Log) (interface{}, error) {
+	event, err := idx.mapper(log)
+	if err != nil {
+		return nil, err
+	}
+	return &indexedLog{Log: log, Event: event}, nil
+}
+
+// rollback reverts the index to the latest stored block that is still in the chain
+// and refreshes the entities updated after it. Returns the block to continue indexing from
+func (idx *Indexer) rollback(ctx context.Context, checkpoint *storages.IndexerCheckpoint) (uint64, error) {
+	idx.reorgs.Add(1)
+
+	blocks, err := idx.storage.GetBlocks()
+	if err != nil {
+		return 0, err
+	}
+
+	// if no common ancestor is found among stored blocks, reindex the whole reorg window
+	from := idx.startBlock
+	if checkpoint.Block > idx.startBlock+indexerReorgDepth {
+		from = checkpoint.Block - indexerReorgDepth
+	}
+	var ancestor *storages.IndexerCheckpoint
+
+	touched := make(map[string]struct{})
+	for j := len(blocks) - 1; j >= 0; j-- {
+		block := blocks[j]
+		header, err := idx.ethClient.HeaderByNumber(ctx, new(big.Int).SetUint64(block.Number))
+		if err != nil && !errors.Is(err, ethereum.NotFound) {
+			return 0, err
+		}
+		if header != nil && header.Hash().Hex() == block.Hash {
+			ancestor = &storages.IndexerCheckpoint{Block: block.Number, Hash: block.Hash}
+			from = block.Number + 1
+			break
+		}
+
+		for _, ref := range block.Entities {
+			touched[ref] = struct{}{}
+		}
+		err = idx.storage.DeleteBlock(block.Number)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	idx.log.Warnf("rolling back index from block %d to %d, refreshing %d entities", checkpoint.Block, from-1, len(touched))
+
+	for ref := range touched {
+		kind, id := storages.ParseEntityRef(ref)
+		err := idx.refreshEntity(ctx, kind, id)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	if ancestor == nil {
+		ancestor = &storages.IndexerCheckpoint{}
+		if from > 0 {
+			header, err := idx.ethClient.HeaderByNumber(ctx, new(big.Int).SetUint64(from-1))
+			if err != nil {
+				return 0, err
+			}
+			ancestor = &storages.IndexerCheckpoint{Block: from - 1, Hash: header.Hash().Hex()}
+		}
+	}
+
+	return from, idx.storage.SetCheckpoint(ancestor)
+}
+
+func (idx *Indexer) checkpointMatches(ctx context.Context, checkpoint *storages.IndexerCheckpoint) (bool, error) {
+	header, err := idx.ethClient.HeaderByNumber(ctx, new(big.Int).SetUint64(checkpoint.Block))
+	if errors.Is(err, ethereum.NotFound) {
+		// the chain is shorter than the checkpoint
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return header.Hash().Hex() == checkpoint.Hash, nil
+}
+
+func (idx *Indexer) prune(head uint64) error {
+	if head <= indexerReorgDepth {
+		return nil
+	}
+	return idx.storage.PruneBlocks(head - indexerReorgDepth)
+}
+
+func (idx *Indexer) processEvent(ctx context.Context, log types.Log, event interface{}) error {
+	kind, id, err := idx.eventEntity(ctx, event)
+	if err != nil {
+		return err
+	}
+	if kind == "" {
+		return nil
+	}
+
+	err = idx.refreshEntity(ctx, kind, id)
+	if err != nil {
+		return err
+	}
+
+	return idx.storage.AddBlockEntities(log.BlockNumber, log.BlockHash.Hex(), storages.FormatEntityRef(kind, id))
+}
+
+// eventEntity returns the kind and the id of the entity updated by the event
+func (idx *Indexer) eventEntity(ctx context.Context, event interface{}) (string, string, error) {
+	switch ev := event.(type) {
+	case *pr.ProviderRegistryProviderRegistered:
+		return entityProvider, ev.Provider.Hex(), nil
+	case *pr.ProviderRegistryProviderDeregistered:
+		return entityProvider, ev.Provider.Hex(), nil
+	case *pr.ProviderRegistryProviderWithdrawn:
+		return entityProvider, ev.Provider.Hex(), nil
+	case *modelregistry.ModelRegistryModelRegisteredUpdated:
+		return entityModel, common.Hash(ev.ModelId).Hex(), nil
+	case *modelregistry.ModelRegistryModelDeregistered:
+		return entityModel, common.Hash(ev.ModelId).Hex(), nil
+	case *m.MarketplaceMarketplaceBidPosted:
+		bidID, err := idx.marketplace.GetBidId(ctx, ev.Provider, ev.ModelId, ev.Nonce)
+		return entityBid, bidID.Hex(), err
+	case *m.MarketplaceMarketplaceBidDeleted:
+		bidID, err := idx.marketplace.GetBidId(ctx, ev.Provider, ev.ModelId, ev.Nonce)
+		return entityBid, bidID.Hex(), err
+	case *s.SessionRouterSessionOpened:
+		return entitySession, common.Hash(ev.SessionId).Hex(), nil
+	case *s.SessionRouterSessionClosed:
+		return entitySession, common.Hash(ev.SessionId).Hex(), nil
+	}
+	return "", "", nil
+}
+
+// refreshEntity stores the current state of the entity from the chain, or removes it if it doesn't exist
+func (idx *Indexer) refreshEntity(ctx context.Context, kind, id string) error {
+	var (
+		entity interface{}
+		exists bool
+	)
+
+	switch kind {
+	case entityProvider:
+		addr := common.HexToAddress(id)
+		provider, err := idx.providerRegistry.GetProviderById(ctx, addr)
+		if err != nil {
+			return err
+		}
+		entity, exists = mapProvider(addr, *provider), provider.CreatedAt.Sign() != 0
+	case entityModel:
+		modelID := common.HexToHash(id)
+		model, err := idx.modelRegistry.GetModelById(ctx, modelID)
+		if err != nil {
+			return err
+		}
+		entity, exists = mapModel(modelID, *model), model.CreatedAt.Sign() != 0
+	case entityBid:
+		bidID := common.HexToHash(id)
+		bid, err := idx.marketplace.GetBidById(ctx, bidID)
+		if err != nil {
+			return err
+		}
+		entity, exists = mapBid(bidID, *bid), bid.CreatedAt.Sign() != 0
+	case entitySession:
+		sessionID := common.HexToHash(id)
+		session, err := idx.sessionRouter.GetSession(ctx, sessionID)
+		if err != nil {
+			return err
+		}
+		exists = session.OpenedAt.Sign() != 0
+		if exists {
+			bid, err := idx.marketplace.GetBidById(ctx, session.BidId)
+			if err != nil {
+				return err
+			}
+			entity = mapSession(sessionID, *session, *bid)
+		}
+	default:
+		return fmt.Errorf("unknown entity kind %s", kind)
+	}
+
+	if !exists {
+		return idx.storage.DeleteEntity(kind, id)
+	}
+
+	data, err := json.Marshal(entity)
+	if err != nil {
+		return err
+	}
+	return idx.storage.PutEntity(kind, id, data)
+}
@@ -0,0 +1,231 @@
+package blockchainapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"slices"
+	"strings"
+
+	"github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/blockchainapi/structs"
+	"github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/lib"
+	r "github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/repositories/registries"
+	"github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/storages"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// IndexerQuery holds sorting and pagination params, zero limit returns all entries
+type IndexerQuery struct {
+	SortBy string
+	Order  r.Order
+	Offset uint64
+	Limit  uint64
+}
+
+type ProviderFilter struct {
+	IncludeDeleted bool
+}
+
+type ModelFilter struct {
+	Owner          common.Address
+	Tag            string
+	IncludeDeleted bool
+}
+
+type BidFilter struct {
+	Provider   common.Address
+	ModelID    common.Hash
+	ActiveOnly bool
+}
+
+type SessionFilter struct {
+	User     common.Address
+	Provider common.Address
+	ModelID  common.Hash
+	OpenOnly bool
+}
+
+var (
+	providerSorters = map[string]func(a, b *structs.Provider) int{
+		"createdAt": func(a, b *structs.Provider) int { return cmpBigInt(a.CreatedAt, b.CreatedAt) },
+		"stake":     func(a, b *structs.Provider) int { return cmpBigInt(a.Stake, b.Stake) },
+	}
+	modelSorters = map[string]func(a, b *structs.Model) int{
+		"createdAt": func(a, b *structs.Model) int { return cmpBig(a.CreatedAt, b.CreatedAt) },
+		"fee":       func(a, b *structs.Model) int { return cmpBig(a.Fee, b.Fee) },
+		"stake":     func(a, b *structs.Model) int { return cmpBig(a.Stake, b.Stake) },
+		"name":      func(a, b *structs.Model) int { return strings.Compare(a.Name, b.Name) },
+	}
+	bidSorters = map[string]func(a, b *structs.Bid) int{
+		"createdAt":      func(a, b *structs.Bid) int { return cmpBigInt(a.CreatedAt, b.CreatedAt) },
+		"pricePerSecond": func(a, b *structs.Bid) int { return cmpBigInt(a.PricePerSecond, b.PricePerSecond) },
+	}
+	sessionSorters = map[string]func(a, b *structs.Session) int{
+		"openedAt": func(a, b *structs.Session) int { return cmpBig(a.OpenedAt, b.OpenedAt) },
+		"endsAt":   func(a, b *structs.Session) int { return cmpBig(a.EndsAt, b.EndsAt) },
+		"stake":    func(a, b *structs.Session) int { return cmpBig(a.Stake, b.Stake) },
+	}
+)
+
+func (idx *Indexer) GetProviders(filter ProviderFilter, query IndexerQuery) ([]*structs.Provider, error) {
+	providers, err := loadEntities[structs.Provider](idx.storage, entityProvider)
+	if err != nil {
+		return nil, err
+	}
+
+	providers = slices.DeleteFunc(providers, func(p *structs.Provider) bool {
+		return p.IsDeleted && !filter.IncludeDeleted
+	})
+
+	return sortAndPage(providers, providerSorters, "createdAt", query)
+}
+
+func (idx *Indexer) GetModels(filter ModelFilter, query IndexerQuery) ([]*structs.Model, error) {
+	models, err := loadEntities[structs.Model](idx.storage, entityModel)
+	if err != nil {
+		return nil, err
+	}
+
+	models = slices.DeleteFunc(models, func(m *structs.Model) bool {
+		if m.IsDeleted && !filter.IncludeDeleted {
+			return true
+		}
+		if (filter.Owner != common.Address{}) && m.Owner != filter.Owner {
+			return true
+		}
+		if filter.Tag != "" && !slices.ContainsFunc(m.Tags, func(tag string) bool { return strings.EqualFold(tag, filter.Tag) }) {
+			return true
+		}
+		return false
+	})
+
+	return sortAndPage(models, modelSorters, "createdAt", query)
+}
+
+func (idx *Indexer) GetBids(filter BidFilter, query IndexerQuery) ([]*structs.Bid, error) {
+	bids, err := loadEntities[structs.Bid](idx.storage, entityBid)
+	if err != nil {
+		return nil, err
+	}
+
+	bids = slices.DeleteFunc(bids, func(b *structs.Bid) bool {
+		if filter.ActiveOnly && b.DeletedAt != nil && b.DeletedAt.Sign() != 0 {
+			return true
+		}
+		if (filter.Provider != common.Address{}) && b.Provider != filter.Provider {
+			return true
+		}
+		if (filter.ModelID != common.Hash{}) && b.ModelAgentId != filter.ModelID {
+			return true
+		}
+		return false
+	})
+
+	return sortAndPage(bids, bidSorters, "createdAt", query)
+}
+
+func (idx *Indexer) GetSessions(filter SessionFilter, query IndexerQuery) ([]*structs.Session, error) {
+	sessions, err := loadEntities[structs.Session](idx.storage, entitySession)
+	if err != nil {
+		return nil, err
+	}
+
+	modelID := lib.BytesToString(filter.ModelID[:])
+	sessions = slices.DeleteFunc(sessions, func(s *structs.Session) bool {
+		if filter.OpenOnly && s.ClosedAt != nil && s.ClosedAt.Sign() != 0 {
+			return true
+		}
+		if (filter.User != common.Address{}) && s.User != filter.User {
+			return true
+		}
+		if (filter.Provider != common.Address{}) && s.Provider != filter.Provider {
+			return true
+		}
+		if (filter.ModelID != common.Hash{}) && s.ModelAgentId != modelID {
+			return true
+		}
+		return false
+	})
+
+	return sortAndPage(sessions, sessionSorters, "openedAt", query)
+}
+
+func loadEntities[T any](storage *storages.IndexerStorage, kind string) ([]*T, error) {
+	values, err := storage.GetEntities(kind)
+	if err != nil {
+		return nil, err
+	}
+
+	entities := make([]*T, len(values))
+	for i, val := range values {
+		entities[i] = new(T)
+		err := json.Unmarshal(val, entities[i])
+		if err != nil {
+			return nil, fmt.Errorf("error parsing indexed %s: %w", kind, err)
+		}
+	}
+	return entities, nil
+}
+
+func sortAndPage[T any](items []*T, sorters map[string]func(a, b *T) int, defaultSort string, query IndexerQuery) ([]*T, error) {
+	sortBy := query.SortBy
+	if sortBy == "" {
+		sortBy = defaultSort
+	}
+	cmp, ok := sorters[sortBy]
+	if !ok {
+		return nil, lib.WrapError(ErrIndexerSortBy, fmt.Errorf("%s", sortBy))
+	}
+
+	// items are loaded ordered by id, stable sort keeps the result deterministic
+	slices.SortStableFunc(items, func(a, b *T) int {
+		if query.Order == r.OrderDESC {
+			return cmp(b, a)
+		}
+		return cmp(a, b)
+	})
+
+	if query.Offset >= uint64(len(items)) {
+		return []*T{}, nil
+	}
+	items = items[query.Offset:]
+	if query.Limit > 0 && query.Limit < uint64(len(items)) {
+		items = items[:query.Limit]
+	}
+	return items, nil
+}
+
+func cmpBig(a, b *big.Int) int {
+	if a == nil || b == nil {
+		return cmpNil(a == nil, b == nil)
+	}
+	return a.Cmp(b)
+}
+
+func cmpBigInt(a, b *lib.BigInt) int {
+	if a == nil || b == nil {
+		return cmpNil(a == nil, b == nil)
+	}
+	return a.Cmp(&b.Int)
+}
+
+// cmpNil orders nil values first
+func cmpNil(aNil, bNil bool) int {
+	switch {
+	case aNil && bNil:
+		return 0
+	case aNil:
+		return -1
+	default:
+		return 1
+	}
+}
+
+// indexerPage maps the pagination params of the contract listings, sorted by creation
+func indexerPage(offset *big.Int, limit uint8, order r.Order) IndexerQuery {
+	return IndexerQuery{
+		Offset: offset.Uint64(),
+		Limit:  uint64(limit),
+		Order:  order,
+	}
+}
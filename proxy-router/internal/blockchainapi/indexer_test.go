@@ -0,0 +1,271 @@
+package blockchainapi
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"testing"
+
+	"github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/blockchainapi/structs"
+	"github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/interfaces/mocks"
+	"github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/lib"
+	m "github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/repositories/contracts/bindings/marketplace"
+	"github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/repositories/contracts/bindings/modelregistry"
+	pr "github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/repositories/contracts/bindings/providerregistry"
+	s "github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/repositories/contracts/bindings/sessionrouter"
+	"github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/repositories/multicall"
+	r "github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/repositories/registries"
+	"github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/storages"
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeChain is a chain with diamond contract state and event logs, which can be reorged by changing the fork
+type fakeChain struct {
+	head     uint64
+	fork     byte
+	forkFrom uint64 // blocks starting from this one belong to the fork
+	logs     []types.Log
+
+	providers map[common.Address]pr.IProviderStorageProvider
+	models    map[common.Hash]modelregistry.IModelStorageModel
+	bids      map[common.Hash]m.IBidStorageBid
+
+	abis map[string]*abi.ABI
+}
+
+func newFakeChain(head uint64) *fakeChain {
+	mpABI, _ := m.MarketplaceMetaData.GetAbi()
+	mrABI, _ := modelregistry.ModelRegistryMetaData.GetAbi()
+	prABI, _ := pr.ProviderRegistryMetaData.GetAbi()
+	srABI, _ := s.SessionRouterMetaData.GetAbi()
+
+	return &fakeChain{
+		head:      head,
+		providers: make(map[common.Address]pr.IProviderStorageProvider),
+		models:    make(map[common.Hash]modelregistry.IModelStorageModel),
+		bids:      make(map[common.Hash]m.IBidStorageBid),
+		abis:      map[string]*abi.ABI{"marketplace": mpABI, "model": mrABI, "provider": prABI, "session": srABI},
+	}
+}
+
+func (c *fakeChain) header(number uint64) *types.Header {
+	extra := []byte{0}
+	if number >= c.forkFrom {
+		extra = []byte{c.fork}
+	}
+	return &types.Header{Number: new(big.Int).SetUint64(number), Extra: extra}
+}
+
+func (c *fakeChain) HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error) {
+	if number == nil {
+		return c.header(c.head), nil
+	}
+	if number.Uint64() > c.head {
+		return nil, ethereum.NotFound
+	}
+	return c.header(number.Uint64()), nil
+}
+
+func (c *fakeChain) FilterLogs(ctx context.Context, q ethereum.FilterQuery) ([]types.Log, error) {
+	res := make([]types.Log, 0)
+	for _, log := range c.logs {
+		if log.BlockNumber >= q.FromBlock.Uint64() && log.BlockNumber <= q.ToBlock.Uint64() {
+			log.BlockHash = c.header(log.BlockNumber).Hash()
+			res = append(res, log)
+		}
+	}
+	return res, nil
+}
+
+func (c *fakeChain) addLog(t *testing.T, contract string, block uint64, event string, args ...interface{}) {
+	ev := c.abis[contract].Events[event]
+
+	var (
+		indexed  [][]interface{}
+		data     []interface{}
+		dataArgs abi.Arguments
+	)
+	for i, input := range ev.Inputs {
+		if input.Indexed {
+			indexed = append(indexed, []interface{}{args[i]})
+		} else {
+			data = append(data, args[i])
+			dataArgs = append(dataArgs, input)
+		}
+	}
+
+	topics, err := abi.MakeTopics(indexed...)
+	require.NoError(t, err)
+	packed, err := dataArgs.Pack(data...)
+	require.NoError(t, err)
+
+	log := types.Log{Address: testDiamondAddr, Topics: []common.Hash{ev.ID}, Data: packed, BlockNumber: block}
+	for _, topic := range topics {
+		log.Topics = append(log.Topics, topic[0])
+	}
+	c.logs = append(c.logs, log)
+}
+
+func (c *fakeChain) CallContract(ctx context.Context, msg ethereum.CallMsg, blockNumber *big.Int) ([]byte, error) {
+	for _, contractABI := range c.abis {
+		method, err := contractABI.MethodById(msg.Data[:4])
+		if err != nil {
+			continue
+		}
+		args, err := method.Inputs.Unpack(msg.Data[4:])
+		if err != nil {
+			return nil, err
+		}
+
+		switch method.Name {
+		case "getBidId":
+			return method.Outputs.Pack(bidID(args[0].(common.Address), args[1].([32]byte), args[2].(*big.Int)))
+		case "getBid":
+			bid, ok := c.bids[args[0].([32]byte)]
+			if !ok {
+				bid = m.IBidStorageBid{PricePerSecond: big.NewInt(0), Nonce: big.NewInt(0), CreatedAt: big.NewInt(0), DeletedAt: big.NewInt(0)}
+			}
+			return method.Outputs.Pack(bid)
+		case "getProvider":
+			provider, ok := c.providers[args[0].(common.Address)]
+			if !ok {
+				provider = pr.IProviderStorageProvider{Stake: big.NewInt(0), CreatedAt: big.NewInt(0), LimitPeriodEnd: big.NewInt(0), LimitPeriodEarned: big.NewInt(0)}
+			}
+			return method.Outputs.Pack(provider)
+		case "getModel":
+			model, ok := c.models[args[0].([32]byte)]
+			if !ok {
+				model = modelregistry.IModelStorageModel{Fee: big.NewInt(0), Stake: big.NewInt(0), CreatedAt: big.NewInt(0)}
+			}
+			return method.Outputs.Pack(model)
+		}
+		return nil, fmt.Errorf("unexpected method %s", method.Name)
+	}
+	return nil, fmt.Errorf("unknown method selector %x", msg.Data[:4])
+}
+
+func bidID(provider common.Address, modelID common.Hash, nonce *big.Int) common.Hash {
+	return crypto.Keccak256Hash(provider.Bytes(), modelID.Bytes(), common.BigToHash(nonce).Bytes())
+}
+
+func newTestIndexer(t *testing.T, chain *fakeChain, startBlock uint64) *Indexer {
+	ethClientMock := mocks.NewEthClientMock(t)
+	ethClientMock.EXPECT().CallContract(mock.Anything, mock.Anything, mock.Anything).RunAndReturn(chain.CallContract).Maybe()
+	ethClientMock.EXPECT().HeaderByNumber(mock.Anything, mock.Anything).RunAndReturn(chain.HeaderByNumber).Maybe()
+	ethClientMock.EXPECT().FilterLogs(mock.Anything, mock.Anything).RunAndReturn(chain.FilterLogs).Maybe()
+
+	storage := storages.NewIndexerStorage(storages.NewTestStorage())
+	mc := multicall.NewMulticall3(ethClientMock)
+	return NewIndexer(ethClientMock, mc, testDiamondAddr, nil, storage, startBlock, 3, lib.NewTestLogger())
+}
+
+func TestIndexerBackfill(t *testing.T) {
+	chain := newFakeChain(20)
+	provider := common.HexToAddress("0x1")
+	modelID := common.HexToHash("0x2")
+
+	chain.providers[provider] = pr.IProviderStorageProvider{Endpoint: "localhost:3333", Stake: big.NewInt(10), CreatedAt: big.NewInt(1), LimitPeriodEnd: big.NewInt(0), LimitPeriodEarned: big.NewInt(0)}
+	chain.models[modelID] = modelregistry.IModelStorageModel{Fee: big.NewInt(0), Stake: big.NewInt(1), Owner: provider, Name: "llama", Tags: []string{"LLM"}, CreatedAt: big.NewInt(2)}
+	chain.bids[bidID(provider, modelID, big.NewInt(0))] = m.IBidStorageBid{Provider: provider, ModelId: modelID, PricePerSecond: big.NewInt(5), Nonce: big.NewInt(0), CreatedAt: big.NewInt(3), DeletedAt: big.NewInt(0)}
+
+	chain.addLog(t, "provider", 5, "ProviderRegistered", provider)
+	chain.addLog(t, "model", 6, "ModelRegisteredUpdated", provider, modelID)
+	chain.addLog(t, "marketplace", 7, "MarketplaceBidPosted", provider, modelID, big.NewInt(0))
+
+	idx := newTestIndexer(t, chain, 2)
+	err := idx.sync(context.Background())
+	require.NoError(t, err)
+
+	providers, err := idx.GetProviders(ProviderFilter{}, IndexerQuery{})
+	require.NoError(t, err)
+	require.Len(t, providers, 1)
+	require.Equal(t, "localhost:3333", providers[0].Endpoint)
+
+	models, err := idx.GetModels(ModelFilter{Tag: "llm"}, IndexerQuery{})
+	require.NoError(t, err)
+	require.Len(t, models, 1)
+	require.Equal(t, modelID, models[0].Id)
+
+	bids, err := idx.GetBids(BidFilter{ModelID: modelID, ActiveOnly: true}, IndexerQuery{})
+	require.NoError(t, err)
+	require.Len(t, bids, 1)
+	require.Equal(t, bidID(provider, modelID, big.NewInt(0)), bids[0].Id)
+
+	status := idx.Status()
+	require.Equal(t, uint64(20), status.Block)
+	require.Equal(t, chain.header(20).Hash().Hex(), status.BlockHash)
+}
+
+func TestIndexerReorg(t *testing.T) {
+	chain := newFakeChain(20)
+	provider := common.HexToAddress("0x1")
+	modelID := common.HexToHash("0x2")
+	bid := bidID(provider, modelID, big.NewInt(0))
+
+	chain.providers[provider] = pr.IProviderStorageProvider{Endpoint: "localhost:3333", Stake: big.NewInt(10), CreatedAt: big.NewInt(1), LimitPeriodEnd: big.NewInt(0), LimitPeriodEarned: big.NewInt(0)}
+	chain.bids[bid] = m.IBidStorageBid{Provider: provider, ModelId: modelID, PricePerSecond: big.NewInt(5), Nonce: big.NewInt(0), CreatedAt: big.NewInt(3), DeletedAt: big.NewInt(0)}
+	chain.addLog(t, "provider", 5, "ProviderRegistered", provider)
+	chain.addLog(t, "marketplace", 15, "MarketplaceBidPosted", provider, modelID, big.NewInt(0))
+
+	idx := newTestIndexer(t, chain, 0)
+	require.NoError(t, idx.sync(context.Background()))
+
+	bids, err := idx.GetBids(BidFilter{}, IndexerQuery{})
+	require.NoError(t, err)
+	require.Len(t, bids, 1)
+
+	// blocks starting from 12 are replaced, the bid is not in the new fork
+	chain.fork, chain.forkFrom, chain.head = 1, 12, 22
+	chain.logs = chain.logs[:1]
+	delete(chain.bids, bid)
+
+	require.NoError(t, idx.sync(context.Background()))
+
+	bids, err = idx.GetBids(BidFilter{}, IndexerQuery{})
+	require.NoError(t, err)
+	require.Len(t, bids, 0)
+
+	providers, err := idx.GetProviders(ProviderFilter{}, IndexerQuery{})
+	require.NoError(t, err)
+	require.Len(t, providers, 1)
+
+	status := idx.Status()
+	require.Equal(t, uint64(1), status.Reorgs)
+	require.Equal(t, uint64(22), status.Block)
+	require.Equal(t, chain.header(22).Hash().Hex(), status.BlockHash)
+}
+
+func TestIndexerQuerySortAndPage(t *testing.T) {
+	idx := newTestIndexer(t, newFakeChain(0), 0)
+	for i, price := range []int64{30, 10, 20} {
+		bid := &structs.Bid{
+			Id:             common.BigToHash(big.NewInt(int64(i + 1))),
+			PricePerSecond: &lib.BigInt{Int: *big.NewInt(price)},
+			CreatedAt:      &lib.BigInt{Int: *big.NewInt(int64(i))},
+			DeletedAt:      &lib.BigInt{},
+		}
+		data, err := json.Marshal(bid)
+		require.NoError(t, err)
+		require.NoError(t, idx.storage.PutEntity(entityBid, bid.Id.Hex(), data))
+	}
+
+	bids, err := idx.GetBids(BidFilter{}, IndexerQuery{SortBy: "pricePerSecond", Order: r.OrderDESC, Offset: 1, Limit: 1})
+	require.NoError(t, err)
+	require.Len(t, bids, 1)
+	require.Equal(t, int64(20), bids[0].PricePerSecond.Int64())
+
+	bids, err = idx.GetBids(BidFilter{}, IndexerQuery{})
+	require.NoError(t, err)
+	require.Len(t, bids, 3)
+	require.Equal(t, int64(30), bids[0].PricePerSecond.Int64())
+
+	_, err = idx.GetBids(BidFilter{}, IndexerQuery{SortBy: "unknown"})
+	require.ErrorIs(t, err, ErrIndexerSortBy)
+}
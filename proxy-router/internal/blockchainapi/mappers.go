@@ -10,6 +10,7 @@ import (
 	pr "github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/repositories/contracts/bindings/providerregistry"
 	s "github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/repositories/contracts/bindings/sessionrouter"
 	"github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/repositories/registries"
+	"github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/storages"
 	"github.com/ethereum/go-ethereum/common"
 )
 
@@ -105,3 +106,20 @@ func mapOrder(order string) registries.Order {
 	}
 	return registries.OrderASC
 }
+
+func mapVirtualSessions(sessions []*storages.VirtualSession) []*structs.VirtualSession {
+	result := make([]*structs.VirtualSession, len(sessions))
+	for i, value := range sessions {
+		result[i] = &structs.VirtualSession{
+			ID:            common.HexToHash(value.ID),
+			SessionID:     common.HexToHash(value.SessionID),
+			ModelID:       common.HexToHash(value.ModelID),
+			BidID:         common.HexToHash(value.BidID),
+			Duration:      value.Duration,
+			DirectPayment: value.DirectPayment,
+			Failover:      value.Failover,
+			Renewals:      value.Renewals,
+		}
+	}
+	return result
+}
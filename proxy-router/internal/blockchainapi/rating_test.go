@@ -12,14 +12,49 @@ import (
 
 func TestRating(t *testing.T) {
 	bidIds, bids, pmStats, mStats := sampleDataTPS()
+	providers := sampleProviders(len(bids))
+
+	rt, err := rating.NewRating(rating.NewScorerMock(), nil, nil, rating.FilterConfig{}, lib.NewTestLogger())
+	require.NoError(t, err)
 
 	bs := BlockchainService{
-		rating: rating.NewRating(rating.NewScorerMock(), nil, lib.NewTestLogger()),
+		rating: rt,
 	}
 
-	scoredBids := bs.rateBids(bidIds, bids, pmStats, []providerregistry.IProviderStorageProvider{}, mStats, big.NewInt(0), lib.NewTestLogger())
+	scoredBids, excluded := bs.rateBids(bidIds, bids, pmStats, providers, mStats, big.NewInt(0), lib.NewTestLogger())
+	require.Len(t, excluded, 0)
 
 	for i := 1; i < len(scoredBids); i++ {
 		require.GreaterOrEqual(t, scoredBids[i-1].Score, scoredBids[i].Score, "scoredBids not sorted")
 	}
 }
+
+func TestRatingExcluded(t *testing.T) {
+	bidIds, bids, pmStats, mStats := sampleDataTPS()
+	providers := sampleProviders(len(bids))
+
+	rt, err := rating.NewRating(rating.NewScorerMock(), nil, nil, rating.FilterConfig{MinSessionCount: 20}, lib.NewTestLogger())
+	require.NoError(t, err)
+
+	bs := BlockchainService{
+		rating: rt,
+	}
+
+	scoredBids, excluded := bs.rateBids(bidIds, bids, pmStats, providers, mStats, big.NewInt(0), lib.NewTestLogger())
+	require.Len(t, scoredBids, 0)
+	require.Len(t, excluded, len(bids))
+	require.Equal(t, rating.ReasonLowSessionCount, excluded[0].Reason)
+	require.Equal(t, bids[0].Provider, excluded[0].Bid.Provider)
+}
+
+func sampleProviders(count int) []providerregistry.IProviderStorageProvider {
+	providers := make([]providerregistry.IProviderStorageProvider, count)
+	for i := range providers {
+		providers[i] = providerregistry.IProviderStorageProvider{
+			Endpoint:  "localhost:3333",
+			Stake:     ToDecimal(100, DecimalsMOR),
+			CreatedAt: big.NewInt(1),
+		}
+	}
+	return providers
+}
@@ -16,6 +16,7 @@ import (
 	"github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/blockchainapi/structs"
 	i "github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/interfaces"
 	"github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/lib"
+	"github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/metrics"
 	"github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/proxyapi"
 	"github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/rating"
 	m "github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/repositories/contracts/bindings/marketplace"
@@ -25,6 +26,8 @@ import (
 	"github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/repositories/multicall"
 	r "github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/repositories/registries"
 	sessionrepo "github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/repositories/session"
+	"github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/repositories/txmanager"
+	"github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/webhooks"
 
 	"github.com/ethereum/go-ethereum"
 	"github.com/ethereum/go-ethereum/accounts/abi/bind"
@@ -33,8 +36,13 @@ import (
 	"github.com/ethereum/go-ethereum/crypto"
 )
 
-// basefeeWiggleMultiplier is a multiplier for the basefee to set the maxFeePerGas
-const basefeeWiggleMultiplier = 2
+const (
+	// basefeeWiggleMultiplier is a multiplier for the basefee to set the maxFeePerGas
+	basefeeWiggleMultiplier = 2
+
+	// providerCacheTTL is the time provider records are cached for bid rating
+	providerCacheTTL = 5 * time.Minute
+)
 
 type BlockchainService struct {
 	ethClient          i.EthClient
@@ -49,10 +57,16 @@ type BlockchainService struct {
 	diamonContractAddr common.Address
 	rating             *rating.Rating
 	minStake           *big.Int
+	providerCache      *lib.TTLCache[common.Address, pr.IProviderStorageProvider]
+	indexer            *Indexer
+	webhooks           *webhooks.Dispatcher
+	txManager          *txmanager.TxManager
 
 	legacyTx   bool
 	privateKey i.PrKeyProvider
-	log        lib.ILogger
+	// providerAccount is used by the provider methods if the request selects no account
+	providerAccount string
+	log             lib.ILogger
 }
 
 var (
@@ -63,6 +77,7 @@ var (
 	ErrSignTx            = errors.New("failed to sign transaction")
 	ErrSendTx            = errors.New("failed to send transaction")
 	ErrWaitMined         = errors.New("failed to wait for transaction to be mined")
+	ErrTxManagerDisabled = errors.New("transaction manager is disabled")
 	ErrSessionStore      = errors.New("failed to store session")
 	ErrSessionReport     = errors.New("failed to get session report from provider")
 	ErrSessionUserReport = errors.New("failed to get session report from user")
@@ -115,15 +130,67 @@ func NewBlockchainService(
 		diamonContractAddr: diamonContractAddr,
 		sessionRepo:        sessionRepo,
 		rating:             scorerAlgo,
+		providerCache:      lib.NewTTLCache[common.Address, pr.IProviderStorageProvider](providerCacheTTL),
 		log:                log,
 	}
 }
 
+// SetIndexer enables serving listings from the local index when it is synced
+func (s *BlockchainService) SetIndexer(indexer *Indexer) {
+	s.indexer = indexer
+}
+
+func (s *BlockchainService) SetWebhooks(dispatcher *webhooks.Dispatcher) {
+	s.webhooks = dispatcher
+}
+
+// SetProviderAccount sets the named account acting as the provider when the request selects no account
+func (s *BlockchainService) SetProviderAccount(name string) {
+	s.providerAccount = name
+}
+
+// WithProviderAccount selects the provider account unless the context already selects one
+func (s *BlockchainService) WithProviderAccount(ctx context.Context) context.Context {
+	if s.providerAccount == "" || lib.AccountFromContext(ctx) != "" {
+		return ctx
+	}
+	return lib.WithAccount(ctx, s.providerAccount)
+}
+
+// SetTxManager makes transactions use the locally allocated nonces, the service eth client
+// should be the one returned by txManager.Client()
+func (s *BlockchainService) SetTxManager(txManager *txmanager.TxManager) {
+	s.txManager = txManager
+}
+
+func (s *BlockchainService) GetTxQueueStatus() (*txmanager.QueueStatus, error) {
+	if s.txManager == nil {
+		return nil, ErrTxManagerDisabled
+	}
+	return s.txManager.Status(), nil
+}
+
+func (s *BlockchainService) GetIndexer() (*Indexer, error) {
+	if s.indexer == nil {
+		return nil, ErrIndexerDisabled
+	}
+	return s.indexer, nil
+}
+
+// useIndex returns true if listings should be served from the local index
+func (s *BlockchainService) useIndex() bool {
+	return s.indexer != nil && s.indexer.IsSynced()
+}
+
 func (s *BlockchainService) GetLatestBlock(ctx context.Context) (uint64, error) {
 	return s.ethClient.BlockNumber(ctx)
 }
 
 func (s *BlockchainService) GetAllProviders(ctx context.Context) ([]*structs.Provider, error) {
+	if s.useIndex() {
+		return s.indexer.GetProviders(ProviderFilter{IncludeDeleted: true}, IndexerQuery{})
+	}
+
 	addrs, providers, err := s.providerRegistry.GetAllProviders(ctx)
 	if err != nil {
 		return nil, err
@@ -133,6 +200,10 @@ func (s *BlockchainService) GetAllProviders(ctx context.Context) ([]*structs.Pro
 }
 
 func (s *BlockchainService) GetProviders(ctx context.Context, offset *big.Int, limit uint8, order r.Order) ([]*structs.Provider, error) {
+	if s.useIndex() {
+		return s.indexer.GetProviders(ProviderFilter{IncludeDeleted: true}, indexerPage(offset, limit, order))
+	}
+
 	addrs, providers, err := s.providerRegistry.GetProviders(ctx, offset, limit, order)
 	if err != nil {
 		return nil, err
@@ -160,6 +231,10 @@ func (s *BlockchainService) GetProvider(ctx context.Context, providerAddr common
 }
 
 func (s *BlockchainService) GetAllModels(ctx context.Context) ([]*structs.Model, error) {
+	if s.useIndex() {
+		return s.indexer.GetModels(ModelFilter{IncludeDeleted: true}, IndexerQuery{})
+	}
+
 	ids, models, err := s.modelRegistry.GetAllModels(ctx)
 	if err != nil {
 		return nil, err
@@ -169,6 +244,10 @@ func (s *BlockchainService) GetAllModels(ctx context.Context) ([]*structs.Model,
 }
 
 func (s *BlockchainService) GetModels(ctx context.Context, offset *big.Int, limit uint8, order r.Order) ([]*structs.Model, error) {
+	if s.useIndex() {
+		return s.indexer.GetModels(ModelFilter{IncludeDeleted: true}, indexerPage(offset, limit, order))
+	}
+
 	ids, models, err := s.modelRegistry.GetModels(ctx, offset, limit, order)
 	if err != nil {
 		return nil, err
@@ -178,6 +257,10 @@ func (s *BlockchainService) GetModels(ctx context.Context, offset *big.Int, limi
 }
 
 func (s *BlockchainService) GetBidsByProvider(ctx context.Context, providerAddr common.Address, offset *big.Int, limit uint8, order r.Order) ([]*structs.Bid, error) {
+	if s.useIndex() {
+		return s.indexer.GetBids(BidFilter{Provider: providerAddr}, indexerPage(offset, limit, order))
+	}
+
 	ids, bids, err := s.marketplace.GetBidsByProvider(ctx, providerAddr, offset, limit, order)
 	if err != nil {
 		return nil, err
@@ -187,6 +270,10 @@ func (s *BlockchainService) GetBidsByProvider(ctx context.Context, providerAddr
 }
 
 func (s *BlockchainService) GetBidsByModelAgent(ctx context.Context, modelId [32]byte, offset *big.Int, limit uint8, order r.Order) ([]*structs.Bid, error) {
+	if s.useIndex() {
+		return s.indexer.GetBids(BidFilter{ModelID: modelId}, indexerPage(offset, limit, order))
+	}
+
 	ids, bids, err := s.marketplace.GetBidsByModelAgent(ctx, modelId, offset, limit, order)
 	if err != nil {
 		return nil, err
@@ -196,6 +283,10 @@ func (s *BlockchainService) GetBidsByModelAgent(ctx context.Context, modelId [32
 }
 
 func (s *BlockchainService) GetActiveBidsByModel(ctx context.Context, modelId common.Hash, offset *big.Int, limit uint8, order r.Order) ([]*structs.Bid, error) {
+	if s.useIndex() {
+		return s.indexer.GetBids(BidFilter{ModelID: modelId, ActiveOnly: true}, indexerPage(offset, limit, order))
+	}
+
 	ids, bids, err := s.marketplace.GetActiveBidsByModel(ctx, modelId, offset, limit, order)
 	if err != nil {
 		return nil, err
@@ -209,6 +300,10 @@ func (s *BlockchainService) GetActiveBidsByProviderCount(ctx context.Context, pr
 }
 
 func (s *BlockchainService) GetActiveBidsByProvider(ctx context.Context, provider common.Address, offset *big.Int, limit uint8, order r.Order) ([]*structs.Bid, error) {
+	if s.useIndex() {
+		return s.indexer.GetBids(BidFilter{Provider: provider, ActiveOnly: true}, indexerPage(offset, limit, order))
+	}
+
 	ids, bids, err := s.marketplace.GetActiveBidsByProvider(ctx, provider, offset, limit, order)
 	if err != nil {
 		return nil, err
@@ -226,27 +321,27 @@ func (s *BlockchainService) GetBidByID(ctx context.Context, ID common.Hash) (*st
 	return mapBid(ID, *bid), nil
 }
 
-func (s *BlockchainService) GetRatedBids(ctx context.Context, modelID common.Hash) ([]structs.ScoredBid, error) {
+func (s *BlockchainService) GetRatedBids(ctx context.Context, modelID common.Hash) ([]structs.ScoredBid, []structs.ExcludedBid, error) {
 	modelStats, err := s.sessionRouter.GetModelStats(ctx, modelID)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	bidIDs, bids, providerModelStats, provider, err := s.GetAllBidsWithRating(ctx, modelID)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	minStake, err := s.getMinStakeCached(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get min stake: %w", err)
+		return nil, nil, fmt.Errorf("failed to get min stake: %w", err)
 	}
 
-	ratedBids := s.rateBids(bidIDs, bids, providerModelStats, provider, modelStats, minStake, s.log)
+	ratedBids, excludedBids := s.rateBids(bidIDs, bids, providerModelStats, provider, modelStats, minStake, s.log)
 
-	return ratedBids, nil
+	return ratedBids, excludedBids, nil
 }
 
-func (s *BlockchainService) rateBids(bidIds [][32]byte, bids []m.IBidStorageBid, pmStats []s.IStatsStorageProviderModelStats, provider []pr.IProviderStorageProvider, mStats *s.IStatsStorageModelStats, minStake *big.Int, log lib.ILogger) []structs.ScoredBid {
+func (s *BlockchainService) rateBids(bidIds [][32]byte, bids []m.IBidStorageBid, pmStats []s.IStatsStorageProviderModelStats, provider []pr.IProviderStorageProvider, mStats *s.IStatsStorageModelStats, minStake *big.Int, log lib.ILogger) ([]structs.ScoredBid, []structs.ExcludedBid) {
 	ratingInputs := make([]rating.RatingInput, len(bids))
 	bidIDIndexMap := make(map[common.Hash]int)
 
@@ -259,28 +354,21 @@ func (s *BlockchainService) rateBids(bidIds [][32]byte, bids []m.IBidStorageBid,
 				PricePerSecond: bids[i].PricePerSecond,
 				MinStake:       minStake,
 			},
-			BidID:      bidIds[i],
-			ModelID:    bids[i].ModelId,
-			ProviderID: bids[i].Provider,
+			BidID:            bidIds[i],
+			ModelID:          bids[i].ModelId,
+			ProviderID:       bids[i].Provider,
+			ProviderEndpoint: provider[i].Endpoint,
 		}
 		bidIDIndexMap[bidIds[i]] = i
 	}
 
-	result := s.rating.RateBids(ratingInputs, log)
+	result, excluded := s.rating.RateBids(ratingInputs, log)
 	scoredBids := make([]structs.ScoredBid, len(result))
 
 	for i, score := range result {
 		inputBidIndex := bidIDIndexMap[score.BidID]
 		scoredBid := structs.ScoredBid{
-			Bid: structs.Bid{
-				Id:             bidIds[inputBidIndex],
-				Provider:       bids[inputBidIndex].Provider,
-				ModelAgentId:   bids[inputBidIndex].ModelId,
-				PricePerSecond: &lib.BigInt{Int: *(bids[inputBidIndex].PricePerSecond)},
-				Nonce:          &lib.BigInt{Int: *(bids[inputBidIndex].Nonce)},
-				CreatedAt:      &lib.BigInt{Int: *(bids[inputBidIndex].CreatedAt)},
-				DeletedAt:      &lib.BigInt{Int: *(bids[inputBidIndex].DeletedAt)},
-			},
+			Bid:   *mapBid(bidIds[inputBidIndex], bids[inputBidIndex]),
 			Score: score.Score,
 		}
 		scoredBids[i] = scoredBid
@@ -290,11 +378,20 @@ func (s *BlockchainService) rateBids(bidIds [][32]byte, bids []m.IBidStorageBid,
 		return scoredBids[i].Score > scoredBids[j].Score
 	})
 
-	return scoredBids
+	excludedBids := make([]structs.ExcludedBid, len(excluded))
+	for i, excl := range excluded {
+		inputBidIndex := bidIDIndexMap[excl.BidID]
+		excludedBids[i] = structs.ExcludedBid{
+			Bid:    *mapBid(bidIds[inputBidIndex], bids[inputBidIndex]),
+			Reason: excl.Reason,
+		}
+	}
+
+	return scoredBids, excludedBids
 }
 
 func (s *BlockchainService) OpenSession(ctx context.Context, approval, approvalSig []byte, stake *big.Int, directPayment bool) (common.Hash, error) {
-	prKey, err := s.privateKey.GetPrivateKey()
+	prKey, err := i.GetPrivateKeyCtx(ctx, s.privateKey)
 	if err != nil {
 		return common.Hash{}, lib.WrapError(ErrPrKey, err)
 	}
@@ -304,12 +401,14 @@ func (s *BlockchainService) OpenSession(ctx context.Context, approval, approvalS
 		return common.Hash{}, lib.WrapError(ErrApprove, err)
 	}
 
-	transactOpt, err := s.getTransactOpts(ctx, prKey)
+	transactOpt, err := s.getTransactOpts(ctx)
 	if err != nil {
 		return common.Hash{}, lib.WrapError(ErrTxOpts, err)
 	}
 
+	start := time.Now()
 	sessionID, _, _, err := s.sessionRouter.OpenSession(transactOpt, approval, approvalSig, stake, directPayment, prKey)
+	metrics.ObserveTx("openSession", start, err)
 	if err != nil {
 		return common.Hash{}, lib.WrapError(ErrSendTx, err)
 	}
@@ -318,12 +417,9 @@ func (s *BlockchainService) OpenSession(ctx context.Context, approval, approvalS
 }
 
 func (s *BlockchainService) CreateNewProvider(ctx context.Context, stake *lib.BigInt, endpoint string) (*structs.Provider, error) {
-	prKey, err := s.privateKey.GetPrivateKey()
-	if err != nil {
-		return nil, lib.WrapError(ErrPrKey, err)
-	}
+	ctx = s.WithProviderAccount(ctx)
 
-	transactOpt, err := s.getTransactOpts(ctx, prKey)
+	transactOpt, err := s.getTransactOpts(ctx)
 	if err != nil {
 		return nil, lib.WrapError(ErrTxOpts, err)
 	}
@@ -333,7 +429,9 @@ func (s *BlockchainService) CreateNewProvider(ctx context.Context, stake *lib.Bi
 		return nil, lib.WrapError(ErrApprove, err)
 	}
 
+	start := time.Now()
 	err = s.providerRegistry.CreateNewProvider(transactOpt, stake, endpoint)
+	metrics.ObserveTx("createProvider", start, err)
 	if err != nil {
 		return nil, lib.WrapError(ErrSendTx, err)
 	}
@@ -353,12 +451,9 @@ func (s *BlockchainService) CreateNewProvider(ctx context.Context, stake *lib.Bi
 }
 
 func (s *BlockchainService) CreateNewModel(ctx context.Context, modelID common.Hash, ipfsID common.Hash, fee *lib.BigInt, stake *lib.BigInt, name string, tags []string) (*structs.Model, error) {
-	prKey, err := s.privateKey.GetPrivateKey()
-	if err != nil {
-		return nil, lib.WrapError(ErrPrKey, err)
-	}
+	ctx = s.WithProviderAccount(ctx)
 
-	transactOpt, err := s.getTransactOpts(ctx, prKey)
+	transactOpt, err := s.getTransactOpts(ctx)
 	if err != nil {
 		return nil, lib.WrapError(ErrTxOpts, err)
 	}
@@ -368,7 +463,9 @@ func (s *BlockchainService) CreateNewModel(ctx context.Context, modelID common.H
 		return nil, lib.WrapError(ErrApprove, err)
 	}
 
+	start := time.Now()
 	err = s.modelRegistry.CreateNewModel(transactOpt, modelID, ipfsID, fee, stake, name, tags)
+	metrics.ObserveTx("createModel", start, err)
 	if err != nil {
 		return nil, lib.WrapError(ErrSendTx, err)
 	}
@@ -397,17 +494,16 @@ func (s *BlockchainService) CreateNewModel(ctx context.Context, modelID common.H
 }
 
 func (s *BlockchainService) DeregisterModel(ctx context.Context, modelId common.Hash) (common.Hash, error) {
-	prKey, err := s.privateKey.GetPrivateKey()
-	if err != nil {
-		return common.Hash{}, lib.WrapError(ErrPrKey, err)
-	}
+	ctx = s.WithProviderAccount(ctx)
 
-	transactOpt, err := s.getTransactOpts(ctx, prKey)
+	transactOpt, err := s.getTransactOpts(ctx)
 	if err != nil {
 		return common.Hash{}, lib.WrapError(ErrTxOpts, err)
 	}
 
+	start := time.Now()
 	tx, err := s.modelRegistry.DeregisterModel(transactOpt, modelId)
+	metrics.ObserveTx("deregisterModel", start, err)
 	if err != nil {
 		return common.Hash{}, lib.WrapError(ErrSendTx, err)
 	}
@@ -415,6 +511,14 @@ func (s *BlockchainService) DeregisterModel(ctx context.Context, modelId common.
 	return tx, nil
 }
 
+func (s *BlockchainService) GetModelByID(ctx context.Context, modelID common.Hash) (*structs.Model, error) {
+	model, err := s.modelRegistry.GetModelById(ctx, modelID)
+	if err != nil {
+		return nil, lib.WrapError(ErrModel, err)
+	}
+	return mapModel(modelID, *model), nil
+}
+
 func (s *BlockchainService) ModelExists(ctx context.Context, modelID common.Hash) (bool, error) {
 	m, err := s.modelRegistry.GetModelById(ctx, modelID)
 
@@ -437,17 +541,14 @@ func (s *BlockchainService) ModelExists(ctx context.Context, modelID common.Hash
 }
 
 func (s *BlockchainService) CreateNewBid(ctx context.Context, modelID common.Hash, pricePerSecond *lib.BigInt) (*structs.Bid, error) {
+	ctx = s.WithProviderAccount(ctx)
+
 	fee, err := s.marketplace.GetBidFee(ctx)
 	if err != nil {
 		return nil, err
 	}
 
-	prKey, err := s.privateKey.GetPrivateKey()
-	if err != nil {
-		return nil, lib.WrapError(ErrPrKey, err)
-	}
-
-	transactOpt, err := s.getTransactOpts(ctx, prKey)
+	transactOpt, err := s.getTransactOpts(ctx)
 	if err != nil {
 		return nil, lib.WrapError(ErrTxOpts, err)
 	}
@@ -457,7 +558,9 @@ func (s *BlockchainService) CreateNewBid(ctx context.Context, modelID common.Has
 		return nil, lib.WrapError(ErrApprove, err)
 	}
 
+	start := time.Now()
 	newBidId, err := s.marketplace.PostModelBid(transactOpt, modelID, &pricePerSecond.Int)
+	metrics.ObserveTx("postBid", start, err)
 	if err != nil {
 		return nil, lib.WrapError(ErrSendTx, err)
 	}
@@ -467,40 +570,50 @@ func (s *BlockchainService) CreateNewBid(ctx context.Context, modelID common.Has
 		return nil, lib.WrapError(ErrBid, err)
 	}
 
+	s.webhooks.Emit(webhooks.EventBidPosted, &webhooks.BidEventData{
+		BidID:          bid.Id.Hex(),
+		ModelID:        bid.ModelAgentId.Hex(),
+		Provider:       bid.Provider.Hex(),
+		PricePerSecond: bid.PricePerSecond.String(),
+	})
+
 	return bid, nil
 }
 
 func (s *BlockchainService) DeleteBid(ctx context.Context, bidId common.Hash) (common.Hash, error) {
-	prKey, err := s.privateKey.GetPrivateKey()
-	if err != nil {
-		return common.Hash{}, lib.WrapError(ErrPrKey, err)
-	}
+	ctx = s.WithProviderAccount(ctx)
 
-	transactOpt, err := s.getTransactOpts(ctx, prKey)
+	transactOpt, err := s.getTransactOpts(ctx)
 	if err != nil {
 		return common.Hash{}, lib.WrapError(ErrTxOpts, err)
 	}
 
+	start := time.Now()
 	tx, err := s.marketplace.DeleteBid(transactOpt, bidId)
+	metrics.ObserveTx("deleteBid", start, err)
 	if err != nil {
 		return common.Hash{}, lib.WrapError(ErrSendTx, err)
 	}
 
+	s.webhooks.Emit(webhooks.EventBidDeleted, &webhooks.BidEventData{
+		BidID:  bidId.Hex(),
+		TxHash: tx.Hex(),
+	})
+
 	return tx, nil
 }
 
 func (s *BlockchainService) DeregisterProdiver(ctx context.Context) (common.Hash, error) {
-	prKey, err := s.privateKey.GetPrivateKey()
-	if err != nil {
-		return common.Hash{}, lib.WrapError(ErrPrKey, err)
-	}
+	ctx = s.WithProviderAccount(ctx)
 
-	transactOpt, err := s.getTransactOpts(ctx, prKey)
+	transactOpt, err := s.getTransactOpts(ctx)
 	if err != nil {
 		return common.Hash{}, lib.WrapError(ErrTxOpts, err)
 	}
 
+	start := time.Now()
 	tx, err := s.providerRegistry.DeregisterProvider(transactOpt)
+	metrics.ObserveTx("deregisterProvider", start, err)
 	if err != nil {
 		return common.Hash{}, lib.WrapError(ErrSendTx, err)
 	}
@@ -509,7 +622,7 @@ func (s *BlockchainService) DeregisterProdiver(ctx context.Context) (common.Hash
 }
 
 func (s *BlockchainService) CloseSession(ctx context.Context, sessionID common.Hash) (common.Hash, error) {
-	prKey, err := s.privateKey.GetPrivateKey()
+	prKey, err := i.GetPrivateKeyCtx(ctx, s.privateKey)
 	if err != nil {
 		return common.Hash{}, lib.WrapError(ErrPrKey, err)
 	}
@@ -531,12 +644,14 @@ func (s *BlockchainService) CloseSession(ctx context.Context, sessionID common.H
 		signedReport = report.SignedReport
 	}
 
-	transactOpt, err := s.getTransactOpts(ctx, prKey)
+	transactOpt, err := s.getTransactOpts(ctx)
 	if err != nil {
 		return common.Hash{}, lib.WrapError(ErrTxOpts, err)
 	}
 
+	start := time.Now()
 	tx, err := s.sessionRouter.CloseSession(transactOpt, sessionID, reportMessage, signedReport, prKey)
+	metrics.ObserveTx("closeSession", start, err)
 	if err != nil {
 		return common.Hash{}, lib.WrapError(ErrSendTx, err)
 	}
@@ -561,12 +676,7 @@ func (s *BlockchainService) GetProviderClaimableBalance(ctx context.Context, ses
 }
 
 func (s *BlockchainService) GetBalance(ctx context.Context) (eth *big.Int, mor *big.Int, err error) {
-	prKey, err := s.privateKey.GetPrivateKey()
-	if err != nil {
-		return nil, nil, lib.WrapError(ErrPrKey, err)
-	}
-
-	transactOpt, err := s.getTransactOpts(ctx, prKey)
+	transactOpt, err := s.getTransactOpts(ctx)
 	if err != nil {
 		return nil, nil, lib.WrapError(ErrTxOpts, err)
 	}
@@ -589,13 +699,19 @@ func (s *BlockchainService) SendETH(ctx context.Context, to common.Address, amou
 		To:    &to,
 		Value: amount,
 	})
+	if err != nil {
+		return common.Hash{}, err
+	}
 
+	start := time.Now()
 	err = s.ethClient.SendTransaction(ctx, signedTx)
 	if err != nil {
+		metrics.ObserveTx("sendETH", start, err)
 		return common.Hash{}, lib.WrapError(ErrSendTx, err)
 	}
 
 	_, err = bind.WaitMined(ctx, s.ethClient, signedTx)
+	metrics.ObserveTx("sendETH", start, err)
 	if err != nil {
 		return common.Hash{}, lib.WrapError(ErrWaitMined, err)
 	}
@@ -604,14 +720,10 @@ func (s *BlockchainService) SendETH(ctx context.Context, to common.Address, amou
 }
 
 func (s *BlockchainService) createSignedTransaction(ctx context.Context, txdata *types.DynamicFeeTx) (*types.Transaction, error) {
-	prKey, err := s.privateKey.GetPrivateKey()
+	addr, err := i.GetAddressCtx(ctx, s.privateKey)
 	if err != nil {
 		return nil, lib.WrapError(ErrPrKey, err)
 	}
-	addr, err := lib.PrivKeyBytesToAddr(prKey)
-	if err != nil {
-		return nil, err
-	}
 
 	gasTipCap, err := s.ethClient.SuggestGasTipCap(ctx)
 	if err != nil {
@@ -638,6 +750,9 @@ func (s *BlockchainService) createSignedTransaction(ctx context.Context, txdata
 		To:    txdata.To,
 		Value: txdata.Value,
 	})
+	if err != nil {
+		return nil, lib.WrapError(ErrEstimateGas, err)
+	}
 
 	chainID, err := s.ethClient.ChainID(ctx)
 	if err != nil {
@@ -654,12 +769,12 @@ func (s *BlockchainService) createSignedTransaction(ctx context.Context, txdata
 		Value:     txdata.Value,
 	})
 
-	privateKey, err := crypto.ToECDSA(prKey)
+	transactOpts, err := s.getTransactOpts(ctx)
 	if err != nil {
-		return nil, err
+		return nil, lib.WrapError(ErrTxOpts, err)
 	}
 
-	signedTx, err := types.SignTx(tx, types.LatestSignerForChainID(tx.ChainId()), privateKey)
+	signedTx, err := transactOpts.Signer(addr, tx)
 	if err != nil {
 		return nil, lib.WrapError(ErrSignTx, err)
 	}
@@ -668,17 +783,14 @@ func (s *BlockchainService) createSignedTransaction(ctx context.Context, txdata
 }
 
 func (s *BlockchainService) SendMOR(ctx context.Context, to common.Address, amount *big.Int) (common.Hash, error) {
-	prKey, err := s.privateKey.GetPrivateKey()
-	if err != nil {
-		return common.Hash{}, lib.WrapError(ErrPrKey, err)
-	}
-
-	transactOpt, err := s.getTransactOpts(ctx, prKey)
+	transactOpt, err := s.getTransactOpts(ctx)
 	if err != nil {
 		return common.Hash{}, lib.WrapError(ErrTxOpts, err)
 	}
 
+	start := time.Now()
 	tx, err := s.morToken.Transfer(transactOpt, to, amount)
+	metrics.ObserveTx("sendMOR", start, err)
 	if err != nil {
 		return common.Hash{}, lib.WrapError(ErrSendTx, err)
 	}
@@ -687,12 +799,7 @@ func (s *BlockchainService) SendMOR(ctx context.Context, to common.Address, amou
 }
 
 func (s *BlockchainService) GetAllowance(ctx context.Context, spender common.Address) (*big.Int, error) {
-	prKey, err := s.privateKey.GetPrivateKey()
-	if err != nil {
-		return nil, lib.WrapError(ErrPrKey, err)
-	}
-
-	transactOpt, err := s.getTransactOpts(ctx, prKey)
+	transactOpt, err := s.getTransactOpts(ctx)
 	if err != nil {
 		return nil, lib.WrapError(ErrTxOpts, err)
 	}
@@ -706,17 +813,14 @@ func (s *BlockchainService) GetAllowance(ctx context.Context, spender common.Add
 }
 
 func (s *BlockchainService) Approve(ctx context.Context, spender common.Address, amount *big.Int) (common.Hash, error) {
-	prKey, err := s.privateKey.GetPrivateKey()
-	if err != nil {
-		return common.Hash{}, lib.WrapError(ErrPrKey, err)
-	}
-
-	transactOpt, err := s.getTransactOpts(ctx, prKey)
+	transactOpt, err := s.getTransactOpts(ctx)
 	if err != nil {
 		return common.Hash{}, lib.WrapError(ErrTxOpts, err)
 	}
 
+	start := time.Now()
 	tx, err := s.morToken.Approve(transactOpt, spender, amount)
+	metrics.ObserveTx("approve", start, err)
 	if err != nil {
 		return common.Hash{}, lib.WrapError(ErrSendTx, err)
 	}
@@ -725,18 +829,21 @@ func (s *BlockchainService) Approve(ctx context.Context, spender common.Address,
 }
 
 func (s *BlockchainService) ClaimProviderBalance(ctx context.Context, sessionID [32]byte) (common.Hash, error) {
-	prKey, err := s.privateKey.GetPrivateKey()
-	if err != nil {
-		return common.Hash{}, lib.WrapError(ErrPrKey, err)
-	}
+	ctx = s.WithProviderAccount(ctx)
 
-	transactOpt, err := s.getTransactOpts(ctx, prKey)
+	transactOpt, err := s.getTransactOpts(ctx)
 	if err != nil {
 		return common.Hash{}, lib.WrapError(ErrTxOpts, err)
 	}
 
+	start := time.Now()
 	txHash, err := s.sessionRouter.ClaimProviderBalance(transactOpt, sessionID)
+	metrics.ObserveTx("claimProviderBalance", start, err)
 	if err != nil {
+		s.webhooks.Emit(webhooks.EventClaimFailed, &webhooks.SessionEventData{
+			SessionID: lib.BytesToString(sessionID[:]),
+			Error:     err.Error(),
+		})
 		return common.Hash{}, err
 	}
 
@@ -752,6 +859,14 @@ func (s *BlockchainService) GetTodaysBudget(ctx context.Context) (*big.Int, erro
 }
 
 func (s *BlockchainService) GetSessions(ctx context.Context, user, provider common.Address, offset *big.Int, limit uint8, order r.Order) ([]*structs.Session, error) {
+	if s.useIndex() {
+		filter := SessionFilter{User: user}
+		if (user == common.Address{}) {
+			filter.Provider = provider
+		}
+		return s.indexer.GetSessions(filter, indexerPage(offset, limit, order))
+	}
+
 	var (
 		ids      [][32]byte
 		sessions []sr.ISessionStorageSession
@@ -796,12 +911,7 @@ func (s *BlockchainService) GetSessionsIds(ctx context.Context, user, provider c
 }
 
 func (s *BlockchainService) GetTransactions(ctx context.Context, page uint64, limit uint8) ([]structs.RawTransaction, error) {
-	prKey, err := s.privateKey.GetPrivateKey()
-	if err != nil {
-		return nil, lib.WrapError(ErrPrKey, err)
-	}
-
-	transactOpt, err := s.getTransactOpts(ctx, prKey)
+	transactOpt, err := s.getTransactOpts(ctx)
 	if err != nil {
 		return nil, lib.WrapError(ErrTxOpts, err)
 	}
@@ -836,7 +946,7 @@ func (s *BlockchainService) GetTransactions(ctx context.Context, page uint64, li
 	return allTrxs, nil
 }
 
-func (s *BlockchainService) openSessionByBid(ctx context.Context, bidID common.Hash, duration *big.Int) (common.Hash, error) {
+func (s *BlockchainService) openSessionByBid(ctx context.Context, bidID common.Hash, duration *big.Int, directPayment bool, failoverEnabled bool) (common.Hash, error) {
 	supply, err := s.GetTokenSupply(ctx)
 	if err != nil {
 		return common.Hash{}, lib.WrapError(ErrTokenSupply, err)
@@ -861,7 +971,7 @@ func (s *BlockchainService) openSessionByBid(ctx context.Context, bidID common.H
 		return common.Hash{}, ErrOpenOwnBid
 	}
 
-	hash, _, err := s.tryOpenSession(ctx, bid, duration, supply, budget, userAddr, false, false)
+	hash, _, err := s.tryOpenSession(ctx, bid, duration, supply, budget, userAddr, directPayment, failoverEnabled)
 	return hash, err
 }
 
@@ -900,7 +1010,7 @@ func (s *BlockchainService) OpenSessionByModelId(ctx context.Context, modelID co
 		return common.Hash{}, fmt.Errorf("failed to get min stake: %w", err)
 	}
 
-	scoredBids := s.rateBids(bidIDs, bids, providerStats, providers, modelStats, minStake, s.log)
+	scoredBids, _ := s.rateBids(bidIDs, bids, providerStats, providers, modelStats, minStake, s.log)
 	for i, bid := range scoredBids {
 		providerAddr := bid.Bid.Provider
 		if providerAddr == omitProvider {
@@ -937,8 +1047,6 @@ func (s *BlockchainService) GetAllBidsWithRating(ctx context.Context, modelAgent
 	offset := big.NewInt(0)
 	bids := make([]m.IBidStorageBid, 0)
 	ids := make([][32]byte, 0)
-	providerModelStats := make([]sr.IStatsStorageProviderModelStats, 0)
-	providers := make([]pr.IProviderStorageProvider, 0)
 
 	for {
 		if ctx.Err() != nil {
@@ -953,20 +1061,6 @@ func (s *BlockchainService) GetAllBidsWithRating(ctx context.Context, modelAgent
 		ids = append(ids, idsBatch...)
 		bids = append(bids, bidsBatch...)
 
-		for _, bid := range bidsBatch {
-			//TODO: replace with multicall
-			providerModelStat, err := s.sessionRouter.GetProviderModelStats(ctx, modelAgentID, bid.Provider)
-			if err != nil {
-				return nil, nil, nil, nil, err
-			}
-			provider, err := s.providerRegistry.GetProviderById(ctx, bid.Provider)
-			if err != nil {
-				return nil, nil, nil, nil, err
-			}
-			providerModelStats = append(providerModelStats, *providerModelStat)
-			providers = append(providers, *provider)
-		}
-
 		if len(bidsBatch) < int(batchSize) {
 			break
 		}
@@ -974,9 +1068,70 @@ func (s *BlockchainService) GetAllBidsWithRating(ctx context.Context, modelAgent
 		offset.Add(offset, big.NewInt(int64(batchSize)))
 	}
 
+	providerAddrs := make([]common.Address, 0, len(bids))
+	seen := make(map[common.Address]struct{}, len(bids))
+	for _, bid := range bids {
+		if _, ok := seen[bid.Provider]; ok {
+			continue
+		}
+		seen[bid.Provider] = struct{}{}
+		providerAddrs = append(providerAddrs, bid.Provider)
+	}
+
+	stats, err := s.sessionRouter.GetMultipleProviderModelStats(ctx, modelAgentID, providerAddrs)
+	if err != nil {
+		return nil, nil, nil, nil, err
+	}
+	statsMap := make(map[common.Address]sr.IStatsStorageProviderModelStats, len(providerAddrs))
+	for i, addr := range providerAddrs {
+		statsMap[addr] = stats[i]
+	}
+
+	providersMap, err := s.getProvidersCached(ctx, providerAddrs)
+	if err != nil {
+		return nil, nil, nil, nil, err
+	}
+
+	providerModelStats := make([]sr.IStatsStorageProviderModelStats, len(bids))
+	providers := make([]pr.IProviderStorageProvider, len(bids))
+	for i, bid := range bids {
+		providerModelStats[i] = statsMap[bid.Provider]
+		providers[i] = providersMap[bid.Provider]
+	}
+
 	return ids, bids, providerModelStats, providers, nil
 }
 
+// getProvidersCached returns providers by addresses, fetching the ones missing in cache with a single multicall
+func (s *BlockchainService) getProvidersCached(ctx context.Context, addrs []common.Address) (map[common.Address]pr.IProviderStorageProvider, error) {
+	res := make(map[common.Address]pr.IProviderStorageProvider, len(addrs))
+	missing := make([]common.Address, 0)
+
+	for _, addr := range addrs {
+		if provider, ok := s.providerCache.Get(addr); ok {
+			res[addr] = provider
+		} else {
+			missing = append(missing, addr)
+		}
+	}
+
+	if len(missing) == 0 {
+		return res, nil
+	}
+
+	_, providers, err := s.providerRegistry.GetMultipleProviders(ctx, missing)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, addr := range missing {
+		s.providerCache.Set(addr, providers[i])
+		res[addr] = providers[i]
+	}
+
+	return res, nil
+}
+
 func (s *BlockchainService) tryOpenSession(ctx context.Context, bid *structs.Bid, duration, supply, budget *big.Int, userAddr common.Address, directPayment bool, failoverEnabled bool) (common.Hash, bool, error) {
 	provider, err := s.providerRegistry.GetProviderById(ctx, bid.Provider)
 	if err != nil {
@@ -1024,6 +1179,7 @@ func (s *BlockchainService) tryOpenSession(ctx context.Context, bid *structs.Bid
 	}
 
 	session.SetFailoverEnabled(failoverEnabled)
+	session.SetCapabilities(initRes.Capabilities)
 
 	err = s.sessionRepo.SaveSession(ctx, session)
 	if err != nil {
@@ -1034,12 +1190,12 @@ func (s *BlockchainService) tryOpenSession(ctx context.Context, bid *structs.Bid
 }
 
 func (s *BlockchainService) GetMyAddress(ctx context.Context) (common.Address, error) {
-	prKey, err := s.privateKey.GetPrivateKey()
+	addr, err := i.GetAddressCtx(ctx, s.privateKey)
 	if err != nil {
 		return common.Address{}, lib.WrapError(ErrPrKey, err)
 	}
 
-	return lib.PrivKeyBytesToAddr(prKey)
+	return addr, nil
 }
 
 func (s *BlockchainService) CheckConnectivity(ctx context.Context, url string, addr common.Address) (time.Duration, error) {
@@ -1105,23 +1261,22 @@ func (s *BlockchainService) CheckPortOpen(ctx context.Context, urlStr string) (b
 	return response.Check[0].Status, nil
 }
 
-func (s *BlockchainService) getTransactOpts(ctx context.Context, privKey lib.HexString) (*bind.TransactOpts, error) {
-	privateKey, err := crypto.ToECDSA(privKey)
-	if err != nil {
-		return nil, err
-	}
-
+func (s *BlockchainService) getTransactOpts(ctx context.Context) (*bind.TransactOpts, error) {
 	chainId, err := s.ethClient.ChainID(ctx)
 	if err != nil {
 		return nil, err
 	}
 
-	transactOpts, err := bind.NewKeyedTransactorWithChainID(privateKey, chainId)
+	var transactOpts *bind.TransactOpts
+	if signer, ok := s.privateKey.(i.Signer); ok {
+		transactOpts, err = s.getSignerTransactOpts(ctx, signer, chainId)
+	} else {
+		transactOpts, err = s.getKeyedTransactOpts(ctx, chainId)
+	}
 	if err != nil {
 		return nil, err
 	}
 
-	// TODO: deal with likely gasPrice issue so our transaction processes before another pending nonce.
 	if s.legacyTx {
 		gasPrice, err := s.ethClient.SuggestGasPrice(ctx)
 		if err != nil {
@@ -1136,18 +1291,56 @@ func (s *BlockchainService) getTransactOpts(ctx context.Context, privKey lib.Hex
 	return transactOpts, nil
 }
 
-func (s *BlockchainService) signTx(ctx context.Context, tx *types.Transaction, privKey lib.HexString) (*types.Transaction, error) {
-	privateKey, err := crypto.ToECDSA(privKey)
+func (s *BlockchainService) getKeyedTransactOpts(ctx context.Context, chainId *big.Int) (*bind.TransactOpts, error) {
+	prKey, err := i.GetPrivateKeyCtx(ctx, s.privateKey)
+	if err != nil {
+		return nil, lib.WrapError(ErrPrKey, err)
+	}
+
+	privateKey, err := crypto.ToECDSA(prKey)
 	if err != nil {
 		return nil, err
 	}
 
-	chainId, err := s.ethClient.ChainID(ctx)
+	transactOpts, err := bind.NewKeyedTransactorWithChainID(privateKey, chainId)
 	if err != nil {
 		return nil, err
 	}
 
-	return types.SignTx(tx, types.NewEIP155Signer(chainId), privateKey)
+	// stuck transactions are resent with higher gas price by the tx manager
+	if s.txManager != nil {
+		transactOpts.Signer = s.txManager.Signer(privateKey)
+	}
+
+	return transactOpts, nil
+}
+
+// getSignerTransactOpts delegates signing to the signer, the private key never reaches the node
+func (s *BlockchainService) getSignerTransactOpts(ctx context.Context, signer i.Signer, chainId *big.Int) (*bind.TransactOpts, error) {
+	from, err := signer.Address(ctx)
+	if err != nil {
+		return nil, lib.WrapError(ErrPrKey, err)
+	}
+
+	sign := func(ctx context.Context, tx *types.Transaction) (*types.Transaction, error) {
+		return signer.SignTx(ctx, tx, chainId)
+	}
+
+	signerFn := func(addr common.Address, tx *types.Transaction) (*types.Transaction, error) {
+		if addr != from {
+			return nil, bind.ErrNotAuthorized
+		}
+		return sign(ctx, tx)
+	}
+	if s.txManager != nil {
+		signerFn = s.txManager.SignerFunc(from, sign)
+	}
+
+	return &bind.TransactOpts{
+		From:    from,
+		Signer:  signerFn,
+		Context: ctx,
+	}, nil
 }
 
 func (s *BlockchainService) getMinStakeCached(ctx context.Context) (*big.Int, error) {
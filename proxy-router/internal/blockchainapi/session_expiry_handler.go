@@ -7,12 +7,15 @@ import (
 	"github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/interfaces"
 	"github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/lib"
 	"github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/storages"
+	"github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/webhooks"
 )
 
 type SessionExpiryHandler struct {
 	blockchainService *BlockchainService
 	sessionStorage    *storages.SessionStorage
 	wallet            interfaces.Wallet
+	accounts          interfaces.Accounts
+	webhooks          *webhooks.Dispatcher
 	log               lib.ILogger
 }
 
@@ -25,13 +28,17 @@ func NewSessionExpiryHandler(blockchainService *BlockchainService, sessionStorag
 	}
 }
 
-func (s *SessionExpiryHandler) getWalletAddress() (string, error) {
-	privateKey, err := s.wallet.GetPrivateKey()
-	if err != nil {
-		return "", err
-	}
+func (s *SessionExpiryHandler) SetWebhooks(dispatcher *webhooks.Dispatcher) {
+	s.webhooks = dispatcher
+}
 
-	addr, err := lib.PrivKeyBytesToAddr(privateKey)
+// SetAccounts makes the handler close the expired sessions of all named accounts, not only of the node wallet
+func (s *SessionExpiryHandler) SetAccounts(accounts interfaces.Accounts) {
+	s.accounts = accounts
+}
+
+func (s *SessionExpiryHandler) getWalletAddress(wallet interfaces.PrKeyProvider) (string, error) {
+	addr, err := interfaces.GetAddressCtx(context.Background(), wallet)
 	if err != nil {
 		return "", err
 	}
@@ -39,6 +46,35 @@ func (s *SessionExpiryHandler) getWalletAddress() (string, error) {
 	return addr.Hex(), nil
 }
 
+// getAccountAddresses returns the wallet addresses by account name, the node wallet has an empty name
+func (s *SessionExpiryHandler) getAccountAddresses() (map[string]string, error) {
+	if s.accounts == nil {
+		addr, err := s.getWalletAddress(s.wallet)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]string{"": addr}, nil
+	}
+
+	names, err := s.accounts.ListAccounts()
+	if err != nil {
+		return nil, err
+	}
+	addrs := make(map[string]string, len(names))
+	for _, name := range names {
+		wallet, err := s.accounts.GetAccount(name)
+		if err != nil {
+			return nil, err
+		}
+		addr, err := s.getWalletAddress(wallet)
+		if err != nil {
+			return nil, err
+		}
+		addrs[name] = addr
+	}
+	return addrs, nil
+}
+
 // Run starts the session autoclose process, checking every minute if any session has ended and closes it.
 func (s *SessionExpiryHandler) Run(ctx context.Context) error {
 	ticker := time.NewTicker(1 * time.Minute)
@@ -50,45 +86,62 @@ func (s *SessionExpiryHandler) Run(ctx context.Context) error {
 		case <-ctx.Done():
 			return ctx.Err()
 		case <-ticker.C:
-			sessions, err := s.sessionStorage.GetSessions()
+			addrs, err := s.getAccountAddresses()
 			if err != nil {
 				s.log.Error(err)
 				continue
 			}
 
-			addr, err := s.getWalletAddress()
-			if err != nil {
-				s.log.Error(err)
-				continue
+			for name, addr := range addrs {
+				sessions, err := s.sessionStorage.GetSessionsForUser(addr)
+				if err != nil {
+					s.log.Error(err)
+					continue
+				}
+				accountCtx := ctx
+				if name != "" {
+					accountCtx = lib.WithAccount(ctx, name)
+				}
+				s.closeExpired(accountCtx, sessions)
 			}
+		}
+	}
+}
 
-			for _, session := range sessions {
-				if session.UserAddr == addr && session.EndsAt.Int64() < time.Now().Unix() {
-					sessionId, err := lib.HexToHash(session.Id)
-					if err != nil {
-						s.log.Error(err)
-						continue
-					}
-					sessionData, err := s.blockchainService.GetSession(ctx, sessionId)
-					if err != nil {
-						s.log.Error(err)
-						continue
-					}
-					if sessionData.ClosedAt.Int64() != 0 {
-						s.log.Infof("Session %s already closed", session.Id)
-						s.sessionStorage.RemoveSession(session.Id)
-						continue
-					}
+func (s *SessionExpiryHandler) closeExpired(ctx context.Context, sessions []storages.Session) {
+	for _, session := range sessions {
+		if session.EndsAt.Int64() >= time.Now().Unix() {
+			continue
+		}
+		sessionId, err := lib.HexToHash(session.Id)
+		if err != nil {
+			s.log.Error(err)
+			continue
+		}
+		sessionData, err := s.blockchainService.GetSession(ctx, sessionId)
+		if err != nil {
+			s.log.Error(err)
+			continue
+		}
+		if sessionData.ClosedAt.Int64() != 0 {
+			s.log.Infof("Session %s already closed", session.Id)
+			s.sessionStorage.RemoveSession(session.Id)
+			continue
+		}
 
-					s.log.Infof("Closing session %s", session.Id)
-					_, err = s.blockchainService.CloseSession(ctx, sessionId)
-					if err != nil {
-						s.log.Warnf("cannot close session: %s", err.Error())
-						continue
-					}
-					s.sessionStorage.RemoveSession(session.Id)
-				}
-			}
+		s.log.Infof("Closing session %s", session.Id)
+		_, err = s.blockchainService.CloseSession(ctx, sessionId)
+		if err != nil {
+			s.log.Warnf("cannot close session: %s", err.Error())
+			s.webhooks.Emit(webhooks.EventSessionCloseFailed, &webhooks.SessionEventData{
+				SessionID: session.Id,
+				User:      session.UserAddr,
+				Provider:  session.ProviderAddr,
+				ModelID:   session.ModelID,
+				Error:     err.Error(),
+			})
+			continue
 		}
+		s.sessionStorage.RemoveSession(session.Id)
 	}
 }
@@ -0,0 +1,268 @@
+package blockchainapi
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/blockchainapi/structs"
+	"github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/lib"
+	"github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/storages"
+	"github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/webhooks"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+var (
+	ErrVirtualSessionReplaced = errors.New("virtual session was replaced during renewal")
+	ErrAutoRenewDisabled      = errors.New("session auto-renewal is disabled")
+	ErrAutoRenew              = errors.New("failed to enable session auto-renewal")
+)
+
+// renewerService is the part of BlockchainService used by the renewer
+type renewerService interface {
+	GetSession(ctx context.Context, sessionID common.Hash) (*structs.Session, error)
+	CloseSession(ctx context.Context, sessionID common.Hash) (common.Hash, error)
+	openSessionByBid(ctx context.Context, bidID common.Hash, duration *big.Int, directPayment bool, failoverEnabled bool) (common.Hash, error)
+	OpenSessionByModelId(ctx context.Context, modelID common.Hash, duration *big.Int, directPayment bool, isFailoverEnabled bool, omitProvider common.Address) (common.Hash, error)
+	GetMyAddress(ctx context.Context) (common.Address, error)
+}
+
+// SessionRenewer keeps the virtual sessions alive. Shortly before the current session ends it opens
+// a follow-up session with the same bid, or with the best bid of the model if the bid is gone, points
+// the virtual session at it and closes the old session. If the renewal fails until the session expires,
+// it keeps opening a follow-up session every check
+type SessionRenewer struct {
+	blockchainService renewerService
+	storage           *storages.VirtualSessionStorage
+	renewBefore       time.Duration
+	checkInterval     time.Duration
+	webhooks          *webhooks.Dispatcher
+	log               lib.ILogger
+
+	mutex sync.Mutex // guards the updates of the virtual sessions
+}
+
+func NewSessionRenewer(blockchainService *BlockchainService, storage *storages.VirtualSessionStorage, renewBefore time.Duration, log lib.ILogger) *SessionRenewer {
+	return &SessionRenewer{
+		blockchainService: blockchainService,
+		storage:           storage,
+		renewBefore:       renewBefore,
+		checkInterval:     time.Minute,
+		log:               log.Named("SESSION_RENEWER"),
+	}
+}
+
+func (s *SessionRenewer) SetWebhooks(dispatcher *webhooks.Dispatcher) {
+	s.webhooks = dispatcher
+}
+
+// Create registers a virtual session for the opened session, follow-up sessions last the same duration
+func (s *SessionRenewer) Create(ctx context.Context, sessionID common.Hash, duration *big.Int, directPayment, failover bool) (common.Hash, error) {
+	session, err := s.blockchainService.GetSession(ctx, sessionID)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	id, err := lib.GetRandomHash()
+	if err != nil {
+		return common.Hash{}, err
+	}
+
+	vs := &storages.VirtualSession{
+		ID:            id.Hex(),
+		SessionID:     sessionID.Hex(),
+		ModelID:       session.ModelAgentId,
+		BidID:         session.BidID,
+		Account:       lib.AccountFromContext(ctx),
+		Duration:      duration.Int64(),
+		DirectPayment: directPayment,
+		Failover:      failover,
+	}
+	err = s.storage.PutVirtualSession(vs)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	return id.Hash, nil
+}
+
+// Resolve returns the current session of the virtual session
+func (s *SessionRenewer) Resolve(id common.Hash) (common.Hash, bool) {
+	vs, err := s.storage.GetVirtualSession(id.Hex())
+	if err != nil {
+		return common.Hash{}, false
+	}
+	return common.HexToHash(vs.SessionID), true
+}
+
+// Replace points the virtual session at another session, e.g. the one opened by the failover
+func (s *SessionRenewer) Replace(id common.Hash, sessionID common.Hash) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	vs, err := s.storage.GetVirtualSession(id.Hex())
+	if err != nil {
+		return err
+	}
+	vs.SessionID = sessionID.Hex()
+	return s.storage.PutVirtualSession(vs)
+}
+
+// Remove stops renewing the virtual session, the current session is not closed
+func (s *SessionRenewer) Remove(id common.Hash) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return s.storage.RemoveVirtualSession(id.Hex())
+}
+
+// GetVirtualSessions returns the virtual sessions of the account selected in the context. Accounts are
+// compared by address, as the consumer account can be selected by name or without the account
+func (s *SessionRenewer) GetVirtualSessions(ctx context.Context) ([]*storages.VirtualSession, error) {
+	addr, err := s.blockchainService.GetMyAddress(ctx)
+	if err != nil {
+		return nil, err
+	}
+	sessions, err := s.storage.GetVirtualSessions()
+	if err != nil {
+		return nil, err
+	}
+
+	accountAddrs := make(map[string]common.Address)
+	result := []*storages.VirtualSession{}
+	for _, vs := range sessions {
+		accountAddr, ok := accountAddrs[vs.Account]
+		if !ok {
+			accountAddr, err = s.blockchainService.GetMyAddress(accountContext(context.Background(), vs))
+			if err != nil {
+				// the account was removed, its sessions are not renewed either
+				s.log.Debugf("cannot get address of account %s: %s", vs.Account, err)
+			}
+			accountAddrs[vs.Account] = accountAddr
+		}
+		if accountAddr == addr {
+			result = append(result, vs)
+		}
+	}
+	return result, nil
+}
+
+// Run checks the virtual sessions every minute and renews the ones ending within renewBefore
+func (s *SessionRenewer) Run(ctx context.Context) error {
+	ticker := time.NewTicker(s.checkInterval)
+	defer ticker.Stop()
+
+	s.log.Info("Session auto-renewal started")
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+			sessions, err := s.storage.GetVirtualSessions()
+			if err != nil {
+				s.log.Error(err)
+				continue
+			}
+			for _, vs := range sessions {
+				err := s.renewIfEnding(ctx, vs)
+				if err != nil {
+					s.log.Warnf("cannot renew virtual session %s: %s", vs.ID, err)
+					s.webhooks.Emit(webhooks.EventSessionRenewFailed, &webhooks.SessionRenewedEventData{
+						VirtualSessionID: vs.ID,
+						OldSessionID:     vs.SessionID,
+						Error:            err.Error(),
+					})
+				}
+			}
+		}
+	}
+}
+
+func (s *SessionRenewer) renewIfEnding(ctx context.Context, vs *storages.VirtualSession) error {
+	ctx = accountContext(ctx, vs)
+	oldID := common.HexToHash(vs.SessionID)
+
+	session, err := s.blockchainService.GetSession(ctx, oldID)
+	if err != nil {
+		return err
+	}
+	closed := session.ClosedAt.Sign() != 0
+	if closed && session.ClosedAt.Cmp(session.EndsAt) < 0 {
+		// closed early by the client, stop renewing. Sessions closed at the end by the expiry
+		// handler are renewed, as the renewal failed until then
+		s.log.Infof("session %s of virtual session %s is closed, stopping renewal", vs.SessionID, vs.ID)
+		return s.Remove(common.HexToHash(vs.ID))
+	}
+	if time.Until(time.Unix(session.EndsAt.Int64(), 0)) > s.renewBefore {
+		return nil
+	}
+
+	newID, err := s.open(ctx, vs)
+	if err != nil {
+		return err
+	}
+
+	err = s.swap(vs.ID, oldID, newID)
+	if err != nil {
+		// keep the session which replaced the old one, the renewed one is not used
+		s.closeSession(ctx, newID)
+		return err
+	}
+	s.log.Infof("virtual session %s renewed, session %s replaced with %s", vs.ID, oldID.Hex(), newID.Hex())
+	s.webhooks.Emit(webhooks.EventSessionRenewed, &webhooks.SessionRenewedEventData{
+		VirtualSessionID: vs.ID,
+		OldSessionID:     oldID.Hex(),
+		NewSessionID:     newID.Hex(),
+	})
+
+	if !closed {
+		s.closeSession(ctx, oldID)
+	}
+	return nil
+}
+
+// open opens the follow-up session with the same bid, falling back to the best bid of the model
+func (s *SessionRenewer) open(ctx context.Context, vs *storages.VirtualSession) (common.Hash, error) {
+	duration := big.NewInt(vs.Duration)
+	if vs.BidID != "" {
+		newID, err := s.blockchainService.openSessionByBid(ctx, common.HexToHash(vs.BidID), duration, vs.DirectPayment, vs.Failover)
+		if err == nil {
+			return newID, nil
+		}
+		s.log.Warnf("cannot renew virtual session %s with bid %s, selecting by model: %s", vs.ID, vs.BidID, err)
+	}
+	return s.blockchainService.OpenSessionByModelId(ctx, common.HexToHash(vs.ModelID), duration, vs.DirectPayment, vs.Failover, common.Address{})
+}
+
+// swap points the virtual session at the new session unless it was replaced meanwhile
+func (s *SessionRenewer) swap(id string, oldID, newID common.Hash) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	vs, err := s.storage.GetVirtualSession(id)
+	if err != nil {
+		return err
+	}
+	if common.HexToHash(vs.SessionID) != oldID {
+		return ErrVirtualSessionReplaced
+	}
+	vs.SessionID = newID.Hex()
+	vs.Renewals++
+	return s.storage.PutVirtualSession(vs)
+}
+
+// closeSession closes the session early to refund the unused stake, the expiry handler retries on failure
+func (s *SessionRenewer) closeSession(ctx context.Context, sessionID common.Hash) {
+	_, err := s.blockchainService.CloseSession(ctx, sessionID)
+	if err != nil {
+		s.log.Warnf("cannot close session %s: %s", sessionID.Hex(), err)
+	}
+}
+
+// accountContext selects the account which created the virtual session
+func accountContext(ctx context.Context, vs *storages.VirtualSession) context.Context {
+	if vs.Account != "" {
+		return lib.WithAccount(ctx, vs.Account)
+	}
+	return ctx
+}
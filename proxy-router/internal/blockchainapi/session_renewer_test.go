@@ -0,0 +1,262 @@
+package blockchainapi
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/blockchainapi/structs"
+	"github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/lib"
+	"github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/storages"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	testModelID = common.HexToHash("0x01")
+	testBidID   = common.HexToHash("0x02")
+)
+
+// fakeRenewerService keeps the sessions in memory, the opened sessions last the requested duration
+type fakeRenewerService struct {
+	sessions map[common.Hash]*structs.Session
+	bidErr   error
+	onOpen   func(newID common.Hash) // called when a session is opened, before the renewer sees it
+
+	accounts map[string]common.Address // account name -> address, a missing account has no wallet
+
+	openedByBid   []common.Hash
+	openedByModel []common.Hash
+	closed        []common.Hash
+	lastID        byte
+}
+
+func newFakeRenewerService() *fakeRenewerService {
+	return &fakeRenewerService{
+		sessions: make(map[common.Hash]*structs.Session),
+		accounts: map[string]common.Address{"": common.HexToAddress("0x10")},
+	}
+}
+
+// addSession adds a session of the test bid ending at endsAt
+func (f *fakeRenewerService) addSession(endsAt time.Time) common.Hash {
+	f.lastID++
+	id := common.BytesToHash([]byte{0xaa, f.lastID})
+	f.sessions[id] = &structs.Session{
+		Id:           id.Hex(),
+		ModelAgentId: testModelID.Hex(),
+		BidID:        testBidID.Hex(),
+		OpenedAt:     big.NewInt(time.Now().Unix()),
+		EndsAt:       big.NewInt(endsAt.Unix()),
+		ClosedAt:     big.NewInt(0),
+	}
+	return id
+}
+
+func (f *fakeRenewerService) GetSession(_ context.Context, sessionID common.Hash) (*structs.Session, error) {
+	session, ok := f.sessions[sessionID]
+	if !ok {
+		return nil, errors.New("session not found")
+	}
+	return session, nil
+}
+
+func (f *fakeRenewerService) CloseSession(_ context.Context, sessionID common.Hash) (common.Hash, error) {
+	f.closed = append(f.closed, sessionID)
+	f.sessions[sessionID].ClosedAt = big.NewInt(time.Now().Unix())
+	return common.Hash{}, nil
+}
+
+func (f *fakeRenewerService) openSessionByBid(_ context.Context, _ common.Hash, duration *big.Int, _ bool, _ bool) (common.Hash, error) {
+	if f.bidErr != nil {
+		return common.Hash{}, f.bidErr
+	}
+	id := f.open(duration)
+	f.openedByBid = append(f.openedByBid, id)
+	return id, nil
+}
+
+func (f *fakeRenewerService) OpenSessionByModelId(_ context.Context, _ common.Hash, duration *big.Int, _ bool, _ bool, _ common.Address) (common.Hash, error) {
+	id := f.open(duration)
+	f.openedByModel = append(f.openedByModel, id)
+	return id, nil
+}
+
+func (f *fakeRenewerService) GetMyAddress(ctx context.Context) (common.Address, error) {
+	addr, ok := f.accounts[lib.AccountFromContext(ctx)]
+	if !ok {
+		return common.Address{}, errors.New("account not found")
+	}
+	return addr, nil
+}
+
+func (f *fakeRenewerService) open(duration *big.Int) common.Hash {
+	id := f.addSession(time.Now().Add(time.Duration(duration.Int64()) * time.Second))
+	if f.onOpen != nil {
+		f.onOpen(id)
+	}
+	return id
+}
+
+func newTestRenewer(service renewerService) *SessionRenewer {
+	return &SessionRenewer{
+		blockchainService: service,
+		storage:           storages.NewVirtualSessionStorage(storages.NewTestStorage()),
+		renewBefore:       10 * time.Minute,
+		checkInterval:     time.Minute,
+		log:               lib.NewTestLogger(),
+	}
+}
+
+// renew runs a check of the virtual session
+func renew(t *testing.T, renewer *SessionRenewer, id common.Hash) error {
+	vs, err := renewer.storage.GetVirtualSession(id.Hex())
+	require.NoError(t, err)
+	return renewer.renewIfEnding(context.Background(), vs)
+}
+
+func TestSessionRenewerRenew(t *testing.T) {
+	service := newFakeRenewerService()
+	renewer := newTestRenewer(service)
+	sessionID := service.addSession(time.Now().Add(time.Hour))
+	id, err := renewer.Create(context.Background(), sessionID, big.NewInt(3600), false, false)
+	require.NoError(t, err)
+
+	// not ending yet
+	require.NoError(t, renew(t, renewer, id))
+	require.Empty(t, service.openedByBid)
+
+	service.sessions[sessionID].EndsAt = big.NewInt(time.Now().Add(5 * time.Minute).Unix())
+	require.NoError(t, renew(t, renewer, id))
+	require.Len(t, service.openedByBid, 1)
+
+	current, ok := renewer.Resolve(id)
+	require.True(t, ok)
+	require.Equal(t, service.openedByBid[0], current)
+	require.Equal(t, []common.Hash{sessionID}, service.closed)
+
+	vs, err := renewer.storage.GetVirtualSession(id.Hex())
+	require.NoError(t, err)
+	require.Equal(t, 1, vs.Renewals)
+}
+
+func TestSessionRenewerFallbackToModel(t *testing.T) {
+	service := newFakeRenewerService()
+	service.bidErr = errors.New("bid is deleted")
+	renewer := newTestRenewer(service)
+	sessionID := service.addSession(time.Now().Add(time.Minute))
+	id, err := renewer.Create(context.Background(), sessionID, big.NewInt(3600), false, false)
+	require.NoError(t, err)
+
+	require.NoError(t, renew(t, renewer, id))
+	require.Empty(t, service.openedByBid)
+	require.Len(t, service.openedByModel, 1)
+
+	current, _ := renewer.Resolve(id)
+	require.Equal(t, service.openedByModel[0], current)
+}
+
+func TestSessionRenewerReplacedByFailover(t *testing.T) {
+	service := newFakeRenewerService()
+	renewer := newTestRenewer(service)
+	sessionID := service.addSession(time.Now().Add(time.Minute))
+	id, err := renewer.Create(context.Background(), sessionID, big.NewInt(3600), false, false)
+	require.NoError(t, err)
+
+	// the failover replaces the session while the renewed one is being opened
+	failoverID := service.addSession(time.Now().Add(time.Hour))
+	service.onOpen = func(common.Hash) {
+		require.NoError(t, renewer.Replace(id, failoverID))
+	}
+
+	err = renew(t, renewer, id)
+	require.ErrorIs(t, err, ErrVirtualSessionReplaced)
+
+	current, _ := renewer.Resolve(id)
+	require.Equal(t, failoverID, current)
+	// the renewed session is closed, the failover session is kept
+	require.Equal(t, service.openedByBid, service.closed)
+}
+
+func TestSessionRenewerClosed(t *testing.T) {
+	t.Run("closed by the client", func(t *testing.T) {
+		service := newFakeRenewerService()
+		renewer := newTestRenewer(service)
+		sessionID := service.addSession(time.Now().Add(time.Hour))
+		id, err := renewer.Create(context.Background(), sessionID, big.NewInt(3600), false, false)
+		require.NoError(t, err)
+
+		service.sessions[sessionID].ClosedAt = big.NewInt(time.Now().Unix())
+		require.NoError(t, renew(t, renewer, id))
+
+		_, ok := renewer.Resolve(id)
+		require.False(t, ok)
+		require.Empty(t, service.openedByBid)
+	})
+
+	t.Run("closed by the expiry handler", func(t *testing.T) {
+		service := newFakeRenewerService()
+		renewer := newTestRenewer(service)
+		sessionID := service.addSession(time.Now().Add(time.Hour))
+		id, err := renewer.Create(context.Background(), sessionID, big.NewInt(3600), false, false)
+		require.NoError(t, err)
+
+		// the renewal failed until the session ended and it was closed as expired
+		endsAt := time.Now().Add(-time.Minute).Unix()
+		service.sessions[sessionID].EndsAt = big.NewInt(endsAt)
+		service.sessions[sessionID].ClosedAt = big.NewInt(endsAt + 10)
+		require.NoError(t, renew(t, renewer, id))
+
+		current, ok := renewer.Resolve(id)
+		require.True(t, ok)
+		require.Len(t, service.openedByBid, 1)
+		require.Equal(t, service.openedByBid[0], current)
+		require.Empty(t, service.closed)
+	})
+}
+
+func TestSessionRenewerSessionsOfAccount(t *testing.T) {
+	service := newFakeRenewerService()
+	service.accounts[lib.DefaultAccount] = service.accounts[""]
+	service.accounts["budget-b"] = common.HexToAddress("0x20")
+	renewer := newTestRenewer(service)
+
+	create := func(account string) common.Hash {
+		ctx := context.Background()
+		if account != "" {
+			ctx = lib.WithAccount(ctx, account)
+		}
+		id, err := renewer.Create(ctx, service.addSession(time.Now().Add(time.Hour)), big.NewInt(3600), false, false)
+		require.NoError(t, err)
+		return id
+	}
+	consumer := create("")
+	named := create(lib.DefaultAccount)
+	other := create("budget-b")
+	create("removed")
+	delete(service.accounts, "removed")
+
+	ids := func(account string) []string {
+		ctx := context.Background()
+		if account != "" {
+			ctx = lib.WithAccount(ctx, account)
+		}
+		sessions, err := renewer.GetVirtualSessions(ctx)
+		require.NoError(t, err)
+		var ids []string
+		for _, vs := range sessions {
+			ids = append(ids, vs.ID)
+		}
+		return ids
+	}
+
+	// the consumer account is the same with and without its name
+	require.ElementsMatch(t, []string{consumer.Hex(), named.Hex()}, ids(""))
+	require.ElementsMatch(t, []string{consumer.Hex(), named.Hex()}, ids(lib.DefaultAccount))
+	require.ElementsMatch(t, []string{other.Hex()}, ids("budget-b"))
+
+	_, err := renewer.GetVirtualSessions(lib.WithAccount(context.Background(), "removed"))
+	require.Error(t, err)
+}
@@ -20,3 +20,8 @@ type ScoredBid struct {
 	Bid   Bid
 	Score float64
 }
+
+type ExcludedBid struct {
+	Bid    Bid
+	Reason string
+}
@@ -65,12 +65,14 @@ type QueryProvider struct {
 
 type OpenSessionWithDurationRequest struct {
 	SessionDuration *lib.BigInt `json:"sessionDuration"`
+	AutoRenew       bool        `json:"autoRenew" binding:"omitempty"`
 }
 
 type OpenSessionWithFailover struct {
 	SessionDuration *lib.BigInt `json:"sessionDuration"`
 	DirectPayment   bool        `json:"directPayment" binding:"omitempty"`
 	Failover        bool        `json:"failover" binding:"omitempty"`
+	AutoRenew       bool        `json:"autoRenew" binding:"omitempty"`
 }
 
 type CreateBidRequest struct {
@@ -91,3 +93,30 @@ type CreateModelRequest struct {
 	Name   string      `json:"name" binding:"required" validate:"min=1,max=64" example:"Llama 2.0"`
 	Tags   []string    `json:"tags" binding:"required" validate:"min=1,max=64,dive,min=1,max=64"`
 }
+
+type QueryIndexerSort struct {
+	SortBy string `form:"sortBy" binding:"omitempty" example:"createdAt"`
+}
+
+type QueryIndexerProviders struct {
+	IncludeDeleted bool `form:"includeDeleted" binding:"omitempty"`
+}
+
+type QueryIndexerModels struct {
+	Owner          lib.Address `form:"owner" binding:"omitempty" validate:"eth_addr"`
+	Tag            string      `form:"tag" binding:"omitempty" example:"llama"`
+	IncludeDeleted bool        `form:"includeDeleted" binding:"omitempty"`
+}
+
+type QueryIndexerBids struct {
+	Provider lib.Address `form:"provider" binding:"omitempty" validate:"eth_addr"`
+	ModelID  lib.Hash    `form:"modelId" binding:"omitempty" validate:"hex32" format:"hex" example:"0x1234"`
+	Active   bool        `form:"active" binding:"omitempty"`
+}
+
+type QueryIndexerSessions struct {
+	User     lib.Address `form:"user" binding:"omitempty" validate:"eth_addr"`
+	Provider lib.Address `form:"provider" binding:"omitempty" validate:"eth_addr"`
+	ModelID  lib.Hash    `form:"modelId" binding:"omitempty" validate:"hex32" format:"hex" example:"0x1234"`
+	Open     bool        `form:"open" binding:"omitempty"`
+}
@@ -18,7 +18,23 @@ type ErrRes struct {
 }
 
 type OpenSessionRes struct {
-	SessionID common.Hash `json:"sessionID" example:"0x1234"`
+	SessionID        common.Hash  `json:"sessionID" example:"0x1234"`
+	VirtualSessionID *common.Hash `json:"virtualSessionID,omitempty" example:"0x1234"`
+}
+
+type VirtualSession struct {
+	ID            common.Hash `json:"id"`
+	SessionID     common.Hash `json:"sessionID"`
+	ModelID       common.Hash `json:"modelID"`
+	BidID         common.Hash `json:"bidID"`
+	Duration      int64       `json:"duration"`
+	DirectPayment bool        `json:"directPayment"`
+	Failover      bool        `json:"failover"`
+	Renewals      int         `json:"renewals"`
+}
+
+type VirtualSessionsRes struct {
+	Sessions []*VirtualSession `json:"sessions"`
 }
 
 type BalanceRes struct {
@@ -42,7 +58,8 @@ type BidsRes struct {
 }
 
 type ScoredBidsRes struct {
-	Bids []ScoredBid `json:"bids"`
+	Bids     []ScoredBid   `json:"bids"`
+	Excluded []ExcludedBid `json:"excluded"`
 }
 
 type ModelRes struct {
@@ -81,3 +98,11 @@ type SupplyRes struct {
 type BlockRes struct {
 	Block uint64 `json:"block" example:"1234"`
 }
+
+type IndexerStatusRes struct {
+	Synced     bool   `json:"synced"`
+	StartBlock uint64 `json:"startBlock" example:"1234"`
+	Block      uint64 `json:"block" example:"1234"`
+	BlockHash  string `json:"blockHash" example:"0x1234"`
+	Reorgs     uint64 `json:"reorgs"`
+}
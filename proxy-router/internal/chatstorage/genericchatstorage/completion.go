@@ -2,6 +2,7 @@ package genericchatstorage
 
 import (
 	"context"
+	"encoding/json"
 
 	"github.com/sashabaranov/go-openai"
 )
@@ -19,6 +20,7 @@ const (
 
 type ChunkText struct {
 	data        *openai.ChatCompletionResponse
+	raw         []byte
 	isStreaming bool
 	tokenCount  int
 }
@@ -29,6 +31,14 @@ func NewChunkText(data *openai.ChatCompletionResponse) *ChunkText {
 	}
 }
 
+// NewChunkTextRaw keeps the upstream json, so fields unknown to the openai structs are passed through
+func NewChunkTextRaw(data *openai.ChatCompletionResponse, raw []byte) *ChunkText {
+	return &ChunkText{
+		data: data,
+		raw:  raw,
+	}
+}
+
 func (c *ChunkText) IsStreaming() bool {
 	return false
 }
@@ -42,6 +52,9 @@ func (c *ChunkText) Type() ChunkType {
 }
 
 func (c *ChunkText) String() string {
+	if len(c.data.Choices) == 0 {
+		return ""
+	}
 	return c.data.Choices[0].Message.Content
 }
 
@@ -49,8 +62,13 @@ func (c *ChunkText) Data() interface{} {
 	return c.data
 }
 
+func (c *ChunkText) Raw() []byte {
+	return c.raw
+}
+
 type ChunkStreaming struct {
 	data *openai.ChatCompletionStreamResponse
+	raw  []byte
 }
 
 func NewChunkStreaming(data *openai.ChatCompletionStreamResponse) *ChunkStreaming {
@@ -59,6 +77,14 @@ func NewChunkStreaming(data *openai.ChatCompletionStreamResponse) *ChunkStreamin
 	}
 }
 
+// NewChunkStreamingRaw keeps the upstream json, so fields unknown to the openai structs are passed through
+func NewChunkStreamingRaw(data *openai.ChatCompletionStreamResponse, raw []byte) *ChunkStreaming {
+	return &ChunkStreaming{
+		data: data,
+		raw:  raw,
+	}
+}
+
 func (c *ChunkStreaming) IsStreaming() bool {
 	return true
 }
@@ -72,6 +98,10 @@ func (c *ChunkStreaming) Type() ChunkType {
 }
 
 func (c *ChunkStreaming) String() string {
+	// the usage chunk at the end of the stream has no choices
+	if len(c.data.Choices) == 0 {
+		return ""
+	}
 	return c.data.Choices[0].Delta.Content
 }
 
@@ -79,6 +109,10 @@ func (c *ChunkStreaming) Data() interface{} {
 	return c.data
 }
 
+func (c *ChunkStreaming) Raw() []byte {
+	return c.raw
+}
+
 type ChunkControl struct {
 	message string
 }
@@ -207,9 +241,24 @@ type Chunk interface {
 	Data() interface{}
 }
 
+// RawChunk is implemented by the chunks which may keep the upstream json
+type RawChunk interface {
+	Raw() []byte
+}
+
+// MarshalChunk returns the upstream json of the chunk if it was kept, otherwise marshals the chunk data
+func MarshalChunk(chunk Chunk) ([]byte, error) {
+	if rawChunk, ok := chunk.(RawChunk); ok && len(rawChunk.Raw()) > 0 {
+		return rawChunk.Raw(), nil
+	}
+	return json.Marshal(chunk.Data())
+}
+
 var _ Chunk = &ChunkText{}
 var _ Chunk = &ChunkImage{}
 var _ Chunk = &ChunkControl{}
 var _ Chunk = &ChunkStreaming{}
 var _ Chunk = &ChunkVideo{}
 var _ Chunk = &ChunkImageRawContent{}
+var _ RawChunk = &ChunkText{}
+var _ RawChunk = &ChunkStreaming{}
@@ -24,22 +24,42 @@ type Config struct {
 		ResetKeychain bool `env:"APP_RESET_KEYCHAIN" flag:"app-reset-keychain" desc:"reset keychain on start"`
 	}
 	Blockchain struct {
-		ChainID            int             `env:"ETH_NODE_CHAIN_ID"  flag:"eth-node-chain-id"  validate:"number"`
-		EthNodeAddress     string          `env:"ETH_NODE_ADDRESS"   flag:"eth-node-address"   validate:"omitempty,url"`
-		EthLegacyTx        bool            `env:"ETH_NODE_LEGACY_TX" flag:"eth-node-legacy-tx" desc:"use it to disable EIP-1559 transactions"`
-		ExplorerApiUrl     string          `env:"EXPLORER_API_URL"   flag:"explorer-api-url"   validate:"required,url"`
-		ExplorerRetryDelay time.Duration   `env:"EXPLORER_RETRY_DELAY" flag:"explorer-retry-delay" validate:"omitempty,duration" desc:"delay between retries"`
-		ExplorerMaxRetries uint8           `env:"EXPLORER_MAX_RETRIES" flag:"explorer-max-retries" validate:"omitempty,gte=0" desc:"max retries for explorer requests"`
-		UseSubscriptions   bool            `env:"ETH_NODE_USE_SUBSCRIPTIONS"  flag:"eth-node-use-subscriptions"  desc:"set it to true to enable subscriptions for blockchain events, otherwise default polling will be used"`
-		PollingInterval    time.Duration   `env:"ETH_NODE_POLLING_INTERVAL" flag:"eth-node-polling-interval" validate:"omitempty,duration" desc:"interval for polling eth node for new events"`
-		MaxReconnects      int             `env:"ETH_NODE_MAX_RECONNECTS" flag:"eth-node-max-reconnects" validate:"omitempty,gte=0" desc:"max reconnects to eth node"`
-		Multicall3Addr     *common.Address `env:"MULTICALL3_ADDR" flag:"multicall3-addr" validate:"omitempty,eth_addr" desc:"multicall3 custom contract address"`
+		ChainID             int             `env:"ETH_NODE_CHAIN_ID"  flag:"eth-node-chain-id"  validate:"number"`
+		EthNodeAddress      string          `env:"ETH_NODE_ADDRESS"   flag:"eth-node-address"   validate:"omitempty,url"`
+		EthLegacyTx         bool            `env:"ETH_NODE_LEGACY_TX" flag:"eth-node-legacy-tx" desc:"use it to disable EIP-1559 transactions"`
+		ExplorerApiUrl      string          `env:"EXPLORER_API_URL"   flag:"explorer-api-url"   validate:"required,url"`
+		ExplorerRetryDelay  time.Duration   `env:"EXPLORER_RETRY_DELAY" flag:"explorer-retry-delay" validate:"omitempty,duration" desc:"delay between retries"`
+		ExplorerMaxRetries  uint8           `env:"EXPLORER_MAX_RETRIES" flag:"explorer-max-retries" validate:"omitempty,gte=0" desc:"max retries for explorer requests"`
+		UseSubscriptions    bool            `env:"ETH_NODE_USE_SUBSCRIPTIONS"  flag:"eth-node-use-subscriptions"  desc:"set it to true to enable subscriptions for blockchain events, otherwise default polling will be used"`
+		PollingInterval     time.Duration   `env:"ETH_NODE_POLLING_INTERVAL" flag:"eth-node-polling-interval" validate:"omitempty,duration" desc:"interval for polling eth node for new events"`
+		MaxReconnects       int             `env:"ETH_NODE_MAX_RECONNECTS" flag:"eth-node-max-reconnects" validate:"omitempty,gte=0" desc:"max reconnects to eth node"`
+		Multicall3Addr      *common.Address `env:"MULTICALL3_ADDR" flag:"multicall3-addr" validate:"omitempty,eth_addr" desc:"multicall3 custom contract address"`
+		Confirmations       uint64          `env:"ETH_NODE_CONFIRMATIONS" flag:"eth-node-confirmations" validate:"omitempty,gte=0" desc:"number of blocks on top of the block before its events are processed, used with polling"`
+		MaxBlockRange       uint64          `env:"ETH_NODE_MAX_BLOCK_RANGE" flag:"eth-node-max-block-range" validate:"omitempty,gte=1" desc:"max number of blocks requested at once when polling for events, the progress is saved after each range"`
+		RPCStrategy         string          `env:"ETH_NODE_RPC_STRATEGY" flag:"eth-node-rpc-strategy" validate:"omitempty,oneof=priority round-robin fastest" desc:"order of trying eth node endpoints: priority, round-robin or fastest"`
+		MaxBlockLag         uint64          `env:"ETH_NODE_MAX_BLOCK_LAG" flag:"eth-node-max-block-lag" validate:"omitempty,gte=0" desc:"endpoints behind the highest seen block by more blocks are used last"`
+		HealthCheckInterval time.Duration   `env:"ETH_NODE_HEALTH_CHECK_INTERVAL" flag:"eth-node-health-check-interval" validate:"omitempty,duration" desc:"interval of checking latency and block height of eth node endpoints"`
+		TxStuckTimeout      time.Duration   `env:"TX_STUCK_TIMEOUT" flag:"tx-stuck-timeout" validate:"omitempty,duration" desc:"time after which a pending transaction is resent with higher gas price"`
+		TxMaxGasBumps       int             `env:"TX_MAX_GAS_BUMPS" flag:"tx-max-gas-bumps" validate:"omitempty,gte=0" desc:"max number of gas price bumps before a pending transaction is dropped"`
 	}
 	Environment string `env:"ENVIRONMENT" flag:"environment"`
+	Indexer     struct {
+		Enable        bool   `env:"INDEXER_ENABLE"              flag:"indexer-enable"              desc:"index marketplace contract events locally and serve listings from the index"`
+		StartBlock    uint64 `env:"INDEXER_START_BLOCK"         flag:"indexer-start-block"         validate:"omitempty,gte=0" desc:"block to start indexing from, usually the diamond contract deployment block"`
+		BackfillRange uint64 `env:"INDEXER_BACKFILL_BLOCK_RANGE" flag:"indexer-backfill-block-range" validate:"omitempty,gte=1" desc:"max number of blocks requested at once when backfilling the index"`
+	}
 	Marketplace struct {
 		DiamondContractAddress *common.Address `env:"DIAMOND_CONTRACT_ADDRESS" flag:"diamond-address"   validate:"omitempty,eth_addr"`
 		MorTokenAddress        *common.Address `env:"MOR_TOKEN_ADDRESS"        flag:"mor-token-address" validate:"omitempty,eth_addr"`
 		WalletPrivateKey       *lib.HexString  `env:"WALLET_PRIVATE_KEY"       flag:"wallet-private-key"     desc:"if set, will use this private key to sign transactions, otherwise it will be retrieved from the system keychain"`
+		KeystoreDir            string          `env:"WALLET_KEYSTORE_DIR"      flag:"wallet-keystore-dir" desc:"if set, accounts are stored in this folder as V3 keystore files instead of the system keychain"`
+		KeystorePassphrase     string          `env:"WALLET_KEYSTORE_PASSPHRASE" flag:"wallet-keystore-passphrase" desc:"passphrase of the keystore files"`
+		KeystorePassFile       string          `env:"WALLET_KEYSTORE_PASSPHRASE_FILE" flag:"wallet-keystore-passphrase-file" validate:"omitempty,filepath" desc:"file with the passphrase of the keystore files, the passphrase is prompted on a terminal if neither is set"`
+		ConsumerAccount        string          `env:"WALLET_CONSUMER_ACCOUNT"  flag:"wallet-consumer-account" desc:"account spending on sessions and used by requests without the X-Account header"`
+		ProviderAccount        string          `env:"WALLET_PROVIDER_ACCOUNT"  flag:"wallet-provider-account" desc:"account used as the provider identity"`
+		RemoteSignerURL        string          `env:"WALLET_REMOTE_SIGNER_URL" flag:"wallet-remote-signer-url" desc:"if set, transactions and session approvals are signed by the external signer at this url (http, ws or ipc path), e.g. Clef or web3signer"`
+		RemoteSignerAPI        string          `env:"WALLET_REMOTE_SIGNER_API" flag:"wallet-remote-signer-api" validate:"omitempty,oneof=eth clef" desc:"json-rpc methods of the remote signer: eth (eth_sign, eth_signTransaction) or clef (account_signData, account_signTransaction)"`
+		RemoteSignerAddress    *common.Address `env:"WALLET_REMOTE_SIGNER_ADDRESS" flag:"wallet-remote-signer-address" validate:"omitempty,eth_addr" desc:"account of the remote signer, defaults to the first account it lists"`
 	}
 	Log struct {
 		Color        bool   `env:"LOG_COLOR"            flag:"log-color"`
@@ -52,12 +72,15 @@ type Config struct {
 		LevelStorage string `env:"LOG_LEVEL_STORAGE"     flag:"log-level-storage"     validate:"omitempty,oneof=debug info warn error dpanic panic fatal"`
 	}
 	Proxy struct {
-		Address            string    `env:"PROXY_ADDRESS" flag:"proxy-address" validate:"required,hostname_port"`
-		StoragePath        string    `env:"PROXY_STORAGE_PATH"    flag:"proxy-storage-path"    validate:"omitempty,dirpath" desc:"enables file storage and sets the folder path"`
-		StoreChatContext   *lib.Bool `env:"PROXY_STORE_CHAT_CONTEXT" flag:"proxy-store-chat-context" desc:"store chat context in the proxy storage"`
-		ForwardChatContext *lib.Bool `env:"PROXY_FORWARD_CHAT_CONTEXT" flag:"proxy-forward-chat-context" desc:"prepend whole stored message history to the prompt"`
-		ModelsConfigPath   string    `env:"MODELS_CONFIG_PATH" flag:"models-config-path" validate:"omitempty"`
-		RatingConfigPath   string    `env:"RATING_CONFIG_PATH" flag:"rating-config-path" validate:"omitempty" desc:"path to the rating config file"`
+		Address            string        `env:"PROXY_ADDRESS" flag:"proxy-address" validate:"required,hostname_port"`
+		StoragePath        string        `env:"PROXY_STORAGE_PATH"    flag:"proxy-storage-path"    validate:"omitempty,dirpath" desc:"enables file storage and sets the folder path"`
+		StoreChatContext   *lib.Bool     `env:"PROXY_STORE_CHAT_CONTEXT" flag:"proxy-store-chat-context" desc:"store chat context in the proxy storage"`
+		ForwardChatContext *lib.Bool     `env:"PROXY_FORWARD_CHAT_CONTEXT" flag:"proxy-forward-chat-context" desc:"prepend whole stored message history to the prompt"`
+		ModelsConfigPath   string        `env:"MODELS_CONFIG_PATH" flag:"models-config-path" validate:"omitempty"`
+		RatingConfigPath   string        `env:"RATING_CONFIG_PATH" flag:"rating-config-path" validate:"omitempty" desc:"path to the rating config file"`
+		OpenAIStrict       bool          `env:"PROXY_OPENAI_STRICT" flag:"proxy-openai-strict" desc:"reply to /v1/chat/completions exactly like the OpenAI API, node control messages are omitted from the stream"`
+		SchemaRetries      int           `env:"PROXY_RESPONSE_SCHEMA_RETRIES" flag:"proxy-response-schema-retries" validate:"omitempty,gte=0" desc:"how many times a remote prompt is repeated if the response doesn't match the requested json schema, only for providers advertising the json_schema capability"`
+		SessionRenewBefore time.Duration `env:"PROXY_SESSION_RENEW_BEFORE" flag:"proxy-session-renew-before" validate:"omitempty,duration" desc:"time before the session end when auto-renewed sessions are replaced with a new one"`
 	}
 	System struct {
 		Enable           bool   `env:"SYS_ENABLE"              flag:"sys-enable" desc:"enable system level configuration adjustments"`
@@ -69,8 +92,22 @@ type Config struct {
 		TcpMaxSynBacklog string `env:"SYS_TCP_MAX_SYN_BACKLOG" flag:"sys-tcp-max-syn-backlog" desc:""`
 	}
 	Web struct {
-		Address   string `env:"WEB_ADDRESS"    flag:"web-address"    validate:"required,hostname_port" desc:"http server address host:port"`
-		PublicUrl string `env:"WEB_PUBLIC_URL" flag:"web-public-url" validate:"omitempty,url"          desc:"public url of the proxyrouter, falls back to web-address if empty" `
+		Address        string `env:"WEB_ADDRESS"    flag:"web-address"    validate:"required,hostname_port" desc:"http server address host:port"`
+		PublicUrl      string `env:"WEB_PUBLIC_URL" flag:"web-public-url" validate:"omitempty,url"          desc:"public url of the proxyrouter, falls back to web-address if empty" `
+		AuthConfigPath string `env:"WEB_AUTH_CONFIG_PATH" flag:"web-auth-config-path" validate:"omitempty" desc:"path to the api auth config file with hashed tokens and their scopes, api is not protected if not set"`
+		CorsOrigins    string `env:"WEB_CORS_ORIGINS" flag:"web-cors-origins" validate:"omitempty" desc:"comma separated list of allowed CORS origins, defaults to *"`
+	}
+	Webhooks struct {
+		ConfigPath string `env:"WEBHOOKS_CONFIG_PATH" flag:"webhooks-config-path" validate:"omitempty" desc:"path to the webhooks config file, webhooks are disabled if not set"`
+	}
+	Metrics struct {
+		Enable bool `env:"METRICS_ENABLE" flag:"metrics-enable" desc:"serve prometheus metrics on /metrics"`
+	}
+	Tracing struct {
+		Endpoint    string       `env:"TRACING_OTLP_ENDPOINT" flag:"tracing-otlp-endpoint" validate:"omitempty,url"      desc:"otlp/http collector url, e.g. http://localhost:4318, tracing is disabled if not set"`
+		ServiceName string       `env:"TRACING_SERVICE_NAME"  flag:"tracing-service-name"  validate:"omitempty"          desc:"service name of the exported spans, defaults to proxy-router"`
+		SampleRatio *lib.Float64 `env:"TRACING_SAMPLE_RATIO"  flag:"tracing-sample-ratio"  validate:"omitempty,ratio" desc:"ratio of the sampled traces from 0 to 1, 0 disables sampling, defaults to 1"`
+		Propagate   bool         `env:"TRACING_PROPAGATE"     flag:"tracing-propagate"     desc:"send and accept trace context in MOR-RPC prompt messages, it is sent outside the signed params so older providers ignore it"`
 	}
 }
 
@@ -86,9 +123,27 @@ func (cfg *Config) SetDefaults() {
 	if cfg.Blockchain.PollingInterval == 0 {
 		cfg.Blockchain.PollingInterval = 10 * time.Second
 	}
+	if cfg.Blockchain.MaxBlockRange == 0 {
+		cfg.Blockchain.MaxBlockRange = 10000
+	}
 	if cfg.Blockchain.Multicall3Addr.Cmp(common.Address{}) == 0 {
 		cfg.Blockchain.Multicall3Addr = &multicall.MULTICALL3_ADDR
 	}
+	if cfg.Blockchain.RPCStrategy == "" {
+		cfg.Blockchain.RPCStrategy = "priority"
+	}
+	if cfg.Blockchain.MaxBlockLag == 0 {
+		cfg.Blockchain.MaxBlockLag = 20
+	}
+	if cfg.Blockchain.HealthCheckInterval == 0 {
+		cfg.Blockchain.HealthCheckInterval = 30 * time.Second
+	}
+	if cfg.Blockchain.TxStuckTimeout == 0 {
+		cfg.Blockchain.TxStuckTimeout = 2 * time.Minute
+	}
+	if cfg.Blockchain.TxMaxGasBumps == 0 {
+		cfg.Blockchain.TxMaxGasBumps = 5
+	}
 	if cfg.Blockchain.ExplorerRetryDelay == 0 {
 		cfg.Blockchain.ExplorerRetryDelay = 5 * time.Second
 	}
@@ -96,6 +151,24 @@ func (cfg *Config) SetDefaults() {
 		cfg.Blockchain.ExplorerMaxRetries = 5
 	}
 
+	// Indexer
+
+	if cfg.Indexer.BackfillRange == 0 {
+		cfg.Indexer.BackfillRange = 10000
+	}
+
+	// Marketplace
+
+	if cfg.Marketplace.ConsumerAccount == "" {
+		cfg.Marketplace.ConsumerAccount = lib.DefaultAccount
+	}
+	if cfg.Marketplace.ProviderAccount == "" {
+		cfg.Marketplace.ProviderAccount = lib.DefaultAccount
+	}
+	if cfg.Marketplace.RemoteSignerAPI == "" {
+		cfg.Marketplace.RemoteSignerAPI = "eth"
+	}
+
 	// Log
 
 	if cfg.Log.LevelTCP == "" {
@@ -165,9 +238,22 @@ func (cfg *Config) SetDefaults() {
 		val := true
 		cfg.Proxy.ForwardChatContext = &lib.Bool{Bool: &val}
 	}
+	if cfg.Proxy.SessionRenewBefore == 0 {
+		cfg.Proxy.SessionRenewBefore = 5 * time.Minute
+	}
 	if cfg.Proxy.RatingConfigPath == "" {
 		cfg.Proxy.RatingConfigPath = "./rating-config.json"
 	}
+
+	// Tracing
+
+	if cfg.Tracing.SampleRatio == nil || cfg.Tracing.SampleRatio.Float64 == nil {
+		val := 1.0
+		cfg.Tracing.SampleRatio = &lib.Float64{Float64: &val}
+	}
+	if cfg.Tracing.ServiceName == "" {
+		cfg.Tracing.ServiceName = "proxy-router"
+	}
 }
 
 // GetSanitized returns a copy of the config with sensitive data removed
@@ -180,12 +266,28 @@ func (cfg *Config) GetSanitized() interface{} {
 	publicCfg.Blockchain.MaxReconnects = cfg.Blockchain.MaxReconnects
 	publicCfg.Blockchain.PollingInterval = cfg.Blockchain.PollingInterval
 	publicCfg.Blockchain.UseSubscriptions = cfg.Blockchain.UseSubscriptions
+	publicCfg.Blockchain.Confirmations = cfg.Blockchain.Confirmations
+	publicCfg.Blockchain.MaxBlockRange = cfg.Blockchain.MaxBlockRange
+	publicCfg.Blockchain.RPCStrategy = cfg.Blockchain.RPCStrategy
+	publicCfg.Blockchain.MaxBlockLag = cfg.Blockchain.MaxBlockLag
+	publicCfg.Blockchain.HealthCheckInterval = cfg.Blockchain.HealthCheckInterval
+	publicCfg.Blockchain.TxStuckTimeout = cfg.Blockchain.TxStuckTimeout
+	publicCfg.Blockchain.TxMaxGasBumps = cfg.Blockchain.TxMaxGasBumps
 	publicCfg.Blockchain.ExplorerApiUrl = cfg.Blockchain.ExplorerApiUrl
 
 	publicCfg.Environment = cfg.Environment
 
+	publicCfg.Indexer.Enable = cfg.Indexer.Enable
+	publicCfg.Indexer.StartBlock = cfg.Indexer.StartBlock
+	publicCfg.Indexer.BackfillRange = cfg.Indexer.BackfillRange
+
 	publicCfg.Marketplace.DiamondContractAddress = cfg.Marketplace.DiamondContractAddress
 	publicCfg.Marketplace.MorTokenAddress = cfg.Marketplace.MorTokenAddress
+	publicCfg.Marketplace.KeystoreDir = cfg.Marketplace.KeystoreDir
+	publicCfg.Marketplace.ConsumerAccount = cfg.Marketplace.ConsumerAccount
+	publicCfg.Marketplace.ProviderAccount = cfg.Marketplace.ProviderAccount
+	publicCfg.Marketplace.RemoteSignerAPI = cfg.Marketplace.RemoteSignerAPI
+	publicCfg.Marketplace.RemoteSignerAddress = cfg.Marketplace.RemoteSignerAddress
 
 	publicCfg.Log.Color = cfg.Log.Color
 	publicCfg.Log.FolderPath = cfg.Log.FolderPath
@@ -200,7 +302,10 @@ func (cfg *Config) GetSanitized() interface{} {
 	publicCfg.Proxy.StoragePath = cfg.Proxy.StoragePath
 	publicCfg.Proxy.StoreChatContext = cfg.Proxy.StoreChatContext
 	publicCfg.Proxy.ForwardChatContext = cfg.Proxy.ForwardChatContext
+	publicCfg.Proxy.OpenAIStrict = cfg.Proxy.OpenAIStrict
+	publicCfg.Proxy.SchemaRetries = cfg.Proxy.SchemaRetries
 	publicCfg.Proxy.RatingConfigPath = cfg.Proxy.RatingConfigPath
+	publicCfg.Proxy.SessionRenewBefore = cfg.Proxy.SessionRenewBefore
 
 	publicCfg.System.Enable = cfg.System.Enable
 	publicCfg.System.LocalPortRange = cfg.System.LocalPortRange
@@ -212,6 +317,15 @@ func (cfg *Config) GetSanitized() interface{} {
 
 	publicCfg.Web.Address = cfg.Web.Address
 	publicCfg.Web.PublicUrl = cfg.Web.PublicUrl
+	publicCfg.Web.AuthConfigPath = cfg.Web.AuthConfigPath
+	publicCfg.Web.CorsOrigins = cfg.Web.CorsOrigins
+
+	publicCfg.Webhooks.ConfigPath = cfg.Webhooks.ConfigPath
+	publicCfg.Metrics.Enable = cfg.Metrics.Enable
+
+	publicCfg.Tracing.ServiceName = cfg.Tracing.ServiceName
+	publicCfg.Tracing.SampleRatio = cfg.Tracing.SampleRatio
+	publicCfg.Tracing.Propagate = cfg.Tracing.Propagate
 
 	return publicCfg
 }
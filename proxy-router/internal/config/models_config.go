@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"time"
 
 	"github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/lib"
 	"github.com/ethereum/go-ethereum/common"
@@ -45,6 +46,69 @@ type ModelConfig struct {
 	ConcurrentSlots int               `json:"concurrentSlots" validate:"number"`
 	CapacityPolicy  string            `json:"capacityPolicy"`
 	Parameters      map[string]string `json:"parameters"`
+
+	// ResponseCache enables caching of the deterministic requests, disabled if not set
+	ResponseCache *ResponseCacheConfig `json:"responseCache,omitempty"`
+
+	// Moderation enforces the content policy of the provider, disabled if not set
+	Moderation *ModerationConfig `json:"moderation,omitempty"`
+
+	// Capabilities are advertised to the consumers when they initiate a session, e.g. json_schema
+	Capabilities []string `json:"capabilities,omitempty"`
+}
+
+const (
+	ResponseCacheTTLDefault           = time.Hour
+	ResponseCacheMaxEntriesDefault    = 1000
+	ResponseCacheMaxEntryBytesDefault = 1024 * 1024
+)
+
+// ResponseCacheConfig limits the cached responses of a model, zero values are replaced by the defaults
+type ResponseCacheConfig struct {
+	TTLSeconds    int `json:"ttlSeconds"`
+	MaxEntries    int `json:"maxEntries"`
+	MaxEntryBytes int `json:"maxEntryBytes"`
+}
+
+// ModerationConfig is the content policy of a model. Prompts are checked before the inference and
+// responses after it, so moderated responses are sent once they are complete unless SkipOutput is set
+type ModerationConfig struct {
+	MaxInputLength int                         `json:"maxInputLength"` // characters of the prompt messages, 0 is unlimited
+	Patterns       []string                    `json:"patterns"`       // regexps refusing the matching content
+	Keywords       []string                    `json:"keywords"`       // case insensitive words refusing the content
+	Classifier     *ModerationClassifierConfig `json:"classifier,omitempty"`
+	SkipOutput     bool                        `json:"skipOutput"` // check only the prompts, the responses are streamed as usual
+	LogContent     bool                        `json:"logContent"` // log the refused content, only the decision is logged by default
+}
+
+// ModerationClassifierConfig is an openai compatible classifier model, e.g. Llama Guard,
+// the content is refused if its answer matches FlaggedPattern
+type ModerationClassifierConfig struct {
+	ModelName      string `json:"modelName"`
+	ApiURL         string `json:"apiUrl"`
+	ApiKey         string `json:"apiKey"`
+	FlaggedPattern string `json:"flaggedPattern"` // regexp, "(?i)^\\s*unsafe" by default
+}
+
+func (c *ResponseCacheConfig) TTL() time.Duration {
+	if c.TTLSeconds == 0 {
+		return ResponseCacheTTLDefault
+	}
+	return time.Duration(c.TTLSeconds) * time.Second
+}
+
+func (c *ResponseCacheConfig) GetMaxEntries() int {
+	if c.MaxEntries == 0 {
+		return ResponseCacheMaxEntriesDefault
+	}
+	return c.MaxEntries
+}
+
+func (c *ResponseCacheConfig) GetMaxEntryBytes() int {
+	if c.MaxEntryBytes == 0 {
+		return ResponseCacheMaxEntryBytesDefault
+	}
+	return c.MaxEntryBytes
 }
 
 type ModelConfigs map[string]ModelConfig
@@ -35,6 +35,11 @@ func NewValidator() (*validator.Validate, error) {
 		return nil, err
 	}
 
+	err = RegisterRatio(valid)
+	if err != nil {
+		return nil, err
+	}
+
 	return valid, nil
 }
 
@@ -117,3 +122,14 @@ func RegisterEthAddr(v *validator.Validate) error {
 		return false
 	})
 }
+
+// RegisterRatio validates that the optional float is unset or between 0 and 1
+func RegisterRatio(v *validator.Validate) error {
+	return v.RegisterValidation("ratio", func(fl validator.FieldLevel) bool {
+		f, ok := fl.Field().Interface().(lib.Float64)
+		if !ok {
+			return false
+		}
+		return f.Float64 == nil || (*f.Float64 >= 0 && *f.Float64 <= 1)
+	})
+}
@@ -0,0 +1,27 @@
+package config
+
+import (
+	"github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/lib"
+	"github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/webhooks"
+)
+
+// LoadWebhooks reads the webhooks config file, returns nil config if the path is not set
+func LoadWebhooks(path string, log lib.ILogger) (*webhooks.Config, error) {
+	if path == "" {
+		return nil, nil
+	}
+	log = log.Named("WEBHOOKS_LOADER")
+
+	config, err := lib.ReadJSONFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg, err := webhooks.ParseConfig([]byte(config))
+	if err != nil {
+		return nil, err
+	}
+
+	log.Infof("webhooks config loaded from file: %s, endpoints: %d", path, len(cfg.Endpoints))
+	return cfg, nil
+}
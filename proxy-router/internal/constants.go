@@ -9,8 +9,11 @@ const (
 
 	CONNECTION_KEEP_ALIVE = "keep-alive"
 
+	CACHE_CONTROL_NO_CACHE = "no-cache"
+
 	HEADER_ACCEPT        = "Accept"
 	HEADER_AUTHORIZATION = "Authorization"
+	HEADER_CACHE_CONTROL = "Cache-Control"
 	HEADER_CONNECTION    = "Connection"
 	HEADER_CONTENT_TYPE  = "Content-Type"
 )
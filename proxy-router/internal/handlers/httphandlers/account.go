@@ -0,0 +1,29 @@
+package httphandlers
+
+import (
+	"net/http"
+
+	"github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/lib"
+	"github.com/gin-gonic/gin"
+)
+
+// HeaderAccount selects the named wallet account used by the request
+const HeaderAccount = "X-Account"
+
+// AccountSelector is a middleware selecting the account from the X-Account header, see lib.AccountFromContext
+func AccountSelector() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		name := c.GetHeader(HeaderAccount)
+		if name == "" {
+			c.Next()
+			return
+		}
+		if err := lib.ValidateAccountName(name); err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.Set(lib.AccountKey, name)
+		c.Request = c.Request.WithContext(lib.WithAccount(c.Request.Context(), name))
+		c.Next()
+	}
+}
@@ -0,0 +1,203 @@
+package httphandlers
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/lib"
+	"github.com/gin-gonic/gin"
+)
+
+type Scope string
+
+const (
+	ScopePublic          Scope = "public" // no token required
+	ScopeRead            Scope = "read"
+	ScopeChat            Scope = "chat"
+	ScopeBlockchainWrite Scope = "blockchain-write"
+	ScopeWalletAdmin     Scope = "wallet-admin" // wallet and node configuration
+
+	HeaderAPIKey = "X-API-Key"
+
+	// AllAccounts in the token accounts allows to select any account
+	AllAccounts = "*"
+)
+
+var (
+	ErrInvalidAuthConfig = errors.New("invalid auth config")
+	ErrUnauthorized      = errors.New("missing or invalid api token")
+	ErrForbidden         = errors.New("api token lacks required scope")
+	ErrAccountForbidden  = errors.New("api token is not allowed to use the account")
+
+	knownScopes = []Scope{ScopePublic, ScopeRead, ScopeChat, ScopeBlockchainWrite, ScopeWalletAdmin}
+)
+
+// AuthConfig is the auth config file format
+type AuthConfig struct {
+	Tokens []AuthToken      `json:"tokens"`
+	Routes map[string]Scope `json:"routes"` // overrides of the default policy, keyed by "METHOD /path"
+}
+
+type AuthToken struct {
+	Name     string   `json:"name"`
+	Hash     string   `json:"hash"` // hex encoded sha256 of the token, plain tokens are never stored
+	Scopes   []Scope  `json:"scopes"`
+	Accounts []string `json:"accounts"` // accounts the token can select with X-Account, only the consumer account if empty
+}
+
+// Authenticator checks api tokens against the scope required by the route
+type Authenticator struct {
+	tokens          map[string]*AuthToken // by hash
+	policies        map[string]Scope
+	consumerAccount string
+	log             lib.ILogger
+}
+
+func ParseAuthConfig(data []byte) (*AuthConfig, error) {
+	var cfg AuthConfig
+	err := json.Unmarshal(data, &cfg)
+	if err != nil {
+		return nil, lib.WrapError(ErrInvalidAuthConfig, err)
+	}
+	return &cfg, nil
+}
+
+// LoadAuthenticator reads the auth config file
+func LoadAuthenticator(path string, log lib.ILogger) (*Authenticator, error) {
+	data, err := lib.ReadJSONFile(path)
+	if err != nil {
+		return nil, err
+	}
+	cfg, err := ParseAuthConfig([]byte(data))
+	if err != nil {
+		return nil, err
+	}
+	auth, err := NewAuthenticator(cfg, log)
+	if err != nil {
+		return nil, err
+	}
+	auth.log.Infof("api auth config loaded from file: %s, tokens: %d", path, len(cfg.Tokens))
+	return auth, nil
+}
+
+func NewAuthenticator(cfg *AuthConfig, log lib.ILogger) (*Authenticator, error) {
+	a := &Authenticator{
+		tokens:          make(map[string]*AuthToken),
+		policies:        make(map[string]Scope, len(DefaultRoutePolicy)),
+		consumerAccount: lib.DefaultAccount,
+		log:             log.Named("AUTH"),
+	}
+
+	for i := range cfg.Tokens {
+		token := &cfg.Tokens[i]
+		hash := strings.ToLower(strings.TrimPrefix(token.Hash, "0x"))
+		decoded, err := hex.DecodeString(hash)
+		if err != nil || len(decoded) != sha256.Size {
+			return nil, lib.WrapError(ErrInvalidAuthConfig, fmt.Errorf("token %s: hash should be hex encoded sha256", token.Name))
+		}
+		for _, scope := range token.Scopes {
+			if !slices.Contains(knownScopes, scope) {
+				return nil, lib.WrapError(ErrInvalidAuthConfig, fmt.Errorf("token %s: unknown scope %s", token.Name, scope))
+			}
+		}
+		for _, account := range token.Accounts {
+			if account == AllAccounts {
+				continue
+			}
+			if err := lib.ValidateAccountName(account); err != nil {
+				return nil, lib.WrapError(ErrInvalidAuthConfig, fmt.Errorf("token %s: %w", token.Name, err))
+			}
+		}
+		a.tokens[hash] = token
+	}
+
+	for route, scope := range DefaultRoutePolicy {
+		a.policies[route] = scope
+	}
+	for route, scope := range cfg.Routes {
+		if !slices.Contains(knownScopes, scope) {
+			return nil, lib.WrapError(ErrInvalidAuthConfig, fmt.Errorf("route %s: unknown scope %s", route, scope))
+		}
+		a.policies[route] = scope
+	}
+
+	return a, nil
+}
+
+// SetConsumerAccount sets the account allowed for the tokens without accounts, lib.DefaultAccount by default
+func (a *Authenticator) SetConsumerAccount(name string) {
+	a.consumerAccount = name
+}
+
+// RouteScope returns the scope required for the route. Routes missing in the policy
+// require read scope for GET and wallet-admin scope for other methods
+func (a *Authenticator) RouteScope(method, path string) Scope {
+	if scope, ok := a.policies[routeKey(method, path)]; ok {
+		return scope
+	}
+	if method == http.MethodGet {
+		return ScopeRead
+	}
+	return ScopeWalletAdmin
+}
+
+// Middleware rejects requests without a token having the scope
+func (a *Authenticator) Middleware(scope Scope) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if scope == ScopePublic {
+			ctx.Next()
+			return
+		}
+
+		plain := requestToken(ctx.Request)
+		token, ok := a.tokens[HashToken(plain)]
+		if plain == "" || !ok {
+			ctx.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": ErrUnauthorized.Error()})
+			return
+		}
+		if !slices.Contains(token.Scopes, scope) {
+			a.log.Debugf("token %s lacks scope %s for %s %s", token.Name, scope, ctx.Request.Method, ctx.FullPath())
+			ctx.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": lib.WrapError(ErrForbidden, fmt.Errorf("%s", scope)).Error()})
+			return
+		}
+		if account := lib.AccountFromContext(ctx.Request.Context()); account != "" && !a.accountAllowed(token, account) {
+			a.log.Debugf("token %s is not allowed to use account %s", token.Name, account)
+			ctx.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": lib.WrapError(ErrAccountForbidden, fmt.Errorf("%s", account)).Error()})
+			return
+		}
+
+		ctx.Next()
+	}
+}
+
+// accountAllowed checks the account selected with X-Account against the accounts of the token
+func (a *Authenticator) accountAllowed(token *AuthToken, account string) bool {
+	if len(token.Accounts) == 0 {
+		return account == a.consumerAccount
+	}
+	return slices.Contains(token.Accounts, AllAccounts) || slices.Contains(token.Accounts, account)
+}
+
+// HashToken returns the value stored in the config for the token
+func HashToken(token string) string {
+	hash := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(hash[:])
+}
+
+// requestToken reads the token from "Authorization: Bearer <token>" or "X-API-Key: <token>" headers
+func requestToken(r *http.Request) string {
+	if bearer, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok {
+		return strings.TrimSpace(bearer)
+	}
+	return r.Header.Get(HeaderAPIKey)
+}
+
+func routeKey(method, path string) string {
+	return method + " " + path
+}
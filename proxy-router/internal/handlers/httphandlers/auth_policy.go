@@ -0,0 +1,88 @@
+package httphandlers
+
+import (
+	"net/http"
+
+	"github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/interfaces"
+	"github.com/gin-gonic/gin"
+)
+
+// DefaultRoutePolicy is the scope required by each route, can be overridden in the auth config
+var DefaultRoutePolicy = map[string]Scope{
+	// system
+	"GET /healthcheck":           ScopePublic,
+	"GET /config":                ScopeRead,
+	"GET /files":                 ScopeWalletAdmin,
+	"POST /config/ethNode":       ScopeWalletAdmin,
+	"GET /config/ethNode/status": ScopeRead,
+	"GET /metrics":               ScopeRead,
+	"POST /webhooks/test":        ScopeWalletAdmin,
+
+	// wallet
+	"GET /wallet":                  ScopeRead,
+	"POST /wallet/privateKey":      ScopeWalletAdmin,
+	"GET /wallet/accounts":         ScopeRead,
+	"POST /wallet/mnemonic":        ScopeWalletAdmin,
+	"POST /wallet/keystore/import": ScopeWalletAdmin,
+	"POST /wallet/keystore/export": ScopeWalletAdmin,
+	"DELETE /wallet":               ScopeWalletAdmin,
+
+	// chat
+	"POST /v1/chat/completions":     ScopeChat,
+	"GET /v1/models":                ScopeChat,
+	"GET /v1/chats":                 ScopeChat,
+	"GET /v1/chats/:id":             ScopeChat,
+	"DELETE /v1/chats/:id":          ScopeChat,
+	"POST /v1/chats/:id":            ScopeChat,
+	"POST /proxy/provider/ping":     ScopeChat,
+	"POST /proxy/sessions/initiate": ScopeChat,
+
+	// sessions are opened and closed by chat clients
+	"POST /blockchain/sessions":           ScopeChat,
+	"POST /blockchain/bids/:id/session":   ScopeChat,
+	"POST /blockchain/models/:id/session": ScopeChat,
+	"POST /blockchain/sessions/:id/close": ScopeChat,
+
+	// blockchain transactions
+	"POST /blockchain/approve":               ScopeBlockchainWrite,
+	"POST /blockchain/send/eth":              ScopeBlockchainWrite,
+	"POST /blockchain/send/mor":              ScopeBlockchainWrite,
+	"POST /blockchain/providers":             ScopeBlockchainWrite,
+	"DELETE /blockchain/providers/:id":       ScopeBlockchainWrite,
+	"POST /blockchain/models":                ScopeBlockchainWrite,
+	"DELETE /blockchain/models/:id":          ScopeBlockchainWrite,
+	"POST /blockchain/bids":                  ScopeBlockchainWrite,
+	"DELETE /blockchain/bids/:id":            ScopeBlockchainWrite,
+	"POST /proxy/sessions/:id/providerClaim": ScopeBlockchainWrite,
+}
+
+// policyRouter adds the auth middleware required by the route policy to each registered route
+type policyRouter struct {
+	router interfaces.Router
+	auth   *Authenticator
+}
+
+func newPolicyRouter(router interfaces.Router, auth *Authenticator) *policyRouter {
+	return &policyRouter{router: router, auth: auth}
+}
+
+func (p *policyRouter) GET(uri string, handl ...gin.HandlerFunc) gin.IRoutes {
+	return p.router.GET(uri, p.withAuth(http.MethodGet, uri, handl)...)
+}
+
+func (p *policyRouter) POST(uri string, handl ...gin.HandlerFunc) gin.IRoutes {
+	return p.router.POST(uri, p.withAuth(http.MethodPost, uri, handl)...)
+}
+
+func (p *policyRouter) DELETE(uri string, handl ...gin.HandlerFunc) gin.IRoutes {
+	return p.router.DELETE(uri, p.withAuth(http.MethodDelete, uri, handl)...)
+}
+
+func (p *policyRouter) Use(middleware ...gin.HandlerFunc) gin.IRoutes {
+	return p.router.Use(middleware...)
+}
+
+func (p *policyRouter) withAuth(method, uri string, handl []gin.HandlerFunc) []gin.HandlerFunc {
+	scope := p.auth.RouteScope(method, uri)
+	return append([]gin.HandlerFunc{p.auth.Middleware(scope)}, handl...)
+}
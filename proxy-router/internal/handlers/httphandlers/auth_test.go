@@ -0,0 +1,166 @@
+package httphandlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/interfaces"
+	"github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/lib"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+)
+
+type testController struct{}
+
+func (testController) RegisterRoutes(r interfaces.Router) {
+	ok := func(ctx *gin.Context) { ctx.JSON(http.StatusOK, gin.H{"status": "ok"}) }
+	r.GET("/healthcheck", ok)
+	r.GET("/blockchain/balance", ok)
+	r.POST("/v1/chat/completions", ok)
+	r.POST("/blockchain/send/mor", ok)
+	r.DELETE("/wallet", ok)
+	r.POST("/unlisted", ok)
+}
+
+func newTestAuthServer(t *testing.T, cfg *AuthConfig) *gin.Engine {
+	auth, err := NewAuthenticator(cfg, lib.NewTestLogger())
+	require.NoError(t, err)
+	return CreateHTTPServer(lib.NewTestLogger(), nil, auth, testController{})
+}
+
+func doRequest(srv *gin.Engine, method, path string, headers map[string]string) int {
+	req := httptest.NewRequest(method, path, nil)
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	rec := httptest.NewRecorder()
+	srv.ServeHTTP(rec, req)
+	return rec.Code
+}
+
+func TestAuthScopes(t *testing.T) {
+	srv := newTestAuthServer(t, &AuthConfig{
+		Tokens: []AuthToken{
+			{Name: "chat", Hash: HashToken("chat-token"), Scopes: []Scope{ScopeRead, ScopeChat}},
+			{Name: "admin", Hash: HashToken("admin-token"), Scopes: []Scope{ScopeRead, ScopeBlockchainWrite, ScopeWalletAdmin}},
+		},
+	})
+	bearer := func(token string) map[string]string { return map[string]string{"Authorization": "Bearer " + token} }
+
+	cases := []struct {
+		name    string
+		method  string
+		path    string
+		headers map[string]string
+		status  int
+	}{
+		{"public route", http.MethodGet, "/healthcheck", nil, http.StatusOK},
+		{"missing token", http.MethodGet, "/blockchain/balance", nil, http.StatusUnauthorized},
+		{"unknown token", http.MethodGet, "/blockchain/balance", bearer("wrong"), http.StatusUnauthorized},
+		{"read", http.MethodGet, "/blockchain/balance", bearer("chat-token"), http.StatusOK},
+		{"api key header", http.MethodPost, "/v1/chat/completions", map[string]string{HeaderAPIKey: "chat-token"}, http.StatusOK},
+		{"chat token sends mor", http.MethodPost, "/blockchain/send/mor", bearer("chat-token"), http.StatusForbidden},
+		{"chat token deletes wallet", http.MethodDelete, "/wallet", bearer("chat-token"), http.StatusForbidden},
+		{"admin sends mor", http.MethodPost, "/blockchain/send/mor", bearer("admin-token"), http.StatusOK},
+		{"admin deletes wallet", http.MethodDelete, "/wallet", bearer("admin-token"), http.StatusOK},
+		{"admin without chat scope", http.MethodPost, "/v1/chat/completions", bearer("admin-token"), http.StatusForbidden},
+		{"unlisted write route requires wallet-admin", http.MethodPost, "/unlisted", bearer("chat-token"), http.StatusForbidden},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			require.Equal(t, c.status, doRequest(srv, c.method, c.path, c.headers))
+		})
+	}
+}
+
+func TestAuthRouteOverride(t *testing.T) {
+	srv := newTestAuthServer(t, &AuthConfig{
+		Tokens: []AuthToken{{Name: "chat", Hash: HashToken("chat-token"), Scopes: []Scope{ScopeChat}}},
+		Routes: map[string]Scope{
+			"GET /blockchain/balance": ScopePublic,
+			"POST /unlisted":          ScopeChat,
+		},
+	})
+
+	require.Equal(t, http.StatusOK, doRequest(srv, http.MethodGet, "/blockchain/balance", nil))
+	require.Equal(t, http.StatusOK, doRequest(srv, http.MethodPost, "/unlisted", map[string]string{HeaderAPIKey: "chat-token"}))
+}
+
+func TestAuthDisabled(t *testing.T) {
+	srv := CreateHTTPServer(lib.NewTestLogger(), nil, nil, testController{})
+	require.Equal(t, http.StatusOK, doRequest(srv, http.MethodDelete, "/wallet", nil))
+}
+
+func TestAuthConfigValidation(t *testing.T) {
+	_, err := NewAuthenticator(&AuthConfig{Tokens: []AuthToken{{Name: "plain", Hash: "my-token"}}}, lib.NewTestLogger())
+	require.ErrorIs(t, err, ErrInvalidAuthConfig)
+
+	_, err = NewAuthenticator(&AuthConfig{Tokens: []AuthToken{{Name: "t", Hash: HashToken("t"), Scopes: []Scope{"root"}}}}, lib.NewTestLogger())
+	require.ErrorIs(t, err, ErrInvalidAuthConfig)
+
+	_, err = NewAuthenticator(&AuthConfig{Routes: map[string]Scope{"GET /config": "none"}}, lib.NewTestLogger())
+	require.ErrorIs(t, err, ErrInvalidAuthConfig)
+}
+
+func TestAccountSelector(t *testing.T) {
+	var selected string
+	srv := gin.New()
+	srv.Use(AccountSelector())
+	srv.GET("/wallet", func(ctx *gin.Context) {
+		selected = lib.AccountFromContext(ctx)
+		ctx.Status(http.StatusOK)
+	})
+
+	require.Equal(t, http.StatusOK, doRequest(srv, http.MethodGet, "/wallet", nil))
+	require.Empty(t, selected)
+
+	require.Equal(t, http.StatusOK, doRequest(srv, http.MethodGet, "/wallet", map[string]string{HeaderAccount: "provider-1"}))
+	require.Equal(t, "provider-1", selected)
+
+	require.Equal(t, http.StatusBadRequest, doRequest(srv, http.MethodGet, "/wallet", map[string]string{HeaderAccount: "Not Valid"}))
+}
+
+func TestAuthAccounts(t *testing.T) {
+	auth, err := NewAuthenticator(&AuthConfig{
+		Tokens: []AuthToken{
+			{Name: "chat", Hash: HashToken("chat-token"), Scopes: []Scope{ScopeChat}},
+			{Name: "budget-b", Hash: HashToken("budget-token"), Scopes: []Scope{ScopeChat}, Accounts: []string{"budget-b"}},
+			{Name: "admin", Hash: HashToken("admin-token"), Scopes: []Scope{ScopeChat}, Accounts: []string{AllAccounts}},
+		},
+	}, lib.NewTestLogger())
+	require.NoError(t, err)
+	auth.SetConsumerAccount("consumer")
+	srv := CreateHTTPServer(lib.NewTestLogger(), nil, auth, testController{})
+
+	request := func(token, account string) int {
+		headers := map[string]string{HeaderAPIKey: token}
+		if account != "" {
+			headers[HeaderAccount] = account
+		}
+		return doRequest(srv, http.MethodPost, "/v1/chat/completions", headers)
+	}
+
+	cases := []struct {
+		name    string
+		token   string
+		account string
+		status  int
+	}{
+		{"no header", "chat-token", "", http.StatusOK},
+		{"consumer account", "chat-token", "consumer", http.StatusOK},
+		{"other account without list", "chat-token", "budget-b", http.StatusForbidden},
+		{"listed account", "budget-token", "budget-b", http.StatusOK},
+		{"unlisted account", "budget-token", "consumer", http.StatusForbidden},
+		{"all accounts", "admin-token", "budget-b", http.StatusOK},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			require.Equal(t, c.status, request(c.token, c.account))
+		})
+	}
+
+	_, err = NewAuthenticator(&AuthConfig{Tokens: []AuthToken{{Name: "t", Hash: HashToken("t"), Accounts: []string{"Not Valid"}}}}, lib.NewTestLogger())
+	require.ErrorIs(t, err, ErrInvalidAuthConfig)
+}
@@ -15,6 +15,7 @@ import (
 	"github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/config"
 	"github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/interfaces"
 	"github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/lib"
+	"github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/tracing"
 )
 
 type Registrable interface {
@@ -27,9 +28,18 @@ type Registrable interface {
 
 //	@BasePath	/
 
+//	@securityDefinitions.apikey	BearerAuth
+//	@in							header
+//	@name						Authorization
+//	@description				"Bearer <token>", required when API auth is enabled
+
+//	@security	BearerAuth
+
 // @externalDocs.description	OpenAPI
 // @externalDocs.url			https://swagger.io/resources/open-api/
-func CreateHTTPServer(log lib.ILogger, controllers ...Registrable) *gin.Engine {
+//
+// CreateHTTPServer creates the API server. If auth is not nil, each route requires the token scope set by the route policy
+func CreateHTTPServer(log lib.ILogger, corsOrigins []string, auth *Authenticator, controllers ...Registrable) *gin.Engine {
 	ginValidatorInstance := binding.Validator.Engine().(*validator.Validate)
 	err := config.RegisterHex32(ginValidatorInstance)
 	if err != nil {
@@ -52,17 +62,27 @@ func CreateHTTPServer(log lib.ILogger, controllers ...Registrable) *gin.Engine {
 
 	r := gin.New()
 	r.Use(RequestLogger(log))
+	r.Use(tracing.Middleware())
+	r.Use(AccountSelector())
 
+	if len(corsOrigins) == 0 {
+		corsOrigins = []string{"*"}
+	}
 	r.Use(cors.New(cors.Config{
-		AllowOrigins: []string{"*"},
-		AllowHeaders: []string{"session_id", "model_id", "chat_id"},
+		AllowOrigins: corsOrigins,
+		AllowHeaders: []string{"session_id", "model_id", "chat_id", "Authorization", HeaderAPIKey, HeaderAccount, "traceparent", "tracestate"},
 	}))
 
 	r.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 	// r.Any("/debug/pprof/*action", gin.WrapF(pprof.Index))
 
+	var router interfaces.Router = r
+	if auth != nil {
+		router = newPolicyRouter(r, auth)
+	}
+
 	for _, c := range controllers {
-		c.RegisterRoutes(r)
+		c.RegisterRoutes(router)
 	}
 
 	if err := r.SetTrustedProxies(nil); err != nil {
@@ -1,8 +1,26 @@
 package interfaces
 
-import "github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/lib"
+import (
+	"context"
+
+	"github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/lib"
+)
 
 type PrKeyProvider interface {
 	GetPrivateKey() (lib.HexString, error)
 	PrivateKeyUpdated() <-chan struct{}
 }
+
+// CtxPrKeyProvider resolves the private key of the account selected with lib.WithAccount
+type CtxPrKeyProvider interface {
+	GetPrivateKeyCtx(ctx context.Context) (lib.HexString, error)
+}
+
+// GetPrivateKeyCtx returns the private key of the account selected in the context
+// if the provider supports accounts, otherwise the provider's private key
+func GetPrivateKeyCtx(ctx context.Context, p PrKeyProvider) (lib.HexString, error) {
+	if cp, ok := p.(CtxPrKeyProvider); ok {
+		return cp.GetPrivateKeyCtx(ctx)
+	}
+	return p.GetPrivateKey()
+}
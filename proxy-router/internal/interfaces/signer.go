@@ -0,0 +1,63 @@
+package interfaces
+
+import (
+	"context"
+	"math/big"
+
+	"github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/lib"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// Signer signs transactions and messages without exposing the private key of the account,
+// e.g. by delegating to an external signer such as Clef or web3signer
+type Signer interface {
+	Address(ctx context.Context) (common.Address, error)
+	SignTx(ctx context.Context, tx *types.Transaction, chainID *big.Int) (*types.Transaction, error)
+	// SignEthMessage returns the signature of msg compatible with lib.SignEthMessageV2
+	SignEthMessage(ctx context.Context, msg []byte) ([]byte, error)
+}
+
+// GetAddressCtx returns the address of the signer if the provider is a Signer,
+// otherwise the address of the private key of the account selected in the context
+func GetAddressCtx(ctx context.Context, p PrKeyProvider) (common.Address, error) {
+	if s, ok := p.(Signer); ok {
+		return s.Address(ctx)
+	}
+	prKey, err := GetPrivateKeyCtx(ctx, p)
+	if err != nil {
+		return common.Address{}, err
+	}
+	return lib.PrivKeyBytesToAddr(prKey)
+}
+
+// SignTxCtx signs the transaction with the signer if the provider is a Signer,
+// otherwise with the private key of the account selected in the context
+func SignTxCtx(ctx context.Context, p PrKeyProvider, tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+	if s, ok := p.(Signer); ok {
+		return s.SignTx(ctx, tx, chainID)
+	}
+	prKey, err := GetPrivateKeyCtx(ctx, p)
+	if err != nil {
+		return nil, err
+	}
+	privateKey, err := crypto.ToECDSA(prKey)
+	if err != nil {
+		return nil, err
+	}
+	return types.SignTx(tx, types.LatestSignerForChainID(chainID), privateKey)
+}
+
+// SignEthMessageCtx signs the message with the signer if the provider is a Signer,
+// otherwise with the private key of the account selected in the context
+func SignEthMessageCtx(ctx context.Context, p PrKeyProvider, msg []byte) ([]byte, error) {
+	if s, ok := p.(Signer); ok {
+		return s.SignEthMessage(ctx, msg)
+	}
+	prKey, err := GetPrivateKeyCtx(ctx, p)
+	if err != nil {
+		return nil, err
+	}
+	return lib.SignEthMessageV2(msg, prKey)
+}
@@ -9,3 +9,10 @@ type Wallet interface {
 	DeleteWallet() error
 	PrivateKeyUpdated() <-chan struct{}
 }
+
+// Accounts is a set of named wallets, lib.DefaultAccount is the wallet used before accounts were introduced
+type Accounts interface {
+	GetAccount(name string) (Wallet, error)
+	// ListAccounts returns the names of the accounts with a stored private key or mnemonic
+	ListAccounts() ([]string, error)
+}
@@ -0,0 +1,43 @@
+package lib
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+)
+
+const (
+	// DefaultAccount is the name of the wallet used before named accounts were introduced
+	DefaultAccount = "default"
+	// AccountKey is the gin context key of the account selected by the request
+	AccountKey = "account"
+)
+
+var (
+	ErrInvalidAccountName = fmt.Errorf("account name must be 1-32 lowercase letters, digits, '-' or '_'")
+	accountNameRegexp     = regexp.MustCompile(`^[a-z0-9_-]{1,32}$`)
+)
+
+type accountCtxKey struct{}
+
+// ValidateAccountName checks that the name can be used as an account name
+func ValidateAccountName(name string) error {
+	if !accountNameRegexp.MatchString(name) {
+		return WrapError(ErrInvalidAccountName, fmt.Errorf("%s", name))
+	}
+	return nil
+}
+
+// WithAccount selects the named account for the operations done with the returned context
+func WithAccount(ctx context.Context, name string) context.Context {
+	return context.WithValue(ctx, accountCtxKey{}, name)
+}
+
+// AccountFromContext returns the account selected by WithAccount or set on the gin context, or an empty string
+func AccountFromContext(ctx context.Context) string {
+	if name, ok := ctx.Value(accountCtxKey{}).(string); ok {
+		return name
+	}
+	name, _ := ctx.Value(AccountKey).(string)
+	return name
+}
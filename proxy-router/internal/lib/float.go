@@ -0,0 +1,33 @@
+package lib
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Float64 is a float config value which distinguishes an explicit 0 from an unset value
+type Float64 struct {
+	Float64 *float64
+}
+
+func (f *Float64) UnmarshalText(data []byte) error {
+	normalized := strings.TrimSpace(string(data))
+	if normalized == "" {
+		*f = Float64{Float64: nil}
+		return nil
+	}
+
+	val, err := strconv.ParseFloat(normalized, 64)
+	if err != nil {
+		return err
+	}
+	*f = Float64{Float64: &val}
+	return nil
+}
+
+func (f *Float64) String() string {
+	if f == nil || f.Float64 == nil {
+		return "nil"
+	}
+	return strconv.FormatFloat(*f.Float64, 'f', -1, 64)
+}
@@ -0,0 +1,23 @@
+package lib
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFloat64UnmarshalText(t *testing.T) {
+	var f Float64
+	require.NoError(t, f.UnmarshalText([]byte("")))
+	require.Nil(t, f.Float64)
+
+	// explicit zero is kept apart from the unset value
+	require.NoError(t, f.UnmarshalText([]byte(" 0 ")))
+	require.NotNil(t, f.Float64)
+	require.Equal(t, 0.0, *f.Float64)
+
+	require.NoError(t, f.UnmarshalText([]byte("0.25")))
+	require.Equal(t, 0.25, *f.Float64)
+
+	require.Error(t, f.UnmarshalText([]byte("abc")))
+}
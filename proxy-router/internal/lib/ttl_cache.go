@@ -0,0 +1,64 @@
+package lib
+
+import (
+	"sync"
+	"time"
+)
+
+type ttlCacheItem[V any] struct {
+	value     V
+	expiresAt time.Time
+}
+
+// TTLCache is a thread-safe map where records expire after the configured ttl
+type TTLCache[K comparable, V any] struct {
+	ttl   time.Duration
+	items map[K]ttlCacheItem[V]
+	now   func() time.Time
+	m     sync.RWMutex
+}
+
+func NewTTLCache[K comparable, V any](ttl time.Duration) *TTLCache[K, V] {
+	return &TTLCache[K, V]{
+		ttl:   ttl,
+		items: make(map[K]ttlCacheItem[V]),
+		now:   time.Now,
+	}
+}
+
+// Get returns the value if it is present and not expired
+func (c *TTLCache[K, V]) Get(key K) (V, bool) {
+	c.m.RLock()
+	item, ok := c.items[key]
+	c.m.RUnlock()
+
+	if !ok || c.now().After(item.expiresAt) {
+		return *new(V), false
+	}
+	return item.value, true
+}
+
+func (c *TTLCache[K, V]) Set(key K, value V) {
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	c.items[key] = ttlCacheItem[V]{value: value, expiresAt: c.now().Add(c.ttl)}
+	c.evictExpired()
+}
+
+func (c *TTLCache[K, V]) Delete(key K) {
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	delete(c.items, key)
+}
+
+// evictExpired removes expired records, must be called under the write lock
+func (c *TTLCache[K, V]) evictExpired() {
+	now := c.now()
+	for key, item := range c.items {
+		if now.After(item.expiresAt) {
+			delete(c.items, key)
+		}
+	}
+}
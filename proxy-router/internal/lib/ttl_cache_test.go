@@ -0,0 +1,39 @@
+package lib
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTTLCacheGetSet(t *testing.T) {
+	c := NewTTLCache[string, int](time.Minute)
+
+	_, ok := c.Get("a")
+	require.False(t, ok)
+
+	c.Set("a", 1)
+	val, ok := c.Get("a")
+	require.True(t, ok)
+	require.Equal(t, 1, val)
+
+	c.Delete("a")
+	_, ok = c.Get("a")
+	require.False(t, ok)
+}
+
+func TestTTLCacheExpiry(t *testing.T) {
+	now := time.Now()
+	c := NewTTLCache[string, int](time.Minute)
+	c.now = func() time.Time { return now }
+
+	c.Set("a", 1)
+
+	now = now.Add(2 * time.Minute)
+	_, ok := c.Get("a")
+	require.False(t, ok)
+
+	c.Set("b", 2)
+	require.Len(t, c.items, 1)
+}
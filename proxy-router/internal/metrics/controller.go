@@ -0,0 +1,33 @@
+package metrics
+
+import (
+	"github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/interfaces"
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+type MetricsController struct {
+	handler gin.HandlerFunc
+}
+
+func NewMetricsController() *MetricsController {
+	return &MetricsController{
+		handler: gin.WrapH(promhttp.HandlerFor(Registry, promhttp.HandlerOpts{})),
+	}
+}
+
+func (c *MetricsController) RegisterRoutes(r interfaces.Router) {
+	r.GET("/metrics", c.GetMetrics)
+}
+
+// GetMetrics godoc
+//
+//	@Summary		Get Metrics
+//	@Description	Node metrics in Prometheus exposition format
+//	@Tags			system
+//	@Produce		plain
+//	@Success		200	{string}	string
+//	@Router			/metrics [get]
+func (c *MetricsController) GetMetrics(ctx *gin.Context) {
+	c.handler(ctx)
+}
@@ -0,0 +1,174 @@
+package metrics
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+)
+
+const (
+	namespace = "morpheus"
+
+	RoleConsumer = "consumer"
+	RoleProvider = "provider"
+
+	ResultSuccess     = "success"
+	ResultError       = "error"
+	ResultRateLimited = "rate_limited"
+	ResultRPCError    = "rpc_error" // the endpoint replied with a json-rpc error
+
+	CacheHit  = "hit"
+	CacheMiss = "miss"
+)
+
+// Registry holds the node metrics, it is served on /metrics when metrics are enabled.
+// Metrics are recorded regardless, so the instrumented code doesn't depend on the config
+var Registry = prometheus.NewRegistry()
+
+// seconds, prompts take from under a second to minutes
+var promptBuckets = []float64{0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60, 120, 300}
+
+var (
+	PromptDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "prompt_duration_seconds",
+		Help:      "Duration of the prompt from the request till the last chunk",
+		Buckets:   promptBuckets,
+	}, []string{"role", "model", "provider"})
+
+	PromptTTFT = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "prompt_ttft_seconds",
+		Help:      "Time to the first token of the prompt response",
+		Buckets:   promptBuckets,
+	}, []string{"role", "model", "provider"})
+
+	PromptTokens = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "prompt_tokens_total",
+		Help:      "Number of tokens in the prompt responses",
+	}, []string{"role", "model", "provider"})
+
+	Prompts = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "prompts_total",
+		Help:      "Number of prompts by result",
+	}, []string{"role", "model", "provider", "result"})
+
+	Failovers = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "failovers_total",
+		Help:      "Number of sessions moved to another provider after the provider failed",
+	}, []string{"model", "provider"})
+
+	ResponseCache = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "response_cache_requests_total",
+		Help:      "Number of cacheable prompts by the provider response cache result",
+	}, []string{"model", "result"})
+
+	ModerationRefusals = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "moderation_refusals_total",
+		Help:      "Number of prompts and responses refused by the provider content policy",
+	}, []string{"model", "stage"})
+
+	CapacityRejections = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "capacity_rejections_total",
+		Help:      "Number of session requests rejected by the provider because of the model capacity",
+	}, []string{"model", "policy"})
+
+	RPCRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "rpc_requests_total",
+		Help:      "Number of eth node requests by endpoint and result",
+	}, []string{"endpoint", "result"})
+
+	RPCDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "rpc_request_duration_seconds",
+		Help:      "Duration of the eth node requests",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"endpoint"})
+
+	LogWatcherEvents = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "logwatcher_events_total",
+		Help:      "Number of contract events received by the log watcher",
+	}, []string{"watcher"})
+
+	LogWatcherErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "logwatcher_errors_total",
+		Help:      "Number of log watcher request or subscription errors",
+	}, []string{"watcher"})
+
+	LogWatcherReorgs = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "logwatcher_reorgs_total",
+		Help:      "Number of chain reorganizations detected by the log watcher",
+	})
+
+	LogWatcherBlock = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "logwatcher_block",
+		Help:      "Latest block processed by the log watcher",
+	})
+
+	Transactions = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "transactions_total",
+		Help:      "Number of transactions sent by the node by method and result",
+	}, []string{"method", "result"})
+
+	TransactionDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "transaction_duration_seconds",
+		Help:      "Duration of sending the transaction and waiting for it to be mined",
+		Buckets:   []float64{0.5, 1, 2.5, 5, 10, 30, 60, 120},
+	}, []string{"method"})
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		PromptDuration, PromptTTFT, PromptTokens, Prompts, Failovers, ResponseCache, ModerationRefusals, CapacityRejections,
+		RPCRequests, RPCDuration,
+		LogWatcherEvents, LogWatcherErrors, LogWatcherReorgs, LogWatcherBlock,
+		Transactions, TransactionDuration,
+	)
+}
+
+// ObservePrompt records the finished prompt, ttft and tokens are ignored for failed prompts
+func ObservePrompt(role, model, provider string, start time.Time, ttftMs int, tokens int, err error) {
+	if err != nil {
+		Prompts.WithLabelValues(role, model, provider, ResultError).Inc()
+		return
+	}
+	Prompts.WithLabelValues(role, model, provider, ResultSuccess).Inc()
+	PromptDuration.WithLabelValues(role, model, provider).Observe(time.Since(start).Seconds())
+	PromptTTFT.WithLabelValues(role, model, provider).Observe(float64(ttftMs) / 1000)
+	PromptTokens.WithLabelValues(role, model, provider).Add(float64(tokens))
+}
+
+// ObserveTx records the transaction sent by the BlockchainService
+func ObserveTx(method string, start time.Time, err error) {
+	TransactionDuration.WithLabelValues(method).Observe(time.Since(start).Seconds())
+	Transactions.WithLabelValues(method, result(err)).Inc()
+}
+
+// ObserveRPC records the eth node request, endpoint should not contain credentials
+func ObserveRPC(endpoint string, duration time.Duration, result string) {
+	RPCRequests.WithLabelValues(endpoint, result).Inc()
+	RPCDuration.WithLabelValues(endpoint).Observe(duration.Seconds())
+}
+
+func result(err error) string {
+	if err != nil {
+		return ResultError
+	}
+	return ResultSuccess
+}
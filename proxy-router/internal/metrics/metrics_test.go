@@ -0,0 +1,79 @@
+package metrics
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/lib"
+	"github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/storages"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/require"
+)
+
+type sessionsStub []storages.Session
+
+func (s sessionsStub) GetSessions() ([]storages.Session, error) {
+	return s, nil
+}
+
+type walletStub struct{ key lib.HexString }
+
+func (w walletStub) GetPrivateKey() (lib.HexString, error) { return w.key, nil }
+func (w walletStub) PrivateKeyUpdated() <-chan struct{}    { return nil }
+
+func TestObservePrompt(t *testing.T) {
+	ObservePrompt(RoleConsumer, "model-1", "0xprovider", time.Now(), 150, 42, nil)
+	ObservePrompt(RoleConsumer, "model-1", "0xprovider", time.Now(), 0, 0, errors.New("failed"))
+
+	require.Equal(t, 1.0, counterValue(t, Prompts.WithLabelValues(RoleConsumer, "model-1", "0xprovider", ResultSuccess)))
+	require.Equal(t, 1.0, counterValue(t, Prompts.WithLabelValues(RoleConsumer, "model-1", "0xprovider", ResultError)))
+	require.Equal(t, 42.0, counterValue(t, PromptTokens.WithLabelValues(RoleConsumer, "model-1", "0xprovider")))
+
+	families, err := Registry.Gather()
+	require.NoError(t, err)
+	names := make([]string, len(families))
+	for i, family := range families {
+		names[i] = family.GetName()
+	}
+	require.Contains(t, names, "morpheus_prompt_ttft_seconds")
+}
+
+func TestSessionsCollector(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	myAddr := crypto.PubkeyToAddress(key.PublicKey).Hex()
+	future, past := big.NewInt(time.Now().Add(time.Hour).Unix()), big.NewInt(time.Now().Add(-time.Hour).Unix())
+
+	sessions := sessionsStub{
+		{Id: "1", ModelID: "m", ProviderAddr: myAddr, EndsAt: future},
+		{Id: "2", ModelID: "m", ProviderAddr: "0x0000000000000000000000000000000000000001", EndsAt: future},
+		{Id: "3", ModelID: "m", ProviderAddr: "0x0000000000000000000000000000000000000001", EndsAt: future},
+		{Id: "4", ModelID: "m", ProviderAddr: myAddr, EndsAt: past},
+	}
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(NewSessionsCollector(sessions, walletStub{lib.HexString(crypto.FromECDSA(key))}))
+
+	families, err := registry.Gather()
+	require.NoError(t, err)
+	require.Len(t, families, 1)
+
+	counts := make(map[string]float64)
+	for _, m := range families[0].GetMetric() {
+		for _, label := range m.GetLabel() {
+			if label.GetName() == "role" {
+				counts[label.GetValue()] = m.GetGauge().GetValue()
+			}
+		}
+	}
+	require.Equal(t, map[string]float64{RoleProvider: 1, RoleConsumer: 2}, counts)
+}
+
+func counterValue(t *testing.T, c prometheus.Counter) float64 {
+	m := &dto.Metric{}
+	require.NoError(t, c.Write(m))
+	return m.GetCounter().GetValue()
+}
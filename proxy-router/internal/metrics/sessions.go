@@ -0,0 +1,70 @@
+package metrics
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	i "github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/interfaces"
+	"github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/storages"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+type SessionLister interface {
+	GetSessions() ([]storages.Session, error)
+}
+
+// sessionsCollector counts the not expired sessions stored by the node on each scrape
+type sessionsCollector struct {
+	sessions SessionLister
+	wallet   i.PrKeyProvider
+	desc     *prometheus.Desc
+}
+
+func NewSessionsCollector(sessions SessionLister, wallet i.PrKeyProvider) prometheus.Collector {
+	return &sessionsCollector{
+		sessions: sessions,
+		wallet:   wallet,
+		desc: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "", "active_sessions"),
+			"Number of not expired sessions, role is provider if the node wallet is the session provider",
+			[]string{"role", "model"}, nil,
+		),
+	}
+}
+
+func (c *sessionsCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.desc
+}
+
+func (c *sessionsCollector) Collect(ch chan<- prometheus.Metric) {
+	sessions, err := c.sessions.GetSessions()
+	if err != nil {
+		ch <- prometheus.NewInvalidMetric(c.desc, err)
+		return
+	}
+
+	var myAddr string
+	addr, err := i.GetAddressCtx(context.Background(), c.wallet)
+	if err == nil {
+		myAddr = addr.Hex()
+	}
+
+	type key struct{ role, model string }
+	counts := make(map[key]int)
+	now := time.Now().Unix()
+	for _, session := range sessions {
+		if session.EndsAt == nil || session.EndsAt.Int64() <= now {
+			continue
+		}
+		role := RoleConsumer
+		if myAddr != "" && strings.EqualFold(session.ProviderAddr, myAddr) {
+			role = RoleProvider
+		}
+		counts[key{role, session.ModelID}]++
+	}
+
+	for k, count := range counts {
+		ch <- prometheus.MustNewConstMetric(c.desc, prometheus.GaugeValue, float64(count), k.role, k.model)
+	}
+}
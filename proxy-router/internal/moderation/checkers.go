@@ -0,0 +1,150 @@
+package moderation
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/aiengine"
+	gcs "github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/chatstorage/genericchatstorage"
+	"github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/config"
+	"github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/lib"
+	"github.com/sashabaranov/go-openai"
+)
+
+const (
+	RuleMaxInputLength = "max_input_length"
+	RuleKeyword        = "keyword"
+	RuleClassifier     = "classifier"
+
+	classifierFlaggedPatternDefault = `(?i)^\s*unsafe`
+)
+
+// LengthChecker refuses prompts longer than the max number of characters
+type LengthChecker struct {
+	maxLength int
+}
+
+func NewLengthChecker(maxLength int) *LengthChecker {
+	return &LengthChecker{maxLength: maxLength}
+}
+
+func (c *LengthChecker) Check(_ context.Context, stage Stage, text string) (string, error) {
+	if stage == StageInput && utf8.RuneCountInString(text) > c.maxLength {
+		return RuleMaxInputLength, nil
+	}
+	return "", nil
+}
+
+// RuleChecker refuses the content matching a pattern or containing a keyword
+type RuleChecker struct {
+	patterns []*regexp.Regexp
+	keywords *regexp.Regexp
+}
+
+// NewRuleChecker returns nil if there are no rules
+func NewRuleChecker(patterns []string, keywords []string) (*RuleChecker, error) {
+	if len(patterns) == 0 && len(keywords) == 0 {
+		return nil, nil
+	}
+
+	c := &RuleChecker{}
+	for _, pattern := range patterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("pattern %q: %w", pattern, err)
+		}
+		c.patterns = append(c.patterns, re)
+	}
+
+	if len(keywords) > 0 {
+		quoted := make([]string, len(keywords))
+		for i, keyword := range keywords {
+			quoted[i] = regexp.QuoteMeta(keyword)
+		}
+		// keywords match whole words, \b isn't used as it fails next to symbols, e.g. "c++"
+		c.keywords = regexp.MustCompile(`(?i)(?:^|[^\pL\pN_])(?:` + strings.Join(quoted, "|") + `)(?:[^\pL\pN_]|$)`)
+	}
+	return c, nil
+}
+
+func (c *RuleChecker) Check(_ context.Context, _ Stage, text string) (string, error) {
+	// the rule names don't contain the patterns, so the logs don't reveal the content
+	for i, re := range c.patterns {
+		if re.MatchString(text) {
+			return fmt.Sprintf("pattern %d", i), nil
+		}
+	}
+	if c.keywords != nil && c.keywords.MatchString(text) {
+		return RuleKeyword, nil
+	}
+	return "", nil
+}
+
+// ClassifierChecker asks a classifier model about the content
+type ClassifierChecker struct {
+	model   aiengine.AIEngineStream
+	flagged *regexp.Regexp
+}
+
+func NewClassifierChecker(cfg *config.ModerationClassifierConfig, log lib.ILogger) (*ClassifierChecker, error) {
+	if cfg.ApiURL == "" {
+		return nil, fmt.Errorf("classifier apiUrl is required")
+	}
+
+	pattern := cfg.FlaggedPattern
+	if pattern == "" {
+		pattern = classifierFlaggedPatternDefault
+	}
+	flagged, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("classifier flaggedPattern %q: %w", pattern, err)
+	}
+
+	return &ClassifierChecker{
+		model:   aiengine.NewOpenAIEngine(cfg.ModelName, cfg.ApiURL, cfg.ApiKey, log.Named("MODERATION")),
+		flagged: flagged,
+	}, nil
+}
+
+func (c *ClassifierChecker) Check(ctx context.Context, stage Stage, text string) (string, error) {
+	role := openai.ChatMessageRoleUser
+	if stage == StageOutput {
+		role = openai.ChatMessageRoleAssistant
+	}
+	req := &openai.ChatCompletionRequest{
+		Messages: []openai.ChatCompletionMessage{{Role: role, Content: text}},
+	}
+
+	var answer strings.Builder
+	err := c.model.Prompt(ctx, req, func(_ context.Context, chunk gcs.Chunk) error {
+		answer.WriteString(chunk.String())
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+
+	if c.flagged.MatchString(answer.String()) {
+		return RuleClassifier, nil
+	}
+	return "", nil
+}
+
+// PromptText returns the text of the prompt messages, which is checked before the inference
+func PromptText(req *openai.ChatCompletionRequest) string {
+	var parts []string
+	for _, message := range req.Messages {
+		if message.Content != "" {
+			parts = append(parts, message.Content)
+		}
+		for _, part := range message.MultiContent {
+			if part.Type == openai.ChatMessagePartTypeText {
+				parts = append(parts, part.Text)
+			}
+		}
+	}
+	return strings.Join(parts, "\n")
+}
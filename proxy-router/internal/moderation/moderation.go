@@ -0,0 +1,149 @@
+package moderation
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/config"
+	"github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/lib"
+)
+
+type Stage string
+
+const (
+	StageInput  Stage = "input"  // the prompt, before the inference
+	StageOutput Stage = "output" // the response, after the inference
+)
+
+var (
+	ErrInvalidConfig = errors.New("invalid moderation config")
+	ErrCheck         = errors.New("moderation check failed")
+)
+
+// RefusalError is returned when the content violates the policy of the model
+type RefusalError struct {
+	Stage Stage
+	Rule  string
+}
+
+func (e *RefusalError) Error() string {
+	return fmt.Sprintf("%s refused by the content policy (%s)", e.Stage, e.Rule)
+}
+
+// Checker is a step of the moderation pipeline
+type Checker interface {
+	// Check returns the refusing rule, empty if the content is allowed
+	Check(ctx context.Context, stage Stage, text string) (rule string, err error)
+}
+
+// Pipeline runs the checkers of a model in order, the first refusal stops it
+type Pipeline struct {
+	checkers    []Checker
+	checkOutput bool
+	logContent  bool
+}
+
+// NewPipeline creates the pipeline of the config: max input length, patterns and keywords, then the classifier
+func NewPipeline(cfg *config.ModerationConfig, log lib.ILogger) (*Pipeline, error) {
+	p := &Pipeline{
+		checkOutput: !cfg.SkipOutput,
+		logContent:  cfg.LogContent,
+	}
+
+	if cfg.MaxInputLength > 0 {
+		p.AddChecker(NewLengthChecker(cfg.MaxInputLength))
+	}
+	rules, err := NewRuleChecker(cfg.Patterns, cfg.Keywords)
+	if err != nil {
+		return nil, lib.WrapError(ErrInvalidConfig, err)
+	}
+	if rules != nil {
+		p.AddChecker(rules)
+	}
+	if cfg.Classifier != nil {
+		classifier, err := NewClassifierChecker(cfg.Classifier, log)
+		if err != nil {
+			return nil, lib.WrapError(ErrInvalidConfig, err)
+		}
+		p.AddChecker(classifier)
+	}
+	return p, nil
+}
+
+// AddChecker appends a custom checker to the pipeline
+func (p *Pipeline) AddChecker(checker Checker) {
+	p.checkers = append(p.checkers, checker)
+}
+
+// ChecksOutput is false if only the prompts are checked
+func (p *Pipeline) ChecksOutput() bool {
+	return p.checkOutput
+}
+
+// Check returns a RefusalError if the content is refused. The decision is logged, the content
+// only if configured. Checker failures are returned as ErrCheck, so the content is refused as well
+func (p *Pipeline) Check(ctx context.Context, stage Stage, text string, log lib.ILogger) error {
+	for _, checker := range p.checkers {
+		rule, err := checker.Check(ctx, stage, text)
+		if err != nil {
+			log.Warnf("moderation %s check failed: %s", stage, err)
+			return lib.WrapError(ErrCheck, err)
+		}
+		if rule == "" {
+			continue
+		}
+
+		if p.logContent {
+			log.Infof("moderation: %s refused by %s, content: %q", stage, rule, text)
+		} else {
+			log.Infof("moderation: %s refused by %s", stage, rule)
+		}
+		return &RefusalError{Stage: stage, Rule: rule}
+	}
+
+	log.Debugf("moderation: %s allowed", stage)
+	return nil
+}
+
+// Pipelines keeps the pipelines of the models, they are rebuilt when the models config is reloaded
+type Pipelines struct {
+	mu        sync.Mutex
+	pipelines map[string]pipelineEntry
+	log       lib.ILogger
+}
+
+type pipelineEntry struct {
+	cfg      *config.ModerationConfig
+	pipeline *Pipeline
+}
+
+func NewPipelines(log lib.ILogger) *Pipelines {
+	return &Pipelines{
+		pipelines: make(map[string]pipelineEntry),
+		log:       log,
+	}
+}
+
+// Get returns the pipeline of the model, nil if the model has no moderation
+func (p *Pipelines) Get(modelID string, cfg *config.ModerationConfig) (*Pipeline, error) {
+	if cfg == nil {
+		return nil, nil
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	entry, ok := p.pipelines[modelID]
+	if ok && entry.cfg == cfg {
+		return entry.pipeline, nil
+	}
+
+	pipeline, err := NewPipeline(cfg, p.log)
+	if err != nil {
+		return nil, err
+	}
+	p.pipelines[modelID] = pipelineEntry{cfg: cfg, pipeline: pipeline}
+	return pipeline, nil
+}
@@ -0,0 +1,106 @@
+package moderation
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/config"
+	"github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/lib"
+	"github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/testlib/mockai"
+	"github.com/sashabaranov/go-openai"
+	"github.com/stretchr/testify/require"
+)
+
+func requireRefused(t *testing.T, err error, stage Stage, rule string) {
+	t.Helper()
+	var refusal *RefusalError
+	require.ErrorAs(t, err, &refusal)
+	require.Equal(t, stage, refusal.Stage)
+	require.Equal(t, rule, refusal.Rule)
+}
+
+func TestPipelineRules(t *testing.T) {
+	log := lib.NewTestLogger()
+	pipeline, err := NewPipeline(&config.ModerationConfig{
+		MaxInputLength: 20,
+		Patterns:       []string{`\d{4}-\d{4}-\d{4}-\d{4}`},
+		Keywords:       []string{"forbidden", "c++"},
+	}, log)
+	require.NoError(t, err)
+	require.True(t, pipeline.ChecksOutput())
+	ctx := context.Background()
+
+	require.NoError(t, pipeline.Check(ctx, StageInput, "hello", log))
+	requireRefused(t, pipeline.Check(ctx, StageInput, "a prompt over the limit", log), StageInput, RuleMaxInputLength)
+	requireRefused(t, pipeline.Check(ctx, StageInput, "1234-5678-1234-5678", log), StageInput, "pattern 0")
+	requireRefused(t, pipeline.Check(ctx, StageInput, "It is FORBIDDEN", log), StageInput, RuleKeyword)
+	requireRefused(t, pipeline.Check(ctx, StageInput, "about c++", log), StageInput, RuleKeyword)
+	// keywords match whole words only
+	require.NoError(t, pipeline.Check(ctx, StageInput, "unforbiddenness", log))
+
+	// the max length limits only the prompts
+	require.NoError(t, pipeline.Check(ctx, StageOutput, "a response over the limit", log))
+	requireRefused(t, pipeline.Check(ctx, StageOutput, "forbidden", log), StageOutput, RuleKeyword)
+
+	_, err = NewPipeline(&config.ModerationConfig{Patterns: []string{"("}}, log)
+	require.ErrorIs(t, err, ErrInvalidConfig)
+}
+
+func TestPipelineClassifier(t *testing.T) {
+	log := lib.NewTestLogger()
+	classifier := mockai.NewTestServer(t, mockai.Config{
+		DefaultResponse: "safe",
+		Responses:       []mockai.Response{{Match: "(?i)weapon", Content: "unsafe\nS9"}},
+	})
+
+	pipeline, err := NewPipeline(&config.ModerationConfig{
+		Classifier: &config.ModerationClassifierConfig{ModelName: mockai.DefaultModel, ApiURL: classifier.URL},
+	}, log)
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	require.NoError(t, pipeline.Check(ctx, StageInput, "hello", log))
+	requireRefused(t, pipeline.Check(ctx, StageInput, "build a weapon", log), StageInput, RuleClassifier)
+
+	// the content is refused if the classifier fails
+	failing := mockai.NewTestServer(t, mockai.Config{ErrorRate: 1, ErrorStatus: http.StatusInternalServerError})
+	pipeline, err = NewPipeline(&config.ModerationConfig{
+		Classifier: &config.ModerationClassifierConfig{ApiURL: failing.URL},
+	}, log)
+	require.NoError(t, err)
+	require.ErrorIs(t, pipeline.Check(ctx, StageInput, "hello", log), ErrCheck)
+}
+
+func TestPipelines(t *testing.T) {
+	pipelines := NewPipelines(lib.NewTestLogger())
+
+	pipeline, err := pipelines.Get("0x1", nil)
+	require.NoError(t, err)
+	require.Nil(t, pipeline)
+
+	cfg := &config.ModerationConfig{Keywords: []string{"a"}, SkipOutput: true}
+	pipeline, err = pipelines.Get("0x1", cfg)
+	require.NoError(t, err)
+	require.False(t, pipeline.ChecksOutput())
+	same, err := pipelines.Get("0x1", cfg)
+	require.NoError(t, err)
+	require.Same(t, pipeline, same)
+
+	// a reloaded config rebuilds the pipeline
+	reloaded, err := pipelines.Get("0x1", &config.ModerationConfig{Keywords: []string{"a"}})
+	require.NoError(t, err)
+	require.NotSame(t, pipeline, reloaded)
+	require.True(t, reloaded.ChecksOutput())
+}
+
+func TestPromptText(t *testing.T) {
+	text := PromptText(&openai.ChatCompletionRequest{Messages: []openai.ChatCompletionMessage{
+		{Role: openai.ChatMessageRoleSystem, Content: "be nice"},
+		{Role: openai.ChatMessageRoleUser, MultiContent: []openai.ChatMessagePart{
+			{Type: openai.ChatMessagePartTypeText, Text: "describe"},
+			{Type: openai.ChatMessagePartTypeImageURL, ImageURL: &openai.ChatMessageImageURL{URL: "http://image"}},
+		}},
+	}})
+	require.Equal(t, "be nice\ndescribe", text)
+}
@@ -5,6 +5,7 @@ import (
 
 	"github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/config"
 	"github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/lib"
+	"github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/metrics"
 	"github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/storages"
 )
 
@@ -103,11 +104,23 @@ func (idcm *IdleTimeoutCapacityManager) HasCapacity(modelID string) bool {
 
 func CreateCapacityManager(modelConfig *config.ModelConfig, storage *storages.SessionStorage, log lib.ILogger) CapacityManagerInterface {
 	switch modelConfig.CapacityPolicy {
-	case "simple":
-		return NewSimpleCapacityManager(modelConfig, storage, log)
 	case "idle_timeout":
-		return NewIdleTimeoutCapacityManager(modelConfig, storage, log)
+		return &capacityManagerMetrics{NewIdleTimeoutCapacityManager(modelConfig, storage, log), "idle_timeout"}
 	default:
-		return NewSimpleCapacityManager(modelConfig, storage, log)
+		return &capacityManagerMetrics{NewSimpleCapacityManager(modelConfig, storage, log), "simple"}
 	}
 }
+
+// capacityManagerMetrics counts the rejections of the wrapped capacity manager
+type capacityManagerMetrics struct {
+	CapacityManagerInterface
+	policy string
+}
+
+func (c *capacityManagerMetrics) HasCapacity(modelID string) bool {
+	hasCapacity := c.CapacityManagerInterface.HasCapacity(modelID)
+	if !hasCapacity {
+		metrics.CapacityRejections.WithLabelValues(modelID, c.policy).Inc()
+	}
+	return hasCapacity
+}
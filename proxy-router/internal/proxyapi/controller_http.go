@@ -3,8 +3,10 @@ package proxyapi
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
+	"slices"
 
 	constants "github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal"
 	"github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/aiengine"
@@ -12,14 +14,17 @@ import (
 	"github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/chatstorage/genericchatstorage"
 	"github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/interfaces"
 	"github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/lib"
+	msgs "github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/proxyapi/morrpcmessage"
+	"github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/tracing"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/gin-gonic/gin"
+	"github.com/santhosh-tekuri/jsonschema/v5"
 	"github.com/sashabaranov/go-openai"
 )
 
 type AIEngine interface {
 	GetLocalModels() ([]aiengine.LocalModel, error)
-	GetAdapter(ctx context.Context, chatID, modelID, sessionID common.Hash, storeContext, forwardContext bool) (aiengine.AIEngineStream, error)
+	GetAdapter(ctx context.Context, chatID, modelID, sessionID common.Hash, storeContext, forwardContext bool, schema *aiengine.ResponseSchema) (aiengine.AIEngineStream, error)
 }
 
 type ProxyController struct {
@@ -28,6 +33,8 @@ type ProxyController struct {
 	chatStorage        genericchatstorage.ChatStorageInterface
 	storeChatContext   bool
 	forwardChatContext bool
+	openAIStrict       bool
+	schemaRetries      int
 	log                lib.ILogger
 }
 
@@ -44,6 +51,18 @@ func NewProxyController(service *ProxyServiceSender, aiEngine AIEngine, chatStor
 	return c
 }
 
+// SetOpenAIStrict makes /v1/chat/completions reply exactly like the OpenAI API: upstream chunks are passed
+// through as is, the stream ends with [DONE], node control messages are omitted and errors use the OpenAI format
+func (s *ProxyController) SetOpenAIStrict(strict bool) {
+	s.openAIStrict = strict
+}
+
+// SetResponseSchemaRetries sets how many times a remote prompt is repeated on the same session
+// if the response doesn't match the requested json schema
+func (s *ProxyController) SetResponseSchemaRetries(retries int) {
+	s.schemaRetries = retries
+}
+
 func (s *ProxyController) RegisterRoutes(r interfaces.Router) {
 	r.POST("/proxy/provider/ping", s.Ping)
 	r.POST("/proxy/sessions/initiate", s.InitiateSession)
@@ -87,7 +106,7 @@ func (s *ProxyController) Ping(ctx *gin.Context) {
 //	@Tags			chat
 //	@Produce		json
 //	@Param			initiateSession	body		proxyapi.InitiateSessionReq	true	"Initiate Session"
-//	@Success		200				{object}	morrpcmesssage.SessionRes
+//	@Success		200				{object}	proxyapi.InitiateSessionRes
 //	@Router			/proxy/sessions/initiate [post]
 func (s *ProxyController) InitiateSession(ctx *gin.Context) {
 	var req *InitiateSessionReq
@@ -125,27 +144,55 @@ func (c *ProxyController) Prompt(ctx *gin.Context) {
 	)
 
 	if err := ctx.ShouldBindHeader(&head); err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.promptError(ctx, http.StatusBadRequest, err)
 		return
 	}
-	if err := ctx.ShouldBindJSON(&body); err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	data, err := ctx.GetRawData()
+	if err != nil {
+		c.promptError(ctx, http.StatusBadRequest, err)
 		return
 	}
+	zeroTemperature, err := decodePromptRequest(data, &body)
+	if err != nil {
+		c.promptError(ctx, http.StatusBadRequest, err)
+		return
+	}
+	promptCtx := tracing.Context(ctx)
+	if zeroTemperature {
+		promptCtx = aiengine.WithZeroTemperature(promptCtx)
+	}
 
 	chatID := head.ChatID
 	if chatID == (lib.Hash{}) {
 		var err error
 		chatID, err = lib.GetRandomHash()
 		if err != nil {
-			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			c.promptError(ctx, http.StatusInternalServerError, err)
+			return
+		}
+	}
+
+	var schema *aiengine.ResponseSchema
+	if head.SessionID.Hash != (common.Hash{}) {
+		// whether the provider honors the schema depends on its backend, so the response is validated here
+		compiled, err := aiengine.CompileResponseSchema(body.ResponseFormat)
+		if err != nil {
+			c.promptError(ctx, http.StatusBadRequest, err)
 			return
 		}
+		if compiled != nil {
+			schema = c.responseSchema(ctx, head.SessionID.Hash, compiled)
+		}
 	}
 
-	adapter, err := c.aiEngine.GetAdapter(ctx, chatID.Hash, head.ModelID.Hash, head.SessionID.Hash, c.storeChatContext, c.forwardChatContext)
+	adapter, err := c.aiEngine.GetAdapter(ctx, chatID.Hash, head.ModelID.Hash, head.SessionID.Hash, c.storeChatContext, c.forwardChatContext, schema)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.promptError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+
+	if c.openAIStrict {
+		c.promptStrict(ctx, promptCtx, adapter, &body)
 		return
 	}
 
@@ -158,7 +205,7 @@ func (c *ProxyController) Prompt(ctx *gin.Context) {
 
 	ctx.Writer.Header().Set(constants.HEADER_CONTENT_TYPE, contentType)
 
-	err = adapter.Prompt(ctx, &body, func(cbctx context.Context, completion genericchatstorage.Chunk) error {
+	err = adapter.Prompt(promptCtx, &body, func(cbctx context.Context, completion genericchatstorage.Chunk) error {
 		marshalledResponse, err := json.Marshal(completion.Data())
 		if err != nil {
 			return err
@@ -175,11 +222,122 @@ func (c *ProxyController) Prompt(ctx *gin.Context) {
 
 	if err != nil {
 		c.log.Errorf("error sending prompt: %s", err)
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		ctx.JSON(promptErrorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 }
 
+// promptStrict writes the completion in the OpenAI format, the response is buffered for non-streaming requests
+// to reply with the proper status on error
+func (c *ProxyController) promptStrict(ctx *gin.Context, promptCtx context.Context, adapter aiengine.AIEngineStream, body *openai.ChatCompletionRequest) {
+	streamStarted := false
+	var completion []byte
+
+	err := adapter.Prompt(promptCtx, body, func(cbctx context.Context, chunk genericchatstorage.Chunk) error {
+		if chunk.Type() == genericchatstorage.ChunkTypeControl {
+			// node messages, e.g. about the failover, are not part of the OpenAI stream
+			c.log.Infof("prompt control message: %s", chunk.Data())
+			return nil
+		}
+
+		data, err := genericchatstorage.MarshalChunk(chunk)
+		if err != nil {
+			return err
+		}
+		if !body.Stream {
+			completion = data
+			return nil
+		}
+
+		if !streamStarted {
+			setEventStreamHeaders(ctx)
+			streamStarted = true
+		}
+		return writeEvent(ctx, data)
+	})
+	if err != nil {
+		c.log.Errorf("error sending prompt: %s", err)
+		if !streamStarted {
+			c.promptError(ctx, promptErrorStatus(err), err)
+			return
+		}
+		// the status is already sent, the error is reported in the stream like OpenAI does
+		data, _ := json.Marshal(newOpenAIErrorRes(err, "server_error"))
+		_ = writeEvent(ctx, data)
+		return
+	}
+
+	if !body.Stream {
+		if completion == nil {
+			c.promptError(ctx, http.StatusBadGateway, ErrEmpty)
+			return
+		}
+		ctx.Data(http.StatusOK, constants.CONTENT_TYPE_JSON, completion)
+		return
+	}
+
+	if !streamStarted {
+		setEventStreamHeaders(ctx)
+	}
+	_ = writeEvent(ctx, []byte(sseDone))
+}
+
+// promptErrorStatus is bad request for the prompts refused by the provider content policy
+// and bad gateway for the responses not matching the requested schema
+func promptErrorStatus(err error) int {
+	if errors.Is(err, ErrContentRefused) {
+		return http.StatusBadRequest
+	}
+	if errors.Is(err, aiengine.ErrResponseSchema) {
+		return http.StatusBadGateway
+	}
+	return http.StatusInternalServerError
+}
+
+// responseSchema validates and retries the responses of the providers advertising the schema support.
+// Retrying the others is unlikely to help, so their response is validated once after a warning
+func (c *ProxyController) responseSchema(ctx context.Context, sessionID common.Hash, compiled *jsonschema.Schema) *aiengine.ResponseSchema {
+	capabilities, err := c.service.SessionCapabilities(ctx, sessionID)
+	if err != nil {
+		c.log.Warnf("failed to get session capabilities: %s", err)
+	}
+	if slices.Contains(capabilities, msgs.CapabilityJSONSchema) {
+		return &aiengine.ResponseSchema{Schema: compiled, Retries: c.schemaRetries}
+	}
+	return &aiengine.ResponseSchema{
+		Schema:  compiled,
+		Warning: fmt.Sprintf("the provider doesn't advertise the %s support, the response may not match the schema", msgs.CapabilityJSONSchema),
+	}
+}
+
+func (c *ProxyController) promptError(ctx *gin.Context, status int, err error) {
+	if !c.openAIStrict {
+		ctx.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+	errType := "server_error"
+	if status < http.StatusInternalServerError {
+		errType = "invalid_request_error"
+	}
+	ctx.JSON(status, newOpenAIErrorRes(err, errType))
+}
+
+func setEventStreamHeaders(ctx *gin.Context) {
+	ctx.Writer.Header().Set(constants.HEADER_CONTENT_TYPE, constants.CONTENT_TYPE_EVENT_STREAM)
+	ctx.Writer.Header().Set(constants.HEADER_CACHE_CONTROL, constants.CACHE_CONTROL_NO_CACHE)
+	ctx.Writer.Header().Set(constants.HEADER_CONNECTION, constants.CONNECTION_KEEP_ALIVE)
+	ctx.Status(http.StatusOK)
+}
+
+func writeEvent(ctx *gin.Context, data []byte) error {
+	_, err := fmt.Fprintf(ctx.Writer, "data: %s\n\n", data)
+	if err != nil {
+		return err
+	}
+	ctx.Writer.Flush()
+	return nil
+}
+
 // GetLocalModels godoc
 //
 //	@Summary	Get local models
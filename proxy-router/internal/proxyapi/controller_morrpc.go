@@ -3,15 +3,20 @@ package proxyapi
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"time"
 
 	"github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/lib"
+	"github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/moderation"
 	m "github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/proxyapi/morrpcmessage"
 	msg "github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/proxyapi/morrpcmessage"
 	sessionrepo "github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/repositories/session"
 	"github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/storages"
+	"github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/tracing"
 	"github.com/go-playground/validator/v10"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 type MORRPCController struct {
@@ -154,8 +159,23 @@ func (s *MORRPCController) sessionPrompt(ctx context.Context, msg m.RPCMessage,
 		return err
 	}
 
+	ctx, span := tracing.Start(tracing.WithTraceParent(ctx, msg.TraceParent), "morrpc session.prompt", trace.SpanKindServer,
+		attribute.String("session.id", req.SessionID.Hex()),
+		attribute.String("model.id", session.ModelID().Hex()),
+	)
+
 	now := time.Now().Unix()
 	ttftMs, totalTokens, err := s.service.SessionPrompt(ctx, msg.ID, user.PubKey, &req, sendResponse, sourceLog)
+	tracing.End(span, err)
+	var refusal *moderation.RefusalError
+	if errors.As(err, &refusal) {
+		// the refusal is sent as a typed error, so the consumer can tell it from a failed provider
+		res, err := s.morRpc.ContentRefusedError(refusal.Error(), s.prKey, msg.ID)
+		if err != nil {
+			return err
+		}
+		return sendResponse(res)
+	}
 	if err != nil {
 		sourceLog.Error(err)
 		return err
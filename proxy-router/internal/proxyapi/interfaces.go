@@ -22,3 +22,9 @@ type SessionService interface {
 	OpenSessionByModelId(ctx context.Context, modelID common.Hash, duration *big.Int, isDirectPayment, isFailoverEnabled bool, omitProvider common.Address) (common.Hash, error)
 	CloseSession(ctx context.Context, sessionID common.Hash) (common.Hash, error)
 }
+
+// VirtualSessions maps the stable session IDs of auto-renewed sessions to their current sessions
+type VirtualSessions interface {
+	Resolve(id common.Hash) (common.Hash, bool)
+	Replace(id common.Hash, sessionID common.Hash) error
+}
@@ -12,6 +12,17 @@ import (
 
 type MORRPCMessage struct{}
 
+// EthMessageSigner signs the messages verified on-chain, such as session approvals and reports,
+// the signature must be compatible with lib.SignEthMessageV2
+type EthMessageSigner func(msg []byte) ([]byte, error)
+
+// KeyEthMessageSigner signs the messages with the private key
+func KeyEthMessageSigner(privateKeyHex lib.HexString) EthMessageSigner {
+	return func(msg []byte) ([]byte, error) {
+		return lib.SignEthMessageV2(msg, privateKeyHex)
+	}
+}
+
 func NewMorRpc() *MORRPCMessage {
 	return &MORRPCMessage{}
 }
@@ -42,14 +53,14 @@ func (m *MORRPCMessage) PongResponce(requestId string, providerPrKey lib.HexStri
 	}, nil
 }
 
-func (m *MORRPCMessage) InitiateSessionResponse(providerPubKey lib.HexString, userAddr common.Address, bidID common.Hash, providerPrivateKeyHex lib.HexString, requestID string, chainID *big.Int) (*RpcResponse, error) {
+func (m *MORRPCMessage) InitiateSessionResponse(providerPubKey lib.HexString, userAddr common.Address, bidID common.Hash, providerPrivateKeyHex lib.HexString, signApproval EthMessageSigner, requestID string, chainID *big.Int) (*RpcResponse, error) {
 	timestamp := m.generateTimestamp()
 
 	approval, err := lib.EncodeAbiParameters(approvalAbi, []interface{}{bidID, chainID, userAddr, big.NewInt(int64(timestamp))})
 	if err != nil {
 		return &RpcResponse{}, err
 	}
-	approvalSig, err := lib.SignEthMessageV2(approval, providerPrivateKeyHex)
+	approvalSig, err := signApproval(approval)
 	if err != nil {
 		return &RpcResponse{}, err
 	}
@@ -82,7 +93,7 @@ func (m *MORRPCMessage) InitiateSessionResponse(providerPubKey lib.HexString, us
 	}, nil
 }
 
-func (m *MORRPCMessage) SessionReportResponse(tps uint32, ttfp uint32, sessionID common.Hash, providerPrivateKeyHex lib.HexString, requestID string, chainID *big.Int) (*RpcResponse, error) {
+func (m *MORRPCMessage) SessionReportResponse(tps uint32, ttfp uint32, sessionID common.Hash, providerPrivateKeyHex lib.HexString, signReport EthMessageSigner, requestID string, chainID *big.Int) (*RpcResponse, error) {
 	timestamp := m.generateTimestamp()
 
 	report, err := lib.EncodeAbiParameters(sessionReportAbi, []interface{}{sessionID, chainID, big.NewInt(int64(timestamp)), tps, ttfp})
@@ -90,7 +101,7 @@ func (m *MORRPCMessage) SessionReportResponse(tps uint32, ttfp uint32, sessionID
 		return &RpcResponse{}, err
 	}
 
-	signedReport, err := lib.SignEthMessageV2(report, providerPrivateKeyHex)
+	signedReport, err := signReport(report)
 	if err != nil {
 		return &RpcResponse{}, err
 	}
@@ -180,10 +191,20 @@ func (m *MORRPCMessage) SessionReport(sessionID string, start uint, end uint, pr
 
 // ERRORS
 
+const (
+	ErrorCodeDefault = 400
+	// ErrorCodeContentRefused is sent when the content violates the policy of the provider
+	ErrorCodeContentRefused = 451
+)
+
 func (m *MORRPCMessage) ResponseError(message string, privateKeyHex lib.HexString, requestId string) (*RpcResponse, error) {
+	return m.responseError(message, ErrorCodeDefault, privateKeyHex, requestId)
+}
+
+func (m *MORRPCMessage) responseError(message string, code int, privateKeyHex lib.HexString, requestId string) (*RpcResponse, error) {
 	params2 := RpcError{
 		Message: message,
-		Code:    400,
+		Code:    code,
 		Data: RPCErrorData{
 			Timestamp: m.generateTimestamp(),
 		},
@@ -217,6 +238,10 @@ func (m *MORRPCMessage) SpendLimitError(privateKeyHex lib.HexString, requestId s
 	return m.ResponseError("Over spend limit", privateKeyHex, requestId)
 }
 
+func (m *MORRPCMessage) ContentRefusedError(reason string, privateKeyHex lib.HexString, requestId string) (*RpcResponse, error) {
+	return m.responseError(reason, ErrorCodeContentRefused, privateKeyHex, requestId)
+}
+
 // REQUESTS
 
 func (m *MORRPCMessage) PingRequest(requestId string, userPrivateKeyHex lib.HexString, nonce lib.HexString) (*RPCMessage, error) {
@@ -272,7 +297,7 @@ func (m *MORRPCMessage) InitiateSessionRequest(user common.Address, provider com
 	}, nil
 }
 
-func (m *MORRPCMessage) SessionPromptRequest(sessionID common.Hash, prompt interface{}, providerPubKey lib.HexString, userPrivateKeyHex lib.HexString, requestId string) (*RPCMessage, error) {
+func (m *MORRPCMessage) SessionPromptRequest(sessionID common.Hash, prompt interface{}, providerPubKey lib.HexString, userPrivateKeyHex lib.HexString, requestId string, traceParent string) (*RPCMessage, error) {
 	method := "session.prompt"
 	promptStr, err := json.Marshal(prompt)
 	if err != nil {
@@ -294,9 +319,10 @@ func (m *MORRPCMessage) SessionPromptRequest(sessionID common.Hash, prompt inter
 		return &RPCMessage{}, err
 	}
 	return &RPCMessage{
-		ID:     requestId,
-		Method: method,
-		Params: serializedParams,
+		ID:          requestId,
+		Method:      method,
+		Params:      serializedParams,
+		TraceParent: traceParent,
 	}, nil
 }
 
@@ -2,10 +2,13 @@ package morrpcmesssage
 
 import (
 	"encoding/hex"
+	"encoding/json"
 	"fmt"
+	"strings"
 	"testing"
 
 	"github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/lib"
+	"github.com/ethereum/go-ethereum/common"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -86,3 +89,52 @@ func TestMorRpc_generate(t *testing.T) {
 	hexSignature := hex.EncodeToString([]byte(signature))
 	fmt.Println(hexSignature)
 }
+
+func TestMorRpc_sessionPromptRequest_traceParent(t *testing.T) {
+	m := NewMorRpc()
+
+	privateKeyHex := lib.MustStringToHexString("81f44a49c40f206517efbbcca783d808914841200e0ac9a769368e1b2741e227")
+	publicKey := lib.MustStringToHexString("033e5e77f12aa67e52484ce64b64737d397098e78d54beba15a0bf6dcfdd5ae7e2")
+	traceParent := "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+
+	for _, tp := range []string{"", traceParent} {
+		msg, err := m.SessionPromptRequest(common.Hash{1}, "prompt", nil, privateKeyHex, "1", tp)
+		assert.NoError(t, err)
+
+		assert.Equal(t, tp, msg.TraceParent)
+		assert.False(t, strings.Contains(string(msg.Params), "traceparent"))
+
+		// the params are the same as without propagation, so providers that don't know the field verify them
+		var req SessionPromptReq
+		assert.NoError(t, json.Unmarshal(msg.Params, &req))
+		sig := req.Signature
+		req.Signature = lib.HexString{}
+		assert.True(t, m.VerifySignature(req, sig, publicKey, nil))
+
+		data, err := json.Marshal(msg)
+		assert.NoError(t, err)
+		var legacy struct {
+			ID     string          `json:"id"`
+			Method string          `json:"method"`
+			Params json.RawMessage `json:"params"`
+		}
+		assert.NoError(t, json.Unmarshal(data, &legacy))
+		assert.JSONEq(t, string(msg.Params), string(legacy.Params))
+	}
+}
+
+func TestMorRpc_ContentRefusedError(t *testing.T) {
+	m := NewMorRpc()
+	privateKeyHex := lib.MustStringToHexString("81f44a49c40f206517efbbcca783d808914841200e0ac9a769368e1b2741e227")
+
+	res, err := m.ContentRefusedError("input refused", privateKeyHex, "1")
+	assert.NoError(t, err)
+	assert.Equal(t, "1", res.ID)
+	assert.Equal(t, ErrorCodeContentRefused, res.Error.Code)
+	assert.Equal(t, "input refused", res.Error.Message)
+	assert.NotNil(t, res.Error.Data.Signature)
+
+	res, err = m.SessionClosedError(privateKeyHex, "1")
+	assert.NoError(t, err)
+	assert.Equal(t, ErrorCodeDefault, res.Error.Code)
+}
@@ -72,14 +72,23 @@ type RPCMessage struct {
 	ID     string          `json:"id"`
 	Method string          `json:"method"`
 	Params json.RawMessage `json:"params"`
+	// W3C trace context of the consumer span. It is kept out of the signed params,
+	// so providers that don't know the field ignore it instead of failing the signature check
+	TraceParent string `json:"traceparent,omitempty"`
 }
 
 type RpcResponse struct {
 	ID     string           `json:"id"`
 	Result *json.RawMessage `json:"result"`
 	Error  *RpcError        `json:"error,omitempty"`
+	// capabilities of the provider for the model of the bid, sent with the session.request response.
+	// Like the trace context, it is kept out of the signed result, so older consumers ignore it
+	Capabilities []string `json:"capabilities,omitempty"`
 }
 
+// CapabilityJSONSchema tells that the provider enforces the json_schema response format
+const CapabilityJSONSchema = "json_schema"
+
 // SessionReport represents the detailed session report
 type SessionReport struct {
 	SessionID string      `json:"sessionid"`
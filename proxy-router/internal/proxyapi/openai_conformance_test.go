@@ -0,0 +1,254 @@
+package proxyapi
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/aiengine"
+	gcs "github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/chatstorage/genericchatstorage"
+	"github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/handlers/httphandlers"
+	"github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/lib"
+	msgs "github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/proxyapi/morrpcmessage"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/sashabaranov/go-openai"
+	"github.com/stretchr/testify/require"
+)
+
+// upstream chunks recorded from the OpenAI API, with the fields missing in the go-openai structs
+var openAIStreamChunks = []string{
+	`{"id":"chatcmpl-A1","object":"chat.completion.chunk","created":1721758293,"model":"gpt-4o-mini","system_fingerprint":"fp_0ba0d124f1","choices":[{"index":0,"delta":{"role":"assistant","content":"","refusal":null},"logprobs":{"content":[],"refusal":null},"finish_reason":null}],"usage":null}`,
+	`{"id":"chatcmpl-A1","object":"chat.completion.chunk","created":1721758293,"model":"gpt-4o-mini","system_fingerprint":"fp_0ba0d124f1","choices":[{"index":0,"delta":{"content":"Hi"},"logprobs":{"content":[{"token":"Hi","logprob":-0.0001,"bytes":[72,105],"top_logprobs":[]}],"refusal":null},"finish_reason":null}],"usage":null}`,
+	`{"id":"chatcmpl-A1","object":"chat.completion.chunk","created":1721758293,"model":"gpt-4o-mini","system_fingerprint":"fp_0ba0d124f1","choices":[{"index":0,"delta":{},"logprobs":null,"finish_reason":"length"}],"usage":null}`,
+	`{"id":"chatcmpl-A1","object":"chat.completion.chunk","created":1721758293,"model":"gpt-4o-mini","system_fingerprint":"fp_0ba0d124f1","choices":[],"usage":{"prompt_tokens":9,"completion_tokens":1,"total_tokens":10,"completion_tokens_details":{"reasoning_tokens":0}}}`,
+}
+
+const openAICompletion = `{"id":"chatcmpl-A2","object":"chat.completion","created":1721758293,"model":"gpt-4o-mini","system_fingerprint":"fp_0ba0d124f1","choices":[{"index":0,"message":{"role":"assistant","content":"Hi","refusal":null},"logprobs":null,"finish_reason":"stop"}],"usage":{"prompt_tokens":9,"completion_tokens":1,"total_tokens":10,"completion_tokens_details":{"reasoning_tokens":0}},"service_tier":"default"}`
+
+func openAIStreamBody() string {
+	var b strings.Builder
+	for _, chunk := range openAIStreamChunks {
+		b.WriteString("data: " + chunk + "\n\n")
+	}
+	b.WriteString("data: [DONE]\n\n")
+	return b.String()
+}
+
+type aiEngineStub struct {
+	adapter aiengine.AIEngineStream
+}
+
+func (e *aiEngineStub) GetLocalModels() ([]aiengine.LocalModel, error) {
+	return nil, nil
+}
+
+func (e *aiEngineStub) GetAdapter(ctx context.Context, chatID, modelID, sessionID common.Hash, storeContext, forwardContext bool, schema *aiengine.ResponseSchema) (aiengine.AIEngineStream, error) {
+	return e.adapter, nil
+}
+
+// newStrictNode starts the consumer API with a local model served by the OpenAI stub
+func newStrictNode(t *testing.T) *httptest.Server {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req openai.ChatCompletionRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		if !req.Stream {
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(openAICompletion))
+			return
+		}
+		w.Header().Set("Content-Type", "text/event-stream; charset=utf-8")
+		_, _ = w.Write([]byte(openAIStreamBody()))
+	}))
+	t.Cleanup(upstream.Close)
+
+	log := lib.NewTestLogger()
+	controller := NewProxyController(nil, &aiEngineStub{aiengine.NewOpenAIEngine("gpt-4o-mini", upstream.URL, "", log)}, nil, false, false, log)
+	controller.SetOpenAIStrict(true)
+
+	node := httptest.NewServer(httphandlers.CreateHTTPServer(log, nil, nil, controller))
+	t.Cleanup(node.Close)
+	return node
+}
+
+func TestOpenAIStrictStreamIsByteCompatible(t *testing.T) {
+	node := newStrictNode(t)
+
+	res, err := http.Post(node.URL+"/v1/chat/completions", "application/json", strings.NewReader(`{"model":"gpt-4o-mini","stream":true,"messages":[{"role":"user","content":"hi"}]}`))
+	require.NoError(t, err)
+	defer res.Body.Close()
+
+	body, err := io.ReadAll(res.Body)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, res.StatusCode)
+	require.Equal(t, "text/event-stream", res.Header.Get("Content-Type"))
+	require.Equal(t, openAIStreamBody(), string(body))
+}
+
+func TestOpenAIStrictCompletionIsByteCompatible(t *testing.T) {
+	node := newStrictNode(t)
+
+	res, err := http.Post(node.URL+"/v1/chat/completions", "application/json", strings.NewReader(`{"model":"gpt-4o-mini","messages":[{"role":"user","content":"hi"}]}`))
+	require.NoError(t, err)
+	defer res.Body.Close()
+
+	body, err := io.ReadAll(res.Body)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, res.StatusCode)
+	require.Equal(t, "application/json", res.Header.Get("Content-Type"))
+	require.Equal(t, openAICompletion, string(body))
+}
+
+func TestOpenAIStrictSDKClient(t *testing.T) {
+	node := newStrictNode(t)
+	cfg := openai.DefaultConfig("")
+	cfg.BaseURL = node.URL + "/v1"
+	client := openai.NewClientWithConfig(cfg)
+	req := openai.ChatCompletionRequest{
+		Model:    "gpt-4o-mini",
+		Messages: []openai.ChatCompletionMessage{{Role: openai.ChatMessageRoleUser, Content: "hi"}},
+	}
+
+	stream, err := client.CreateChatCompletionStream(context.Background(), req)
+	require.NoError(t, err)
+	defer stream.Close()
+
+	var content string
+	var usage *openai.Usage
+	for {
+		chunk, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		require.NoError(t, err)
+		require.Equal(t, "fp_0ba0d124f1", chunk.SystemFingerprint)
+		for _, choice := range chunk.Choices {
+			content += choice.Delta.Content
+		}
+		if chunk.Usage != nil {
+			usage = chunk.Usage
+		}
+	}
+	require.Equal(t, "Hi", content)
+	require.NotNil(t, usage)
+	require.Equal(t, 10, usage.TotalTokens)
+
+	res, err := client.CreateChatCompletion(context.Background(), req)
+	require.NoError(t, err)
+	require.Equal(t, "Hi", res.Choices[0].Message.Content)
+	require.Equal(t, "fp_0ba0d124f1", res.SystemFingerprint)
+}
+
+type prKeyStub struct{ key lib.HexString }
+
+func (p prKeyStub) GetPrivateKey() (lib.HexString, error) { return p.key, nil }
+func (p prKeyStub) PrivateKeyUpdated() <-chan struct{}    { return nil }
+
+// startProviderStub replies to the prompt with the chunks encrypted for the consumer and closes the connection
+func startProviderStub(t *testing.T, providerKey lib.HexString, consumerPubKey lib.HexString, chunks []string) string {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = listener.Close() })
+
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		var req msgs.RPCMessage
+		if err := json.NewDecoder(conn).Decode(&req); err != nil {
+			return
+		}
+		morRPC := msgs.NewMorRpc()
+		for _, chunk := range chunks {
+			encrypted, err := lib.EncryptString(chunk, hex.EncodeToString(consumerPubKey))
+			if err != nil {
+				return
+			}
+			res, err := morRPC.SessionPromptResponse(encrypted, providerKey, req.ID)
+			if err != nil {
+				return
+			}
+			if err := json.NewEncoder(conn).Encode(res); err != nil {
+				return
+			}
+		}
+	}()
+
+	return listener.Addr().String()
+}
+
+func TestOpenAIStrictRemoteStreamKeepsProviderChunks(t *testing.T) {
+	consumerKey, providerKey := newTestKey(t), newTestKey(t)
+	consumerPubKey, err := lib.PubKeyFromPrivate(consumerKey)
+	require.NoError(t, err)
+	providerPubKey, err := lib.PubKeyFromPrivate(providerKey)
+	require.NoError(t, err)
+
+	sender := &ProxyServiceSender{
+		privateKey:   prKeyStub{consumerKey},
+		morRPC:       msgs.NewMorRpc(),
+		openAIStrict: true,
+		log:          lib.NewTestLogger(),
+	}
+
+	t.Run("stream", func(t *testing.T) {
+		url := startProviderStub(t, providerKey, consumerPubKey, openAIStreamChunks)
+
+		var received [][]byte
+		_, _, tokens, err := sender.rpcRequestStreamV2(context.Background(), func(ctx context.Context, chunk gcs.Chunk) error {
+			data, err := gcs.MarshalChunk(chunk)
+			received = append(received, data)
+			return err
+		}, url, &msgs.RPCMessage{ID: "1", Method: "session.prompt"}, providerPubKey)
+		require.NoError(t, err)
+		require.Equal(t, 3, tokens) // one per chunk with choices
+
+		// the usage chunk after the finish reason is delivered too
+		require.Len(t, received, len(openAIStreamChunks))
+		for i, chunk := range openAIStreamChunks {
+			require.True(t, bytes.Equal([]byte(chunk), received[i]), "chunk %d differs", i)
+		}
+	})
+
+	t.Run("completion", func(t *testing.T) {
+		url := startProviderStub(t, providerKey, consumerPubKey, []string{openAICompletion})
+
+		var received []gcs.Chunk
+		_, _, _, err := sender.rpcRequestStreamV2(context.Background(), func(ctx context.Context, chunk gcs.Chunk) error {
+			received = append(received, chunk)
+			return nil
+		}, url, &msgs.RPCMessage{ID: "1", Method: "session.prompt"}, providerPubKey)
+		require.NoError(t, err)
+		require.Len(t, received, 1)
+		require.Equal(t, "Hi", received[0].String())
+		data, err := gcs.MarshalChunk(received[0])
+		require.NoError(t, err)
+		require.Equal(t, openAICompletion, string(data))
+	})
+
+	t.Run("closed before finish", func(t *testing.T) {
+		url := startProviderStub(t, providerKey, consumerPubKey, openAIStreamChunks[:2])
+
+		_, _, _, err := sender.rpcRequestStreamV2(context.Background(), func(ctx context.Context, chunk gcs.Chunk) error {
+			return nil
+		}, url, &msgs.RPCMessage{ID: "1", Method: "session.prompt"}, providerPubKey)
+		require.Error(t, err)
+	})
+}
+
+func newTestKey(t *testing.T) lib.HexString {
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	return crypto.FromECDSA(key)
+}
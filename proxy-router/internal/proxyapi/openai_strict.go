@@ -0,0 +1,71 @@
+package proxyapi
+
+import (
+	"encoding/json"
+	"fmt"
+
+	gcs "github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/chatstorage/genericchatstorage"
+	"github.com/sashabaranov/go-openai"
+)
+
+const (
+	openAIObjectCompletion      = "chat.completion"
+	openAIObjectCompletionChunk = "chat.completion.chunk"
+
+	sseDone = "[DONE]"
+)
+
+var ErrNotChatCompletion = fmt.Errorf("not a chat completion")
+
+// OpenAIErrorRes is the error body of the OpenAI API, returned in strict mode
+type OpenAIErrorRes struct {
+	Error OpenAIError `json:"error"`
+}
+
+type OpenAIError struct {
+	Message string  `json:"message"`
+	Type    string  `json:"type"`
+	Param   *string `json:"param"`
+	Code    *string `json:"code"`
+}
+
+func newOpenAIErrorRes(err error, errType string) *OpenAIErrorRes {
+	return &OpenAIErrorRes{Error: OpenAIError{Message: err.Error(), Type: errType}}
+}
+
+// decodeOpenAIChunk decodes the provider response by its object type keeping the original json.
+// finished is true if the response is the last one carrying choices, the usage chunk may follow it
+func decodeOpenAIChunk(data []byte) (chunk gcs.Chunk, finished bool, err error) {
+	var head struct {
+		Object string `json:"object"`
+	}
+	if err := json.Unmarshal(data, &head); err != nil {
+		return nil, false, err
+	}
+
+	switch head.Object {
+	case openAIObjectCompletionChunk:
+		var payload openai.ChatCompletionStreamResponse
+		if err := json.Unmarshal(data, &payload); err != nil {
+			return nil, false, err
+		}
+		for _, choice := range payload.Choices {
+			if choice.FinishReason != "" {
+				finished = true
+			}
+		}
+		// the usage chunk is sent after the finish reason
+		if payload.Usage != nil {
+			finished = true
+		}
+		return gcs.NewChunkStreamingRaw(&payload, data), finished, nil
+	case openAIObjectCompletion:
+		var payload openai.ChatCompletionResponse
+		if err := json.Unmarshal(data, &payload); err != nil {
+			return nil, false, err
+		}
+		return gcs.NewChunkTextRaw(&payload, data), true, nil
+	}
+
+	return nil, false, ErrNotChatCompletion
+}
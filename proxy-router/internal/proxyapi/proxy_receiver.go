@@ -2,21 +2,27 @@ package proxyapi
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
 	"math/big"
+	"strings"
 	"time"
 
 	"github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/aiengine"
 	"github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/chatstorage/genericchatstorage"
 	"github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/config"
 	"github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/lib"
+	"github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/metrics"
+	"github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/moderation"
 	m "github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/proxyapi/morrpcmessage"
 	msg "github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/proxyapi/morrpcmessage"
 	sessionrepo "github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/repositories/session"
 	"github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/storages"
+	"github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/tracing"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/sashabaranov/go-openai"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 type ProxyReceiver struct {
@@ -29,6 +35,9 @@ type ProxyReceiver struct {
 	modelConfigLoader *config.ModelConfigLoader
 	service           BidGetter
 	sessionRepo       *sessionrepo.SessionRepositoryCached
+	signEthMessage    m.EthMessageSigner
+	responseCache     *storages.ResponseCacheStorage
+	moderation        *moderation.Pipelines
 }
 
 func NewProxyReceiver(privateKeyHex, publicKeyHex lib.HexString, sessionStorage *storages.SessionStorage, aiEngine *aiengine.AiEngine, chainID *big.Int, modelConfigLoader *config.ModelConfigLoader, blockchainService BidGetter, sessionRepo *sessionrepo.SessionRepositoryCached) *ProxyReceiver {
@@ -42,18 +51,38 @@ func NewProxyReceiver(privateKeyHex, publicKeyHex lib.HexString, sessionStorage
 		service:           blockchainService,
 		sessionStorage:    sessionStorage,
 		sessionRepo:       sessionRepo,
+		signEthMessage:    m.KeyEthMessageSigner(privateKeyHex),
 	}
 }
 
+// SetEthMessageSigner sets the signer of the session approvals and reports, which are verified on-chain
+// against the provider address. By default they are signed with the private key of the receiver
+func (s *ProxyReceiver) SetEthMessageSigner(signer m.EthMessageSigner) {
+	s.signEthMessage = signer
+}
+
+// SetResponseCache enables caching of the deterministic requests for the models with the cache configured
+func (s *ProxyReceiver) SetResponseCache(cache *storages.ResponseCacheStorage) {
+	s.responseCache = cache
+}
+
+// SetModeration enables the content policy of the models configured with it
+func (s *ProxyReceiver) SetModeration(pipelines *moderation.Pipelines) {
+	s.moderation = pipelines
+}
+
 func (s *ProxyReceiver) SessionPrompt(ctx context.Context, requestID string, userPubKey string, rq *m.SessionPromptReq, sendResponse SendResponse, sourceLog lib.ILogger) (int, int, error) {
-	var req *openai.ChatCompletionRequest
+	req := &openai.ChatCompletionRequest{}
 
-	err := json.Unmarshal([]byte(rq.Message), &req)
+	zeroTemperature, err := decodePromptRequest([]byte(rq.Message), req)
 	if err != nil {
 		err := lib.WrapError(fmt.Errorf("failed to unmarshal prompt"), err)
 		sourceLog.Error(err)
 		return 0, 0, err
 	}
+	if zeroTemperature {
+		ctx = aiengine.WithZeroTemperature(ctx)
+	}
 
 	session, err := s.sessionRepo.GetSession(ctx, rq.SessionID)
 	if err != nil {
@@ -64,28 +93,23 @@ func (s *ProxyReceiver) SessionPrompt(ctx context.Context, requestID string, use
 
 	ttftMs := 0
 	totalTokens := 0
-	now := time.Now().UnixMilli()
+	start := time.Now()
+	now := start.UnixMilli()
+	modelID, providerAddr := session.ModelID().Hex(), session.ProviderAddr().Hex()
 
-	adapter, err := s.aiEngine.GetAdapter(ctx, common.Hash{}, session.ModelID(), common.Hash{}, false, false)
+	adapter, err := s.aiEngine.GetAdapter(ctx, common.Hash{}, session.ModelID(), common.Hash{}, false, false, nil)
 	if err != nil {
 		err := lib.WrapError(fmt.Errorf("failed to get adapter"), err)
 		sourceLog.Error(err)
 		return 0, 0, err
 	}
 
-	err = adapter.Prompt(ctx, req, func(ctx context.Context, completion genericchatstorage.Chunk) error {
-		totalTokens += completion.Tokens()
-
+	sendChunk := func(data []byte) error {
 		if ttftMs == 0 {
 			ttftMs = int(time.Now().UnixMilli() - now)
 		}
 
-		marshalledResponse, err := json.Marshal(completion.Data())
-		if err != nil {
-			return err
-		}
-
-		encryptedResponse, err := lib.EncryptString(string(marshalledResponse), lib.RemoveHexPrefix(userPubKey))
+		encryptedResponse, err := lib.EncryptString(string(data), lib.RemoveHexPrefix(userPubKey))
 		if err != nil {
 			return err
 		}
@@ -102,7 +126,113 @@ func (s *ProxyReceiver) SessionPrompt(ctx context.Context, requestID string, use
 			return err
 		}
 		return sendResponse(r)
-	})
+	}
+
+	modelCfg := s.modelConfigLoader.ModelConfigFromID(modelID)
+	var pipeline *moderation.Pipeline
+	if s.moderation != nil {
+		pipeline, err = s.moderation.Get(modelID, modelCfg.Moderation)
+		if err != nil {
+			err := lib.WrapError(fmt.Errorf("failed to create moderation pipeline"), err)
+			sourceLog.Error(err)
+			return 0, 0, err
+		}
+	}
+	if pipeline != nil {
+		err = pipeline.Check(ctx, moderation.StageInput, moderation.PromptText(req), sourceLog)
+		if err != nil {
+			observeRefusal(modelID, err)
+			return 0, 0, err
+		}
+	}
+
+	cacheCfg := modelCfg.ResponseCache
+	cacheKey, cacheable := "", false
+	if s.responseCache != nil && cacheCfg != nil {
+		cacheKey, cacheable = responseCacheKey([]byte(rq.Message))
+	}
+
+	var cached *storages.CachedResponse
+	if cacheable {
+		cached, err = s.responseCache.GetResponse(modelID, cacheKey)
+		if err != nil && !errors.Is(err, storages.ErrCachedResponseNotFound) {
+			sourceLog.Warnf("failed to get cached response: %s", err)
+		}
+		if cached != nil {
+			metrics.ResponseCache.WithLabelValues(modelID, metrics.CacheHit).Inc()
+		} else {
+			metrics.ResponseCache.WithLabelValues(modelID, metrics.CacheMiss).Inc()
+		}
+	}
+
+	promptCtx, span := tracing.Start(ctx, "aiengine.Prompt", trace.SpanKindClient,
+		attribute.String("model.id", modelID),
+		attribute.String("aiengine.api_type", adapter.ApiType()),
+		attribute.Bool("prompt.cached", cached != nil),
+	)
+	if cached != nil {
+		// the output policy could have changed since the response was cached
+		if pipeline != nil && pipeline.ChecksOutput() {
+			err = pipeline.Check(promptCtx, moderation.StageOutput, cached.Text, sourceLog)
+			observeRefusal(modelID, err)
+		}
+		// the cached response is replayed chunk by chunk, so the consumer can't tell it from the upstream one
+		totalTokens = cached.Tokens
+		for _, chunk := range cached.Chunks {
+			if err != nil {
+				break
+			}
+			err = sendChunk(chunk)
+		}
+	} else {
+		var recorder *responseRecorder
+		if cacheable {
+			recorder = newResponseRecorder(cacheCfg.GetMaxEntryBytes())
+		}
+		// the response is held until it is checked
+		var output *heldResponse
+		if pipeline != nil && pipeline.ChecksOutput() {
+			output = &heldResponse{}
+		}
+
+		err = adapter.Prompt(promptCtx, req, func(ctx context.Context, completion genericchatstorage.Chunk) error {
+			totalTokens += completion.Tokens()
+
+			// upstream json is forwarded as is, so the consumer gets the fields missing in the openai structs
+			marshalledResponse, err := genericchatstorage.MarshalChunk(completion)
+			if err != nil {
+				return err
+			}
+			if recorder != nil {
+				recorder.Add(completion, marshalledResponse)
+			}
+			if output != nil {
+				output.Add(completion, marshalledResponse)
+				return nil
+			}
+			return sendChunk(marshalledResponse)
+		})
+
+		if err == nil && output != nil {
+			err = pipeline.Check(promptCtx, moderation.StageOutput, output.Text(), sourceLog)
+			observeRefusal(modelID, err)
+			if err == nil {
+				err = output.Send(sendChunk)
+			}
+		}
+
+		if err == nil && recorder != nil {
+			if res := recorder.Response(); res != nil {
+				err := s.responseCache.PutResponse(modelID, cacheKey, res, cacheCfg.TTL(), cacheCfg.GetMaxEntries())
+				if err != nil {
+					sourceLog.Warnf("failed to cache response: %s", err)
+				}
+			}
+		}
+	}
+	span.SetAttributes(attribute.Int("prompt.ttft_ms", ttftMs), attribute.Int("prompt.tokens", totalTokens))
+	tracing.End(span, err)
+	metrics.ObservePrompt(metrics.RoleProvider, modelID, providerAddr, start, ttftMs, totalTokens, err)
 	if err != nil {
 		err := lib.WrapError(fmt.Errorf("failed to prompt"), err)
 		sourceLog.Error(err)
@@ -149,6 +279,7 @@ func (s *ProxyReceiver) SessionRequest(ctx context.Context, msgID string, reqID
 		req.User,
 		req.BidID,
 		s.privateKeyHex,
+		s.signEthMessage,
 		reqID,
 		s.chainID,
 	)
@@ -157,6 +288,7 @@ func (s *ProxyReceiver) SessionRequest(ctx context.Context, msgID string, reqID
 		log.Error(err)
 		return nil, err
 	}
+	response.Capabilities = modelConfig.Capabilities
 
 	user := storages.User{
 		Addr:   req.User.Hex(),
@@ -197,6 +329,7 @@ func (s *ProxyReceiver) SessionReport(ctx context.Context, msgID string, reqID s
 		uint32(ttft),
 		common.HexToHash(session.Id),
 		s.privateKeyHex,
+		s.signEthMessage,
 		reqID,
 		s.chainID,
 	)
@@ -208,3 +341,37 @@ func (s *ProxyReceiver) SessionReport(ctx context.Context, msgID string, reqID s
 
 	return response, nil
 }
+
+func observeRefusal(modelID string, err error) {
+	var refusal *moderation.RefusalError
+	if errors.As(err, &refusal) {
+		metrics.ModerationRefusals.WithLabelValues(modelID, string(refusal.Stage)).Inc()
+	}
+}
+
+// heldResponse keeps the chunks of a response until the content policy is checked
+type heldResponse struct {
+	chunks [][]byte
+	text   strings.Builder
+}
+
+func (r *heldResponse) Add(chunk genericchatstorage.Chunk, data []byte) {
+	if chunk.Type() == genericchatstorage.ChunkTypeText {
+		r.text.WriteString(chunk.String())
+	}
+	r.chunks = append(r.chunks, data)
+}
+
+func (r *heldResponse) Text() string {
+	return r.text.String()
+}
+
+func (r *heldResponse) Send(send func(data []byte) error) error {
+	for _, chunk := range r.chunks {
+		err := send(chunk)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -6,6 +6,7 @@ import (
 	"context"
 	"crypto/rand"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"math/big"
@@ -14,15 +15,21 @@ import (
 	"strconv"
 	"time"
 
+	"github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/aiengine"
 	gcs "github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/chatstorage/genericchatstorage"
 	"github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/interfaces"
 	"github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/lib"
+	"github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/metrics"
 	msgs "github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/proxyapi/morrpcmessage"
 	sessionrepo "github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/repositories/session"
 	"github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/storages"
+	"github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/tracing"
+	"github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/webhooks"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/gin-gonic/gin/binding"
 	"github.com/sashabaranov/go-openai"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 var (
@@ -39,9 +46,11 @@ var (
 	ErrSessionNotFound  = fmt.Errorf("session not found")
 	ErrSessionExpired   = fmt.Errorf("session expired")
 	ErrProviderNotFound = fmt.Errorf("provider not found")
+	ErrSessionAccount   = fmt.Errorf("session is opened by another account")
 	ErrEmpty            = fmt.Errorf("empty result and no error")
 	ErrConnectProvider  = fmt.Errorf("failed to connect to provider")
 	ErrWriteProvider    = fmt.Errorf("failed to write to provider")
+	ErrContentRefused   = fmt.Errorf("refused by the provider content policy")
 )
 
 const (
@@ -56,6 +65,9 @@ type ProxyServiceSender struct {
 	sessionRepo    *sessionrepo.SessionRepositoryCached
 	morRPC         *msgs.MORRPCMessage
 	sessionService SessionService
+	virtualSess    VirtualSessions
+	webhooks       *webhooks.Dispatcher
+	openAIStrict   bool
 	log            lib.ILogger
 }
 
@@ -75,8 +87,22 @@ func (p *ProxyServiceSender) SetSessionService(service SessionService) {
 	p.sessionService = service
 }
 
+// SetVirtualSessions makes the prompts accept virtual session IDs of auto-renewed sessions
+func (p *ProxyServiceSender) SetVirtualSessions(virtualSessions VirtualSessions) {
+	p.virtualSess = virtualSessions
+}
+
+func (p *ProxyServiceSender) SetWebhooks(dispatcher *webhooks.Dispatcher) {
+	p.webhooks = dispatcher
+}
+
+// SetOpenAIStrict makes remote prompts keep the provider chunks as is and read the stream till the provider closes it
+func (p *ProxyServiceSender) SetOpenAIStrict(strict bool) {
+	p.openAIStrict = strict
+}
+
 func (p *ProxyServiceSender) Ping(ctx context.Context, providerURL string, providerAddr common.Address) (time.Duration, error) {
-	prKey, err := p.privateKey.GetPrivateKey()
+	prKey, err := interfaces.GetPrivateKeyCtx(ctx, p.privateKey)
 	if err != nil {
 		return 0, ErrMissingPrKey
 	}
@@ -120,17 +146,17 @@ func (p *ProxyServiceSender) Ping(ctx context.Context, providerURL string, provi
 	signature := typedMsg.Signature
 	typedMsg.Signature = lib.HexString{}
 
-	if !p.morRPC.VerifySignatureAddr(typedMsg, signature, providerAddr, p.log) {
+	if !p.morRPC.VerifySignatureAddr(typedMsg, signature, providerAddr, p.log) && !p.verifyProviderTransportSig(typedMsg, signature, providerAddr) {
 		return pingDuration, ErrInvalidSig
 	}
 
 	return pingDuration, nil
 }
 
-func (p *ProxyServiceSender) InitiateSession(ctx context.Context, user common.Address, provider common.Address, spend *big.Int, bidID common.Hash, providerURL string) (*msgs.SessionRes, error) {
+func (p *ProxyServiceSender) InitiateSession(ctx context.Context, user common.Address, provider common.Address, spend *big.Int, bidID common.Hash, providerURL string) (*InitiateSessionRes, error) {
 	requestID := "1"
 
-	prKey, err := p.privateKey.GetPrivateKey()
+	prKey, err := interfaces.GetPrivateKeyCtx(ctx, p.privateKey)
 	if err != nil {
 		return nil, ErrMissingPrKey
 	}
@@ -181,13 +207,13 @@ func (p *ProxyServiceSender) InitiateSession(ctx context.Context, user common.Ad
 		return nil, lib.WrapError(ErrFailedStore, err)
 	}
 
-	return typedMsg, nil
+	return &InitiateSessionRes{SessionRes: typedMsg, Capabilities: msg.Capabilities}, nil
 }
 
 func (p *ProxyServiceSender) GetSessionReportFromProvider(ctx context.Context, sessionID common.Hash) (*msgs.SessionReportRes, error) {
 	requestID := "1"
 
-	prKey, err := p.privateKey.GetPrivateKey()
+	prKey, err := interfaces.GetPrivateKeyCtx(ctx, p.privateKey)
 	if err != nil {
 		return nil, ErrMissingPrKey
 	}
@@ -269,16 +295,21 @@ func (p *ProxyServiceSender) GetSessionReportFromUser(ctx context.Context, sessi
 		ttft /= len(TTFTMsArr)
 	}
 
-	prKey, err := p.privateKey.GetPrivateKey()
+	prKey, err := interfaces.GetPrivateKeyCtx(ctx, p.privateKey)
 	if err != nil {
 		return nil, nil, ErrMissingPrKey
 	}
 
+	signReport := func(msg []byte) ([]byte, error) {
+		return interfaces.SignEthMessageCtx(ctx, p.privateKey, msg)
+	}
+
 	response, err := p.morRPC.SessionReportResponse(
 		uint32(tps),
 		uint32(ttft),
 		sessionID,
 		prKey,
+		signReport,
 		"1",
 		p.chainID,
 	)
@@ -343,6 +374,7 @@ func (p *ProxyServiceSender) validateMsgSignature(result any, signature lib.HexS
 }
 
 func (p *ProxyServiceSender) GetModelIdSession(ctx context.Context, sessionID common.Hash) (common.Hash, error) {
+	sessionID, _ = p.resolveVirtualSession(sessionID)
 	session, err := p.sessionRepo.GetSession(ctx, sessionID)
 	if err != nil {
 		return common.Hash{}, ErrSessionNotFound
@@ -350,11 +382,53 @@ func (p *ProxyServiceSender) GetModelIdSession(ctx context.Context, sessionID co
 	return session.ModelID(), nil
 }
 
+// SessionCapabilities returns the capabilities the provider advertised for the model of the session
+func (p *ProxyServiceSender) SessionCapabilities(ctx context.Context, sessionID common.Hash) ([]string, error) {
+	sessionID, _ = p.resolveVirtualSession(sessionID)
+	session, err := p.sessionRepo.GetSession(ctx, sessionID)
+	if err != nil {
+		return nil, ErrSessionNotFound
+	}
+	return session.Capabilities(), nil
+}
+
+// verifyProviderTransportSig checks the signature against the MOR-RPC key the provider sent on session
+// initiation, providers using a remote signer sign MOR-RPC messages with a key other than the wallet one
+func (p *ProxyServiceSender) verifyProviderTransportSig(result any, signature lib.HexString, providerAddr common.Address) bool {
+	provider, ok := p.sessionStorage.GetUser(providerAddr.Hex())
+	if !ok {
+		return false
+	}
+	pubKey, err := lib.StringToHexString(provider.PubKey)
+	if err != nil {
+		return false
+	}
+	return p.validateMsgSignature(result, signature, pubKey)
+}
+
+// resolveVirtualSession returns the current session of the virtual session and the virtual session ID,
+// other session IDs are returned as is
+func (p *ProxyServiceSender) resolveVirtualSession(sessionID common.Hash) (common.Hash, *common.Hash) {
+	if p.virtualSess == nil {
+		return sessionID, nil
+	}
+	current, ok := p.virtualSess.Resolve(sessionID)
+	if !ok {
+		return sessionID, nil
+	}
+	return current, &sessionID
+}
+
 func (p *ProxyServiceSender) validateMsgSignatureAddr(result any, signature lib.HexString, providerAddr common.Address) bool {
 	return p.morRPC.VerifySignatureAddr(result, signature, providerAddr, p.log)
 }
 
-func (p *ProxyServiceSender) SendPromptV2(ctx context.Context, sessionID common.Hash, prompt *openai.ChatCompletionRequest, cb gcs.CompletionCallback) (interface{}, error) {
+func (p *ProxyServiceSender) SendPromptV2(ctx context.Context, sessionID common.Hash, prompt *openai.ChatCompletionRequest, cb gcs.CompletionCallback) (res interface{}, err error) {
+	sessionID, virtualID := p.resolveVirtualSession(sessionID)
+
+	ctx, span := tracing.Start(ctx, "proxy.SendPrompt", trace.SpanKindInternal, attribute.String("session.id", sessionID.Hex()))
+	defer func() { tracing.End(span, err) }()
+
 	session, err := p.sessionRepo.GetSession(ctx, sessionID)
 	if err != nil {
 		return nil, ErrSessionNotFound
@@ -370,9 +444,17 @@ func (p *ProxyServiceSender) SendPromptV2(ctx context.Context, sessionID common.
 		return nil, ErrProviderNotFound
 	}
 
-	prKey, err := p.privateKey.GetPrivateKey()
+	prKey, err := interfaces.GetPrivateKeyCtx(ctx, p.privateKey)
 	if err != nil {
-		return nil, ErrMissingPrKey
+		return nil, lib.WrapError(ErrMissingPrKey, err)
+	}
+	// the provider accepts prompts signed by the session user only
+	userAddr, err := interfaces.GetAddressCtx(ctx, p.privateKey)
+	if err != nil {
+		return nil, lib.WrapError(ErrMissingPrKey, err)
+	}
+	if userAddr != session.UserAddr() {
+		return nil, lib.WrapError(ErrSessionAccount, fmt.Errorf("%s", session.UserAddr().Hex()))
 	}
 
 	requestID := "1"
@@ -380,18 +462,39 @@ func (p *ProxyServiceSender) SendPromptV2(ctx context.Context, sessionID common.
 	if err != nil {
 		return nil, lib.WrapError(ErrCreateReq, err)
 	}
-	promptRequest, err := p.morRPC.SessionPromptRequest(sessionID, prompt, pubKey, prKey, requestID)
+
+	rpcCtx, rpcSpan := tracing.Start(ctx, "morrpc session.prompt", trace.SpanKindClient,
+		attribute.String("model.id", session.ModelID().Hex()),
+		attribute.String("provider.address", session.ProviderAddr().Hex()),
+		attribute.String("server.address", provider.Url),
+	)
+	promptRequest, err := p.morRPC.SessionPromptRequest(sessionID, aiengine.PromptMessage(ctx, prompt), pubKey, prKey, requestID, tracing.TraceParent(rpcCtx))
 	if err != nil {
+		tracing.End(rpcSpan, err)
 		return nil, lib.WrapError(ErrCreateReq, err)
 	}
 
-	now := time.Now().Unix()
-	result, ttftMs, totalTokens, err := p.rpcRequestStreamV2(ctx, cb, provider.Url, promptRequest, pubKey)
+	start := time.Now()
+	now := start.Unix()
+	result, ttftMs, totalTokens, err := p.rpcRequestStreamV2(rpcCtx, cb, provider.Url, promptRequest, pubKey)
+	rpcSpan.SetAttributes(attribute.Int("prompt.ttft_ms", ttftMs), attribute.Int("prompt.tokens", totalTokens))
+	tracing.End(rpcSpan, err)
+	metrics.ObservePrompt(metrics.RoleConsumer, session.ModelID().Hex(), session.ProviderAddr().Hex(), start, ttftMs, totalTokens, err)
 	if err != nil {
-		if !session.FailoverEnabled() {
+		// a refusal is the policy of a working provider, so it doesn't trigger the failover
+		if !session.FailoverEnabled() || errors.Is(err, ErrContentRefused) {
 			return nil, lib.WrapError(ErrProvider, err)
 		}
 
+		metrics.Failovers.WithLabelValues(session.ModelID().Hex(), session.ProviderAddr().Hex()).Inc()
+
+		p.webhooks.Emit(webhooks.EventFailoverTriggered, &webhooks.FailoverEventData{
+			SessionID: sessionID.Hex(),
+			ModelID:   session.ModelID().Hex(),
+			Provider:  session.ProviderAddr().Hex(),
+			Reason:    err.Error(),
+		})
+
 		_, err := p.sessionService.CloseSession(ctx, sessionID)
 		if err != nil {
 			return nil, err
@@ -416,6 +519,13 @@ func (p *ProxyServiceSender) SendPromptV2(ctx context.Context, sessionID common.
 			return nil, err
 		}
 
+		if virtualID != nil {
+			err = p.virtualSess.Replace(*virtualID, newSessionID)
+			if err != nil {
+				p.log.Warnf("failed to point virtual session %s at %s: %s", virtualID.Hex(), newSessionID.Hex(), err)
+			}
+		}
+
 		msg := fmt.Sprintf("new session opened: %s", newSessionID.Hex())
 		err = cb(ctx, gcs.NewChunkControl(msg))
 		if err != nil {
@@ -453,7 +563,7 @@ func (p *ProxyServiceSender) rpcRequestStreamV2(
 
 	dialer := net.Dialer{Timeout: TIMEOUT_TO_ESTABLISH_CONNECTION}
 
-	prKey, err := p.privateKey.GetPrivateKey()
+	prKey, err := interfaces.GetPrivateKeyCtx(ctx, p.privateKey)
 	if err != nil {
 		return nil, 0, 0, ErrMissingPrKey
 	}
@@ -490,6 +600,7 @@ func (p *ProxyServiceSender) rpcRequestStreamV2(
 	responses := make([]interface{}, 0)
 
 	retryCount := 0
+	finished := false
 
 	for {
 		if ctx.Err() != nil {
@@ -529,6 +640,10 @@ func (p *ProxyServiceSender) rpcRequestStreamV2(
 					return nil, ttftMs, totalTokens, fmt.Errorf("read timed out after %d retries: %w", retryCount, err)
 				}
 			} else if err == io.EOF {
+				if finished {
+					// in strict mode the stream ends when the provider closes the connection
+					break
+				}
 				p.log.Warnf("Connection closed by provider")
 				return nil, ttftMs, totalTokens, fmt.Errorf("connection closed by provider")
 			} else {
@@ -538,6 +653,9 @@ func (p *ProxyServiceSender) rpcRequestStreamV2(
 		}
 
 		if msg.Error != nil {
+			if msg.Error.Code == msgs.ErrorCodeContentRefused {
+				return nil, ttftMs, totalTokens, lib.WrapError(ErrContentRefused, fmt.Errorf("%s", msg.Error.Message))
+			}
 			return nil, ttftMs, totalTokens, lib.WrapError(ErrResponseErr, fmt.Errorf("error: %v, data: %v", msg.Error.Message, msg.Error.Data))
 		}
 
@@ -573,6 +691,25 @@ func (p *ProxyServiceSender) rpcRequestStreamV2(
 			return nil, ttftMs, totalTokens, lib.WrapError(ErrDecrFailed, err)
 		}
 
+		if p.openAIStrict {
+			chunk, chunkFinished, err := decodeOpenAIChunk(aiResponse)
+			if err == nil {
+				finished = finished || chunkFinished
+				totalTokens += chunk.Tokens()
+				responses = append(responses, chunk.Data())
+
+				if ctx.Err() != nil {
+					return nil, ttftMs, totalTokens, ctx.Err()
+				}
+				err = cb(ctx, chunk)
+				if err != nil {
+					return nil, ttftMs, totalTokens, lib.WrapError(ErrResponseErr, err)
+				}
+				continue
+			}
+			// not a chat completion, handled as usual
+		}
+
 		var payload openai.ChatCompletionStreamResponse
 		err = json.Unmarshal(aiResponse, &payload)
 		var stop = true
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 
 	"github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/lib"
+	msgs "github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/proxyapi/morrpcmessage"
 	"github.com/ethereum/go-ethereum/common"
 )
 
@@ -24,6 +25,13 @@ type InitiateSessionReq struct {
 	BidID       common.Hash    `json:"bidId"       validate:"required,hex32"`
 }
 
+// InitiateSessionRes is the signed provider response and the capabilities the provider advertises
+// for the model of the bid
+type InitiateSessionRes struct {
+	*msgs.SessionRes
+	Capabilities []string `json:"capabilities,omitempty"`
+}
+
 type PromptReq struct {
 	Signature string          `json:"signature" validate:"required,hexadecimal"`
 	Message   json.RawMessage `json:"message"   validate:"required"`
@@ -0,0 +1,84 @@
+package proxyapi
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/chatstorage/genericchatstorage"
+	"github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/storages"
+)
+
+// fields of the request, which don't change the response
+var responseCacheIgnoredFields = []string{"user"}
+
+// responseCacheKey returns the hash of a deterministic request, i.e. with temperature 0 or a seed.
+// The consumer sends temperature 0 explicitly, see aiengine.PromptMessage. The request is canonicalized by decoding it into maps, which are encoded with sorted keys
+func responseCacheKey(message []byte) (string, bool) {
+	var req map[string]interface{}
+	err := json.Unmarshal(message, &req)
+	if err != nil {
+		return "", false
+	}
+
+	temperature, hasTemperature := req["temperature"].(float64)
+	seed := req["seed"]
+	if !(hasTemperature && temperature == 0) && seed == nil {
+		return "", false
+	}
+
+	for _, field := range responseCacheIgnoredFields {
+		delete(req, field)
+	}
+	canonical, err := json.Marshal(req)
+	if err != nil {
+		return "", false
+	}
+	hash := sha256.Sum256(canonical)
+	return hex.EncodeToString(hash[:]), true
+}
+
+// responseRecorder collects the upstream chunks of a response to be cached, responses
+// with other than text chunks or over the size limit are dropped
+type responseRecorder struct {
+	chunks   []json.RawMessage
+	text     strings.Builder
+	tokens   int
+	size     int
+	maxBytes int
+	dropped  bool
+}
+
+func newResponseRecorder(maxBytes int) *responseRecorder {
+	return &responseRecorder{maxBytes: maxBytes}
+}
+
+func (r *responseRecorder) Add(chunk genericchatstorage.Chunk, data []byte) {
+	if r.dropped {
+		return
+	}
+	r.size += len(data)
+	if chunk.Type() != genericchatstorage.ChunkTypeText || r.size > r.maxBytes {
+		r.dropped = true
+		r.chunks = nil
+		return
+	}
+	r.chunks = append(r.chunks, append(json.RawMessage{}, data...))
+	r.text.WriteString(chunk.String())
+	r.tokens += chunk.Tokens()
+}
+
+// Response returns the response to be cached, nil if it was dropped
+func (r *responseRecorder) Response() *storages.CachedResponse {
+	if r.dropped || len(r.chunks) == 0 {
+		return nil
+	}
+	return &storages.CachedResponse{
+		Chunks:    r.chunks,
+		Tokens:    r.tokens,
+		Text:      r.text.String(),
+		CreatedAt: time.Now().UnixNano(),
+	}
+}
@@ -0,0 +1,109 @@
+package proxyapi
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/aiengine"
+	"github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/chatstorage/genericchatstorage"
+	"github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/lib"
+	msgs "github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/proxyapi/morrpcmessage"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/sashabaranov/go-openai"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResponseCacheKey(t *testing.T) {
+	key, ok := responseCacheKey([]byte(`{"model":"llama","temperature":0,"messages":[{"role":"user","content":"hi"}],"user":"a"}`))
+	require.True(t, ok)
+
+	// the key doesn't depend on the field order, number format and the ignored fields
+	same, ok := responseCacheKey([]byte(`{"messages":[{"content":"hi","role":"user"}],"user":"b","temperature":0.0,"model":"llama"}`))
+	require.True(t, ok)
+	require.Equal(t, key, same)
+
+	other, ok := responseCacheKey([]byte(`{"model":"llama","temperature":0,"messages":[{"role":"user","content":"hello"}]}`))
+	require.True(t, ok)
+	require.NotEqual(t, key, other)
+
+	_, ok = responseCacheKey([]byte(`{"model":"llama","seed":42,"temperature":0.7,"messages":[]}`))
+	require.True(t, ok)
+
+	for _, req := range []string{
+		`{"model":"llama","messages":[]}`,
+		`{"model":"llama","temperature":0.7,"messages":[]}`,
+		`{"model":"llama","seed":null,"messages":[]}`,
+		`invalid`,
+	} {
+		_, ok := responseCacheKey([]byte(req))
+		require.False(t, ok, req)
+	}
+}
+
+func TestResponseCacheKeyFromConsumer(t *testing.T) {
+	morRPC := msgs.NewMorRpc()
+	prKey := lib.MustStringToHexString("81f44a49c40f206517efbbcca783d808914841200e0ac9a769368e1b2741e227")
+
+	// the prompt goes the way of the consumer: decoded by the api, encoded into the MOR-RPC message
+	// by the sender and decoded by the provider
+	send := func(body string) (string, bool) {
+		var prompt openai.ChatCompletionRequest
+		zeroTemperature, err := decodePromptRequest([]byte(body), &prompt)
+		require.NoError(t, err)
+		ctx := context.Background()
+		if zeroTemperature {
+			ctx = aiengine.WithZeroTemperature(ctx)
+		}
+
+		msg, err := morRPC.SessionPromptRequest(common.Hash{1}, aiengine.PromptMessage(ctx, &prompt), nil, prKey, "1", "")
+		require.NoError(t, err)
+		var req msgs.SessionPromptReq
+		require.NoError(t, json.Unmarshal(msg.Params, &req))
+
+		var received openai.ChatCompletionRequest
+		receivedZero, err := decodePromptRequest([]byte(req.Message), &received)
+		require.NoError(t, err)
+		require.Equal(t, zeroTemperature, receivedZero)
+		require.Equal(t, prompt.Temperature, received.Temperature)
+
+		return responseCacheKey([]byte(req.Message))
+	}
+
+	_, ok := send(`{"model":"llama","temperature":0,"messages":[{"role":"user","content":"hi"}]}`)
+	require.True(t, ok)
+	_, ok = send(`{"model":"llama","seed":1,"messages":[{"role":"user","content":"hi"}]}`)
+	require.True(t, ok)
+	_, ok = send(`{"model":"llama","messages":[{"role":"user","content":"hi"}]}`)
+	require.False(t, ok)
+	_, ok = send(`{"model":"llama","temperature":0.7,"messages":[{"role":"user","content":"hi"}]}`)
+	require.False(t, ok)
+}
+
+func TestResponseRecorder(t *testing.T) {
+	chunk := func(content string) (genericchatstorage.Chunk, []byte) {
+		raw := []byte(`{"choices":[{"delta":{"content":"` + content + `"}}]}`)
+		data := &openai.ChatCompletionStreamResponse{Choices: []openai.ChatCompletionStreamChoice{{Delta: openai.ChatCompletionStreamChoiceDelta{Content: content}}}}
+		return genericchatstorage.NewChunkStreamingRaw(data, raw), raw
+	}
+
+	recorder := newResponseRecorder(1024)
+	recorder.Add(chunk("hello"))
+	recorder.Add(chunk(" world"))
+	res := recorder.Response()
+	require.NotNil(t, res)
+	require.Len(t, res.Chunks, 2)
+	require.Equal(t, 2, res.Tokens)
+	require.Equal(t, "hello world", res.Text)
+
+	// responses over the size limit are not cached
+	recorder = newResponseRecorder(60)
+	recorder.Add(chunk("hello"))
+	recorder.Add(chunk(" world"))
+	require.Nil(t, recorder.Response())
+
+	// only text is cached
+	recorder = newResponseRecorder(1024)
+	recorder.Add(genericchatstorage.NewChunkImage(&genericchatstorage.ImageGenerationResult{}), []byte(`{}`))
+	require.Nil(t, recorder.Response())
+}
@@ -0,0 +1,58 @@
+package proxyapi
+
+import (
+	"encoding/json"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+// promptRequest keeps the requested json schema as raw json, so it is validated and forwarded as is
+type promptRequest struct {
+	openai.ChatCompletionRequest
+	ResponseFormat *promptResponseFormat `json:"response_format,omitempty"`
+	// tells an explicit temperature 0 from a missing one, see aiengine.WithZeroTemperature
+	Temperature *float32 `json:"temperature,omitempty"`
+}
+
+type promptResponseFormat struct {
+	Type       openai.ChatCompletionResponseFormatType `json:"type,omitempty"`
+	JSONSchema *struct {
+		Name        string          `json:"name"`
+		Description string          `json:"description,omitempty"`
+		Schema      json.RawMessage `json:"schema"`
+		Strict      bool            `json:"strict"`
+	} `json:"json_schema,omitempty"`
+}
+
+// decodePromptRequest decodes the chat completion request, the json schema is kept as raw json.
+// zeroTemperature is true if the request sets temperature 0 explicitly
+func decodePromptRequest(data []byte, req *openai.ChatCompletionRequest) (zeroTemperature bool, err error) {
+	var raw promptRequest
+	err = json.Unmarshal(data, &raw)
+	if err != nil {
+		return false, err
+	}
+
+	*req = raw.ChatCompletionRequest
+	if raw.Temperature != nil {
+		req.Temperature = *raw.Temperature
+		zeroTemperature = *raw.Temperature == 0
+	}
+	req.ResponseFormat = nil
+	if raw.ResponseFormat == nil {
+		return zeroTemperature, nil
+	}
+
+	req.ResponseFormat = &openai.ChatCompletionResponseFormat{Type: raw.ResponseFormat.Type}
+	if s := raw.ResponseFormat.JSONSchema; s != nil {
+		req.ResponseFormat.JSONSchema = &openai.ChatCompletionResponseFormatJSONSchema{
+			Name:        s.Name,
+			Description: s.Description,
+			Strict:      s.Strict,
+		}
+		if len(s.Schema) > 0 && string(s.Schema) != "null" {
+			req.ResponseFormat.JSONSchema.Schema = s.Schema
+		}
+	}
+	return zeroTemperature, nil
+}
@@ -0,0 +1,52 @@
+package proxyapi
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/aiengine"
+	"github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/lib"
+	"github.com/sashabaranov/go-openai"
+	"github.com/stretchr/testify/require"
+)
+
+const testResponseSchema = `{"type":"object","properties":{"name":{"type":["string","null"]},"tags":{"type":"array","minItems":1}},"required":["name","tags"],"additionalProperties":false}`
+
+func TestDecodePromptRequest(t *testing.T) {
+	var req openai.ChatCompletionRequest
+	_, err := decodePromptRequest([]byte(`{"model":"llama","messages":[{"role":"user","content":"hi"}],
+		"response_format":{"type":"json_schema","json_schema":{"name":"person","strict":true,"schema":`+testResponseSchema+`}}}`), &req)
+	require.NoError(t, err)
+	require.Equal(t, "hi", req.Messages[0].Content)
+	require.Equal(t, "person", req.ResponseFormat.JSONSchema.Name)
+
+	// the schema is forwarded as is, with the keywords unknown to openai
+	data, err := json.Marshal(req)
+	require.NoError(t, err)
+	var forwarded struct {
+		ResponseFormat struct {
+			JSONSchema struct {
+				Schema json.RawMessage `json:"schema"`
+			} `json:"json_schema"`
+		} `json:"response_format"`
+	}
+	require.NoError(t, json.Unmarshal(data, &forwarded))
+	require.JSONEq(t, testResponseSchema, string(forwarded.ResponseFormat.JSONSchema.Schema))
+
+	_, err = decodePromptRequest([]byte(`{"model":"llama","messages":[],"response_format":{"type":"json_object"}}`), &req)
+	require.NoError(t, err)
+	require.Equal(t, openai.ChatCompletionResponseFormatTypeJSONObject, req.ResponseFormat.Type)
+	require.Nil(t, req.ResponseFormat.JSONSchema)
+
+	schema, err := aiengine.CompileResponseSchema(req.ResponseFormat)
+	require.NoError(t, err)
+	require.Nil(t, schema)
+}
+
+func TestPromptErrorStatus(t *testing.T) {
+	require.Equal(t, http.StatusBadRequest, promptErrorStatus(lib.WrapError(ErrContentRefused, errors.New("keyword"))))
+	require.Equal(t, http.StatusBadGateway, promptErrorStatus(lib.WrapError(aiengine.ErrResponseSchema, errors.New("invalid json"))))
+	require.Equal(t, http.StatusInternalServerError, promptErrorStatus(errors.New("failed")))
+}
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"math/big"
+	"time"
 
 	"github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/aiengine"
 	"github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/blockchainapi"
@@ -13,11 +14,13 @@ import (
 	"github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/handlers/tcphandlers"
 	"github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/interfaces"
 	"github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/lib"
+	"github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/moderation"
 	"github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/proxyapi"
 	"github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/repositories/registries"
 	sessionrepo "github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/repositories/session"
 	"github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/repositories/transport"
 	"github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/storages"
+	"github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/webhooks"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/go-playground/validator/v10"
 	"golang.org/x/sync/errgroup"
@@ -71,6 +74,8 @@ type Proxy struct {
 	modelConfigLoader    *config.ModelConfigLoader
 	blockchainService    *blockchainapi.BlockchainService
 	sessionExpiryHandler *blockchainapi.SessionExpiryHandler
+	webhooks             *webhooks.Dispatcher
+	responseCache        *storages.ResponseCacheStorage
 
 	state         lib.AtomicValue[ProxyState]
 	tsk           *lib.Task
@@ -97,6 +102,16 @@ func NewProxyCtl(eventListerer *blockchainapi.EventsListener, wallet interfaces.
 	}
 }
 
+// SetWebhooks enables webhook events for provider checks and wallet balance thresholds
+func (p *Proxy) SetWebhooks(dispatcher *webhooks.Dispatcher) {
+	p.webhooks = dispatcher
+}
+
+// SetResponseCache enables the response cache of the models configured with it
+func (p *Proxy) SetResponseCache(cache *storages.ResponseCacheStorage) {
+	p.responseCache = cache
+}
+
 func (p *Proxy) Run(ctx context.Context) error {
 	var tsk *lib.Task
 
@@ -151,13 +166,13 @@ func (p *Proxy) run(ctx context.Context, prKey lib.HexString) error {
 		return err
 	}
 
-	walletAddr, err := lib.PrivKeyBytesToAddr(prKey)
+	walletAddr, err := interfaces.GetAddressCtx(ctx, p.wallet)
 	if err != nil {
 		return err
 	}
 	p.log.Infof("Wallet address: %s", walletAddr.String())
 
-	ethBalance, morBalance, err := p.blockchainService.GetBalance(ctx)
+	ethBalance, morBalance, err := p.blockchainService.GetBalance(p.blockchainService.WithProviderAccount(ctx))
 	if err != nil {
 		return err
 	}
@@ -187,6 +202,14 @@ func (p *Proxy) run(ctx context.Context, prKey lib.HexString) error {
 	}
 
 	proxyReceiver := proxyapi.NewProxyReceiver(prKey, pubKey, p.sessionStorage, p.aiEngine, p.chainID, p.modelConfigLoader, p.blockchainService, p.sessionRepo)
+	if signer, ok := p.wallet.(interfaces.Signer); ok {
+		// approvals are verified on-chain against the wallet address, MOR-RPC messages are signed with the transport key
+		proxyReceiver.SetEthMessageSigner(func(msg []byte) ([]byte, error) {
+			return signer.SignEthMessage(ctx, msg)
+		})
+	}
+	proxyReceiver.SetResponseCache(p.responseCache)
+	proxyReceiver.SetModeration(moderation.NewPipelines(p.log))
 	morTcpHandler := proxyapi.NewMORRPCController(proxyReceiver, p.validator, p.sessionRepo, p.sessionStorage, prKey)
 	tcpHandler := tcphandlers.NewTCPHandler(
 		p.tcpLog, morTcpHandler,
@@ -200,7 +223,7 @@ func (p *Proxy) run(ctx context.Context, prKey lib.HexString) error {
 
 	g.Go(func() error {
 		<-tcpServer.Started()
-		return p.afterStart(errCtx, walletAddr)
+		return p.afterStart(errCtx, walletAddr, pubKey)
 	})
 
 	g.Go(func() error {
@@ -211,10 +234,16 @@ func (p *Proxy) run(ctx context.Context, prKey lib.HexString) error {
 		return p.sessionExpiryHandler.Run(errCtx)
 	})
 
+	if p.webhooks != nil {
+		g.Go(func() error {
+			return p.monitorBalance(errCtx, walletAddr)
+		})
+	}
+
 	return g.Wait()
 }
 
-func (p *Proxy) afterStart(ctx context.Context, walletAddr common.Address) error {
+func (p *Proxy) afterStart(ctx context.Context, walletAddr common.Address, pubKey lib.HexString) error {
 	log := p.log.Named("PROVIDER_CHECK")
 
 	// check if provider exists
@@ -228,10 +257,23 @@ func (p *Proxy) afterStart(ctx context.Context, walletAddr common.Address) error
 	}
 
 	if pr != nil {
+		if _, ok := p.wallet.(interfaces.Signer); ok {
+			// pong is signed with the transport key, let the ping below verify it
+			err = p.sessionStorage.AddUser(&storages.User{Addr: pr.Address.Hex(), PubKey: pubKey.String(), Url: pr.Endpoint})
+			if err != nil {
+				log.Warnf("failed to store transport key: %s", err)
+			}
+		}
+
 		// check provider connectivity from localhost
 		pingDuration, err := p.blockchainService.CheckConnectivity(ctx, pr.Endpoint, pr.Address)
 		if err != nil {
 			log.Warnf("provider %s is not reachable from localhost by address %s: %s", pr.Address, pr.Endpoint, err)
+			p.webhooks.Emit(webhooks.EventProviderUnreachable, &webhooks.ProviderEventData{
+				Provider: pr.Address.Hex(),
+				Endpoint: pr.Endpoint,
+				Reason:   err.Error(),
+			})
 		} else {
 			log.Infof("provider is reachable from localhost, ping: %s", pingDuration)
 		}
@@ -242,6 +284,11 @@ func (p *Proxy) afterStart(ctx context.Context, walletAddr common.Address) error
 			log.Warnf("cannot check if port open for %s %s %s", pr.Address, pr.Endpoint, err)
 		} else if !ok {
 			log.Warnf("provider is not reachable from internet by %s", pr.Endpoint)
+			p.webhooks.Emit(webhooks.EventProviderUnreachable, &webhooks.ProviderEventData{
+				Provider: pr.Address.Hex(),
+				Endpoint: pr.Endpoint,
+				Reason:   "port is not open to the internet",
+			})
 		} else {
 			log.Infof("provider is reachable from internet")
 		}
@@ -277,6 +324,55 @@ func (p *Proxy) afterStart(ctx context.Context, walletAddr common.Address) error
 	return nil
 }
 
+// monitorBalance periodically checks the wallet balance and emits a webhook event when it drops below the threshold
+func (p *Proxy) monitorBalance(ctx context.Context, walletAddr common.Address) error {
+	cfg := p.webhooks.Balance()
+	ethThreshold, morThreshold := &ETHBalanceThreshold, &MORBalanceThreshold
+	if cfg.ETHThreshold != nil {
+		ethThreshold = cfg.ETHThreshold.Unpack()
+	}
+	if cfg.MORThreshold != nil {
+		morThreshold = cfg.MORThreshold.Unpack()
+	}
+
+	ticker := time.NewTicker(time.Duration(cfg.CheckIntervalSeconds) * time.Second)
+	defer ticker.Stop()
+
+	// the balance of the provider wallet is monitored
+	balanceCtx := p.blockchainService.WithProviderAccount(ctx)
+
+	ethLow, morLow := false, false
+	for {
+		ethBalance, morBalance, err := p.blockchainService.GetBalance(balanceCtx)
+		if err != nil {
+			p.log.Warnf("cannot check wallet balance: %s", err)
+		} else {
+			ethLow = p.checkBalance(walletAddr, "ETH", ethBalance, ethThreshold, ethLow)
+			morLow = p.checkBalance(walletAddr, "MOR", morBalance, morThreshold, morLow)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// checkBalance emits the event only when the balance crosses the threshold, returns if the balance is low
+func (p *Proxy) checkBalance(walletAddr common.Address, token string, balance, threshold *big.Int, wasLow bool) bool {
+	isLow := balance.Cmp(threshold) < 0
+	if isLow && !wasLow {
+		p.webhooks.Emit(webhooks.EventBalanceLow, &webhooks.BalanceEventData{
+			Address:   walletAddr.Hex(),
+			Token:     token,
+			Balance:   balance.String(),
+			Threshold: threshold.String(),
+		})
+	}
+	return isLow
+}
+
 func (p *Proxy) GetState() ProxyState {
 	return p.state.Load()
 }
@@ -0,0 +1,154 @@
+package rating
+
+import (
+	"fmt"
+	"math/big"
+	"net"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/lib"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+const (
+	ReasonNotInAllowList  = "provider is not in the allow list"
+	ReasonInDenyList      = "provider is in the deny list"
+	ReasonEndpointDenied  = "provider endpoint host matches deny pattern"
+	ReasonEndpointAllowed = "provider endpoint host does not match any allow pattern"
+	ReasonLowStake        = "provider stake is below minimum"
+	ReasonLowSessionCount = "provider session count for the model is below minimum"
+	ReasonHighPrice       = "bid price per second is above maximum"
+	ReasonInvalidScore    = "bid score is not valid"
+)
+
+// FilterConfig holds the conditions a bid should satisfy to be rated
+type FilterConfig struct {
+	EndpointDenyPatterns  []string    `json:"endpointDenyPatterns"`  // regexps matched against provider endpoint host
+	EndpointAllowPatterns []string    `json:"endpointAllowPatterns"` // if set, endpoint host must match at least one of them
+	MinProviderStake      *lib.BigInt `json:"minProviderStake"`
+	MinSessionCount       uint32      `json:"minSessionCount"` // minimum number of sessions of the provider for the model
+	MaxPricePerSecond     *lib.BigInt `json:"maxPricePerSecond"`
+}
+
+// Filter excludes bids that do not satisfy the configured conditions
+type Filter struct {
+	providerAllowList map[common.Address]struct{}
+	providerDenyList  map[common.Address]struct{}
+	endpointDeny      []*regexp.Regexp
+	endpointAllow     []*regexp.Regexp
+	minProviderStake  *big.Int
+	minSessionCount   uint32
+	maxPricePerSecond *big.Int
+}
+
+func NewFilter(cfg FilterConfig, providerAllowList, providerDenyList []common.Address) (*Filter, error) {
+	endpointDeny, err := compilePatterns(cfg.EndpointDenyPatterns)
+	if err != nil {
+		return nil, err
+	}
+	endpointAllow, err := compilePatterns(cfg.EndpointAllowPatterns)
+	if err != nil {
+		return nil, err
+	}
+
+	f := &Filter{
+		providerAllowList: addrSet(providerAllowList),
+		providerDenyList:  addrSet(providerDenyList),
+		endpointDeny:      endpointDeny,
+		endpointAllow:     endpointAllow,
+		minSessionCount:   cfg.MinSessionCount,
+	}
+	if cfg.MinProviderStake != nil {
+		f.minProviderStake = cfg.MinProviderStake.Unpack()
+	}
+	if cfg.MaxPricePerSecond != nil {
+		f.maxPricePerSecond = cfg.MaxPricePerSecond.Unpack()
+	}
+
+	return f, nil
+}
+
+// Check returns the reason the bid should be excluded, or empty string if the bid passes all filters
+func (f *Filter) Check(input *RatingInput) string {
+	if !f.isAllowed(input.ProviderID) {
+		return ReasonNotInAllowList
+	}
+	if _, ok := f.providerDenyList[input.ProviderID]; ok {
+		return ReasonInDenyList
+	}
+
+	if len(f.endpointDeny) > 0 || len(f.endpointAllow) > 0 {
+		host := endpointHost(input.ProviderEndpoint)
+		if matchAny(f.endpointDeny, host) {
+			return ReasonEndpointDenied
+		}
+		if len(f.endpointAllow) > 0 && !matchAny(f.endpointAllow, host) {
+			return ReasonEndpointAllowed
+		}
+	}
+
+	if f.minProviderStake != nil && (input.ProviderStake == nil || input.ProviderStake.Cmp(f.minProviderStake) < 0) {
+		return ReasonLowStake
+	}
+	if f.minSessionCount > 0 && (input.ProviderModel == nil || input.ProviderModel.TotalCount < f.minSessionCount) {
+		return ReasonLowSessionCount
+	}
+	if f.maxPricePerSecond != nil && input.PricePerSecond != nil && input.PricePerSecond.Cmp(f.maxPricePerSecond) > 0 {
+		return ReasonHighPrice
+	}
+
+	return ""
+}
+
+func (f *Filter) isAllowed(provider common.Address) bool {
+	if len(f.providerAllowList) == 0 {
+		return true
+	}
+	_, ok := f.providerAllowList[provider]
+	return ok
+}
+
+func compilePatterns(patterns []string) ([]*regexp.Regexp, error) {
+	res := make([]*regexp.Regexp, 0, len(patterns))
+	for _, p := range patterns {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return nil, lib.WrapError(ErrFilterParams, fmt.Errorf("pattern %s: %w", p, err))
+		}
+		res = append(res, re)
+	}
+	return res, nil
+}
+
+func matchAny(patterns []*regexp.Regexp, s string) bool {
+	for _, re := range patterns {
+		if re.MatchString(s) {
+			return true
+		}
+	}
+	return false
+}
+
+// endpointHost extracts the host from the provider endpoint, which is usually in host:port format
+func endpointHost(endpoint string) string {
+	if strings.Contains(endpoint, "://") {
+		if u, err := url.Parse(endpoint); err == nil {
+			return u.Hostname()
+		}
+	}
+	host, _, err := net.SplitHostPort(endpoint)
+	if err != nil {
+		return endpoint
+	}
+	return host
+}
+
+func addrSet(addrs []common.Address) map[common.Address]struct{} {
+	set := make(map[common.Address]struct{}, len(addrs))
+	for _, addr := range addrs {
+		set[addr] = struct{}{}
+	}
+	return set
+}
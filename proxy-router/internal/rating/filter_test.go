@@ -0,0 +1,96 @@
+package rating
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/lib"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/require"
+)
+
+func sampleRatingInput() *RatingInput {
+	args := NewScoreArgs()
+	args.PricePerSecond.SetUint64(100)
+	args.ProviderStake.SetUint64(1000)
+	args.ProviderModel.TotalCount = 5
+
+	return &RatingInput{
+		ScoreInput:       *args,
+		BidID:            common.HexToHash("0x01"),
+		ModelID:          common.HexToHash("0x02"),
+		ProviderID:       common.HexToAddress("0x03"),
+		ProviderEndpoint: "provider.example.com:3333",
+	}
+}
+
+func TestFilterPassesByDefault(t *testing.T) {
+	f, err := NewFilter(FilterConfig{}, nil, nil)
+	require.NoError(t, err)
+	require.Equal(t, "", f.Check(sampleRatingInput()))
+}
+
+func TestFilterAllowDenyList(t *testing.T) {
+	input := sampleRatingInput()
+
+	f, err := NewFilter(FilterConfig{}, []common.Address{common.HexToAddress("0x04")}, nil)
+	require.NoError(t, err)
+	require.Equal(t, ReasonNotInAllowList, f.Check(input))
+
+	f, err = NewFilter(FilterConfig{}, nil, []common.Address{input.ProviderID})
+	require.NoError(t, err)
+	require.Equal(t, ReasonInDenyList, f.Check(input))
+}
+
+func TestFilterEndpointPatterns(t *testing.T) {
+	input := sampleRatingInput()
+
+	f, err := NewFilter(FilterConfig{EndpointDenyPatterns: []string{`\.example\.com$`}}, nil, nil)
+	require.NoError(t, err)
+	require.Equal(t, ReasonEndpointDenied, f.Check(input))
+
+	f, err = NewFilter(FilterConfig{EndpointAllowPatterns: []string{`^(\d{1,3}\.){3}\d{1,3}$`}}, nil, nil)
+	require.NoError(t, err)
+	require.Equal(t, ReasonEndpointAllowed, f.Check(input))
+
+	input.ProviderEndpoint = "10.0.0.1:3333"
+	require.Equal(t, "", f.Check(input))
+}
+
+func TestFilterInvalidPattern(t *testing.T) {
+	_, err := NewFilter(FilterConfig{EndpointDenyPatterns: []string{`(`}}, nil, nil)
+	require.ErrorIs(t, err, ErrFilterParams)
+}
+
+func TestFilterStakeSessionsPrice(t *testing.T) {
+	input := sampleRatingInput()
+
+	f, err := NewFilter(FilterConfig{MinProviderStake: &lib.BigInt{Int: *big.NewInt(1001)}}, nil, nil)
+	require.NoError(t, err)
+	require.Equal(t, ReasonLowStake, f.Check(input))
+
+	f, err = NewFilter(FilterConfig{MinSessionCount: 6}, nil, nil)
+	require.NoError(t, err)
+	require.Equal(t, ReasonLowSessionCount, f.Check(input))
+
+	f, err = NewFilter(FilterConfig{MaxPricePerSecond: &lib.BigInt{Int: *big.NewInt(99)}}, nil, nil)
+	require.NoError(t, err)
+	require.Equal(t, ReasonHighPrice, f.Check(input))
+}
+
+func TestRateBidsReturnsExcluded(t *testing.T) {
+	input := sampleRatingInput()
+	denied := *sampleRatingInput()
+	denied.BidID = common.HexToHash("0x05")
+	denied.ProviderID = common.HexToAddress("0x06")
+
+	r, err := NewRating(NewScorerMock(), nil, []common.Address{denied.ProviderID}, FilterConfig{}, lib.NewTestLogger())
+	require.NoError(t, err)
+
+	rated, excluded := r.RateBids([]RatingInput{*input, denied}, lib.NewTestLogger())
+	require.Len(t, rated, 1)
+	require.Equal(t, input.BidID, rated[0].BidID)
+	require.Len(t, excluded, 1)
+	require.Equal(t, denied.BidID, excluded[0].BidID)
+	require.Equal(t, ReasonInDenyList, excluded[0].Reason)
+}
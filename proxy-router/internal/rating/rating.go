@@ -10,22 +10,26 @@ import (
 
 // Filters bids based on the config, uses the scorer to rate the bids and sorts them
 type Rating struct {
-	scorer            Scorer
-	providerAllowList map[common.Address]struct{}
+	scorer Scorer
+	filter *Filter
 }
 
-func (r *Rating) RateBids(scoreInputs []RatingInput, log lib.ILogger) []RatingRes {
+// RateBids returns the rated bids sorted by score and the bids excluded by filters with the reason
+func (r *Rating) RateBids(scoreInputs []RatingInput, log lib.ILogger) ([]RatingRes, []ExcludedRes) {
 	scoredBids := make([]RatingRes, 0)
+	excludedBids := make([]ExcludedRes, 0)
 
 	for _, input := range scoreInputs {
-		score := r.scorer.GetScore(&input.ScoreInput)
-		if !r.isAllowed(input.ProviderID) {
-			log.Warnf("provider %s is not in the allow list, skipping", input.ProviderID.String())
+		if reason := r.filter.Check(&input); reason != "" {
+			log.Warnf("bid %s of provider %s excluded: %s", input.BidID.Hex(), input.ProviderID.String(), reason)
+			excludedBids = append(excludedBids, ExcludedRes{BidID: input.BidID, Reason: reason})
 			continue
 		}
 
+		score := r.scorer.GetScore(&input.ScoreInput)
 		if math.IsNaN(score) || math.IsInf(score, 0) {
-			log.Warnf("provider score is not valid %d for %+v), skipping", score, input)
+			log.Warnf("provider score is not valid %f for %+v), skipping", score, input)
+			excludedBids = append(excludedBids, ExcludedRes{BidID: input.BidID, Reason: ReasonInvalidScore})
 			continue
 		}
 
@@ -40,25 +44,23 @@ func (r *Rating) RateBids(scoreInputs []RatingInput, log lib.ILogger) []RatingRe
 		return scoredBids[i].Score > scoredBids[j].Score
 	})
 
-	return scoredBids
-}
-
-func (r *Rating) isAllowed(provider common.Address) bool {
-	if len(r.providerAllowList) == 0 {
-		return true
-	}
-	_, ok := r.providerAllowList[provider]
-	return ok
+	return scoredBids, excludedBids
 }
 
 type RatingInput struct {
 	ScoreInput
-	BidID      common.Hash
-	ModelID    common.Hash
-	ProviderID common.Address
+	BidID            common.Hash
+	ModelID          common.Hash
+	ProviderID       common.Address
+	ProviderEndpoint string
 }
 
 type RatingRes struct {
 	BidID common.Hash
 	Score float64
 }
+
+type ExcludedRes struct {
+	BidID  common.Hash
+	Reason string
+}
@@ -17,6 +17,8 @@ type RatingConfig struct {
 	Algorithm         string           `json:"algorithm"`
 	Params            json.RawMessage  `json:"params"`
 	ProviderAllowList []common.Address `json:"providerAllowlist"`
+	ProviderDenyList  []common.Address `json:"providerDenylist"`
+	Filters           FilterConfig     `json:"filters"`
 }
 
 func NewRatingFromConfig(config json.RawMessage, log lib.ILogger) (*Rating, error) {
@@ -33,10 +35,10 @@ func NewRatingFromConfig(config json.RawMessage, log lib.ILogger) (*Rating, erro
 		return nil, err
 	}
 
-	return NewRating(scorer, cfg.ProviderAllowList, log), nil
+	return NewRating(scorer, cfg.ProviderAllowList, cfg.ProviderDenyList, cfg.Filters, log)
 }
 
-func NewRating(scorer Scorer, providerAllowList []common.Address, log lib.ILogger) *Rating {
+func NewRating(scorer Scorer, providerAllowList []common.Address, providerDenyList []common.Address, filters FilterConfig, log lib.ILogger) (*Rating, error) {
 	allowList := map[common.Address]struct{}{}
 
 	if providerAllowList != nil {
@@ -67,15 +69,25 @@ func NewRating(scorer Scorer, providerAllowList []common.Address, log lib.ILogge
 		log.Warnf("provider filtering is enabled, allowList: %v", keys)
 	}
 
-	return &Rating{
-		scorer:            scorer,
-		providerAllowList: allowList,
+	if len(providerDenyList) > 0 {
+		log.Warnf("provider deny list is enabled, denyList: %v", providerDenyList)
 	}
+
+	filter, err := NewFilter(filters, maps.Keys(allowList), providerDenyList)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Rating{
+		scorer: scorer,
+		filter: filter,
+	}, nil
 }
 
 var (
 	ErrUnknownAlgorithm = errors.New("unknown rating algorithm")
 	ErrAlgorithmParams  = errors.New("invalid algorithm params")
+	ErrFilterParams     = errors.New("invalid filter params")
 )
 
 func factory(algo string, params json.RawMessage) (a Scorer, err error) {
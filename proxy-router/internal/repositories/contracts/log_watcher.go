@@ -10,4 +10,12 @@ import (
 
 type LogWatcher interface {
 	Watch(ctx context.Context, contractAddr common.Address, mapper EventMapper, fromBlock *big.Int) (*lib.Subscription, error)
+	// WatchFromCheckpoint resumes watching from the last processed block persisted under the key, or from the latest block if there is no checkpoint
+	WatchFromCheckpoint(ctx context.Context, key string, contractAddr common.Address, mapper EventMapper) (*lib.Subscription, error)
+}
+
+// CheckpointStorage persists the last processed block of a watcher
+type CheckpointStorage interface {
+	GetCheckpoint(key string) (block uint64, hash common.Hash, ok bool)
+	SetCheckpoint(key string, block uint64, hash common.Hash) error
 }
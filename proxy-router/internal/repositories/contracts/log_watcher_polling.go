@@ -5,14 +5,23 @@ import (
 	"errors"
 	"fmt"
 	"math/big"
+	"slices"
 	"time"
 
 	i "github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/interfaces"
 	"github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/lib"
+	"github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/metrics"
 	"github.com/ethereum/go-ethereum"
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
 )
 
+// MaxReorgDepth is the number of recent processed blocks which hashes are kept to find the common ancestor on reorg
+const MaxReorgDepth = 128
+
+// DefaultMaxBlockRange is the default max number of blocks requested with a single FilterLogs
+const DefaultMaxBlockRange = 10000
+
 var (
 	SubClosedError = errors.New("subscription closed")
 )
@@ -21,142 +30,310 @@ type LogWatcherPolling struct {
 	// config
 	maxReconnects int
 	pollInterval  time.Duration
+	confirmations uint64
+	maxBlockRange uint64
 
 	// deps
-	client i.EthClient
-	log    lib.ILogger
+	client      i.EthClient
+	checkpoints CheckpointStorage
+	log         lib.ILogger
 }
 
-func NewLogWatcherPolling(client i.EthClient, pollInterval time.Duration, maxReconnects int, log lib.ILogger) *LogWatcherPolling {
+// pollState is the progress of a single watch
+type pollState struct {
+	key           string                 // checkpoint key, empty if the progress is not persisted
+	nextFromBlock *big.Int               // nil means start from the latest confirmed block
+	hashes        map[uint64]common.Hash // hashes of recently processed blocks
+}
+
+// NewLogWatcherPolling creates a new log watcher polling the node for new logs. Only logs of blocks with
+// at least confirmations blocks on top of them are emitted. If checkpoints is not nil, the progress
+// of WatchFromCheckpoint calls is persisted
+func NewLogWatcherPolling(client i.EthClient, pollInterval time.Duration, maxReconnects int, confirmations uint64, checkpoints CheckpointStorage, log lib.ILogger) *LogWatcherPolling {
 	return &LogWatcherPolling{
 		client:        client,
 		pollInterval:  pollInterval,
 		maxReconnects: maxReconnects,
+		confirmations: confirmations,
+		maxBlockRange: DefaultMaxBlockRange,
+		checkpoints:   checkpoints,
 		log:           log.Named("POLLING"),
 	}
 }
 
-func (w *LogWatcherPolling) Watch(ctx context.Context, contractAddr common.Address, mapper EventMapper, fromBlock *big.Int) (*lib.Subscription, error) {
-	var nextFromBlock *big.Int
+// SetMaxBlockRange limits the blocks requested at once, e.g. when catching up after downtime, as public
+// nodes reject large ranges. The checkpoint is saved after each range, 0 disables the limit
+func (w *LogWatcherPolling) SetMaxBlockRange(maxBlockRange uint64) {
+	w.maxBlockRange = maxBlockRange
+}
 
+func (w *LogWatcherPolling) Watch(ctx context.Context, contractAddr common.Address, mapper EventMapper, fromBlock *big.Int) (*lib.Subscription, error) {
+	state := &pollState{hashes: make(map[uint64]common.Hash)}
 	if fromBlock != nil {
-		nextFromBlock = new(big.Int).Set(fromBlock)
+		state.nextFromBlock = new(big.Int).Set(fromBlock)
 	}
+	return w.watch(ctx, state, contractAddr, mapper), nil
+}
 
+func (w *LogWatcherPolling) WatchFromCheckpoint(ctx context.Context, key string, contractAddr common.Address, mapper EventMapper) (*lib.Subscription, error) {
+	state := &pollState{key: key, hashes: make(map[uint64]common.Hash)}
+
+	if w.checkpoints != nil {
+		block, hash, ok := w.checkpoints.GetCheckpoint(key)
+		if ok {
+			// the checkpoint hash is verified on the first poll, so reorgs during downtime are detected
+			state.nextFromBlock = new(big.Int).SetUint64(block + 1)
+			metrics.LogWatcherBlock.Set(float64(block))
+			state.hashes[block] = hash
+			w.log.Infof("resuming %s from checkpoint block %d", key, block)
+		}
+	}
+
+	return w.watch(ctx, state, contractAddr, mapper), nil
+}
+
+func (w *LogWatcherPolling) watch(ctx context.Context, state *pollState, contractAddr common.Address, mapper EventMapper) *lib.Subscription {
 	sink := make(chan interface{})
 	return lib.NewSubscription(func(quit <-chan struct{}) error {
 		defer close(sink)
 
 		for { // infinite polling loop
-			nextFrom, err := w.pollReconnect(ctx, quit, nextFromBlock, contractAddr, mapper, sink)
+			err := w.pollReconnect(ctx, quit, state, contractAddr, mapper, sink)
 			if err != nil {
 				return err
 			}
-			nextFromBlock = nextFrom
 		}
-	}, sink), nil
+	}, sink)
 }
 
-func (w *LogWatcherPolling) pollReconnect(ctx context.Context, quit <-chan struct{}, nextFromBlock *big.Int, contractAddr common.Address, mapper EventMapper, sink chan interface{}) (*big.Int, error) {
+func (w *LogWatcherPolling) pollReconnect(ctx context.Context, quit <-chan struct{}, state *pollState, contractAddr common.Address, mapper EventMapper, sink chan interface{}) error {
 	var lastErr error
 	for i := 0; i < w.maxReconnects || w.maxReconnects == 0; i++ {
 		// for any of those cases, we should stop retrying
 		select {
 		case <-quit:
-			return nextFromBlock, SubClosedError
+			return SubClosedError
 		case <-ctx.Done():
-			return nextFromBlock, ctx.Err()
+			return ctx.Err()
 		default:
 		}
-		newNextFromBlock, err := w.pollChanges(ctx, nextFromBlock, quit, contractAddr, mapper, sink)
+		err := w.pollChanges(ctx, state, quit, contractAddr, mapper, sink)
 		if err == nil {
-			return newNextFromBlock, nil
+			return nil
+		}
+		if errors.Is(err, SubClosedError) || ctx.Err() != nil {
+			return err
 		}
 		maxReconnects := fmt.Sprintf("%d", w.maxReconnects)
 		if w.maxReconnects == 0 {
 			maxReconnects = "∞"
 		}
+		metrics.LogWatcherErrors.WithLabelValues("polling").Inc()
 		w.log.Warnf("request error, retrying (%d/%s): %s", i, maxReconnects, err)
 		lastErr = err
 
 		// retry delay
 		select {
 		case <-quit:
-			return nextFromBlock, SubClosedError
+			return SubClosedError
 		case <-ctx.Done():
-			return nextFromBlock, ctx.Err()
+			return ctx.Err()
 		case <-time.After(w.pollInterval):
 		}
 	}
 
-	err := fmt.Errorf("request error, retries exhausted (%d), stopping: %s", w.maxReconnects, lastErr)
+	err := fmt.Errorf("request error, retries exhausted (%d), stopping: %w", w.maxReconnects, lastErr)
 	w.log.Warnf(err.Error())
-	return nextFromBlock, err
+	return err
 }
 
-func (w *LogWatcherPolling) pollChanges(ctx context.Context, nextFromBlock *big.Int, quit <-chan struct{}, contractAddr common.Address, mapper EventMapper, sink chan interface{}) (*big.Int, error) {
+func (w *LogWatcherPolling) pollChanges(ctx context.Context, state *pollState, quit <-chan struct{}, contractAddr common.Address, mapper EventMapper, sink chan interface{}) error {
 	currentBlock, err := w.client.HeaderByNumber(ctx, nil)
 	if err != nil {
-		return nil, err
+		return err
 	}
 
-	if nextFromBlock == nil {
-		nextFromBlock = new(big.Int).Set(currentBlock.Number)
+	// latest block with enough confirmations
+	toBlock := new(big.Int).Sub(currentBlock.Number, new(big.Int).SetUint64(w.confirmations))
+	if toBlock.Sign() < 0 {
+		return w.wait(ctx, quit)
+	}
+
+	if state.nextFromBlock == nil {
+		state.nextFromBlock = new(big.Int).Set(toBlock)
+	}
+
+	err = w.handleReorg(ctx, state)
+	if err != nil {
+		return err
 	}
 
 	// if we poll too often, we might be behind the chain, so we wait for the next block
-	// mapper error, retry won't help, but we can continue
-	if currentBlock.Number.Cmp(nextFromBlock) < 0 {
-		select {
-		case <-quit:
-			return nextFromBlock, SubClosedError
-		case <-ctx.Done():
-			return nextFromBlock, ctx.Err()
-		case <-time.After(w.pollInterval):
+	if toBlock.Cmp(state.nextFromBlock) < 0 {
+		return w.wait(ctx, quit)
+	}
+
+	for state.nextFromBlock.Cmp(toBlock) <= 0 {
+		rangeTo := toBlock
+		if w.maxBlockRange > 0 {
+			last := new(big.Int).Add(state.nextFromBlock, new(big.Int).SetUint64(w.maxBlockRange-1))
+			if last.Cmp(toBlock) < 0 {
+				rangeTo = last
+			}
+		}
+
+		var toHeader *types.Header
+		if w.confirmations == 0 && rangeTo.Cmp(currentBlock.Number) == 0 {
+			toHeader = currentBlock
+		}
+		err = w.pollRange(ctx, state, quit, contractAddr, mapper, sink, rangeTo, toHeader)
+		if err != nil {
+			return err
 		}
-		return nextFromBlock, nil
 	}
 
+	return w.wait(ctx, quit)
+}
+
+// pollRange emits the events from the next block to toBlock and commits toBlock. toHeader is
+// the header of toBlock, it is requested if nil
+func (w *LogWatcherPolling) pollRange(ctx context.Context, state *pollState, quit <-chan struct{}, contractAddr common.Address, mapper EventMapper, sink chan interface{}, toBlock *big.Int, toHeader *types.Header) error {
 	query := ethereum.FilterQuery{
 		Addresses: []common.Address{contractAddr},
-		FromBlock: nextFromBlock,
-		ToBlock:   currentBlock.Number,
+		FromBlock: state.nextFromBlock,
+		ToBlock:   toBlock,
 	}
 
 	w.log.Debugf("requesting changes from %s to %s block", query.FromBlock.String(), query.ToBlock.String())
 	sub, err := w.client.FilterLogs(ctx, query)
 	if err != nil {
-		return nextFromBlock, err
+		return err
+	}
+
+	if toHeader == nil {
+		toHeader, err = w.client.HeaderByNumber(ctx, toBlock)
+		if err != nil {
+			return err
+		}
 	}
 
 	for _, log := range sub {
 		if log.Removed {
 			continue
 		}
+		state.hashes[log.BlockNumber] = log.BlockHash
+
 		event, err := mapper(log)
 		if err != nil {
+			// mapper error, retry won't help, but we can continue
 			w.log.Debugf("error mapping event, skipping: %s", err)
 			continue
 		}
 
 		select {
 		case <-quit:
-			return nextFromBlock, SubClosedError
+			return SubClosedError
 		case <-ctx.Done():
-			return nextFromBlock, ctx.Err()
+			return ctx.Err()
 		case sink <- event:
+			metrics.LogWatcherEvents.WithLabelValues("polling").Inc()
+		}
+	}
+
+	return w.commit(state, toHeader)
+}
+
+// commit marks the block as processed and persists the checkpoint
+func (w *LogWatcherPolling) commit(state *pollState, header *types.Header) error {
+	block := header.Number.Uint64()
+	state.hashes[block] = header.Hash()
+	state.nextFromBlock = new(big.Int).SetUint64(block + 1)
+
+	for number := range state.hashes {
+		if number+MaxReorgDepth < block {
+			delete(state.hashes, number)
+		}
+	}
+
+	if state.key == "" || w.checkpoints == nil {
+		return nil
+	}
+	return w.checkpoints.SetCheckpoint(state.key, block, header.Hash())
+}
+
+// handleReorg checks that the last processed block is still in the chain, otherwise
+// rewinds to the common ancestor, so the events of the new chain are emitted
+func (w *LogWatcherPolling) handleReorg(ctx context.Context, state *pollState) error {
+	if state.nextFromBlock.Sign() == 0 {
+		return nil
+	}
+	last := state.nextFromBlock.Uint64() - 1
+	lastHash, ok := state.hashes[last]
+	if !ok {
+		return nil
+	}
+
+	header, err := w.client.HeaderByNumber(ctx, new(big.Int).SetUint64(last))
+	if err != nil && !errors.Is(err, ethereum.NotFound) {
+		return err
+	}
+	if header != nil && header.Hash() == lastHash {
+		return nil
+	}
+
+	ancestor, err := w.findAncestor(ctx, state, last)
+	if err != nil {
+		return err
+	}
+	metrics.LogWatcherReorgs.Inc()
+	w.log.Warnf("chain reorganization detected at block %d, replaying events from block %d", last, ancestor+1)
+
+	for number := range state.hashes {
+		if number > ancestor {
+			delete(state.hashes, number)
+		}
+	}
+	state.nextFromBlock = new(big.Int).SetUint64(ancestor + 1)
+	return nil
+}
+
+// findAncestor returns the latest processed block which hash matches the chain. If none of them
+// match, it returns the block MaxReorgDepth blocks behind the last processed one
+func (w *LogWatcherPolling) findAncestor(ctx context.Context, state *pollState, last uint64) (uint64, error) {
+	numbers := make([]uint64, 0, len(state.hashes))
+	for number := range state.hashes {
+		if number < last {
+			numbers = append(numbers, number)
+		}
+	}
+	slices.Sort(numbers)
+
+	for j := len(numbers) - 1; j >= 0; j-- {
+		header, err := w.client.HeaderByNumber(ctx, new(big.Int).SetUint64(numbers[j]))
+		if errors.Is(err, ethereum.NotFound) {
+			continue
+		}
+		if err != nil {
+			return 0, err
+		}
+		if header.Hash() == state.hashes[numbers[j]] {
+			return numbers[j], nil
 		}
 	}
 
-	nextFromBlock.Add(currentBlock.Number, big.NewInt(1))
+	if last < MaxReorgDepth {
+		return 0, nil
+	}
+	return last - MaxReorgDepth, nil
+}
 
+func (w *LogWatcherPolling) wait(ctx context.Context, quit <-chan struct{}) error {
 	select {
 	case <-quit:
-		return nextFromBlock, SubClosedError
+		return SubClosedError
 	case <-ctx.Done():
-		return nextFromBlock, ctx.Err()
+		return ctx.Err()
 	case <-time.After(w.pollInterval):
 	}
-
-	return nextFromBlock, nil
+	return nil
 }
@@ -5,7 +5,11 @@ import (
 	"errors"
 	"fmt"
 	"math/big"
+	"slices"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/interfaces/mocks"
 	"github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/lib"
@@ -22,33 +26,43 @@ func TestLogWatcherPolling(t *testing.T) {
 	failTimes := 5
 
 	ethClientMock := mocks.NewEthClientMock(t)
+	ethClientMock.EXPECT().HeaderByNumber(mock.Anything, mock.Anything).Return(&types.Header{Number: big.NewInt(1)}, nil)
 	call1 := ethClientMock.EXPECT().FilterLogs(mock.Anything, mock.Anything).Return(nil, TEST_ERR).Times(failTimes)
 	_ = ethClientMock.EXPECT().FilterLogs(mock.Anything, mock.Anything).Return([]types.Log{}, nil).Times(1).NotBefore(call1)
-	logWatcherPolling := NewLogWatcherPolling(ethClientMock, 0, 10, lib.NewTestLogger())
+	logWatcherPolling := NewLogWatcherPolling(ethClientMock, 0, 10, 0, nil, lib.NewTestLogger())
 
-	_, err := logWatcherPolling.filterLogsRetry(context.Background(), ethereum.FilterQuery{}, make(<-chan struct{}))
+	state := &pollState{nextFromBlock: big.NewInt(1), hashes: make(map[uint64]common.Hash)}
+	err := logWatcherPolling.pollReconnect(context.Background(), make(<-chan struct{}), state, common.Address{}, eventMapper, make(chan interface{}))
 	require.NoError(t, err)
 	ethClientMock.AssertNumberOfCalls(t, "FilterLogs", failTimes+1)
 }
 
 func TestWatchDoesntReturnEventsTwice(t *testing.T) {
 	ethClientMock := mocks.NewEthClientMock(t)
+	head := atomic.Int64{}
+	ethClientMock.EXPECT().HeaderByNumber(mock.Anything, mock.Anything).RunAndReturn(func(ctx context.Context, number *big.Int) (*types.Header, error) {
+		if number == nil {
+			// chain advances by one block on each poll
+			return &types.Header{Number: big.NewInt(head.Add(1))}, nil
+		}
+		return &types.Header{Number: number}, nil
+	})
 	event1 := types.Log{
-		BlockNumber: 2,
+		BlockNumber: 1,
 		Index:       1,
-		Data:        []byte{2},
+		Data:        []byte{1},
 	}
 	event2 := types.Log{
-		BlockNumber: 3,
+		BlockNumber: 2,
 		Index:       1,
-		Data:        []byte{3},
+		Data:        []byte{2},
 	}
 
 	_ = ethClientMock.EXPECT().FilterLogs(mock.Anything, matchBlockNumber(1)).Return([]types.Log{event1}, nil)
 	_ = ethClientMock.EXPECT().FilterLogs(mock.Anything, matchBlockNumber(2)).Return([]types.Log{event2}, nil)
 	_ = ethClientMock.EXPECT().FilterLogs(mock.Anything, mock.Anything).Return([]types.Log{}, nil)
 
-	logWatcherPolling := NewLogWatcherPolling(ethClientMock, 0, 10, lib.NewTestLogger())
+	logWatcherPolling := NewLogWatcherPolling(ethClientMock, 0, 10, 0, nil, lib.NewTestLogger())
 	sub, err := logWatcherPolling.Watch(context.Background(), common.Address{}, eventMapper, big.NewInt(1))
 	require.NoError(t, err)
 	defer sub.Unsubscribe()
@@ -76,11 +90,12 @@ OUTER:
 
 func TestWatchShouldErrorAfterMaxReconnects(t *testing.T) {
 	ethClientMock := mocks.NewEthClientMock(t)
+	ethClientMock.EXPECT().HeaderByNumber(mock.Anything, mock.Anything).Return(&types.Header{Number: big.NewInt(1)}, nil)
 	maxRetries := 10
 
 	_ = ethClientMock.EXPECT().FilterLogs(mock.Anything, mock.Anything).Return([]types.Log{}, TEST_ERR)
 
-	logWatcherPolling := NewLogWatcherPolling(ethClientMock, 0, maxRetries, lib.NewTestLogger())
+	logWatcherPolling := NewLogWatcherPolling(ethClientMock, 0, maxRetries, 0, nil, lib.NewTestLogger())
 	sub, err := logWatcherPolling.Watch(context.Background(), common.Address{}, eventMapper, big.NewInt(1))
 	require.NoError(t, err)
 	defer sub.Unsubscribe()
@@ -103,9 +118,10 @@ func TestShouldHandleContextCancellation(t *testing.T) {
 	ethClientMock := mocks.NewEthClientMock(t)
 	ctx, cancel := context.WithCancel(context.Background())
 
-	_ = ethClientMock.EXPECT().FilterLogs(mock.Anything, mock.Anything).Return([]types.Log{}, nil)
+	ethClientMock.EXPECT().HeaderByNumber(mock.Anything, mock.Anything).Return(&types.Header{Number: big.NewInt(1)}, nil).Maybe()
+	_ = ethClientMock.EXPECT().FilterLogs(mock.Anything, mock.Anything).Return([]types.Log{}, nil).Maybe()
 
-	logWatcherPolling := NewLogWatcherPolling(ethClientMock, 0, 10, lib.NewTestLogger())
+	logWatcherPolling := NewLogWatcherPolling(ethClientMock, 0, 10, 0, nil, lib.NewTestLogger())
 	sub, err := logWatcherPolling.Watch(ctx, common.Address{}, eventMapper, big.NewInt(1))
 	require.NoError(t, err)
 	defer sub.Unsubscribe()
@@ -127,9 +143,10 @@ func TestShouldHandleContextCancellation(t *testing.T) {
 
 func TestShouldUnsubscribe(t *testing.T) {
 	ethClientMock := mocks.NewEthClientMock(t)
-	_ = ethClientMock.EXPECT().FilterLogs(mock.Anything, mock.Anything).Return([]types.Log{}, nil)
+	ethClientMock.EXPECT().HeaderByNumber(mock.Anything, mock.Anything).Return(&types.Header{Number: big.NewInt(1)}, nil).Maybe()
+	_ = ethClientMock.EXPECT().FilterLogs(mock.Anything, mock.Anything).Return([]types.Log{}, nil).Maybe()
 
-	logWatcherPolling := NewLogWatcherPolling(ethClientMock, 0, 10, lib.NewTestLogger())
+	logWatcherPolling := NewLogWatcherPolling(ethClientMock, 0, 10, 0, nil, lib.NewTestLogger())
 	sub, err := logWatcherPolling.Watch(context.Background(), common.Address{}, eventMapper, big.NewInt(1))
 	require.NoError(t, err)
 
@@ -157,3 +174,233 @@ func matchBlockNumber(blockNumber int) any {
 func eventMapper(log types.Log) (interface{}, error) {
 	return log, nil
 }
+
+// testChain is an in-memory chain, fork changes the hashes of the blocks starting from the given number
+type testChain struct {
+	mu      sync.Mutex
+	head    uint64
+	forks   map[uint64]byte // block number -> fork id
+	logs    []types.Log
+	queries []ethereum.FilterQuery
+}
+
+func newTestChain(head uint64) *testChain {
+	return &testChain{head: head, forks: make(map[uint64]byte)}
+}
+
+func (c *testChain) header(number uint64) *types.Header {
+	return &types.Header{Number: new(big.Int).SetUint64(number), Extra: []byte{c.forks[number]}}
+}
+
+func (c *testChain) HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if number == nil {
+		return c.header(c.head), nil
+	}
+	if number.Uint64() > c.head {
+		return nil, ethereum.NotFound
+	}
+	return c.header(number.Uint64()), nil
+}
+
+func (c *testChain) FilterLogs(ctx context.Context, q ethereum.FilterQuery) ([]types.Log, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.queries = append(c.queries, q)
+	res := []types.Log{}
+	for _, log := range c.logs {
+		if log.BlockNumber >= q.FromBlock.Uint64() && log.BlockNumber <= q.ToBlock.Uint64() {
+			log.BlockHash = c.header(log.BlockNumber).Hash()
+			res = append(res, log)
+		}
+	}
+	return res, nil
+}
+
+func (c *testChain) addLog(block uint64, data byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.logs = append(c.logs, types.Log{BlockNumber: block, Data: []byte{data}})
+}
+
+// reorg replaces the blocks starting from the given number, dropping their logs
+func (c *testChain) reorg(from uint64, fork byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for n := from; n <= c.head; n++ {
+		c.forks[n] = fork
+	}
+	c.logs = slices.DeleteFunc(c.logs, func(log types.Log) bool { return log.BlockNumber >= from })
+}
+
+func (c *testChain) setHead(head uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.head = head
+}
+
+func (c *testChain) mock(t *testing.T) *mocks.EthClientMock {
+	ethClientMock := mocks.NewEthClientMock(t)
+	ethClientMock.EXPECT().HeaderByNumber(mock.Anything, mock.Anything).RunAndReturn(c.HeaderByNumber).Maybe()
+	ethClientMock.EXPECT().FilterLogs(mock.Anything, mock.Anything).RunAndReturn(c.FilterLogs).Maybe()
+	return ethClientMock
+}
+
+type memCheckpoints struct {
+	mu     sync.Mutex
+	blocks map[string]uint64
+	hashes map[string]common.Hash
+}
+
+func newMemCheckpoints() *memCheckpoints {
+	return &memCheckpoints{blocks: make(map[string]uint64), hashes: make(map[string]common.Hash)}
+}
+
+func (m *memCheckpoints) GetCheckpoint(key string) (uint64, common.Hash, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	block, ok := m.blocks[key]
+	return block, m.hashes[key], ok
+}
+
+func (m *memCheckpoints) SetCheckpoint(key string, block uint64, hash common.Hash) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.blocks[key], m.hashes[key] = block, hash
+	return nil
+}
+
+func nextEventData(t *testing.T, sub *lib.Subscription) byte {
+	select {
+	case e := <-sub.Events():
+		return e.(types.Log).Data[0]
+	case err := <-sub.Err():
+		require.FailNow(t, "unexpected subscription error", err)
+	case <-time.After(5 * time.Second):
+		require.FailNow(t, "timeout waiting for event")
+	}
+	return 0
+}
+
+func TestWatchRespectsConfirmations(t *testing.T) {
+	chain := newTestChain(10)
+	chain.addLog(5, 5)
+	chain.addLog(8, 8)
+
+	w := NewLogWatcherPolling(chain.mock(t), time.Millisecond, 10, 3, nil, lib.NewTestLogger())
+	sub, err := w.Watch(context.Background(), common.Address{}, eventMapper, big.NewInt(1))
+	require.NoError(t, err)
+	defer sub.Unsubscribe()
+
+	require.Equal(t, byte(5), nextEventData(t, sub))
+
+	// block 8 gets enough confirmations only at head 11
+	chain.setHead(11)
+	require.Equal(t, byte(8), nextEventData(t, sub))
+
+	chain.mu.Lock()
+	defer chain.mu.Unlock()
+	for _, q := range chain.queries {
+		require.LessOrEqual(t, q.ToBlock.Uint64(), chain.head-3)
+	}
+}
+
+func TestWatchFromCheckpointResumes(t *testing.T) {
+	chain := newTestChain(10)
+	chain.addLog(4, 4)
+	chain.addLog(7, 7)
+	checkpoints := newMemCheckpoints()
+	require.NoError(t, checkpoints.SetCheckpoint("test", 5, chain.header(5).Hash()))
+
+	w := NewLogWatcherPolling(chain.mock(t), time.Millisecond, 10, 0, checkpoints, lib.NewTestLogger())
+	sub, err := w.WatchFromCheckpoint(context.Background(), "test", common.Address{}, eventMapper)
+	require.NoError(t, err)
+
+	// the event before the checkpoint is not replayed
+	require.Equal(t, byte(7), nextEventData(t, sub))
+	sub.Unsubscribe()
+
+	block, hash, ok := checkpoints.GetCheckpoint("test")
+	require.True(t, ok)
+	require.Equal(t, uint64(10), block)
+	require.Equal(t, chain.header(10).Hash(), hash)
+}
+
+func TestWatchReplaysEventsOnReorg(t *testing.T) {
+	chain := newTestChain(10)
+	chain.addLog(9, 1)
+
+	w := NewLogWatcherPolling(chain.mock(t), time.Millisecond, 10, 0, nil, lib.NewTestLogger())
+	sub, err := w.Watch(context.Background(), common.Address{}, eventMapper, big.NewInt(8))
+	require.NoError(t, err)
+	defer sub.Unsubscribe()
+
+	require.Equal(t, byte(1), nextEventData(t, sub))
+
+	// block 9 is replaced by a block with a different event
+	chain.reorg(9, 1)
+	chain.addLog(9, 2)
+	require.Equal(t, byte(2), nextEventData(t, sub))
+}
+
+func TestWatchFromCheckpointDetectsReorgDuringDowntime(t *testing.T) {
+	chain := newTestChain(10)
+	checkpoints := newMemCheckpoints()
+	for n := uint64(1); n <= 10; n++ {
+		require.NoError(t, checkpoints.SetCheckpoint("test", n, chain.header(n).Hash()))
+	}
+
+	// the checkpointed block was reorged while the node was stopped
+	chain.reorg(10, 1)
+	chain.addLog(10, 10)
+
+	w := NewLogWatcherPolling(chain.mock(t), time.Millisecond, 10, 0, checkpoints, lib.NewTestLogger())
+	sub, err := w.WatchFromCheckpoint(context.Background(), "test", common.Address{}, eventMapper)
+	require.NoError(t, err)
+	defer sub.Unsubscribe()
+
+	require.Equal(t, byte(10), nextEventData(t, sub))
+}
+
+func TestWatchFromCheckpointSplitsBlockRange(t *testing.T) {
+	chain := newTestChain(25)
+	chain.addLog(3, 3)
+	chain.addLog(12, 12)
+	chain.addLog(25, 25)
+	checkpoints := newMemCheckpoints()
+	require.NoError(t, checkpoints.SetCheckpoint("test", 1, chain.header(1).Hash()))
+
+	// records the checkpoint at the time of each query
+	var checkpointed []uint64
+	ethClientMock := mocks.NewEthClientMock(t)
+	ethClientMock.EXPECT().HeaderByNumber(mock.Anything, mock.Anything).RunAndReturn(chain.HeaderByNumber).Maybe()
+	ethClientMock.EXPECT().FilterLogs(mock.Anything, mock.Anything).RunAndReturn(func(ctx context.Context, q ethereum.FilterQuery) ([]types.Log, error) {
+		block, _, _ := checkpoints.GetCheckpoint("test")
+		checkpointed = append(checkpointed, block)
+		return chain.FilterLogs(ctx, q)
+	}).Maybe()
+
+	w := NewLogWatcherPolling(ethClientMock, time.Hour, 10, 0, checkpoints, lib.NewTestLogger())
+	w.SetMaxBlockRange(10)
+	sub, err := w.WatchFromCheckpoint(context.Background(), "test", common.Address{}, eventMapper)
+	require.NoError(t, err)
+
+	require.Equal(t, byte(3), nextEventData(t, sub))
+	require.Equal(t, byte(12), nextEventData(t, sub))
+	require.Equal(t, byte(25), nextEventData(t, sub))
+	require.Eventually(t, func() bool {
+		block, _, _ := checkpoints.GetCheckpoint("test")
+		return block == 25
+	}, 5*time.Second, time.Millisecond)
+	sub.Unsubscribe()
+
+	chain.mu.Lock()
+	defer chain.mu.Unlock()
+	ranges := [][2]uint64{}
+	for _, q := range chain.queries {
+		ranges = append(ranges, [2]uint64{q.FromBlock.Uint64(), q.ToBlock.Uint64()})
+	}
+	require.Equal(t, [][2]uint64{{2, 11}, {12, 21}, {22, 25}}, ranges)
+	require.Equal(t, []uint64{1, 11, 21}, checkpointed)
+}
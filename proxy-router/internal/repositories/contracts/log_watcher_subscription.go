@@ -7,6 +7,7 @@ import (
 
 	i "github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/interfaces"
 	"github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/lib"
+	"github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/metrics"
 	"github.com/ethereum/go-ethereum"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
@@ -66,7 +67,9 @@ func (w *LogWatcherSubscription) Watch(ctx context.Context, contractAddr common.
 
 					select {
 					case sink <- event:
+						metrics.LogWatcherEvents.WithLabelValues("subscription").Inc()
 					case err := <-sub.Err():
+						metrics.LogWatcherErrors.WithLabelValues("subscription").Inc()
 						w.log.Debugf("subscription error: %s", err)
 						break EVENTS_LOOP
 					case <-quit:
@@ -75,6 +78,7 @@ func (w *LogWatcherSubscription) Watch(ctx context.Context, contractAddr common.
 						return ctx.Err()
 					}
 				case err := <-sub.Err():
+					metrics.LogWatcherErrors.WithLabelValues("subscription").Inc()
 					w.log.Debugf("subscription error: %s", err)
 					break EVENTS_LOOP
 				case <-quit:
@@ -87,6 +91,12 @@ func (w *LogWatcherSubscription) Watch(ctx context.Context, contractAddr common.
 	}, sink), nil
 }
 
+// WatchFromCheckpoint is not supported by subscriptions, events are watched from the latest block
+func (w *LogWatcherSubscription) WatchFromCheckpoint(ctx context.Context, key string, contractAddr common.Address, mapper EventMapper) (*lib.Subscription, error) {
+	w.log.Warnf("checkpoints are not supported with subscriptions, %s events that happened while offline are skipped", key)
+	return w.Watch(ctx, contractAddr, mapper, nil)
+}
+
 func (w *LogWatcherSubscription) subscribeFilterLogsRetry(ctx context.Context, query ethereum.FilterQuery, ch chan<- types.Log) (ethereum.Subscription, error) {
 	var lastErr error
 
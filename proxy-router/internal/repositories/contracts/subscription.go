@@ -3,6 +3,9 @@ package contracts
 import (
 	"time"
 
+	"github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/repositories/contracts/bindings/marketplace"
+	"github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/repositories/contracts/bindings/modelregistry"
+	"github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/repositories/contracts/bindings/providerregistry"
 	"github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/repositories/contracts/bindings/sessionrouter"
 	"github.com/ethereum/go-ethereum/core/types"
 )
@@ -21,3 +24,54 @@ func SessionRouterEventFactory(name string) interface{} {
 		return nil
 	}
 }
+
+func MarketplaceEventFactory(name string) interface{} {
+	switch name {
+	case "MarketplaceBidPosted":
+		return new(marketplace.MarketplaceMarketplaceBidPosted)
+	case "MarketplaceBidDeleted":
+		return new(marketplace.MarketplaceMarketplaceBidDeleted)
+	default:
+		return nil
+	}
+}
+
+func ProviderRegistryEventFactory(name string) interface{} {
+	switch name {
+	case "ProviderRegistered":
+		return new(providerregistry.ProviderRegistryProviderRegistered)
+	case "ProviderDeregistered":
+		return new(providerregistry.ProviderRegistryProviderDeregistered)
+	case "ProviderWithdrawn":
+		return new(providerregistry.ProviderRegistryProviderWithdrawn)
+	default:
+		return nil
+	}
+}
+
+func ModelRegistryEventFactory(name string) interface{} {
+	switch name {
+	case "ModelRegisteredUpdated":
+		return new(modelregistry.ModelRegistryModelRegisteredUpdated)
+	case "ModelDeregistered":
+		return new(modelregistry.ModelRegistryModelDeregistered)
+	default:
+		return nil
+	}
+}
+
+// CombineEventMappers creates a mapper for contracts sharing the same address, like diamond facets.
+// The log is mapped by the first mapper that recognizes the event
+func CombineEventMappers(mappers ...EventMapper) EventMapper {
+	return func(log types.Log) (interface{}, error) {
+		var lastErr error = ErrUnknownEvent
+		for _, mapper := range mappers {
+			event, err := mapper(log)
+			if err == nil {
+				return event, nil
+			}
+			lastErr = err
+		}
+		return nil, lastErr
+	}
+}
@@ -2,6 +2,7 @@ package ethclient
 
 import (
 	"fmt"
+	"math/big"
 	"sync"
 	"testing"
 
@@ -15,7 +16,7 @@ func TestEthClient(t *testing.T) {
 	t.SkipNow()
 	rpcClient, err := NewRPCClientMultiple([]string{
 		"https://arbitrum.blockpi.network/v1/rpc/public",
-	}, &lib.LoggerMock{})
+	}, RPCClientConfig{}, &lib.LoggerMock{})
 	require.NoError(t, err)
 	client := NewClient(rpcClient)
 
@@ -27,7 +28,7 @@ func TestEthClient(t *testing.T) {
 	for i := 0; i < 500; i++ {
 		wg.Add(1)
 		go func() {
-			ids, _, err := mr.ModelGetAll(nil)
+			ids, _, err := mr.GetModelIds(nil, big.NewInt(0), big.NewInt(100))
 			defer wg.Done()
 			if err != nil {
 				fmt.Printf("Error: %s\n", err)
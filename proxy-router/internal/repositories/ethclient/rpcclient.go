@@ -17,4 +17,6 @@ type RPCClientModifiable interface {
 	RPCClient
 	GetURLs() []string
 	SetURLs(urls []string) error
+	GetStatus() []EndpointStatus
+	RunHealthCheck(ctx context.Context) error
 }
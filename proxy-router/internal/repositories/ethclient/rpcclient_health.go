@@ -0,0 +1,223 @@
+package ethclient
+
+import (
+	"errors"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+const (
+	// breakerThreshold is the number of consecutive failures which opens the circuit of the endpoint
+	breakerThreshold   = 3
+	breakerCooldown    = 30 * time.Second
+	maxBreakerCooldown = 5 * time.Minute
+
+	minRateLimitBackoff = time.Second
+	maxRateLimitBackoff = time.Minute
+
+	// ewmaWeight is the weight of the latest sample in latency and error rate averages
+	ewmaWeight = 0.2
+	// endpoints scoring lower are tried after the healthy ones
+	degradedScore = 50
+)
+
+type EndpointState string
+
+const (
+	EndpointHealthy     EndpointState = "healthy"
+	EndpointDegraded    EndpointState = "degraded"
+	EndpointLagging     EndpointState = "lagging"
+	EndpointRateLimited EndpointState = "rate-limited"
+	EndpointOpen        EndpointState = "circuit-open"
+)
+
+// EndpointStatus is the health of the eth node endpoint as seen by the node
+type EndpointStatus struct {
+	URL         string        `json:"url"`
+	State       EndpointState `json:"state"`
+	Score       int           `json:"score"`     // 0-100, based on latency, error rate and block lag
+	LatencyMs   int64         `json:"latencyMs"` // moving average
+	ErrorRate   float64       `json:"errorRate"` // moving average, 0-1
+	Requests    uint64        `json:"requests"`
+	Failures    uint64        `json:"failures"`
+	BlockNumber uint64        `json:"blockNumber"`
+	BlockLag    uint64        `json:"blockLag"`
+	LastError   string        `json:"lastError,omitempty"`
+	RetryAt     *time.Time    `json:"retryAt,omitempty"` // when the open or rate limited endpoint is tried again
+}
+
+// endpointHealth tracks the endpoint responses, it opens the circuit after consecutive failures
+// and backs off after rate limit responses
+type endpointHealth struct {
+	mu sync.Mutex
+
+	latency   time.Duration
+	errorRate float64
+	requests  uint64
+	failures  uint64
+	lastError string
+
+	consecutiveFailures int
+	openings            int
+	openUntil           time.Time
+
+	rateLimits       int
+	rateLimitedUntil time.Time
+
+	blockNumber uint64
+	blockLag    uint64
+}
+
+func (h *endpointHealth) recordSuccess(latency time.Duration) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.requests++
+	if h.latency == 0 {
+		h.latency = latency
+	} else {
+		h.latency = time.Duration(ewmaWeight*float64(latency) + (1-ewmaWeight)*float64(h.latency))
+	}
+	h.errorRate = (1 - ewmaWeight) * h.errorRate
+	h.consecutiveFailures = 0
+	h.openings = 0
+	h.rateLimits = 0
+}
+
+func (h *endpointHealth) recordFailure(err error, now time.Time) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.requests++
+	h.failures++
+	h.lastError = err.Error()
+	h.errorRate = ewmaWeight + (1-ewmaWeight)*h.errorRate
+	h.consecutiveFailures++
+
+	if h.consecutiveFailures >= breakerThreshold {
+		h.openUntil = now.Add(backoff(breakerCooldown, maxBreakerCooldown, h.openings))
+		h.openings++
+	}
+}
+
+func (h *endpointHealth) recordRateLimit(err error, now time.Time) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.requests++
+	h.lastError = err.Error()
+	h.rateLimitedUntil = now.Add(backoff(minRateLimitBackoff, maxRateLimitBackoff, h.rateLimits))
+	h.rateLimits++
+}
+
+func (h *endpointHealth) setBlock(blockNumber, lag uint64) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.blockNumber = blockNumber
+	h.blockLag = lag
+}
+
+func (h *endpointHealth) getBlockNumber() uint64 {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.blockNumber
+}
+
+func (h *endpointHealth) getLatency() time.Duration {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.latency
+}
+
+func (h *endpointHealth) status(url string, maxBlockLag uint64, now time.Time) EndpointStatus {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	status := EndpointStatus{
+		URL:         url,
+		Score:       h.score(maxBlockLag),
+		LatencyMs:   h.latency.Milliseconds(),
+		ErrorRate:   math.Round(h.errorRate*1000) / 1000,
+		Requests:    h.requests,
+		Failures:    h.failures,
+		BlockNumber: h.blockNumber,
+		BlockLag:    h.blockLag,
+		LastError:   h.lastError,
+	}
+
+	switch {
+	case now.Before(h.openUntil):
+		status.State = EndpointOpen
+		status.RetryAt = &h.openUntil
+	case now.Before(h.rateLimitedUntil):
+		status.State = EndpointRateLimited
+		status.RetryAt = &h.rateLimitedUntil
+	case maxBlockLag > 0 && h.blockLag > maxBlockLag:
+		status.State = EndpointLagging
+	case status.Score < degradedScore:
+		status.State = EndpointDegraded
+	default:
+		status.State = EndpointHealthy
+	}
+	if status.RetryAt != nil {
+		retryAt := *status.RetryAt
+		status.RetryAt = &retryAt
+	}
+
+	return status
+}
+
+// score should be called with mu locked
+func (h *endpointHealth) score(maxBlockLag uint64) int {
+	score := 100 - h.errorRate*50
+
+	// up to 25 points for latency, 1s and slower endpoints lose all of them
+	score -= math.Min(float64(h.latency.Milliseconds())/40, 25)
+
+	if maxBlockLag > 0 {
+		score -= math.Min(float64(h.blockLag)/float64(maxBlockLag)*25, 25)
+	}
+
+	return int(math.Max(math.Round(score), 0))
+}
+
+// rank orders endpoints by state, lower is tried first
+func (s EndpointStatus) rank() int {
+	switch s.State {
+	case EndpointHealthy:
+		return 0
+	case EndpointDegraded:
+		return 1
+	case EndpointLagging:
+		return 2
+	default:
+		return 3
+	}
+}
+
+func backoff(min, max time.Duration, attempt int) time.Duration {
+	if attempt > 30 {
+		return max
+	}
+	d := min << attempt
+	if d <= 0 || d > max {
+		return max
+	}
+	return d
+}
+
+// isRateLimitError checks for 429 responses and the json-rpc "limit exceeded" error
+func isRateLimitError(err error) bool {
+	var httpErr rpc.HTTPError
+	if errors.As(err, &httpErr) {
+		return httpErr.StatusCode == 429
+	}
+	var jsonErr JSONError
+	if errors.As(err, &jsonErr) {
+		return jsonErr.ErrorCode() == -32005 || jsonErr.ErrorCode() == 429
+	}
+	return false
+}
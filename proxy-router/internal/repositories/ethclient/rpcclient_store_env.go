@@ -1,6 +1,7 @@
 package ethclient
 
 import (
+	"context"
 	"errors"
 )
 
@@ -25,3 +26,11 @@ func (p *RPCClientStoreEnv) RemoveURLs() error {
 func (p *RPCClientStoreEnv) GetClient() RPCClient {
 	return p.rpcClient
 }
+
+func (p *RPCClientStoreEnv) GetStatus() []EndpointStatus {
+	return p.rpcClient.GetStatus()
+}
+
+func (p *RPCClientStoreEnv) RunHealthCheck(ctx context.Context) error {
+	return p.rpcClient.RunHealthCheck(ctx)
+}
@@ -1,6 +1,7 @@
 package ethclient
 
 import (
+	"context"
 	"errors"
 
 	"github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/interfaces"
@@ -13,12 +14,14 @@ type RPCEndpointsPersister interface {
 	SetURLs(urls []string) error
 	RemoveURLs() error
 	GetClient() RPCClient
+	GetStatus() []EndpointStatus
+	RunHealthCheck(ctx context.Context) error
 }
 
-func ConfigureRPCClientStore(storage interfaces.KeyValueStorage, envURLs []string, chainID int, log lib.ILogger) (RPCEndpointsPersister, error) {
+func ConfigureRPCClientStore(storage interfaces.KeyValueStorage, envURLs []string, chainID int, cfg RPCClientConfig, log lib.ILogger) (RPCEndpointsPersister, error) {
 	// if env set, use env store
 	if len(envURLs) > 0 {
-		rpcClient, err := NewRPCClientMultiple(envURLs, log)
+		rpcClient, err := NewRPCClientMultiple(envURLs, cfg, log)
 		if err != nil {
 			return nil, err
 		}
@@ -32,8 +35,9 @@ func ConfigureRPCClientStore(storage interfaces.KeyValueStorage, envURLs []strin
 	}
 
 	// if no env set, try use keychain store
-	rpcClient := &RPCClientMultiple{
-		log: log,
+	rpcClient, err := NewRPCClientMultiple(nil, cfg, log)
+	if err != nil {
+		return nil, err
 	}
 	p := &RPCClientStoreKeychain{
 		storage:   storage,
@@ -69,7 +73,7 @@ func ConfigureRPCClientStore(storage interfaces.KeyValueStorage, envURLs []strin
 
 	log.Info("using public eth node addresses")
 
-	rpcClient, err = NewRPCClientMultiple(publicURLs, log)
+	rpcClient, err = NewRPCClientMultiple(publicURLs, cfg, log)
 	if err != nil {
 		return nil, err
 	}
@@ -77,6 +81,7 @@ func ConfigureRPCClientStore(storage interfaces.KeyValueStorage, envURLs []strin
 	rpc := &RPCClientStoreKeychain{
 		rpcClient: rpcClient,
 		storage:   storage,
+		log:       log,
 	}
 
 	return rpc, nil
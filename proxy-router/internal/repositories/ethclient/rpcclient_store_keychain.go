@@ -1,6 +1,7 @@
 package ethclient
 
 import (
+	"context"
 	"encoding/json"
 
 	"github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/interfaces"
@@ -79,3 +80,11 @@ func (p *RPCClientStoreKeychain) storeURLsInStorage(urls []string) error {
 func (p *RPCClientStoreKeychain) deleteURLsInStorage() error {
 	return p.storage.DeleteIfExists(ETH_NODE_URL_KEY)
 }
+
+func (p *RPCClientStoreKeychain) GetStatus() []EndpointStatus {
+	return p.rpcClient.GetStatus()
+}
+
+func (p *RPCClientStoreKeychain) RunHealthCheck(ctx context.Context) error {
+	return p.rpcClient.RunHealthCheck(ctx)
+}
@@ -2,34 +2,73 @@ package ethclient
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/lib"
+	"github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/metrics"
+	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/rpc"
 )
 
-// Wrapper around multiple RPC clients, used to retry calls on multiple endpoints
+type Strategy string
+
+const (
+	StrategyPriority   Strategy = "priority"    // endpoints are tried in the configured order
+	StrategyRoundRobin Strategy = "round-robin" // calls are spread across the endpoints
+	StrategyFastest    Strategy = "fastest"     // the endpoint with the lowest average latency is tried first
+
+	healthCheckTimeout = 10 * time.Second
+)
+
+var (
+	ErrUnknownStrategy = errors.New("unknown rpc strategy")
+	ErrNoEndpoints     = errors.New("no eth node endpoints configured")
+)
+
+func ParseStrategy(s string) (Strategy, error) {
+	switch Strategy(s) {
+	case "":
+		return StrategyPriority, nil
+	case StrategyPriority, StrategyRoundRobin, StrategyFastest:
+		return Strategy(s), nil
+	}
+	return "", lib.WrapError(ErrUnknownStrategy, fmt.Errorf("%s", s))
+}
+
+type RPCClientConfig struct {
+	Strategy            Strategy
+	MaxBlockLag         uint64        // endpoints behind the highest seen block by more than this are tried last, 0 disables
+	HealthCheckInterval time.Duration // interval of polling the block number of each endpoint, 0 disables
+}
+
+// Wrapper around multiple RPC clients, used to retry calls on multiple endpoints.
+// Endpoints are ordered by the strategy, unhealthy ones are tried after the healthy ones
 type RPCClientMultiple struct {
 	lock    sync.RWMutex
 	clients []*rpcClient
+	cfg     RPCClientConfig
+	next    atomic.Uint64 // round-robin counter
 	log     lib.ILogger
 }
 
-func NewRPCClientMultiple(urls []string, log lib.ILogger) (*RPCClientMultiple, error) {
-	clients := make([]*rpcClient, len(urls))
-
-	for i, url := range urls {
-		client, err := rpc.DialOptions(context.Background(), url)
-		if err != nil {
-			return nil, err
-		}
-		clients[i] = &rpcClient{
-			url:    url,
-			client: client,
-		}
+func NewRPCClientMultiple(urls []string, cfg RPCClientConfig, log lib.ILogger) (*RPCClientMultiple, error) {
+	clients, err := dialClients(urls)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.Strategy == "" {
+		cfg.Strategy = StrategyPriority
 	}
 
-	return &RPCClientMultiple{clients: clients, log: log}, nil
+	return &RPCClientMultiple{clients: clients, cfg: cfg, log: log}, nil
 }
 
 func (c *RPCClientMultiple) CallContext(ctx context.Context, result interface{}, method string, args ...interface{}) error {
@@ -51,9 +90,37 @@ func (c *RPCClientMultiple) Close() {
 	}
 }
 
+// EthSubscribe subscribes on the healthiest websocket endpoint, failing over to the next one on error.
+// If no websocket endpoints are configured all endpoints are tried
 func (c *RPCClientMultiple) EthSubscribe(ctx context.Context, channel interface{}, args ...interface{}) (*rpc.ClientSubscription, error) {
-	client := c.getClients()[0]
-	return client.client.EthSubscribe(ctx, channel, args...)
+	clients := c.orderedClients()
+	wsClients := slices.DeleteFunc(slices.Clone(clients), func(client *rpcClient) bool {
+		return !isWebsocketURL(client.url)
+	})
+	if len(wsClients) > 0 {
+		clients = wsClients
+	}
+	if len(clients) == 0 {
+		return nil, ErrNoEndpoints
+	}
+
+	var lastErr error
+	for _, client := range clients {
+		sub, err := client.client.EthSubscribe(ctx, channel, args...)
+		if err == nil {
+			return sub, nil
+		}
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		if !errors.Is(err, rpc.ErrNotificationsUnsupported) {
+			client.health.recordFailure(err, time.Now())
+		}
+		c.log.Debugf("failed to subscribe on eth endpoint %s: %s", client.url, err)
+		lastErr = err
+	}
+
+	return nil, lastErr
 }
 
 func (c *RPCClientMultiple) GetURLs() []string {
@@ -66,17 +133,9 @@ func (c *RPCClientMultiple) GetURLs() []string {
 }
 
 func (c *RPCClientMultiple) SetURLs(urls []string) error {
-	clients := make([]*rpcClient, len(urls))
-
-	for i, url := range urls {
-		client, err := rpc.DialOptions(context.Background(), url)
-		if err != nil {
-			return err
-		}
-		clients[i] = &rpcClient{
-			url:    url,
-			client: client,
-		}
+	clients, err := dialClients(urls)
+	if err != nil {
+		return err
 	}
 
 	c.lock.Lock()
@@ -90,22 +149,119 @@ func (c *RPCClientMultiple) SetURLs(urls []string) error {
 	return nil
 }
 
+// GetStatus returns the health of the endpoints in the configured order
+func (c *RPCClientMultiple) GetStatus() []EndpointStatus {
+	now := time.Now()
+	clients := c.getClients()
+	statuses := make([]EndpointStatus, len(clients))
+	for i, client := range clients {
+		statuses[i] = client.health.status(client.url, c.cfg.MaxBlockLag, now)
+	}
+	return statuses
+}
+
+// RunHealthCheck polls the block number of the endpoints to track their latency and block lag
+func (c *RPCClientMultiple) RunHealthCheck(ctx context.Context) error {
+	if c.cfg.HealthCheckInterval == 0 {
+		return nil
+	}
+
+	ticker := time.NewTicker(c.cfg.HealthCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		c.checkHealth(ctx)
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+func (c *RPCClientMultiple) checkHealth(ctx context.Context) {
+	clients := c.getClients()
+
+	wg := sync.WaitGroup{}
+	for _, client := range clients {
+		wg.Add(1)
+		go func(client *rpcClient) {
+			defer wg.Done()
+
+			timeoutCtx, cancel := context.WithTimeout(ctx, healthCheckTimeout)
+			defer cancel()
+
+			var blockNumber hexutil.Uint64
+			err := c.call(timeoutCtx, client, func(client *rpcClient) error {
+				return client.client.CallContext(timeoutCtx, &blockNumber, "eth_blockNumber")
+			})
+			if err != nil {
+				c.log.Debugf("health check of eth endpoint %s failed: %s", client.url, err)
+				return
+			}
+			client.health.setBlock(uint64(blockNumber), 0)
+		}(client)
+	}
+	wg.Wait()
+
+	var head uint64
+	for _, client := range clients {
+		head = max(head, client.health.getBlockNumber())
+	}
+	for _, client := range clients {
+		blockNumber := client.health.getBlockNumber()
+		if blockNumber > 0 {
+			client.health.setBlock(blockNumber, head-blockNumber)
+		}
+	}
+}
+
 func (c *RPCClientMultiple) getClients() []*rpcClient {
 	c.lock.RLock()
 	defer c.lock.RUnlock()
 	return c.clients
 }
 
+// orderedClients returns the clients in the order they should be tried
+func (c *RPCClientMultiple) orderedClients() []*rpcClient {
+	clients := slices.Clone(c.getClients())
+	if len(clients) == 0 {
+		return clients
+	}
+
+	switch c.cfg.Strategy {
+	case StrategyRoundRobin:
+		start := int((c.next.Add(1) - 1) % uint64(len(clients)))
+		clients = append(slices.Clone(clients[start:]), clients[:start]...)
+	case StrategyFastest:
+		slices.SortStableFunc(clients, func(a, b *rpcClient) int {
+			return int(a.health.getLatency() - b.health.getLatency())
+		})
+	}
+
+	now := time.Now()
+	ranks := make(map[*rpcClient]int, len(clients))
+	for _, client := range clients {
+		ranks[client] = client.health.status(client.url, c.cfg.MaxBlockLag, now).rank()
+	}
+	slices.SortStableFunc(clients, func(a, b *rpcClient) int {
+		return ranks[a] - ranks[b]
+	})
+
+	return clients
+}
+
 // retriableCall is a helper function that retries the call on different endpoints
 func (c *RPCClientMultiple) retriableCall(ctx context.Context, fn func(client *rpcClient) error) error {
 	var lastErr error
 
-	for _, rpcClient := range c.getClients() {
+	for _, rpcClient := range c.orderedClients() {
 		if ctx.Err() != nil {
 			return ctx.Err()
 		}
 
-		err := fn(rpcClient)
+		err := c.call(ctx, rpcClient, fn)
 		if err == nil {
 			return nil
 		}
@@ -120,20 +276,59 @@ func (c *RPCClientMultiple) retriableCall(ctx context.Context, fn func(client *r
 	}
 
 	c.log.Debugf("all endpoints failed")
+	if lastErr == nil {
+		return ErrNoEndpoints
+	}
 	return lastErr
 }
 
+// call calls the endpoint and records the result in its health
+func (c *RPCClientMultiple) call(ctx context.Context, client *rpcClient, fn func(client *rpcClient) error) error {
+	start := time.Now()
+	err := fn(client)
+	duration := time.Since(start)
+
+	switch {
+	case err == nil:
+		client.health.recordSuccess(duration)
+		metrics.ObserveRPC(client.label, duration, metrics.ResultSuccess)
+	case ctx.Err() != nil:
+		// cancelled by the caller, not the endpoint fault
+	case isRateLimitError(err):
+		client.health.recordRateLimit(err, time.Now())
+		metrics.ObserveRPC(client.label, duration, metrics.ResultRateLimited)
+	case c.isEndpointError(err):
+		client.health.recordFailure(err, time.Now())
+		metrics.ObserveRPC(client.label, duration, metrics.ResultError)
+	default:
+		// the endpoint replied with a json-rpc error, e.g. reverted call
+		client.health.recordSuccess(duration)
+		metrics.ObserveRPC(client.label, duration, metrics.ResultRPCError)
+	}
+
+	return err
+}
+
 func (c *RPCClientMultiple) shouldBeRetried(err error) bool {
-	switch err.(type) {
-	case rpc.HTTPError:
-		// if err.(rpc.HTTPError).StatusCode == 429 {
-		// 	return true
-		// }
+	return isRateLimitError(err) || c.isEndpointError(err)
+}
+
+// isEndpointError checks if the error is caused by the endpoint, not by the request
+func (c *RPCClientMultiple) isEndpointError(err error) bool {
+	var httpErr rpc.HTTPError
+	if errors.As(err, &httpErr) {
 		return true
-	case JSONError:
+	}
+	var jsonErr JSONError
+	if errors.As(err, &jsonErr) {
+		return false
+	}
+	var unmarshalErr *json.UnmarshalTypeError
+	if errors.As(err, &unmarshalErr) {
 		return false
 	}
-	return false
+	// network errors, timeouts, closed connections
+	return true
 }
 
 type JSONError interface {
@@ -144,5 +339,39 @@ type JSONError interface {
 
 type rpcClient struct {
 	url    string
+	label  string // url without path and credentials, used in metrics
 	client *rpc.Client
+	health *endpointHealth
+}
+
+func dialClients(urls []string) ([]*rpcClient, error) {
+	clients := make([]*rpcClient, len(urls))
+
+	for i, url := range urls {
+		client, err := rpc.DialOptions(context.Background(), url)
+		if err != nil {
+			return nil, err
+		}
+		clients[i] = &rpcClient{
+			url:    url,
+			label:  endpointLabel(url),
+			client: client,
+			health: &endpointHealth{},
+		}
+	}
+
+	return clients, nil
+}
+
+// endpointLabel strips the path and user info, providers often put api keys there
+func endpointLabel(endpoint string) string {
+	u, err := url.Parse(endpoint)
+	if err != nil || u.Host == "" {
+		return "unknown"
+	}
+	return u.Scheme + "://" + u.Host
+}
+
+func isWebsocketURL(url string) bool {
+	return strings.HasPrefix(url, "ws://") || strings.HasPrefix(url, "wss://")
 }
@@ -0,0 +1,161 @@
+package ethclient
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/lib"
+	"github.com/stretchr/testify/require"
+)
+
+// rpcStub is a json-rpc endpoint replying to eth_blockNumber
+type rpcStub struct {
+	mu     sync.Mutex
+	status int
+	block  uint64
+	calls  int
+	srv    *httptest.Server
+}
+
+func newRPCStub(t *testing.T, block uint64) *rpcStub {
+	stub := &rpcStub{status: http.StatusOK, block: block}
+	stub.srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			ID json.RawMessage `json:"id"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&req)
+
+		stub.mu.Lock()
+		stub.calls++
+		status, block := stub.status, stub.block
+		stub.mu.Unlock()
+
+		if status != http.StatusOK {
+			w.WriteHeader(status)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"jsonrpc":"2.0","id":%s,"result":"0x%x"}`, req.ID, block)
+	}))
+	t.Cleanup(stub.srv.Close)
+	return stub
+}
+
+func (s *rpcStub) setStatus(status int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.status = status
+}
+
+func (s *rpcStub) Calls() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.calls
+}
+
+func newTestRPCClient(t *testing.T, cfg RPCClientConfig, stubs ...*rpcStub) *RPCClientMultiple {
+	urls := make([]string, len(stubs))
+	for i, stub := range stubs {
+		urls[i] = stub.srv.URL
+	}
+	c, err := NewRPCClientMultiple(urls, cfg, lib.NewTestLogger())
+	require.NoError(t, err)
+	t.Cleanup(c.Close)
+	return c
+}
+
+func blockNumber(t *testing.T, c *RPCClientMultiple) uint64 {
+	var res string
+	err := c.CallContext(context.Background(), &res, "eth_blockNumber")
+	require.NoError(t, err)
+	var n uint64
+	_, err = fmt.Sscanf(res, "0x%x", &n)
+	require.NoError(t, err)
+	return n
+}
+
+func TestRPCClientBacksOffRateLimitedEndpoint(t *testing.T) {
+	limited, fallback := newRPCStub(t, 1), newRPCStub(t, 2)
+	limited.setStatus(http.StatusTooManyRequests)
+	c := newTestRPCClient(t, RPCClientConfig{}, limited, fallback)
+
+	require.Equal(t, uint64(2), blockNumber(t, c))
+	require.Equal(t, uint64(2), blockNumber(t, c))
+	require.Equal(t, 1, limited.Calls())
+
+	status := c.GetStatus()
+	require.Equal(t, EndpointRateLimited, status[0].State)
+	require.NotNil(t, status[0].RetryAt)
+	require.Equal(t, EndpointHealthy, status[1].State)
+}
+
+func TestRPCClientOpensCircuitAfterFailures(t *testing.T) {
+	failing, fallback := newRPCStub(t, 1), newRPCStub(t, 2)
+	failing.setStatus(http.StatusBadGateway)
+	c := newTestRPCClient(t, RPCClientConfig{}, failing, fallback)
+
+	for i := 0; i < breakerThreshold+2; i++ {
+		require.Equal(t, uint64(2), blockNumber(t, c))
+	}
+	require.Equal(t, breakerThreshold, failing.Calls())
+	require.Equal(t, EndpointOpen, c.GetStatus()[0].State)
+
+	// the endpoint is tried again when the cooldown passes
+	failing.setStatus(http.StatusOK)
+	c.getClients()[0].health.openUntil = time.Now()
+	require.Equal(t, uint64(1), blockNumber(t, c))
+	require.Equal(t, EndpointHealthy, c.GetStatus()[0].State)
+}
+
+func TestRPCClientStrategies(t *testing.T) {
+	t.Run("round-robin", func(t *testing.T) {
+		a, b := newRPCStub(t, 1), newRPCStub(t, 2)
+		c := newTestRPCClient(t, RPCClientConfig{Strategy: StrategyRoundRobin}, a, b)
+
+		for i := 0; i < 4; i++ {
+			blockNumber(t, c)
+		}
+		require.Equal(t, 2, a.Calls())
+		require.Equal(t, 2, b.Calls())
+	})
+
+	t.Run("fastest", func(t *testing.T) {
+		slow, fast := newRPCStub(t, 1), newRPCStub(t, 2)
+		c := newTestRPCClient(t, RPCClientConfig{Strategy: StrategyFastest}, slow, fast)
+		c.getClients()[0].health.recordSuccess(200 * time.Millisecond)
+		c.getClients()[1].health.recordSuccess(10 * time.Millisecond)
+
+		require.Equal(t, uint64(2), blockNumber(t, c))
+	})
+}
+
+func TestRPCClientHealthCheckTracksBlockLag(t *testing.T) {
+	lagging, synced := newRPCStub(t, 100), newRPCStub(t, 150)
+	c := newTestRPCClient(t, RPCClientConfig{MaxBlockLag: 10}, lagging, synced)
+
+	c.checkHealth(context.Background())
+
+	status := c.GetStatus()
+	require.Equal(t, EndpointLagging, status[0].State)
+	require.Equal(t, uint64(50), status[0].BlockLag)
+	require.Equal(t, uint64(150), status[1].BlockNumber)
+	require.Equal(t, uint64(0), status[1].BlockLag)
+
+	// the lagging endpoint is tried last despite the priority
+	require.Equal(t, uint64(150), blockNumber(t, c))
+}
+
+func TestParseStrategy(t *testing.T) {
+	strategy, err := ParseStrategy("")
+	require.NoError(t, err)
+	require.Equal(t, StrategyPriority, strategy)
+
+	_, err = ParseStrategy("random")
+	require.ErrorIs(t, err, ErrUnknownStrategy)
+}
@@ -2,43 +2,70 @@ package multicall
 
 import (
 	"context"
+	"fmt"
 
 	"github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/repositories/contracts/bindings/multicall3"
 	"github.com/ethereum/go-ethereum/accounts/abi"
 	"github.com/ethereum/go-ethereum/common"
 )
 
+// MaxBatchSize limits the number of calls in a single multicall to keep the request under the node gas limit for eth_call
+const MaxBatchSize = 500
+
 // Batch executes multiple calls to the same method on the same contract in a single multicall and converts the results to the specified type
-// TODO: configure max batch size, enabling multicall level and json-rpc level batching
+// If there are more than MaxBatchSize calls, they are split into several multicalls
 func Batch[T any](ctx context.Context, mc MulticallBackend, contractABI *abi.ABI, addr common.Address, method string, callsArgs [][]interface{}) ([]T, error) {
-	var calls []multicall3.Multicall3Call
+	results := make([]T, 0, len(callsArgs))
 
-	for _, args := range callsArgs {
+	for start := 0; start < len(callsArgs); start += MaxBatchSize {
+		end := min(start+MaxBatchSize, len(callsArgs))
+		res, err := batch[T](ctx, mc, contractABI, addr, method, callsArgs[start:end])
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, res...)
+	}
+
+	return results, nil
+}
+
+func batch[T any](ctx context.Context, mc MulticallBackend, contractABI *abi.ABI, addr common.Address, method string, callsArgs [][]interface{}) ([]T, error) {
+	calls := make([]multicall3.Multicall3Call3, len(callsArgs))
+
+	for i, args := range callsArgs {
 		calldata, err := contractABI.Pack(method, args...)
 		if err != nil {
 			return nil, err
 		}
-		calls = append(calls, multicall3.Multicall3Call{
-			Target:   addr,
-			CallData: calldata,
-		})
+		calls[i] = multicall3.Multicall3Call3{
+			Target:       addr,
+			AllowFailure: false,
+			CallData:     calldata,
+		}
 	}
 
-	_, res, err := mc.Aggregate(ctx, calls)
+	res, err := mc.Aggregate3(ctx, calls)
 	if err != nil {
 		return nil, err
 	}
+	if len(res) != len(calls) {
+		return nil, fmt.Errorf("multicall returned %d results for %d calls", len(res), len(calls))
+	}
 
-	sessions := make([]T, len(res))
+	results := make([]T, len(res))
 	for i, result := range res {
+		if !result.Success {
+			return nil, fmt.Errorf("multicall %s call #%d failed", method, i)
+		}
+
 		var data interface{}
-		err := contractABI.UnpackIntoInterface(&data, method, result)
+		err := contractABI.UnpackIntoInterface(&data, method, result.ReturnData)
 		if err != nil {
 			return nil, err
 		}
 
-		sessions[i] = *abi.ConvertType(data, new(T)).(*T)
+		results[i] = *abi.ConvertType(data, new(T)).(*T)
 	}
 
-	return sessions, nil
+	return results, nil
 }
@@ -9,4 +9,5 @@ import (
 
 type MulticallBackend interface {
 	Aggregate(ctx context.Context, calls []multicall3.Multicall3Call) (blockNumer *big.Int, returnData [][]byte, err error)
+	Aggregate3(ctx context.Context, calls []multicall3.Multicall3Call3) ([]multicall3.Multicall3Result, error)
 }
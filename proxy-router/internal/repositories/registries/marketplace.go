@@ -117,6 +117,10 @@ func (g *Marketplace) GetBidById(ctx context.Context, bidID common.Hash) (*marke
 	return &bid, nil
 }
 
+func (g *Marketplace) GetBidId(ctx context.Context, provider common.Address, modelID common.Hash, nonce *big.Int) (common.Hash, error) {
+	return g.marketplace.GetBidId(&bind.CallOpts{Context: ctx}, provider, modelID, nonce)
+}
+
 func (g *Marketplace) GetBestBidByModelId(ctx context.Context, modelID common.Hash) (common.Hash, *marketplace.IBidStorageBid, error) {
 	limit := big.NewInt(100)
 	offset := big.NewInt(0)
@@ -242,3 +246,7 @@ func (g *Marketplace) GetMinMaxBidPricePerSecond(ctx context.Context) (*big.Int,
 	}
 	return min, max, nil
 }
+
+func (g *Marketplace) GetABI() *abi.ABI {
+	return g.marketplaceABI
+}
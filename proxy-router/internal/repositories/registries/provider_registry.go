@@ -84,7 +84,7 @@ func (g *ProviderRegistry) GetProviders(ctx context.Context, offset *big.Int, li
 	}
 
 	adjustOrder(order, ids)
-	return g.getMultipleProviders(ctx, ids)
+	return g.GetMultipleProviders(ctx, ids)
 }
 
 func (g *ProviderRegistry) CreateNewProvider(opts *bind.TransactOpts, addStake *lib.BigInt, endpoint string) error {
@@ -145,7 +145,8 @@ func (g *ProviderRegistry) GetMinStake(ctx context.Context) (*big.Int, error) {
 	return minStake, nil
 }
 
-func (g *ProviderRegistry) getMultipleProviders(ctx context.Context, IDs []common.Address) ([]common.Address, []providerregistry.IProviderStorageProvider, error) {
+// GetMultipleProviders returns providers by their addresses using a single multicall
+func (g *ProviderRegistry) GetMultipleProviders(ctx context.Context, IDs []common.Address) ([]common.Address, []providerregistry.IProviderStorageProvider, error) {
 	args := make([][]interface{}, len(IDs))
 	for i, id := range IDs {
 		args[i] = []interface{}{id}
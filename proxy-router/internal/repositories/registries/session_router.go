@@ -237,6 +237,15 @@ func (g *SessionRouter) GetProviderModelStats(ctx context.Context, modelID [32]b
 	return &res, nil
 }
 
+// GetMultipleProviderModelStats returns model stats of multiple providers using a single multicall
+func (g *SessionRouter) GetMultipleProviderModelStats(ctx context.Context, modelID [32]byte, providers []common.Address) ([]src.IStatsStorageProviderModelStats, error) {
+	args := make([][]interface{}, len(providers))
+	for i, provider := range providers {
+		args[i] = []interface{}{modelID, provider}
+	}
+	return mc.Batch[src.IStatsStorageProviderModelStats](ctx, g.multicall, g.srABI, g.sessionRouterAddr, "getProviderModelStats", args)
+}
+
 func (g *SessionRouter) GetContractAddress() common.Address {
 	return g.sessionRouterAddr
 }
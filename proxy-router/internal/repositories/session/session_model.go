@@ -17,6 +17,7 @@ type sessionModel struct {
 	ttftMsArr        []int
 	failoverEnabled  bool
 	directPayment    bool
+	capabilities     []string
 }
 
 func (s *sessionModel) ID() common.Hash {
@@ -52,6 +53,11 @@ func (s *sessionModel) DirectPayment() bool {
 	return s.directPayment
 }
 
+// Capabilities are advertised by the provider for the model when the session is initiated
+func (s *sessionModel) Capabilities() []string {
+	return s.capabilities
+}
+
 func (s *sessionModel) AddStats(tpsScaled1000 int, ttftMs int) {
 	s.tpsScaled1000Arr = append(s.tpsScaled1000Arr, tpsScaled1000)
 	s.ttftMsArr = append(s.ttftMsArr, ttftMs)
@@ -60,3 +66,7 @@ func (s *sessionModel) AddStats(tpsScaled1000 int, ttftMs int) {
 func (s *sessionModel) SetFailoverEnabled(enabled bool) {
 	s.failoverEnabled = enabled
 }
+
+func (s *sessionModel) SetCapabilities(capabilities []string) {
+	s.capabilities = capabilities
+}
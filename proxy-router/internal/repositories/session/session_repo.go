@@ -98,6 +98,7 @@ func (r *SessionRepositoryCached) getSessionFromCache(id common.Hash) (*sessionM
 		tpsScaled1000Arr: ses.TPSScaled1000Arr,
 		ttftMsArr:        ses.TTFTMsArr,
 		failoverEnabled:  ses.FailoverEnabled,
+		capabilities:     ses.Capabilities,
 	}, true
 }
 
@@ -112,5 +113,6 @@ func (r *SessionRepositoryCached) saveSessionToCache(ses *sessionModel) error {
 		TTFTMsArr:        ses.ttftMsArr,
 		FailoverEnabled:  ses.failoverEnabled,
 		DirectPayment:    ses.directPayment,
+		Capabilities:     ses.capabilities,
 	})
 }
@@ -0,0 +1,29 @@
+package txmanager
+
+import (
+	"context"
+
+	i "github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/interfaces"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// managedClient routes nonce queries, sending and receipts through the manager
+type managedClient struct {
+	i.EthClient
+	m *TxManager
+}
+
+func (c *managedClient) PendingNonceAt(ctx context.Context, account common.Address) (uint64, error) {
+	return c.m.PendingNonce(ctx, account)
+}
+
+func (c *managedClient) SendTransaction(ctx context.Context, tx *types.Transaction) error {
+	return c.m.send(ctx, tx)
+}
+
+// TransactionReceipt returns the receipt of the replacement transaction if the original was bumped,
+// so bind.WaitMined doesn't hang on the replaced hash
+func (c *managedClient) TransactionReceipt(ctx context.Context, txHash common.Hash) (*types.Receipt, error) {
+	return c.m.receipt(ctx, txHash)
+}
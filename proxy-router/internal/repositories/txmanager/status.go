@@ -0,0 +1,61 @@
+package txmanager
+
+import (
+	"sort"
+
+	"github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/storages"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+type TxStatus struct {
+	Hash       common.Hash    `json:"hash"`
+	From       common.Address `json:"from"`
+	Nonce      uint64         `json:"nonce"`
+	Status     string         `json:"status"`
+	SentAt     int64          `json:"sentAt"`
+	Bumps      int            `json:"bumps"`
+	PrevHashes []common.Hash  `json:"prevHashes,omitempty"`
+}
+
+type QueueStatus struct {
+	NextNonces map[common.Address]uint64 `json:"nextNonces"`
+	Pending    []TxStatus                `json:"pending"`
+	Recent     []TxStatus                `json:"recent"`
+}
+
+// Status returns the pending transactions and the recently finished ones
+func (m *TxManager) Status() *QueueStatus {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	status := &QueueStatus{
+		NextNonces: make(map[common.Address]uint64),
+		Pending:    []TxStatus{},
+		Recent:     append([]TxStatus{}, m.recent...),
+	}
+	for addr, acc := range m.accounts {
+		if acc.synced {
+			status.NextNonces[addr] = acc.nextNonce
+		}
+		for _, tx := range acc.pending {
+			status.Pending = append(status.Pending, statusFromPendingTx(tx, TxStatusPending, tx.Hash))
+		}
+	}
+	sort.Slice(status.Pending, func(a, b int) bool {
+		return status.Pending[a].Nonce < status.Pending[b].Nonce
+	})
+
+	return status
+}
+
+func statusFromPendingTx(tx *storages.PendingTx, status string, hash common.Hash) TxStatus {
+	return TxStatus{
+		Hash:       hash,
+		From:       tx.From,
+		Nonce:      tx.Nonce,
+		Status:     status,
+		SentAt:     tx.SentAt,
+		Bumps:      tx.Bumps,
+		PrevHashes: append([]common.Hash{}, tx.PrevHashes...),
+	}
+}
@@ -0,0 +1,523 @@
+package txmanager
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"errors"
+	"fmt"
+	"math/big"
+	"slices"
+	"sync"
+	"time"
+
+	i "github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/interfaces"
+	"github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/lib"
+	"github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/storages"
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+const (
+	// bumpPercent is the gas price increase of the replacement transaction, nodes require at least 10%
+	bumpPercent = 20
+	// defaultSendSlotTimeout is the time a transaction waits for the previous one to be sent before failing
+	defaultSendSlotTimeout = time.Minute
+	// recentTxsSize is the number of finished transactions kept for the status
+	recentTxsSize = 20
+)
+
+const (
+	TxStatusPending = "pending"
+	TxStatusMined   = "mined"
+	TxStatusDropped = "dropped"
+)
+
+var (
+	ErrSendSlotTimeout = errors.New("timeout waiting for the previous transaction to be sent")
+	ErrNonceSync       = errors.New("failed to sync nonce")
+	ErrWalletChanged   = errors.New("wallet changed, cannot sign transaction")
+)
+
+// SignFn signs the transaction on behalf of the sender account
+type SignFn func(ctx context.Context, tx *types.Transaction) (*types.Transaction, error)
+
+type Storage interface {
+	PutPendingTx(tx *storages.PendingTx) error
+	DeletePendingTx(from common.Address, nonce uint64) error
+	GetPendingTxs() ([]*storages.PendingTx, error)
+}
+
+// TxManager allocates nonces locally and tracks the transactions sent by the node. Transactions are
+// signed and sent one at a time, so concurrent writes don't collide on nonces. Transactions which are not
+// mined in time are resent with increased gas price
+type TxManager struct {
+	client       i.EthClient
+	storage      Storage
+	privateKey   i.PrKeyProvider
+	wallets      i.Accounts
+	chainID      *big.Int
+	stuckTimeout time.Duration
+	maxBumps     int
+	pollInterval time.Duration
+	log          lib.ILogger
+
+	sendSlotTimeout time.Duration
+	// sendSlot is held from signing a transaction till it is sent
+	sendSlot chan struct{}
+	inflight *types.Transaction
+
+	mu       sync.Mutex
+	accounts map[common.Address]*account
+	replaced map[common.Hash]common.Hash // hash of the replaced transaction -> hash of the replacement
+	recent   []TxStatus
+}
+
+type account struct {
+	synced    bool
+	nextNonce uint64
+	pending   map[uint64]*storages.PendingTx
+}
+
+func NewTxManager(client i.EthClient, storage Storage, privateKey i.PrKeyProvider, chainID *big.Int, stuckTimeout time.Duration, maxBumps int, log lib.ILogger) *TxManager {
+	return &TxManager{
+		client:       client,
+		storage:      storage,
+		privateKey:   privateKey,
+		chainID:      chainID,
+		stuckTimeout: stuckTimeout,
+		maxBumps:     maxBumps,
+		pollInterval: 15 * time.Second,
+		log:          log.Named("TX_MANAGER"),
+
+		sendSlotTimeout: defaultSendSlotTimeout,
+		sendSlot:        make(chan struct{}, 1),
+		accounts:        make(map[common.Address]*account),
+		replaced:        make(map[common.Hash]common.Hash),
+	}
+}
+
+// SetWalletAccounts lets the manager bump transactions sent by any of the named accounts
+func (m *TxManager) SetWalletAccounts(wallets i.Accounts) {
+	m.wallets = wallets
+}
+
+// Client returns the eth client which assigns nonces and tracks transactions of the manager.
+// Contract bindings should use it along with the Signer
+func (m *TxManager) Client() i.EthClient {
+	return &managedClient{EthClient: m.client, m: m}
+}
+
+// Load restores the pending transactions persisted before restart
+func (m *TxManager) Load() error {
+	txs, err := m.storage.GetPendingTxs()
+	if err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, tx := range txs {
+		acc := m.getAccount(tx.From)
+		acc.pending[tx.Nonce] = tx
+		for _, prev := range tx.PrevHashes {
+			m.replaced[prev] = tx.Hash
+		}
+	}
+	if len(txs) > 0 {
+		m.log.Infof("loaded %d pending transactions", len(txs))
+	}
+	return nil
+}
+
+// Run watches pending transactions until they are mined, bumping the gas price of the stuck ones
+func (m *TxManager) Run(ctx context.Context) error {
+	ticker := time.NewTicker(m.pollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+			m.checkPending(ctx)
+		}
+	}
+}
+
+// Signer returns the signer function for the bind.TransactOpts, it replaces the nonce of the
+// transaction with the locally allocated one. The transaction should be sent right after signing
+func (m *TxManager) Signer(privateKey *ecdsa.PrivateKey) bind.SignerFn {
+	return m.SignerFunc(crypto.PubkeyToAddress(privateKey.PublicKey), m.keySignFn(privateKey))
+}
+
+// SignerFunc is the Signer for accounts whose private key is not available to the node, e.g. remote signers
+func (m *TxManager) SignerFunc(from common.Address, sign SignFn) bind.SignerFn {
+	return func(addr common.Address, tx *types.Transaction) (*types.Transaction, error) {
+		if addr != from {
+			return nil, bind.ErrNotAuthorized
+		}
+
+		// the slot is not taken over on timeout, the previous transaction can be still sent with the same nonce
+		select {
+		case m.sendSlot <- struct{}{}:
+		case <-time.After(m.sendSlotTimeout):
+			return nil, ErrSendSlotTimeout
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), m.sendSlotTimeout)
+		defer cancel()
+
+		nonce, err := m.PendingNonce(ctx, from)
+		if err != nil {
+			<-m.sendSlot
+			return nil, err
+		}
+
+		signedTx, err := sign(ctx, withNonce(tx, nonce))
+		if err != nil {
+			<-m.sendSlot
+			return nil, err
+		}
+
+		m.mu.Lock()
+		m.inflight = signedTx
+		m.mu.Unlock()
+
+		return signedTx, nil
+	}
+}
+
+// PendingNonce returns the next nonce of the account, taking into account the transactions sent by the manager
+func (m *TxManager) PendingNonce(ctx context.Context, from common.Address) (uint64, error) {
+	m.mu.Lock()
+	acc := m.getAccount(from)
+	if acc.synced {
+		nonce := acc.nextNonce
+		m.mu.Unlock()
+		return nonce, nil
+	}
+	m.mu.Unlock()
+
+	chainNonce, err := m.client.PendingNonceAt(ctx, from)
+	if err != nil {
+		return 0, lib.WrapError(ErrNonceSync, err)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	acc.nextNonce = chainNonce
+	for nonce := range acc.pending {
+		acc.nextNonce = max(acc.nextNonce, nonce+1)
+	}
+	acc.synced = true
+
+	return acc.nextNonce, nil
+}
+
+// send sends the transaction, if it was signed by the manager the nonce is marked as used and the send slot is released
+func (m *TxManager) send(ctx context.Context, tx *types.Transaction) error {
+	m.mu.Lock()
+	managed := m.inflight != nil && m.inflight.Hash() == tx.Hash()
+	m.mu.Unlock()
+
+	if !managed {
+		return m.client.SendTransaction(ctx, tx)
+	}
+
+	defer func() {
+		m.mu.Lock()
+		m.inflight = nil
+		m.mu.Unlock()
+		<-m.sendSlot
+	}()
+
+	from, err := types.Sender(types.LatestSignerForChainID(m.chainID), tx)
+	if err != nil {
+		return err
+	}
+
+	err = m.client.SendTransaction(ctx, tx)
+	if err != nil {
+		// the nonce could be used outside of the node
+		m.mu.Lock()
+		m.getAccount(from).synced = false
+		m.mu.Unlock()
+		return err
+	}
+
+	return m.addPending(from, tx)
+}
+
+func (m *TxManager) addPending(from common.Address, tx *types.Transaction) error {
+	raw, err := tx.MarshalBinary()
+	if err != nil {
+		return err
+	}
+	pendingTx := &storages.PendingTx{
+		From:   from,
+		Nonce:  tx.Nonce(),
+		Hash:   tx.Hash(),
+		RawTx:  hexutil.Encode(raw),
+		SentAt: time.Now().Unix(),
+	}
+
+	m.mu.Lock()
+	acc := m.getAccount(from)
+	acc.pending[tx.Nonce()] = pendingTx
+	acc.nextNonce = max(acc.nextNonce, tx.Nonce()+1)
+	m.mu.Unlock()
+
+	m.log.Debugf("sent transaction %s, nonce %d", tx.Hash().Hex(), tx.Nonce())
+
+	// the transaction is already sent, so the storage error shouldn't fail it
+	err = m.storage.PutPendingTx(pendingTx)
+	if err != nil {
+		m.log.Warnf("failed to store pending transaction %s: %s", tx.Hash().Hex(), err)
+	}
+	return nil
+}
+
+// receipt returns the receipt of the transaction or of any transaction which replaced it
+func (m *TxManager) receipt(ctx context.Context, hash common.Hash) (*types.Receipt, error) {
+	m.mu.Lock()
+	hashes := []common.Hash{hash}
+	for next, ok := m.replaced[hash]; ok && !slices.Contains(hashes, next); next, ok = m.replaced[next] {
+		hashes = append(hashes, next)
+	}
+	m.mu.Unlock()
+
+	// the latest replacement is the most likely to be mined
+	for j := len(hashes) - 1; j >= 0; j-- {
+		receipt, err := m.client.TransactionReceipt(ctx, hashes[j])
+		if err == nil {
+			return receipt, nil
+		}
+		if !errors.Is(err, ethereum.NotFound) {
+			return nil, err
+		}
+	}
+	return nil, ethereum.NotFound
+}
+
+func (m *TxManager) checkPending(ctx context.Context) {
+	m.mu.Lock()
+	var txs []*storages.PendingTx
+	for _, acc := range m.accounts {
+		for _, tx := range acc.pending {
+			txs = append(txs, tx)
+		}
+	}
+	m.mu.Unlock()
+
+	for _, tx := range txs {
+		receipt, err := m.receipt(ctx, tx.Hash)
+		if err == nil {
+			m.finish(tx, TxStatusMined, receipt.TxHash)
+			continue
+		}
+		if !errors.Is(err, ethereum.NotFound) {
+			m.log.Warnf("failed to get receipt of transaction %s: %s", tx.Hash.Hex(), err)
+			continue
+		}
+		if time.Since(time.Unix(tx.SentAt, 0)) < m.stuckTimeout {
+			continue
+		}
+		if tx.Bumps >= m.maxBumps {
+			m.log.Warnf("transaction %s is not mined after %d gas bumps, dropping it", tx.Hash.Hex(), tx.Bumps)
+			m.finish(tx, TxStatusDropped, tx.Hash)
+			continue
+		}
+		err = m.bump(ctx, tx)
+		if err != nil {
+			m.log.Warnf("failed to bump gas of transaction %s: %s", tx.Hash.Hex(), err)
+		}
+	}
+}
+
+// bump resends the stuck transaction with the same nonce and increased gas price
+func (m *TxManager) bump(ctx context.Context, pendingTx *storages.PendingTx) error {
+	raw, err := hexutil.Decode(pendingTx.RawTx)
+	if err != nil {
+		return err
+	}
+	tx := new(types.Transaction)
+	err = tx.UnmarshalBinary(raw)
+	if err != nil {
+		return err
+	}
+
+	sign, err := m.getSignFn(ctx, pendingTx.From)
+	if err != nil {
+		return err
+	}
+
+	bumped, err := sign(ctx, withBumpedGas(tx))
+	if err != nil {
+		return err
+	}
+	err = m.client.SendTransaction(ctx, bumped)
+	if err != nil {
+		return err
+	}
+
+	bumpedRaw, err := bumped.MarshalBinary()
+	if err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	m.replaced[pendingTx.Hash] = bumped.Hash()
+	pendingTx.PrevHashes = append(pendingTx.PrevHashes, pendingTx.Hash)
+	pendingTx.Hash = bumped.Hash()
+	pendingTx.RawTx = hexutil.Encode(bumpedRaw)
+	pendingTx.SentAt = time.Now().Unix()
+	pendingTx.Bumps++
+	m.mu.Unlock()
+
+	m.log.Infof("bumped gas of transaction with nonce %d, new hash %s", pendingTx.Nonce, bumped.Hash().Hex())
+	return m.storage.PutPendingTx(pendingTx)
+}
+
+// getSignFn returns the signing function of the node wallet or named account with the address
+func (m *TxManager) getSignFn(ctx context.Context, from common.Address) (SignFn, error) {
+	if signer, ok := m.privateKey.(i.Signer); ok {
+		addr, err := signer.Address(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if addr != from {
+			return nil, lib.WrapError(ErrWalletChanged, fmt.Errorf("%s", from.Hex()))
+		}
+		return func(ctx context.Context, tx *types.Transaction) (*types.Transaction, error) {
+			return signer.SignTx(ctx, tx, m.chainID)
+		}, nil
+	}
+
+	wallets := []i.PrKeyProvider{m.privateKey}
+	if m.wallets != nil {
+		names, err := m.wallets.ListAccounts()
+		if err != nil {
+			return nil, err
+		}
+		for _, name := range names {
+			wallet, err := m.wallets.GetAccount(name)
+			if err != nil {
+				return nil, err
+			}
+			wallets = append(wallets, wallet)
+		}
+	}
+
+	for _, wallet := range wallets {
+		prKey, err := wallet.GetPrivateKey()
+		if err != nil {
+			continue
+		}
+		privateKey, err := crypto.ToECDSA(prKey)
+		if err != nil {
+			continue
+		}
+		if crypto.PubkeyToAddress(privateKey.PublicKey) == from {
+			return m.keySignFn(privateKey), nil
+		}
+	}
+	return nil, lib.WrapError(ErrWalletChanged, fmt.Errorf("%s", from.Hex()))
+}
+
+func (m *TxManager) keySignFn(privateKey *ecdsa.PrivateKey) SignFn {
+	signer := types.LatestSignerForChainID(m.chainID)
+	return func(_ context.Context, tx *types.Transaction) (*types.Transaction, error) {
+		return types.SignTx(tx, signer, privateKey)
+	}
+}
+
+func (m *TxManager) finish(tx *storages.PendingTx, status string, hash common.Hash) {
+	m.mu.Lock()
+	acc := m.getAccount(tx.From)
+	delete(acc.pending, tx.Nonce)
+	if status == TxStatusDropped {
+		// the nonce could be still free, take it from the node
+		acc.synced = false
+	}
+	m.recent = append(m.recent, statusFromPendingTx(tx, status, hash))
+	if len(m.recent) > recentTxsSize {
+		m.recent = m.recent[len(m.recent)-recentTxsSize:]
+	}
+	m.mu.Unlock()
+
+	err := m.storage.DeletePendingTx(tx.From, tx.Nonce)
+	if err != nil {
+		m.log.Warnf("failed to delete pending transaction %s: %s", tx.Hash.Hex(), err)
+	}
+}
+
+// getAccount should be called with mu locked
+func (m *TxManager) getAccount(addr common.Address) *account {
+	acc, ok := m.accounts[addr]
+	if !ok {
+		acc = &account{pending: make(map[uint64]*storages.PendingTx)}
+		m.accounts[addr] = acc
+	}
+	return acc
+}
+
+func withNonce(tx *types.Transaction, nonce uint64) *types.Transaction {
+	return rebuildTx(tx, nonce, tx.GasPrice(), tx.GasTipCap(), tx.GasFeeCap())
+}
+
+func withBumpedGas(tx *types.Transaction) *types.Transaction {
+	return rebuildTx(tx, tx.Nonce(), bumpGas(tx.GasPrice()), bumpGas(tx.GasTipCap()), bumpGas(tx.GasFeeCap()))
+}
+
+func bumpGas(value *big.Int) *big.Int {
+	bumped := new(big.Int).Mul(value, big.NewInt(100+bumpPercent))
+	bumped.Div(bumped, big.NewInt(100))
+	// small values are not increased by the percentage due to rounding
+	if bumped.Cmp(value) <= 0 {
+		bumped.Add(value, big.NewInt(1))
+	}
+	return bumped
+}
+
+func rebuildTx(tx *types.Transaction, nonce uint64, gasPrice, gasTipCap, gasFeeCap *big.Int) *types.Transaction {
+	switch tx.Type() {
+	case types.LegacyTxType:
+		return types.NewTx(&types.LegacyTx{
+			Nonce:    nonce,
+			GasPrice: gasPrice,
+			Gas:      tx.Gas(),
+			To:       tx.To(),
+			Value:    tx.Value(),
+			Data:     tx.Data(),
+		})
+	case types.AccessListTxType:
+		return types.NewTx(&types.AccessListTx{
+			ChainID:    tx.ChainId(),
+			Nonce:      nonce,
+			GasPrice:   gasPrice,
+			Gas:        tx.Gas(),
+			To:         tx.To(),
+			Value:      tx.Value(),
+			Data:       tx.Data(),
+			AccessList: tx.AccessList(),
+		})
+	default:
+		return types.NewTx(&types.DynamicFeeTx{
+			ChainID:    tx.ChainId(),
+			Nonce:      nonce,
+			GasTipCap:  gasTipCap,
+			GasFeeCap:  gasFeeCap,
+			Gas:        tx.Gas(),
+			To:         tx.To(),
+			Value:      tx.Value(),
+			Data:       tx.Data(),
+			AccessList: tx.AccessList(),
+		})
+	}
+}
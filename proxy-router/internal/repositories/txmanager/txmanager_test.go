@@ -0,0 +1,227 @@
+package txmanager
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/interfaces/mocks"
+	"github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/lib"
+	"github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/storages"
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+var testChainID = big.NewInt(1337)
+
+type memStorage struct {
+	mu  sync.Mutex
+	txs map[uint64]*storages.PendingTx
+}
+
+func newMemStorage() *memStorage {
+	return &memStorage{txs: make(map[uint64]*storages.PendingTx)}
+}
+
+func (s *memStorage) PutPendingTx(tx *storages.PendingTx) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	copied := *tx
+	s.txs[tx.Nonce] = &copied
+	return nil
+}
+
+func (s *memStorage) DeletePendingTx(from common.Address, nonce uint64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.txs, nonce)
+	return nil
+}
+
+func (s *memStorage) GetPendingTxs() ([]*storages.PendingTx, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var txs []*storages.PendingTx
+	for _, tx := range s.txs {
+		copied := *tx
+		txs = append(txs, &copied)
+	}
+	return txs, nil
+}
+
+type testKey struct {
+	key lib.HexString
+}
+
+func (k *testKey) GetPrivateKey() (lib.HexString, error) { return k.key, nil }
+func (k *testKey) PrivateKeyUpdated() <-chan struct{}    { return nil }
+
+func newTestManager(t *testing.T, storage Storage) (*TxManager, *mocks.EthClientMock, *testKey) {
+	prKey, err := crypto.GenerateKey()
+	require.NoError(t, err)
+
+	client := mocks.NewEthClientMock(t)
+	key := &testKey{key: crypto.FromECDSA(prKey)}
+	m := NewTxManager(client, storage, key, testChainID, time.Minute, 2, lib.NewTestLogger())
+	return m, client, key
+}
+
+func signTx(t *testing.T, m *TxManager, key *testKey) (*types.Transaction, error) {
+	prKey, err := crypto.ToECDSA(key.key)
+	require.NoError(t, err)
+	from := crypto.PubkeyToAddress(prKey.PublicKey)
+	to := common.HexToAddress("0x01")
+
+	return m.Signer(prKey)(from, types.NewTx(&types.DynamicFeeTx{
+		ChainID:   testChainID,
+		GasTipCap: big.NewInt(100),
+		GasFeeCap: big.NewInt(1000),
+		Gas:       21000,
+		To:        &to,
+		Value:     big.NewInt(1),
+	}))
+}
+
+// sendTx signs and sends the transaction the same way contract bindings do
+func sendTx(t *testing.T, m *TxManager, key *testKey) (*types.Transaction, error) {
+	signed, err := signTx(t, m, key)
+	require.NoError(t, err)
+
+	return signed, m.Client().SendTransaction(context.Background(), signed)
+}
+
+func TestConcurrentTransactionsGetSequentialNonces(t *testing.T) {
+	m, client, key := newTestManager(t, newMemStorage())
+
+	client.EXPECT().PendingNonceAt(mock.Anything, mock.Anything).Return(5, nil).Once()
+
+	mu := sync.Mutex{}
+	sent := map[uint64]bool{}
+	client.EXPECT().SendTransaction(mock.Anything, mock.Anything).RunAndReturn(func(ctx context.Context, tx *types.Transaction) error {
+		mu.Lock()
+		defer mu.Unlock()
+		sent[tx.Nonce()] = true
+		return nil
+	})
+
+	n := 10
+	wg := sync.WaitGroup{}
+	for j := 0; j < n; j++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := sendTx(t, m, key)
+			require.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+
+	require.Len(t, sent, n)
+	for nonce := uint64(5); nonce < uint64(5+n); nonce++ {
+		require.True(t, sent[nonce], "nonce %d is not used", nonce)
+	}
+	require.Len(t, m.Status().Pending, n)
+}
+
+func TestFailedSendResyncsNonce(t *testing.T) {
+	m, client, key := newTestManager(t, newMemStorage())
+
+	client.EXPECT().PendingNonceAt(mock.Anything, mock.Anything).Return(3, nil).Times(2)
+	client.EXPECT().SendTransaction(mock.Anything, mock.Anything).Return(errors.New("nonce too low")).Once()
+	client.EXPECT().SendTransaction(mock.Anything, mock.Anything).Return(nil).Once()
+
+	_, err := sendTx(t, m, key)
+	require.Error(t, err)
+
+	tx, err := sendTx(t, m, key)
+	require.NoError(t, err)
+	require.Equal(t, uint64(3), tx.Nonce())
+}
+
+func TestSendSlotTimeout(t *testing.T) {
+	m, client, key := newTestManager(t, newMemStorage())
+	m.sendSlotTimeout = 50 * time.Millisecond
+
+	client.EXPECT().PendingNonceAt(mock.Anything, mock.Anything).Return(7, nil).Once()
+	client.EXPECT().SendTransaction(mock.Anything, mock.Anything).Return(nil).Times(2)
+
+	// the signed transaction holds the slot until it is sent
+	first, err := signTx(t, m, key)
+	require.NoError(t, err)
+
+	_, err = signTx(t, m, key)
+	require.ErrorIs(t, err, ErrSendSlotTimeout)
+
+	require.NoError(t, m.Client().SendTransaction(context.Background(), first))
+	require.Equal(t, uint64(7), first.Nonce())
+
+	// the nonce of the late transaction is not reused
+	second, err := sendTx(t, m, key)
+	require.NoError(t, err)
+	require.Equal(t, uint64(8), second.Nonce())
+}
+
+func TestStuckTransactionIsBumped(t *testing.T) {
+	storage := newMemStorage()
+	m, client, key := newTestManager(t, storage)
+	m.stuckTimeout = 0
+
+	client.EXPECT().PendingNonceAt(mock.Anything, mock.Anything).Return(0, nil).Once()
+
+	var bumped *types.Transaction
+	client.EXPECT().SendTransaction(mock.Anything, mock.Anything).Return(nil).Once()
+	client.EXPECT().SendTransaction(mock.Anything, mock.Anything).RunAndReturn(func(ctx context.Context, tx *types.Transaction) error {
+		bumped = tx
+		return nil
+	}).Once()
+
+	original, err := sendTx(t, m, key)
+	require.NoError(t, err)
+
+	client.EXPECT().TransactionReceipt(mock.Anything, original.Hash()).Return(nil, ethereum.NotFound)
+	m.checkPending(context.Background())
+
+	require.NotNil(t, bumped)
+	require.Equal(t, original.Nonce(), bumped.Nonce())
+	require.Equal(t, big.NewInt(120), bumped.GasTipCap())
+	require.Equal(t, big.NewInt(1200), bumped.GasFeeCap())
+	require.Equal(t, bumped.Hash(), storage.txs[0].Hash)
+	require.Equal(t, []common.Hash{original.Hash()}, storage.txs[0].PrevHashes)
+
+	// waiting for the original transaction resolves to the receipt of the replacement
+	client.EXPECT().TransactionReceipt(mock.Anything, bumped.Hash()).Return(&types.Receipt{TxHash: bumped.Hash()}, nil)
+	receipt, err := m.Client().TransactionReceipt(context.Background(), original.Hash())
+	require.NoError(t, err)
+	require.Equal(t, bumped.Hash(), receipt.TxHash)
+
+	m.checkPending(context.Background())
+	require.Empty(t, storage.txs)
+	status := m.Status()
+	require.Empty(t, status.Pending)
+	require.Equal(t, TxStatusMined, status.Recent[0].Status)
+}
+
+func TestPendingTransactionsLoadedAfterRestart(t *testing.T) {
+	storage := newMemStorage()
+	m, client, key := newTestManager(t, storage)
+
+	prKey, err := crypto.ToECDSA(key.key)
+	require.NoError(t, err)
+	from := crypto.PubkeyToAddress(prKey.PublicKey)
+	require.NoError(t, storage.PutPendingTx(&storages.PendingTx{From: from, Nonce: 7, SentAt: time.Now().Unix()}))
+
+	require.NoError(t, m.Load())
+
+	// the node hasn't seen the pending transaction yet
+	client.EXPECT().PendingNonceAt(mock.Anything, from).Return(7, nil).Once()
+	nonce, err := m.PendingNonce(context.Background(), from)
+	require.NoError(t, err)
+	require.Equal(t, uint64(8), nonce)
+}
@@ -0,0 +1,238 @@
+package wallet
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+
+	i "github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/interfaces"
+	"github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/lib"
+	"github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/repositories/keychain"
+)
+
+const (
+	ACCOUNTS_KEY       = "accounts"
+	ACCOUNT_KEY_PREFIX = "account:"
+)
+
+var ErrAccountNotFound = errors.New("account not found")
+
+// KeychainAccounts stores named wallets in the keychain. The default account keeps
+// the keys of the single wallet, so existing setups become the default account
+type KeychainAccounts struct {
+	storage i.KeyValueStorage
+	wallets map[string]i.Wallet
+	mutex   sync.Mutex
+}
+
+func NewKeychainAccounts(storage i.KeyValueStorage) *KeychainAccounts {
+	return &KeychainAccounts{
+		storage: storage,
+		wallets: map[string]i.Wallet{
+			lib.DefaultAccount: NewKeychainWallet(storage),
+		},
+	}
+}
+
+// GetAccount returns the wallet of the account, the wallet is empty until its private key or mnemonic is set
+func (a *KeychainAccounts) GetAccount(name string) (i.Wallet, error) {
+	if err := lib.ValidateAccountName(name); err != nil {
+		return nil, err
+	}
+
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	if w, ok := a.wallets[name]; ok {
+		return w, nil
+	}
+	w := &accountWallet{
+		KeychainWallet: NewKeychainWallet(&prefixedStorage{a.storage, ACCOUNT_KEY_PREFIX + name + ":"}),
+		name:           name,
+		accounts:       a,
+	}
+	a.wallets[name] = w
+	return w, nil
+}
+
+func (a *KeychainAccounts) ListAccounts() ([]string, error) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	names, err := a.getStoredNames()
+	if err != nil {
+		return nil, err
+	}
+	_, err = a.wallets[lib.DefaultAccount].GetPrivateKey()
+	if err == nil {
+		names = append([]string{lib.DefaultAccount}, names...)
+	}
+	return names, nil
+}
+
+func (a *KeychainAccounts) addName(name string) error {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	names, err := a.getStoredNames()
+	if err != nil {
+		return err
+	}
+	if slices.Contains(names, name) {
+		return nil
+	}
+	return a.storeNames(append(names, name))
+}
+
+func (a *KeychainAccounts) removeName(name string) error {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	names, err := a.getStoredNames()
+	if err != nil {
+		return err
+	}
+	return a.storeNames(slices.DeleteFunc(names, func(n string) bool { return n == name }))
+}
+
+func (a *KeychainAccounts) getStoredNames() ([]string, error) {
+	data, err := a.storage.Get(ACCOUNTS_KEY)
+	if errors.Is(err, keychain.ErrKeyNotFound) {
+		return []string{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	err = json.Unmarshal([]byte(data), &names)
+	if err != nil {
+		return nil, err
+	}
+	return names, nil
+}
+
+func (a *KeychainAccounts) storeNames(names []string) error {
+	data, err := json.Marshal(names)
+	if err != nil {
+		return err
+	}
+	return a.storage.Upsert(ACCOUNTS_KEY, string(data))
+}
+
+// accountWallet is a named account wallet keeping the list of stored accounts up to date
+type accountWallet struct {
+	*KeychainWallet
+	name     string
+	accounts *KeychainAccounts
+}
+
+func (w *accountWallet) SetPrivateKey(privateKey lib.HexString) error {
+	err := w.accounts.addName(w.name)
+	if err != nil {
+		return err
+	}
+	return w.KeychainWallet.SetPrivateKey(privateKey)
+}
+
+func (w *accountWallet) SetMnemonic(mnemonic string, derivationPath string) error {
+	err := w.accounts.addName(w.name)
+	if err != nil {
+		return err
+	}
+	return w.KeychainWallet.SetMnemonic(mnemonic, derivationPath)
+}
+
+func (w *accountWallet) DeleteWallet() error {
+	err := w.KeychainWallet.DeleteWallet()
+	if err != nil {
+		return err
+	}
+	return w.accounts.removeName(w.name)
+}
+
+type prefixedStorage struct {
+	storage i.KeyValueStorage
+	prefix  string
+}
+
+func (s *prefixedStorage) Get(key string) (string, error) {
+	return s.storage.Get(s.prefix + key)
+}
+
+func (s *prefixedStorage) Insert(key string, value string) error {
+	return s.storage.Insert(s.prefix+key, value)
+}
+
+func (s *prefixedStorage) Upsert(key string, value string) error {
+	return s.storage.Upsert(s.prefix+key, value)
+}
+
+func (s *prefixedStorage) Delete(key string) error {
+	return s.storage.Delete(s.prefix + key)
+}
+
+func (s *prefixedStorage) DeleteIfExists(key string) error {
+	return s.storage.DeleteIfExists(s.prefix + key)
+}
+
+// AccountSelector is the wallet of the account selected in the request context with lib.WithAccount.
+// Without a selected account it acts as the fallback account wallet
+type AccountSelector struct {
+	accounts i.Accounts
+	fallback i.Wallet
+}
+
+func NewAccountSelector(accounts i.Accounts, fallback string) (*AccountSelector, error) {
+	w, err := accounts.GetAccount(fallback)
+	if err != nil {
+		return nil, err
+	}
+	return &AccountSelector{
+		accounts: accounts,
+		fallback: w,
+	}, nil
+}
+
+// Account returns the wallet of the account selected in the context
+func (s *AccountSelector) Account(ctx context.Context) (i.Wallet, error) {
+	name := lib.AccountFromContext(ctx)
+	if name == "" {
+		return s.fallback, nil
+	}
+	return s.accounts.GetAccount(name)
+}
+
+func (s *AccountSelector) GetPrivateKeyCtx(ctx context.Context) (lib.HexString, error) {
+	w, err := s.Account(ctx)
+	if err != nil {
+		return nil, err
+	}
+	prKey, err := w.GetPrivateKey()
+	if name := lib.AccountFromContext(ctx); name != "" && errors.Is(err, ErrWalletNotSet) {
+		return nil, lib.WrapError(ErrAccountNotFound, fmt.Errorf("%s", name))
+	}
+	return prKey, err
+}
+
+func (s *AccountSelector) GetPrivateKey() (lib.HexString, error) {
+	return s.fallback.GetPrivateKey()
+}
+
+func (s *AccountSelector) SetPrivateKey(privateKey lib.HexString) error {
+	return s.fallback.SetPrivateKey(privateKey)
+}
+
+func (s *AccountSelector) SetMnemonic(mnemonic string, derivationPath string) error {
+	return s.fallback.SetMnemonic(mnemonic, derivationPath)
+}
+
+func (s *AccountSelector) DeleteWallet() error {
+	return s.fallback.DeleteWallet()
+}
+
+func (s *AccountSelector) PrivateKeyUpdated() <-chan struct{} {
+	return s.fallback.PrivateKeyUpdated()
+}
@@ -0,0 +1,79 @@
+package wallet
+
+import (
+	"context"
+	"testing"
+
+	"github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/lib"
+	"github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/repositories/keychain"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/require"
+	"github.com/zalando/go-keyring"
+)
+
+func newTestKey(t *testing.T) lib.HexString {
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	return crypto.FromECDSA(key)
+}
+
+func TestKeychainAccounts(t *testing.T) {
+	keyring.MockInit()
+	storage := keychain.NewKeychain()
+
+	// the single wallet of the previous versions becomes the default account
+	defaultKey := newTestKey(t)
+	require.NoError(t, NewKeychainWallet(storage).SetPrivateKey(defaultKey))
+
+	accounts := NewKeychainAccounts(storage)
+	names, err := accounts.ListAccounts()
+	require.NoError(t, err)
+	require.Equal(t, []string{lib.DefaultAccount}, names)
+
+	providerKey := newTestKey(t)
+	provider, err := accounts.GetAccount("provider")
+	require.NoError(t, err)
+	require.NoError(t, provider.SetPrivateKey(providerKey))
+
+	names, err = accounts.ListAccounts()
+	require.NoError(t, err)
+	require.Equal(t, []string{lib.DefaultAccount, "provider"}, names)
+
+	selector, err := NewAccountSelector(accounts, lib.DefaultAccount)
+	require.NoError(t, err)
+
+	prKey, err := selector.GetPrivateKeyCtx(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, defaultKey, prKey)
+
+	prKey, err = selector.GetPrivateKeyCtx(lib.WithAccount(context.Background(), "provider"))
+	require.NoError(t, err)
+	require.Equal(t, providerKey, prKey)
+
+	_, err = selector.GetPrivateKeyCtx(lib.WithAccount(context.Background(), "unknown"))
+	require.ErrorIs(t, err, ErrAccountNotFound)
+
+	_, err = accounts.GetAccount("Invalid Name")
+	require.ErrorIs(t, err, lib.ErrInvalidAccountName)
+
+	require.NoError(t, provider.DeleteWallet())
+	names, err = accounts.ListAccounts()
+	require.NoError(t, err)
+	require.Equal(t, []string{lib.DefaultAccount}, names)
+
+	prKey, err = NewKeychainWallet(storage).GetPrivateKey()
+	require.NoError(t, err)
+	require.Equal(t, defaultKey, prKey)
+}
+
+func TestEnvWalletRejectsNamedAccounts(t *testing.T) {
+	key := newTestKey(t)
+	w := NewEnvWallet(key)
+
+	prKey, err := w.GetPrivateKeyCtx(lib.WithAccount(context.Background(), lib.DefaultAccount))
+	require.NoError(t, err)
+	require.Equal(t, key, prKey)
+
+	_, err = w.GetPrivateKeyCtx(lib.WithAccount(context.Background(), "provider"))
+	require.ErrorIs(t, err, ErrEnvWalletAccounts)
+}
@@ -1,12 +1,16 @@
 package wallet
 
 import (
+	"context"
 	"errors"
 
 	"github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/lib"
 )
 
-var ErrEnvWalletSet = errors.New("cannot set private key for env wallet, switch to keychain wallet by removing WALLET_PRIVATE_KEY env var")
+var (
+	ErrEnvWalletSet      = errors.New("cannot set private key for env wallet, switch to keychain wallet by removing WALLET_PRIVATE_KEY env var")
+	ErrEnvWalletAccounts = errors.New("named accounts are not available for env wallet, switch to keychain wallet by removing WALLET_PRIVATE_KEY env var")
+)
 
 type EnvWallet struct {
 	privKey   lib.HexString
@@ -24,6 +28,15 @@ func (w *EnvWallet) GetPrivateKey() (lib.HexString, error) {
 	return w.privKey, nil
 }
 
+// GetPrivateKeyCtx fails if an account other than the default one is selected, so it is never spent from by mistake
+func (w *EnvWallet) GetPrivateKeyCtx(ctx context.Context) (lib.HexString, error) {
+	name := lib.AccountFromContext(ctx)
+	if name != "" && name != lib.DefaultAccount {
+		return nil, ErrEnvWalletAccounts
+	}
+	return w.privKey, nil
+}
+
 func (w *EnvWallet) SetPrivateKey(privateKeyOxHex lib.HexString) error {
 	return ErrEnvWalletSet
 }
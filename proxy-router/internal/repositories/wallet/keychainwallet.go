@@ -55,7 +55,7 @@ func (w *KeychainWallet) GetPrivateKey() (lib.HexString, error) {
 	}
 
 	if mnem != "" && derivation != "" {
-		return w.mnemonicToPrivateKey(mnem, derivation)
+		return mnemonicToPrivateKey(mnem, derivation)
 	}
 
 	var err = ErrWallet
@@ -160,7 +160,7 @@ func (w *KeychainWallet) getStoredMnemonic() (string, string, error) {
 	return mnemonic, derivationPath, nil
 }
 
-func (w *KeychainWallet) mnemonicToPrivateKey(mnemonic, derivationPath string) (lib.HexString, error) {
+func mnemonicToPrivateKey(mnemonic, derivationPath string) (lib.HexString, error) {
 	wallet, err := NewFromMnemonic(mnemonic)
 	if err != nil {
 		return nil, err
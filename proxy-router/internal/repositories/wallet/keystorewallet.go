@@ -0,0 +1,265 @@
+package wallet
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	i "github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/interfaces"
+	"github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/lib"
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/google/uuid"
+	"golang.org/x/term"
+)
+
+const keystoreFileExt = ".json"
+
+var (
+	ErrKeystore           = errors.New("invalid keystore or passphrase")
+	ErrKeystorePassphrase = errors.New("keystore passphrase is not set, use WALLET_KEYSTORE_PASSPHRASE or WALLET_KEYSTORE_PASSPHRASE_FILE")
+)
+
+// KeystoreWallet keeps the private key in an Ethereum V3 keystore (Web3 Secret Storage) file
+// encrypted with the passphrase. Mnemonics are not stored, only the derived private key
+type KeystoreWallet struct {
+	path       string
+	passphrase string
+	scryptN    int
+	scryptP    int
+
+	prKey     lib.HexString // decrypted key, decryption is slow by design
+	updatedCh chan struct{}
+	mutex     sync.Mutex
+}
+
+func NewKeystoreWallet(path string, passphrase string) *KeystoreWallet {
+	return &KeystoreWallet{
+		path:       path,
+		passphrase: passphrase,
+		scryptN:    keystore.StandardScryptN,
+		scryptP:    keystore.StandardScryptP,
+		updatedCh:  make(chan struct{}),
+	}
+}
+
+func (w *KeystoreWallet) GetPrivateKey() (lib.HexString, error) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	if w.prKey != nil {
+		return w.prKey, nil
+	}
+
+	data, err := os.ReadFile(w.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrWalletNotSet
+	}
+	if err != nil {
+		return nil, err
+	}
+	prKey, err := DecryptKeystore(data, w.passphrase)
+	if err != nil {
+		return nil, err
+	}
+	w.prKey = prKey
+	return prKey, nil
+}
+
+func (w *KeystoreWallet) SetPrivateKey(privateKey lib.HexString) error {
+	data, err := encryptKeystore(privateKey, w.passphrase, w.scryptN, w.scryptP)
+	if err != nil {
+		return err
+	}
+
+	w.mutex.Lock()
+	err = writeFileAtomic(w.path, data)
+	if err == nil {
+		w.prKey = privateKey
+	}
+	w.mutex.Unlock()
+	if err != nil {
+		return err
+	}
+
+	w.notifyUpdated()
+	return nil
+}
+
+func (w *KeystoreWallet) SetMnemonic(mnemonic string, derivationPath string) error {
+	prKey, err := mnemonicToPrivateKey(mnemonic, derivationPath)
+	if err != nil {
+		return err
+	}
+	return w.SetPrivateKey(prKey)
+}
+
+func (w *KeystoreWallet) DeleteWallet() error {
+	w.mutex.Lock()
+	err := os.Remove(w.path)
+	if errors.Is(err, os.ErrNotExist) {
+		err = nil
+	}
+	if err == nil {
+		w.prKey = nil
+	}
+	w.mutex.Unlock()
+	if err != nil {
+		return err
+	}
+
+	w.notifyUpdated()
+	return nil
+}
+
+func (w *KeystoreWallet) PrivateKeyUpdated() <-chan struct{} {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	return w.updatedCh
+}
+
+func (w *KeystoreWallet) notifyUpdated() {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	close(w.updatedCh)
+	w.updatedCh = make(chan struct{})
+}
+
+// KeystoreAccounts stores each named account in the <name>.json keystore file of the directory
+type KeystoreAccounts struct {
+	dir        string
+	passphrase string
+	wallets    map[string]*KeystoreWallet
+	mutex      sync.Mutex
+}
+
+func NewKeystoreAccounts(dir string, passphrase string) (*KeystoreAccounts, error) {
+	err := os.MkdirAll(dir, 0700)
+	if err != nil {
+		return nil, err
+	}
+	return &KeystoreAccounts{
+		dir:        dir,
+		passphrase: passphrase,
+		wallets:    make(map[string]*KeystoreWallet),
+	}, nil
+}
+
+func (a *KeystoreAccounts) GetAccount(name string) (i.Wallet, error) {
+	if err := lib.ValidateAccountName(name); err != nil {
+		return nil, err
+	}
+
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	if w, ok := a.wallets[name]; ok {
+		return w, nil
+	}
+	w := NewKeystoreWallet(filepath.Join(a.dir, name+keystoreFileExt), a.passphrase)
+	a.wallets[name] = w
+	return w, nil
+}
+
+// ListAccounts returns the accounts with a keystore file, the default account goes first
+func (a *KeystoreAccounts) ListAccounts() ([]string, error) {
+	entries, err := os.ReadDir(a.dir)
+	if err != nil {
+		return nil, err
+	}
+
+	names := []string{}
+	for _, entry := range entries {
+		name, ok := strings.CutSuffix(entry.Name(), keystoreFileExt)
+		if !ok || entry.IsDir() || lib.ValidateAccountName(name) != nil {
+			continue
+		}
+		names = append(names, name)
+	}
+	sort.SliceStable(names, func(i, j int) bool {
+		return names[i] == lib.DefaultAccount && names[j] != lib.DefaultAccount
+	})
+	return names, nil
+}
+
+// EncryptKeystore returns the V3 keystore json of the private key encrypted with the passphrase
+func EncryptKeystore(privateKey lib.HexString, passphrase string) ([]byte, error) {
+	return encryptKeystore(privateKey, passphrase, keystore.StandardScryptN, keystore.StandardScryptP)
+}
+
+func encryptKeystore(privateKey lib.HexString, passphrase string, scryptN, scryptP int) ([]byte, error) {
+	key, err := crypto.ToECDSA(privateKey)
+	if err != nil {
+		return nil, err
+	}
+	id, err := uuid.NewRandom()
+	if err != nil {
+		return nil, err
+	}
+	return keystore.EncryptKey(&keystore.Key{
+		Id:         id,
+		Address:    crypto.PubkeyToAddress(key.PublicKey),
+		PrivateKey: key,
+	}, passphrase, scryptN, scryptP)
+}
+
+// DecryptKeystore returns the private key of the V3 keystore json
+func DecryptKeystore(data []byte, passphrase string) (lib.HexString, error) {
+	key, err := keystore.DecryptKey(data, passphrase)
+	if err != nil {
+		return nil, lib.WrapError(ErrKeystore, err)
+	}
+	return crypto.FromECDSA(key.PrivateKey), nil
+}
+
+// ReadKeystorePassphrase returns the passphrase, reads it from the file if set or prompts for it on a terminal
+func ReadKeystorePassphrase(passphrase string, passphraseFile string) (string, error) {
+	if passphrase != "" {
+		return passphrase, nil
+	}
+	if passphraseFile != "" {
+		data, err := os.ReadFile(passphraseFile)
+		if err != nil {
+			return "", err
+		}
+		return strings.TrimRight(string(data), "\r\n"), nil
+	}
+	if !term.IsTerminal(int(os.Stdin.Fd())) {
+		return "", ErrKeystorePassphrase
+	}
+
+	fmt.Fprint(os.Stderr, "Keystore passphrase: ")
+	data, err := term.ReadPassword(int(os.Stdin.Fd()))
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// writeFileAtomic replaces the file, so the keystore is never left half written
+func writeFileAtomic(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	_, err = tmp.Write(data)
+	if err == nil {
+		err = tmp.Sync()
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,91 @@
+package wallet
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/lib"
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/stretchr/testify/require"
+)
+
+func newLightKeystoreWallet(path, passphrase string) *KeystoreWallet {
+	w := NewKeystoreWallet(path, passphrase)
+	w.scryptN, w.scryptP = keystore.LightScryptN, keystore.LightScryptP
+	return w
+}
+
+func TestKeystoreWallet(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "default.json")
+	w := newLightKeystoreWallet(path, "secret")
+
+	_, err := w.GetPrivateKey()
+	require.ErrorIs(t, err, ErrWalletNotSet)
+
+	key := newTestKey(t)
+	updated := w.PrivateKeyUpdated()
+	require.NoError(t, w.SetPrivateKey(key))
+	<-updated
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	require.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+	// a new instance decrypts the file
+	prKey, err := newLightKeystoreWallet(path, "secret").GetPrivateKey()
+	require.NoError(t, err)
+	require.Equal(t, key, prKey)
+
+	_, err = newLightKeystoreWallet(path, "wrong").GetPrivateKey()
+	require.ErrorIs(t, err, ErrKeystore)
+
+	require.NoError(t, w.DeleteWallet())
+	_, err = os.Stat(path)
+	require.ErrorIs(t, err, os.ErrNotExist)
+	_, err = w.GetPrivateKey()
+	require.ErrorIs(t, err, ErrWalletNotSet)
+}
+
+func TestKeystoreImportExport(t *testing.T) {
+	key := newTestKey(t)
+	data, err := encryptKeystore(key, "secret", keystore.LightScryptN, keystore.LightScryptP)
+	require.NoError(t, err)
+
+	prKey, err := DecryptKeystore(data, "secret")
+	require.NoError(t, err)
+	require.Equal(t, key, prKey)
+
+	_, err = DecryptKeystore(data, "wrong")
+	require.ErrorIs(t, err, ErrKeystore)
+}
+
+func TestKeystoreAccounts(t *testing.T) {
+	dir := t.TempDir()
+	accounts, err := NewKeystoreAccounts(dir, "secret")
+	require.NoError(t, err)
+
+	for _, name := range []string{"provider", lib.DefaultAccount} {
+		w, err := accounts.GetAccount(name)
+		require.NoError(t, err)
+		w.(*KeystoreWallet).scryptN, w.(*KeystoreWallet).scryptP = keystore.LightScryptN, keystore.LightScryptP
+		require.NoError(t, w.SetPrivateKey(newTestKey(t)))
+	}
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "notes.txt"), []byte{}, 0600))
+
+	names, err := accounts.ListAccounts()
+	require.NoError(t, err)
+	require.Equal(t, []string{lib.DefaultAccount, "provider"}, names)
+}
+
+func TestReadKeystorePassphrase(t *testing.T) {
+	passphrase, err := ReadKeystorePassphrase("secret", "")
+	require.NoError(t, err)
+	require.Equal(t, "secret", passphrase)
+
+	file := filepath.Join(t.TempDir(), "passphrase")
+	require.NoError(t, os.WriteFile(file, []byte("from file\n"), 0600))
+	passphrase, err = ReadKeystorePassphrase("", file)
+	require.NoError(t, err)
+	require.Equal(t, "from file", passphrase)
+}
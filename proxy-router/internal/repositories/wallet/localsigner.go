@@ -0,0 +1,74 @@
+package wallet
+
+import (
+	"crypto/ecdsa"
+	"errors"
+	"math/big"
+
+	"github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/lib"
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+var ErrUnknownAccount = errors.New("unknown account")
+
+// LocalSignerAPI is a stand-in for an external signer that serves the eth_accounts, eth_sign and
+// eth_signTransaction methods with a private key held in memory. It is meant for tests and local
+// development, use Clef or web3signer in production
+type LocalSignerAPI struct {
+	privateKey *ecdsa.PrivateKey
+	address    common.Address
+}
+
+// NewLocalSignerServer returns a JSON-RPC server of the LocalSignerAPI, it can be served over http with httptest.NewServer
+func NewLocalSignerServer(privateKey lib.HexString) (*rpc.Server, error) {
+	key, err := crypto.ToECDSA(privateKey)
+	if err != nil {
+		return nil, err
+	}
+
+	server := rpc.NewServer()
+	err = server.RegisterName("eth", &LocalSignerAPI{
+		privateKey: key,
+		address:    crypto.PubkeyToAddress(key.PublicKey),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return server, nil
+}
+
+func (a *LocalSignerAPI) Accounts() []common.Address {
+	return []common.Address{a.address}
+}
+
+func (a *LocalSignerAPI) Sign(addr common.Address, data hexutil.Bytes) (hexutil.Bytes, error) {
+	if addr != a.address {
+		return nil, ErrUnknownAccount
+	}
+	sig, err := crypto.Sign(accounts.TextHash(data), a.privateKey)
+	if err != nil {
+		return nil, err
+	}
+	sig[crypto.RecoveryIDOffset] += 27
+	return sig, nil
+}
+
+func (a *LocalSignerAPI) SignTransaction(args signTxArgs) (hexutil.Bytes, error) {
+	if args.From != a.address {
+		return nil, ErrUnknownAccount
+	}
+	chainID := new(big.Int)
+	if args.ChainID != nil {
+		chainID = args.ChainID.ToInt()
+	}
+	tx, err := types.SignTx(args.toTransaction(), types.LatestSignerForChainID(chainID), a.privateKey)
+	if err != nil {
+		return nil, err
+	}
+	return tx.MarshalBinary()
+}
@@ -0,0 +1,273 @@
+package wallet
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+
+	"github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/lib"
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+const (
+	RemoteSignerAPIEth  = "eth"  // eth_accounts, eth_sign, eth_signTransaction, e.g. web3signer
+	RemoteSignerAPIClef = "clef" // account_list, account_signData, account_signTransaction
+)
+
+var (
+	ErrRemoteSigner         = errors.New("remote signer error")
+	ErrRemoteSignerSet      = errors.New("cannot set private key for remote signer wallet, manage the keys in the signer or remove WALLET_REMOTE_SIGNER_URL env var")
+	ErrRemoteSignerExport   = errors.New("the wallet key is held by the remote signer and cannot be exported")
+	ErrRemoteSignerAccounts = errors.New("named accounts are not available for remote signer wallet, remove WALLET_REMOTE_SIGNER_URL env var")
+	ErrRemoteSignerNoAcc    = errors.New("remote signer has no accounts")
+	ErrRemoteSignerMismatch = errors.New("remote signer returned a signature of another account or transaction")
+)
+
+type rpcCaller interface {
+	CallContext(ctx context.Context, result interface{}, method string, args ...interface{}) error
+}
+
+// RemoteSigner delegates transaction and message signing to an external signer over JSON-RPC, such as
+// Clef or web3signer, so the wallet private key never reaches the node. GetPrivateKey returns the node
+// transport key, it is used only to sign and encrypt MOR-RPC messages and holds no funds
+type RemoteSigner struct {
+	client       rpcCaller
+	api          string
+	transportKey lib.HexString
+	updatedCh    chan struct{}
+
+	address common.Address // resolved from the signer if not set
+	mutex   sync.Mutex
+}
+
+// NewRemoteSigner connects to the signer at url (http, ws or ipc). If address is zero the first account
+// of the signer is used
+func NewRemoteSigner(ctx context.Context, url string, api string, address common.Address, transportKey lib.HexString) (*RemoteSigner, error) {
+	if api != RemoteSignerAPIEth && api != RemoteSignerAPIClef {
+		return nil, fmt.Errorf("unknown remote signer api: %s", api)
+	}
+	client, err := rpc.DialContext(ctx, url)
+	if err != nil {
+		return nil, lib.WrapError(ErrRemoteSigner, err)
+	}
+	return &RemoteSigner{
+		client:       client,
+		api:          api,
+		address:      address,
+		transportKey: transportKey,
+		updatedCh:    make(chan struct{}),
+	}, nil
+}
+
+func (s *RemoteSigner) Address(ctx context.Context) (common.Address, error) {
+	if err := checkDefaultAccount(ctx); err != nil {
+		return common.Address{}, err
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.address != (common.Address{}) {
+		return s.address, nil
+	}
+
+	method := "eth_accounts"
+	if s.api == RemoteSignerAPIClef {
+		method = "account_list"
+	}
+	var addrs []common.Address
+	err := s.client.CallContext(ctx, &addrs, method)
+	if err != nil {
+		return common.Address{}, lib.WrapError(ErrRemoteSigner, err)
+	}
+	if len(addrs) == 0 {
+		return common.Address{}, ErrRemoteSignerNoAcc
+	}
+	s.address = addrs[0]
+	return s.address, nil
+}
+
+// SignTx signs the transaction with the signer and verifies the signer didn't alter it
+func (s *RemoteSigner) SignTx(ctx context.Context, tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+	from, err := s.Address(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	args := newSignTxArgs(from, tx, chainID)
+	var raw hexutil.Bytes
+	if s.api == RemoteSignerAPIClef {
+		var res struct {
+			Raw hexutil.Bytes `json:"raw"`
+		}
+		err = s.client.CallContext(ctx, &res, "account_signTransaction", args)
+		raw = res.Raw
+	} else {
+		err = s.client.CallContext(ctx, &raw, "eth_signTransaction", args)
+	}
+	if err != nil {
+		return nil, lib.WrapError(ErrRemoteSigner, err)
+	}
+
+	signedTx := new(types.Transaction)
+	err = signedTx.UnmarshalBinary(raw)
+	if err != nil {
+		return nil, lib.WrapError(ErrRemoteSigner, err)
+	}
+
+	signer := types.LatestSignerForChainID(chainID)
+	sender, err := types.Sender(signer, signedTx)
+	if err != nil {
+		return nil, lib.WrapError(ErrRemoteSignerMismatch, err)
+	}
+	if sender != from || signer.Hash(signedTx) != signer.Hash(tx) {
+		return nil, ErrRemoteSignerMismatch
+	}
+	return signedTx, nil
+}
+
+// SignEthMessage asks the signer for a personal_sign signature of the message hash, the same
+// scheme lib.SignEthMessageV2 uses for session approvals and reports
+func (s *RemoteSigner) SignEthMessage(ctx context.Context, msg []byte) ([]byte, error) {
+	from, err := s.Address(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	hash := hexutil.Bytes(crypto.Keccak256(msg))
+	var sig hexutil.Bytes
+	if s.api == RemoteSignerAPIClef {
+		err = s.client.CallContext(ctx, &sig, "account_signData", accounts.MimetypeTextPlain, from, hash)
+	} else {
+		err = s.client.CallContext(ctx, &sig, "eth_sign", from, hash)
+	}
+	if err != nil {
+		return nil, lib.WrapError(ErrRemoteSigner, err)
+	}
+	if len(sig) != crypto.SignatureLength {
+		return nil, lib.WrapError(ErrRemoteSigner, fmt.Errorf("invalid signature length %d", len(sig)))
+	}
+	if sig[crypto.RecoveryIDOffset] < 27 {
+		sig[crypto.RecoveryIDOffset] += 27
+	}
+
+	recoverSig := append([]byte{}, sig...)
+	recoverSig[crypto.RecoveryIDOffset] -= 27
+	pubKey, err := crypto.SigToPub(accounts.TextHash(hash), recoverSig)
+	if err != nil {
+		return nil, lib.WrapError(ErrRemoteSignerMismatch, err)
+	}
+	if crypto.PubkeyToAddress(*pubKey) != from {
+		return nil, ErrRemoteSignerMismatch
+	}
+	return sig, nil
+}
+
+func (s *RemoteSigner) GetPrivateKey() (lib.HexString, error) {
+	return s.transportKey, nil
+}
+
+// GetPrivateKeyCtx fails if an account other than the default one is selected, the signer serves a single account
+func (s *RemoteSigner) GetPrivateKeyCtx(ctx context.Context) (lib.HexString, error) {
+	if err := checkDefaultAccount(ctx); err != nil {
+		return nil, err
+	}
+	return s.transportKey, nil
+}
+
+func (s *RemoteSigner) SetPrivateKey(privateKey lib.HexString) error {
+	return ErrRemoteSignerSet
+}
+
+func (s *RemoteSigner) SetMnemonic(mnemonic string, derivationPath string) error {
+	return ErrRemoteSignerSet
+}
+
+func (s *RemoteSigner) DeleteWallet() error {
+	return ErrRemoteSignerSet
+}
+
+func (s *RemoteSigner) PrivateKeyUpdated() <-chan struct{} {
+	return s.updatedCh
+}
+
+func checkDefaultAccount(ctx context.Context) error {
+	name := lib.AccountFromContext(ctx)
+	if name != "" && name != lib.DefaultAccount {
+		return ErrRemoteSignerAccounts
+	}
+	return nil
+}
+
+// signTxArgs is the transaction object of eth_signTransaction and account_signTransaction
+type signTxArgs struct {
+	From                 common.Address    `json:"from"`
+	To                   *common.Address   `json:"to"`
+	Gas                  hexutil.Uint64    `json:"gas"`
+	GasPrice             *hexutil.Big      `json:"gasPrice,omitempty"`
+	MaxFeePerGas         *hexutil.Big      `json:"maxFeePerGas,omitempty"`
+	MaxPriorityFeePerGas *hexutil.Big      `json:"maxPriorityFeePerGas,omitempty"`
+	Value                hexutil.Big       `json:"value"`
+	Nonce                hexutil.Uint64    `json:"nonce"`
+	Data                 hexutil.Bytes     `json:"data"`
+	ChainID              *hexutil.Big      `json:"chainId,omitempty"`
+	AccessList           *types.AccessList `json:"accessList,omitempty"`
+}
+
+func newSignTxArgs(from common.Address, tx *types.Transaction, chainID *big.Int) *signTxArgs {
+	args := &signTxArgs{
+		From:    from,
+		To:      tx.To(),
+		Gas:     hexutil.Uint64(tx.Gas()),
+		Value:   hexutil.Big(*tx.Value()),
+		Nonce:   hexutil.Uint64(tx.Nonce()),
+		Data:    tx.Data(),
+		ChainID: (*hexutil.Big)(chainID),
+	}
+	if tx.Type() == types.LegacyTxType {
+		args.GasPrice = (*hexutil.Big)(tx.GasPrice())
+	} else {
+		args.MaxFeePerGas = (*hexutil.Big)(tx.GasFeeCap())
+		args.MaxPriorityFeePerGas = (*hexutil.Big)(tx.GasTipCap())
+	}
+	if len(tx.AccessList()) > 0 {
+		al := tx.AccessList()
+		args.AccessList = &al
+	}
+	return args
+}
+
+func (a *signTxArgs) toTransaction() *types.Transaction {
+	var accessList types.AccessList
+	if a.AccessList != nil {
+		accessList = *a.AccessList
+	}
+	if a.MaxFeePerGas != nil {
+		return types.NewTx(&types.DynamicFeeTx{
+			ChainID:    (*big.Int)(a.ChainID),
+			Nonce:      uint64(a.Nonce),
+			GasTipCap:  (*big.Int)(a.MaxPriorityFeePerGas),
+			GasFeeCap:  (*big.Int)(a.MaxFeePerGas),
+			Gas:        uint64(a.Gas),
+			To:         a.To,
+			Value:      a.Value.ToInt(),
+			Data:       a.Data,
+			AccessList: accessList,
+		})
+	}
+	return types.NewTx(&types.LegacyTx{
+		Nonce:    uint64(a.Nonce),
+		GasPrice: (*big.Int)(a.GasPrice),
+		Gas:      uint64(a.Gas),
+		To:       a.To,
+		Value:    a.Value.ToInt(),
+		Data:     a.Data,
+	})
+}
@@ -0,0 +1,130 @@
+package wallet
+
+import (
+	"context"
+	"math/big"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/lib"
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/stretchr/testify/require"
+)
+
+// clefAPI serves the Clef account_ methods with the stand-in signer
+type clefAPI struct {
+	eth *LocalSignerAPI
+}
+
+func (a *clefAPI) List() []common.Address {
+	return a.eth.Accounts()
+}
+
+func (a *clefAPI) SignData(contentType string, addr common.Address, data hexutil.Bytes) (hexutil.Bytes, error) {
+	if contentType != accounts.MimetypeTextPlain {
+		return nil, ErrUnknownAccount
+	}
+	return a.eth.Sign(addr, data)
+}
+
+func (a *clefAPI) SignTransaction(args signTxArgs) (map[string]hexutil.Bytes, error) {
+	raw, err := a.eth.SignTransaction(args)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]hexutil.Bytes{"raw": raw}, nil
+}
+
+func newTestRemoteSigner(t *testing.T, key lib.HexString, api string, address common.Address) *RemoteSigner {
+	server, err := NewLocalSignerServer(key)
+	require.NoError(t, err)
+	if api == RemoteSignerAPIClef {
+		privateKey, err := crypto.ToECDSA(key)
+		require.NoError(t, err)
+		eth := &LocalSignerAPI{privateKey: privateKey, address: crypto.PubkeyToAddress(privateKey.PublicKey)}
+		server = rpc.NewServer()
+		require.NoError(t, server.RegisterName("account", &clefAPI{eth: eth}))
+	}
+	httpServer := httptest.NewServer(server)
+	t.Cleanup(httpServer.Close)
+
+	signer, err := NewRemoteSigner(context.Background(), httpServer.URL, api, address, newTestKey(t))
+	require.NoError(t, err)
+	return signer
+}
+
+func TestRemoteSigner(t *testing.T) {
+	for _, api := range []string{RemoteSignerAPIEth, RemoteSignerAPIClef} {
+		t.Run(api, func(t *testing.T) {
+			ctx := context.Background()
+			key := newTestKey(t)
+			keyAddr, err := lib.PrivKeyBytesToAddr(key)
+			require.NoError(t, err)
+
+			signer := newTestRemoteSigner(t, key, api, common.Address{})
+
+			addr, err := signer.Address(ctx)
+			require.NoError(t, err)
+			require.Equal(t, keyAddr, addr)
+
+			// approvals signed remotely are the same as the locally signed ones
+			msg := []byte("session approval")
+			sig, err := signer.SignEthMessage(ctx, msg)
+			require.NoError(t, err)
+			localSig, err := lib.SignEthMessageV2(msg, key)
+			require.NoError(t, err)
+			require.Equal(t, localSig, sig)
+
+			chainID := big.NewInt(421614)
+			to := common.HexToAddress("0x1")
+			txs := []*types.Transaction{
+				types.NewTx(&types.DynamicFeeTx{ChainID: chainID, Nonce: 3, GasTipCap: big.NewInt(1), GasFeeCap: big.NewInt(2), Gas: 21000, To: &to, Value: big.NewInt(5), Data: []byte{1, 2}}),
+				types.NewTx(&types.LegacyTx{Nonce: 4, GasPrice: big.NewInt(2), Gas: 21000, To: &to, Value: big.NewInt(5)}),
+			}
+			for _, tx := range txs {
+				signedTx, err := signer.SignTx(ctx, tx, chainID)
+				require.NoError(t, err)
+				sender, err := types.Sender(types.LatestSignerForChainID(chainID), signedTx)
+				require.NoError(t, err)
+				require.Equal(t, keyAddr, sender)
+				require.Equal(t, tx.Nonce(), signedTx.Nonce())
+			}
+
+			_, err = signer.Address(lib.WithAccount(ctx, "trading"))
+			require.ErrorIs(t, err, ErrRemoteSignerAccounts)
+		})
+	}
+}
+
+func TestRemoteSignerMismatch(t *testing.T) {
+	ctx := context.Background()
+	otherAddr, err := lib.PrivKeyBytesToAddr(newTestKey(t))
+	require.NoError(t, err)
+
+	// the signer holds a key of another account
+	signer := newTestRemoteSigner(t, newTestKey(t), RemoteSignerAPIEth, otherAddr)
+
+	_, err = signer.SignEthMessage(ctx, []byte("session approval"))
+	require.Error(t, err)
+}
+
+func TestRemoteSignerReadOnly(t *testing.T) {
+	signer := newTestRemoteSigner(t, newTestKey(t), RemoteSignerAPIEth, common.Address{})
+
+	require.ErrorIs(t, signer.SetPrivateKey(newTestKey(t)), ErrRemoteSignerSet)
+	require.ErrorIs(t, signer.DeleteWallet(), ErrRemoteSignerSet)
+
+	// the node key is the transport key, not the signer one
+	transportKey, err := signer.GetPrivateKey()
+	require.NoError(t, err)
+	transportAddr, err := lib.PrivKeyBytesToAddr(transportKey)
+	require.NoError(t, err)
+	addr, err := signer.Address(context.Background())
+	require.NoError(t, err)
+	require.NotEqual(t, addr, transportAddr)
+}
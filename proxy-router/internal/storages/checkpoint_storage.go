@@ -0,0 +1,46 @@
+package storages
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// CheckpointStorage persists the last processed block of log watchers
+type CheckpointStorage struct {
+	db *Storage
+}
+
+func NewCheckpointStorage(storage *Storage) *CheckpointStorage {
+	return &CheckpointStorage{
+		db: storage,
+	}
+}
+
+func (s *CheckpointStorage) GetCheckpoint(key string) (uint64, common.Hash, bool) {
+	data, err := s.db.Get(formatCheckpointKey(key))
+	if err != nil {
+		return 0, common.Hash{}, false
+	}
+
+	checkpoint := &LogWatcherCheckpoint{}
+	err = json.Unmarshal(data, checkpoint)
+	if err != nil {
+		return 0, common.Hash{}, false
+	}
+
+	return checkpoint.Block, checkpoint.Hash, true
+}
+
+func (s *CheckpointStorage) SetCheckpoint(key string, block uint64, hash common.Hash) error {
+	data, err := json.Marshal(&LogWatcherCheckpoint{Block: block, Hash: hash})
+	if err != nil {
+		return err
+	}
+	return s.db.Set(formatCheckpointKey(key), data)
+}
+
+func formatCheckpointKey(key string) []byte {
+	return []byte(fmt.Sprintf("checkpoint:%s", key))
+}
@@ -0,0 +1,178 @@
+package storages
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	badger "github.com/dgraph-io/badger/v4"
+)
+
+const (
+	indexerCheckpointKey = "idx:checkpoint"
+)
+
+// IndexerStorage persists marketplace entities indexed from contract events,
+// along with the hashes of processed blocks, used to detect chain reorganizations
+type IndexerStorage struct {
+	db *Storage
+}
+
+func NewIndexerStorage(storage *Storage) *IndexerStorage {
+	return &IndexerStorage{
+		db: storage,
+	}
+}
+
+func (s *IndexerStorage) PutEntity(kind, id string, data []byte) error {
+	return s.db.Set(formatEntityKey(kind, id), data)
+}
+
+func (s *IndexerStorage) GetEntity(kind, id string) ([]byte, bool) {
+	data, err := s.db.Get(formatEntityKey(kind, id))
+	if err != nil {
+		return nil, false
+	}
+	return data, true
+}
+
+func (s *IndexerStorage) DeleteEntity(kind, id string) error {
+	err := s.db.Delete(formatEntityKey(kind, id))
+	if errors.Is(err, badger.ErrKeyNotFound) {
+		return nil
+	}
+	return err
+}
+
+func (s *IndexerStorage) GetEntities(kind string) ([][]byte, error) {
+	return s.db.GetPrefixValues(formatEntityKey(kind, ""))
+}
+
+func (s *IndexerStorage) GetCheckpoint() (*IndexerCheckpoint, bool) {
+	data, err := s.db.Get([]byte(indexerCheckpointKey))
+	if err != nil {
+		return nil, false
+	}
+
+	checkpoint := &IndexerCheckpoint{}
+	err = json.Unmarshal(data, checkpoint)
+	if err != nil {
+		return nil, false
+	}
+	return checkpoint, true
+}
+
+func (s *IndexerStorage) SetCheckpoint(checkpoint *IndexerCheckpoint) error {
+	data, err := json.Marshal(checkpoint)
+	if err != nil {
+		return err
+	}
+	return s.db.Set([]byte(indexerCheckpointKey), data)
+}
+
+// AddBlockEntities records the block hash and appends entity keys to the list of entities updated in the block
+func (s *IndexerStorage) AddBlockEntities(number uint64, hash string, entities ...string) error {
+	block, ok := s.GetBlock(number)
+	if !ok || block.Hash != hash {
+		block = &IndexedBlock{Number: number, Hash: hash}
+	}
+
+	for _, entity := range entities {
+		if !contains(block.Entities, entity) {
+			block.Entities = append(block.Entities, entity)
+		}
+	}
+
+	data, err := json.Marshal(block)
+	if err != nil {
+		return err
+	}
+	return s.db.Set(formatBlockKey(number), data)
+}
+
+func (s *IndexerStorage) GetBlock(number uint64) (*IndexedBlock, bool) {
+	data, err := s.db.Get(formatBlockKey(number))
+	if err != nil {
+		return nil, false
+	}
+
+	block := &IndexedBlock{}
+	err = json.Unmarshal(data, block)
+	if err != nil {
+		return nil, false
+	}
+	return block, true
+}
+
+// GetBlocks returns indexed blocks in ascending order
+func (s *IndexerStorage) GetBlocks() ([]IndexedBlock, error) {
+	values, err := s.db.GetPrefixValues([]byte("idx:block:"))
+	if err != nil {
+		return nil, err
+	}
+
+	blocks := make([]IndexedBlock, len(values))
+	for i, val := range values {
+		err := json.Unmarshal(val, &blocks[i])
+		if err != nil {
+			return nil, fmt.Errorf("error parsing indexed block: %w", err)
+		}
+	}
+	return blocks, nil
+}
+
+func (s *IndexerStorage) DeleteBlock(number uint64) error {
+	err := s.db.Delete(formatBlockKey(number))
+	if errors.Is(err, badger.ErrKeyNotFound) {
+		return nil
+	}
+	return err
+}
+
+// PruneBlocks removes the records of blocks older than the given block number
+func (s *IndexerStorage) PruneBlocks(before uint64) error {
+	blocks, err := s.GetBlocks()
+	if err != nil {
+		return err
+	}
+	for _, block := range blocks {
+		if block.Number >= before {
+			break
+		}
+		err := s.DeleteBlock(block.Number)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// FormatEntityRef returns the reference to the entity stored in the block record
+func FormatEntityRef(kind, id string) string {
+	return fmt.Sprintf("%s:%s", kind, strings.ToLower(id))
+}
+
+// ParseEntityRef parses the reference created by FormatEntityRef
+func ParseEntityRef(ref string) (kind, id string) {
+	kind, id, _ = strings.Cut(ref, ":")
+	return kind, id
+}
+
+func formatEntityKey(kind, id string) []byte {
+	return []byte(fmt.Sprintf("idx:%s:%s", kind, strings.ToLower(id)))
+}
+
+func formatBlockKey(number uint64) []byte {
+	// zero padded, so keys are sorted by block number
+	return []byte(fmt.Sprintf("idx:block:%020d", number))
+}
+
+func contains(arr []string, s string) bool {
+	for _, v := range arr {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
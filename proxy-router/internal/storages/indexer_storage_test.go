@@ -0,0 +1,56 @@
+package storages
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestIndexerStorageBlocks(t *testing.T) {
+	storage := NewIndexerStorage(NewTestStorage())
+
+	require.NoError(t, storage.AddBlockEntities(10, "0xa", FormatEntityRef("bid", "0x1")))
+	require.NoError(t, storage.AddBlockEntities(10, "0xa", FormatEntityRef("bid", "0x1"), FormatEntityRef("provider", "0x2")))
+	require.NoError(t, storage.AddBlockEntities(9, "0xb", FormatEntityRef("model", "0x3")))
+	require.NoError(t, storage.AddBlockEntities(100, "0xc"))
+
+	blocks, err := storage.GetBlocks()
+	require.NoError(t, err)
+	require.Len(t, blocks, 3)
+	require.Equal(t, []uint64{9, 10, 100}, []uint64{blocks[0].Number, blocks[1].Number, blocks[2].Number})
+	require.Equal(t, []string{"bid:0x1", "provider:0x2"}, blocks[1].Entities)
+
+	// block with the same number but different hash replaces the record
+	require.NoError(t, storage.AddBlockEntities(10, "0xd", FormatEntityRef("session", "0x4")))
+	block, ok := storage.GetBlock(10)
+	require.True(t, ok)
+	require.Equal(t, []string{"session:0x4"}, block.Entities)
+
+	require.NoError(t, storage.PruneBlocks(11))
+	blocks, err = storage.GetBlocks()
+	require.NoError(t, err)
+	require.Len(t, blocks, 1)
+	require.Equal(t, uint64(100), blocks[0].Number)
+}
+
+func TestIndexerStorageEntities(t *testing.T) {
+	storage := NewIndexerStorage(NewTestStorage())
+
+	require.NoError(t, storage.PutEntity("bid", "0xAB", []byte(`{"id":1}`)))
+	require.NoError(t, storage.PutEntity("bid", "0xcd", []byte(`{"id":2}`)))
+	require.NoError(t, storage.PutEntity("model", "0xab", []byte(`{"id":3}`)))
+
+	data, ok := storage.GetEntity("bid", "0xab")
+	require.True(t, ok)
+	require.Equal(t, `{"id":1}`, string(data))
+
+	bids, err := storage.GetEntities("bid")
+	require.NoError(t, err)
+	require.Len(t, bids, 2)
+
+	require.NoError(t, storage.DeleteEntity("bid", "0xab"))
+	require.NoError(t, storage.DeleteEntity("bid", "0xab"))
+	bids, err = storage.GetEntities("bid")
+	require.NoError(t, err)
+	require.Len(t, bids, 1)
+}
@@ -0,0 +1,124 @@
+package storages
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	badger "github.com/dgraph-io/badger/v4"
+)
+
+var ErrCachedResponseNotFound = errors.New("cached response not found")
+
+// CachedResponse is an upstream response kept by the provider, chunks are the upstream json
+type CachedResponse struct {
+	Chunks    []json.RawMessage `json:"chunks"`
+	Tokens    int               `json:"tokens"`
+	Text      string            `json:"text"`      // checked by the output moderation on every hit
+	CreatedAt int64             `json:"createdAt"` // unix nano
+}
+
+// ResponseCacheStorage keeps the responses per model. Entries expire with the badger TTL,
+// an index ordered by the creation time is used to evict the oldest entries of a model
+type ResponseCacheStorage struct {
+	db *Storage
+}
+
+func NewResponseCacheStorage(storage *Storage) *ResponseCacheStorage {
+	return &ResponseCacheStorage{
+		db: storage,
+	}
+}
+
+func (s *ResponseCacheStorage) GetResponse(modelID string, key string) (*CachedResponse, error) {
+	data, err := s.db.Get(formatResponseCacheKey(modelID, key))
+	if errors.Is(err, badger.ErrKeyNotFound) {
+		return nil, ErrCachedResponseNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	res := &CachedResponse{}
+	err = json.Unmarshal(data, res)
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// PutResponse stores the response for ttl and evicts the oldest responses of the model
+// so at most maxEntries are kept
+func (s *ResponseCacheStorage) PutResponse(modelID string, key string, res *CachedResponse, ttl time.Duration, maxEntries int) error {
+	data, err := json.Marshal(res)
+	if err != nil {
+		return err
+	}
+
+	err = s.db.db.Update(func(txn *badger.Txn) error {
+		err := txn.SetEntry(badger.NewEntry(formatResponseCacheKey(modelID, key), data).WithTTL(ttl))
+		if err != nil {
+			return err
+		}
+		return txn.SetEntry(badger.NewEntry(formatResponseCacheIndexKey(modelID, res.CreatedAt, key), []byte(key)).WithTTL(ttl))
+	})
+	if err != nil {
+		return err
+	}
+
+	return s.evict(modelID, maxEntries)
+}
+
+// CountResponses returns the number of unexpired responses of the model
+func (s *ResponseCacheStorage) CountResponses(modelID string) (int, error) {
+	keys, err := s.db.GetPrefix(formatResponseCacheIndexPrefix(modelID))
+	if err != nil {
+		return 0, err
+	}
+	return len(keys), nil
+}
+
+func (s *ResponseCacheStorage) evict(modelID string, maxEntries int) error {
+	if maxEntries <= 0 {
+		return nil
+	}
+
+	// index keys are ordered by the creation time, so the oldest come first
+	indexKeys, err := s.db.GetPrefix(formatResponseCacheIndexPrefix(modelID))
+	if err != nil {
+		return err
+	}
+	if len(indexKeys) <= maxEntries {
+		return nil
+	}
+
+	return s.db.db.Update(func(txn *badger.Txn) error {
+		for _, indexKey := range indexKeys[:len(indexKeys)-maxEntries] {
+			parts := strings.Split(string(indexKey), ":")
+			err := txn.Delete(formatResponseCacheKey(modelID, parts[len(parts)-1]))
+			if err != nil {
+				return err
+			}
+			err = txn.Delete(indexKey)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func formatResponseCacheKey(modelID string, key string) []byte {
+	return []byte(fmt.Sprintf("rcache:%s:%s", strings.ToLower(modelID), key))
+}
+
+func formatResponseCacheIndexPrefix(modelID string) []byte {
+	return []byte(fmt.Sprintf("rcacheidx:%s:", strings.ToLower(modelID)))
+}
+
+func formatResponseCacheIndexKey(modelID string, createdAt int64, key string) []byte {
+	// fixed width, so the keys sort by time
+	return []byte(fmt.Sprintf("rcacheidx:%s:%020d:%s", strings.ToLower(modelID), createdAt, key))
+}
@@ -0,0 +1,40 @@
+package storages
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestResponseCacheStorage(t *testing.T) {
+	storage := NewResponseCacheStorage(NewTestStorage())
+	put := func(modelID, key string, createdAt int64) {
+		res := &CachedResponse{Chunks: []json.RawMessage{json.RawMessage(`{"id":"` + key + `"}`)}, Tokens: 1, CreatedAt: createdAt}
+		require.NoError(t, storage.PutResponse(modelID, key, res, time.Hour, 2))
+	}
+
+	put("0xAB", "a", 1)
+	put("0xAB", "b", 2)
+	put("0xcd", "c", 3)
+
+	res, err := storage.GetResponse("0xab", "a")
+	require.NoError(t, err)
+	require.JSONEq(t, `{"id":"a"}`, string(res.Chunks[0]))
+	require.Equal(t, 1, res.Tokens)
+
+	// the oldest response of the model is evicted
+	put("0xab", "d", 4)
+	_, err = storage.GetResponse("0xab", "a")
+	require.ErrorIs(t, err, ErrCachedResponseNotFound)
+	_, err = storage.GetResponse("0xab", "b")
+	require.NoError(t, err)
+
+	count, err := storage.CountResponses("0xAB")
+	require.NoError(t, err)
+	require.Equal(t, 2, count)
+	count, err = storage.CountResponses("0xcd")
+	require.NoError(t, err)
+	require.Equal(t, 1, count)
+}
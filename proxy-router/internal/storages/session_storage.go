@@ -78,6 +78,11 @@ func (s *SessionStorage) AddSession(session *Session) error {
 	if err != nil {
 		return err
 	}
+	// sessions are indexed by user to keep the accounts of the node apart
+	err = s.db.Set(formatUserSessionKey(session.UserAddr, session.Id), []byte{})
+	if err != nil {
+		return fmt.Errorf("error adding session to user: %s", err)
+	}
 	return nil
 }
 
@@ -94,6 +99,11 @@ func (s *SessionStorage) RemoveSession(id string) error {
 		return err
 	}
 
+	err = s.db.Delete(formatUserSessionKey(ses.UserAddr, sessionId))
+	if err != nil {
+		return fmt.Errorf("error removing session from user: %s", err)
+	}
+
 	return s.removeSessionFromModel(ses.ModelID, sessionId)
 }
 
@@ -147,6 +157,41 @@ func (s *SessionStorage) GetSessionsForModel(modelID string) ([]string, error) {
 	return sessionIDs, nil
 }
 
+// IndexUserSessions adds the sessions stored before the user index was introduced to the index
+func (s *SessionStorage) IndexUserSessions() error {
+	sessions, err := s.GetSessions()
+	if err != nil {
+		return err
+	}
+	for _, session := range sessions {
+		err = s.db.Set(formatUserSessionKey(session.UserAddr, session.Id), []byte{})
+		if err != nil {
+			return fmt.Errorf("error adding session to user: %s", err)
+		}
+	}
+	return nil
+}
+
+// GetSessionsForUser returns the sessions opened by the user address
+func (s *SessionStorage) GetSessionsForUser(userAddr string) ([]Session, error) {
+	keys, err := s.db.GetPrefix(formatUserSessionKey(userAddr, ""))
+	if err != nil {
+		return []Session{}, err
+	}
+
+	sessions := make([]Session, 0, len(keys))
+	for _, key := range keys {
+		_, sessionID := parseUserSessionKey(key)
+		session, ok := s.GetSession(sessionID)
+		if !ok {
+			return nil, fmt.Errorf("error getting session: %s", sessionID)
+		}
+		sessions = append(sessions, *session)
+	}
+
+	return sessions, nil
+}
+
 func (s *SessionStorage) AddActivity(modelID string, activity *PromptActivity) error {
 	modelID = strings.ToLower(modelID)
 	key := fmt.Sprintf("activity:%s", modelID)
@@ -234,6 +279,10 @@ func formatModelSessionKey(modelID string, sessionID string) []byte {
 	return []byte(fmt.Sprintf("model:%s:session:%s", strings.ToLower(modelID), strings.ToLower(sessionID)))
 }
 
+func formatUserSessionKey(userAddr string, sessionID string) []byte {
+	return []byte(fmt.Sprintf("user:%s:session:%s", strings.ToLower(userAddr), strings.ToLower(sessionID)))
+}
+
 func formatSessionKey(sessionID string) []byte {
 	return []byte(fmt.Sprintf("session:%s", strings.ToLower(sessionID)))
 }
@@ -243,6 +292,11 @@ func parseModelSessionKey(key []byte) (string, string) {
 	return parts[1], parts[3]
 }
 
+func parseUserSessionKey(key []byte) (string, string) {
+	parts := strings.Split(string(key), ":")
+	return parts[1], parts[3]
+}
+
 func parseSessionKey(key []byte) (string, string) {
 	parts := strings.Split(string(key), ":")
 	return parts[0], parts[1]
@@ -62,3 +62,35 @@ func TestRemoveSession(t *testing.T) {
 	require.NoError(t, err)
 	require.Empty(t, sessionIds)
 }
+
+func TestGetSessionsForUser(t *testing.T) {
+	storage := NewTestStorage()
+	sessionStorage := NewSessionStorage(storage)
+
+	sessions := []*Session{
+		{Id: "0x10", UserAddr: "0xAA", ProviderAddr: "0x2", EndsAt: big.NewInt(100), ModelID: "0x3"},
+		{Id: "0x11", UserAddr: "0xaa", ProviderAddr: "0x2", EndsAt: big.NewInt(100), ModelID: "0x3"},
+		{Id: "0x12", UserAddr: "0xbb", ProviderAddr: "0x2", EndsAt: big.NewInt(100), ModelID: "0x3"},
+	}
+	for _, session := range sessions {
+		require.NoError(t, sessionStorage.AddSession(session))
+	}
+
+	userSessions, err := sessionStorage.GetSessionsForUser("0xaa")
+	require.NoError(t, err)
+	require.Len(t, userSessions, 2)
+	require.Equal(t, "0x10", userSessions[0].Id)
+	require.Equal(t, "0x11", userSessions[1].Id)
+
+	require.NoError(t, sessionStorage.RemoveSession("0x10"))
+	userSessions, err = sessionStorage.GetSessionsForUser("0xAA")
+	require.NoError(t, err)
+	require.Len(t, userSessions, 1)
+	require.Equal(t, "0x11", userSessions[0].Id)
+
+	// the user record is not mistaken for a session
+	require.NoError(t, sessionStorage.AddUser(&User{Addr: "0xbb", PubKey: "0x", Url: "localhost"}))
+	userSessions, err = sessionStorage.GetSessionsForUser("0xbb")
+	require.NoError(t, err)
+	require.Len(t, userSessions, 1)
+}
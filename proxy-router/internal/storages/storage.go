@@ -88,3 +88,21 @@ func (s *Storage) Delete(key []byte) error {
 		return txn.Delete(key)
 	})
 }
+
+func (s *Storage) GetPrefixValues(prefix []byte) ([][]byte, error) {
+	values := make([][]byte, 0)
+	err := s.db.View(func(txn *badger.Txn) error {
+		it := txn.NewIterator(badger.IteratorOptions{PrefetchValues: true, PrefetchSize: 100, Prefix: prefix})
+		defer it.Close()
+
+		for it.Seek(prefix); it.ValidForPrefix(prefix); it.Next() {
+			val, err := it.Item().ValueCopy(nil)
+			if err != nil {
+				return err
+			}
+			values = append(values, val)
+		}
+		return nil
+	})
+	return values, err
+}
@@ -1,6 +1,10 @@
 package storages
 
-import "math/big"
+import (
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+)
 
 type Session struct {
 	Id           string
@@ -13,6 +17,21 @@ type Session struct {
 	TTFTMsArr        []int
 	FailoverEnabled  bool
 	DirectPayment    bool
+	Capabilities     []string // advertised by the provider on session initiation
+}
+
+// VirtualSession is a stable session ID for API clients, the renewer replaces the on-chain session
+// behind it shortly before the session ends
+type VirtualSession struct {
+	ID            string
+	SessionID     string // current on-chain session
+	ModelID       string
+	BidID         string // renewals fall back to the best bid of the model if the bid is gone
+	Account       string
+	Duration      int64 // of each session, seconds
+	DirectPayment bool
+	Failover      bool
+	Renewals      int
 }
 
 type User struct {
@@ -26,3 +45,31 @@ type PromptActivity struct {
 	StartTime int64
 	EndTime   int64
 }
+
+type IndexerCheckpoint struct {
+	Block uint64
+	Hash  string
+}
+
+// IndexedBlock is a block that contained indexed events, entities are the keys of records updated in this block
+type IndexedBlock struct {
+	Number   uint64
+	Hash     string
+	Entities []string
+}
+
+type LogWatcherCheckpoint struct {
+	Block uint64
+	Hash  common.Hash
+}
+
+// PendingTx is a transaction sent by the node and not yet mined
+type PendingTx struct {
+	From       common.Address
+	Nonce      uint64
+	Hash       common.Hash
+	RawTx      string // hex encoded signed transaction, used to bump the gas price
+	SentAt     int64
+	Bumps      int
+	PrevHashes []common.Hash // hashes of the replaced transactions
+}
@@ -0,0 +1,47 @@
+package storages
+
+import (
+	"errors"
+
+	"github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/lib"
+	badger "github.com/dgraph-io/badger/v4"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+var transportKey = []byte("wallet:transport-key")
+
+// TransportKeyStorage keeps the node key used to sign and encrypt MOR-RPC messages when the wallet key
+// is held by a remote signer. The key holds no funds, it is persisted so the peers of the open sessions
+// can still reach the node after restart
+type TransportKeyStorage struct {
+	db *Storage
+}
+
+func NewTransportKeyStorage(storage *Storage) *TransportKeyStorage {
+	return &TransportKeyStorage{
+		db: storage,
+	}
+}
+
+// GetOrCreateKey returns the stored key, a new key is generated on the first call
+func (s *TransportKeyStorage) GetOrCreateKey() (lib.HexString, error) {
+	key, err := s.db.Get(transportKey)
+	if err == nil {
+		return key, nil
+	}
+	if !errors.Is(err, badger.ErrKeyNotFound) {
+		return nil, err
+	}
+
+	privateKey, err := crypto.GenerateKey()
+	if err != nil {
+		return nil, err
+	}
+	key = crypto.FromECDSA(privateKey)
+
+	err = s.db.Set(transportKey, key)
+	if err != nil {
+		return nil, err
+	}
+	return key, nil
+}
@@ -0,0 +1,60 @@
+package storages
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	badger "github.com/dgraph-io/badger/v4"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// TxQueueStorage persists pending transactions, so they are tracked after restart
+type TxQueueStorage struct {
+	db *Storage
+}
+
+func NewTxQueueStorage(storage *Storage) *TxQueueStorage {
+	return &TxQueueStorage{
+		db: storage,
+	}
+}
+
+func (s *TxQueueStorage) PutPendingTx(tx *PendingTx) error {
+	data, err := json.Marshal(tx)
+	if err != nil {
+		return err
+	}
+	return s.db.Set(formatPendingTxKey(tx.From, tx.Nonce), data)
+}
+
+func (s *TxQueueStorage) DeletePendingTx(from common.Address, nonce uint64) error {
+	err := s.db.Delete(formatPendingTxKey(from, nonce))
+	if errors.Is(err, badger.ErrKeyNotFound) {
+		return nil
+	}
+	return err
+}
+
+// GetPendingTxs returns pending transactions ordered by sender and nonce
+func (s *TxQueueStorage) GetPendingTxs() ([]*PendingTx, error) {
+	values, err := s.db.GetPrefixValues([]byte("txqueue:"))
+	if err != nil {
+		return nil, err
+	}
+
+	txs := make([]*PendingTx, len(values))
+	for i, val := range values {
+		txs[i] = &PendingTx{}
+		err := json.Unmarshal(val, txs[i])
+		if err != nil {
+			return nil, err
+		}
+	}
+	return txs, nil
+}
+
+func formatPendingTxKey(from common.Address, nonce uint64) []byte {
+	return []byte(fmt.Sprintf("txqueue:%s:%020d", strings.ToLower(from.Hex()), nonce))
+}
@@ -0,0 +1,77 @@
+package storages
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	badger "github.com/dgraph-io/badger/v4"
+)
+
+var ErrVirtualSessionNotFound = errors.New("virtual session not found")
+
+// VirtualSessionStorage persists the virtual sessions, so they are renewed after restart
+type VirtualSessionStorage struct {
+	db *Storage
+}
+
+func NewVirtualSessionStorage(storage *Storage) *VirtualSessionStorage {
+	return &VirtualSessionStorage{
+		db: storage,
+	}
+}
+
+func (s *VirtualSessionStorage) PutVirtualSession(session *VirtualSession) error {
+	data, err := json.Marshal(session)
+	if err != nil {
+		return err
+	}
+	return s.db.Set(formatVirtualSessionKey(session.ID), data)
+}
+
+func (s *VirtualSessionStorage) GetVirtualSession(id string) (*VirtualSession, error) {
+	data, err := s.db.Get(formatVirtualSessionKey(id))
+	if errors.Is(err, badger.ErrKeyNotFound) {
+		return nil, ErrVirtualSessionNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	session := &VirtualSession{}
+	err = json.Unmarshal(data, session)
+	if err != nil {
+		return nil, err
+	}
+	return session, nil
+}
+
+func (s *VirtualSessionStorage) GetVirtualSessions() ([]*VirtualSession, error) {
+	values, err := s.db.GetPrefixValues([]byte("vsession:"))
+	if err != nil {
+		return nil, err
+	}
+
+	sessions := make([]*VirtualSession, len(values))
+	for i, val := range values {
+		sessions[i] = &VirtualSession{}
+		err := json.Unmarshal(val, sessions[i])
+		if err != nil {
+			return nil, err
+		}
+	}
+	return sessions, nil
+}
+
+func (s *VirtualSessionStorage) RemoveVirtualSession(id string) error {
+	err := s.db.Delete(formatVirtualSessionKey(id))
+	if errors.Is(err, badger.ErrKeyNotFound) {
+		return nil
+	}
+	return err
+}
+
+func formatVirtualSessionKey(id string) []byte {
+	return []byte(fmt.Sprintf("vsession:%s", strings.ToLower(id)))
+}
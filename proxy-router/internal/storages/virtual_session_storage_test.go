@@ -0,0 +1,38 @@
+package storages
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestVirtualSessionStorage(t *testing.T) {
+	storage := NewVirtualSessionStorage(NewTestStorage())
+
+	require.NoError(t, storage.PutVirtualSession(&VirtualSession{ID: "0xAB", SessionID: "0x1", Duration: 3600}))
+	require.NoError(t, storage.PutVirtualSession(&VirtualSession{ID: "0xcd", SessionID: "0x2"}))
+
+	// ids are case insensitive
+	session, err := storage.GetVirtualSession("0xab")
+	require.NoError(t, err)
+	require.Equal(t, "0x1", session.SessionID)
+	require.Equal(t, int64(3600), session.Duration)
+
+	session.SessionID = "0x3"
+	session.Renewals++
+	require.NoError(t, storage.PutVirtualSession(session))
+
+	sessions, err := storage.GetVirtualSessions()
+	require.NoError(t, err)
+	require.Len(t, sessions, 2)
+
+	session, err = storage.GetVirtualSession("0xAB")
+	require.NoError(t, err)
+	require.Equal(t, "0x3", session.SessionID)
+	require.Equal(t, 1, session.Renewals)
+
+	require.NoError(t, storage.RemoveVirtualSession("0xab"))
+	require.NoError(t, storage.RemoveVirtualSession("0xab"))
+	_, err = storage.GetVirtualSession("0xAB")
+	require.ErrorIs(t, err, ErrVirtualSessionNotFound)
+}
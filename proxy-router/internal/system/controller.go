@@ -12,6 +12,7 @@ import (
 	"github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/config"
 	i "github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/interfaces"
 	"github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/lib"
+	"github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/webhooks"
 	"github.com/gin-gonic/gin"
 )
 
@@ -24,6 +25,8 @@ type SystemController struct {
 	chainID                *big.Int
 	log                    lib.ILogger
 	ethConnectionValidator IEthConnectionValidator
+	webhooks               *webhooks.Dispatcher
+	ethNodeHealth          IEthNodeHealth
 }
 
 func NewSystemController(config *config.Config, wallet i.Wallet, ethRPC i.RPCEndpoints, sysConfig *SystemConfigurator, appStartTime time.Time, chainID *big.Int, log lib.ILogger, ethConnectionValidator IEthConnectionValidator) *SystemController {
@@ -41,12 +44,22 @@ func NewSystemController(config *config.Config, wallet i.Wallet, ethRPC i.RPCEnd
 	return c
 }
 
+func (s *SystemController) SetWebhooks(dispatcher *webhooks.Dispatcher) {
+	s.webhooks = dispatcher
+}
+
+func (s *SystemController) SetEthNodeHealth(ethNodeHealth IEthNodeHealth) {
+	s.ethNodeHealth = ethNodeHealth
+}
+
 func (s *SystemController) RegisterRoutes(r i.Router) {
 	r.GET("/healthcheck", s.HealthCheck)
 	r.GET("/config", s.GetConfig)
 	r.GET("/files", s.GetFiles)
 
 	r.POST("/config/ethNode", s.SetEthNode)
+	r.GET("/config/ethNode/status", s.GetEthNodeStatus)
+	r.POST("/webhooks/test", s.SendTestWebhook)
 }
 
 // HealthCheck godoc
@@ -74,12 +87,7 @@ func (s *SystemController) HealthCheck(ctx *gin.Context) {
 //	@Success		200	{object}	ConfigResponse
 //	@Router			/config [get]
 func (s *SystemController) GetConfig(ctx *gin.Context) {
-	prkey, err := s.wallet.GetPrivateKey()
-	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		return
-	}
-	addr, err := lib.PrivKeyBytesToAddr(prkey)
+	addr, err := i.GetAddressCtx(ctx, s.wallet)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -164,10 +172,28 @@ func (s *SystemController) SetEthNode(ctx *gin.Context) {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
+	s.webhooks.Emit(webhooks.EventConfigReloaded, &webhooks.ConfigEventData{Section: "ethNode"})
 
 	ctx.JSON(http.StatusOK, gin.H{"status": "ok"})
 }
 
+// GetEthNodeStatus godoc
+//
+//	@Summary		Get Eth Node Status
+//	@Description	Return the health of the eth node endpoints: state, score, latency, error rate and block lag
+//	@Tags			system
+//	@Produce		json
+//	@Success		200	{object}	[]ethclient.EndpointStatus
+//	@Router			/config/ethNode/status [get]
+func (s *SystemController) GetEthNodeStatus(ctx *gin.Context) {
+	if s.ethNodeHealth == nil {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "eth node health is not tracked"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, s.ethNodeHealth.GetStatus())
+}
+
 // DeleteEthNode godoc
 //
 //	@Summary		Delete Eth Node URLs
@@ -182,6 +208,25 @@ func (c *SystemController) RemoveEthNode(ctx *gin.Context) {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
+	c.webhooks.Emit(webhooks.EventConfigReloaded, &webhooks.ConfigEventData{Section: "ethNode"})
+
+	ctx.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
+
+// SendTestWebhook godoc
+//
+//	@Summary		Send test webhook
+//	@Description	Sends a test event to all configured webhook endpoints
+//	@Tags			system
+//	@Produce		json
+//	@Success		200	{object}	ConfigResponse
+//	@Router			/webhooks/test [post]
+func (s *SystemController) SendTestWebhook(ctx *gin.Context) {
+	if s.webhooks == nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "webhooks are not configured"})
+		return
+	}
+	s.webhooks.Emit(webhooks.EventTest, gin.H{"message": "test event"})
 
 	ctx.JSON(http.StatusOK, gin.H{"status": "ok"})
 }
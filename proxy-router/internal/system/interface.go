@@ -3,6 +3,8 @@ package system
 import (
 	"context"
 	"time"
+
+	"github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/repositories/ethclient"
 )
 
 type osConfigurator interface {
@@ -14,3 +16,7 @@ type osConfigurator interface {
 type IEthConnectionValidator interface {
 	ValidateEthResourse(ctx context.Context, url string, timeout time.Duration) error
 }
+
+type IEthNodeHealth interface {
+	GetStatus() []ethclient.EndpointStatus
+}
@@ -0,0 +1,211 @@
+package e2e
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/blockchainapi/structs"
+	"github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/lib"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/sashabaranov/go-openai"
+	"github.com/stretchr/testify/require"
+)
+
+// apiTimeout limits the api calls, opening a session takes a few transactions
+const apiTimeout = 2 * time.Minute
+
+// RegisterProvider registers the node as a provider with its MOR-RPC address as the endpoint
+func (n *Node) RegisterProvider(t testing.TB, stake *big.Int) *structs.Provider {
+	var res structs.ProviderRes
+	n.call(t, http.MethodPost, "/blockchain/providers", structs.CreateProviderRequest{
+		Stake:    bigInt(stake),
+		Endpoint: n.ProxyAddr,
+	}, &res)
+	return res.Provider
+}
+
+// RegisterModel registers a model owned by the node
+func (n *Node) RegisterModel(t testing.TB, name string, stake *big.Int) *structs.Model {
+	ipfsID, err := lib.GetRandomHash()
+	require.NoError(t, err)
+
+	var res structs.ModelRes
+	n.call(t, http.MethodPost, "/blockchain/models", structs.CreateModelRequest{
+		IpfsID: ipfsID.Hex(),
+		Fee:    bigInt(big.NewInt(0)),
+		Stake:  bigInt(stake),
+		Name:   name,
+		Tags:   []string{"e2e"},
+	}, &res)
+	return res.Model
+}
+
+func (n *Node) CreateBid(t testing.TB, modelID common.Hash, pricePerSecond *big.Int) *structs.Bid {
+	var res structs.BidRes
+	n.call(t, http.MethodPost, "/blockchain/bids", structs.CreateBidRequest{
+		ModelID:        modelID.Hex(),
+		PricePerSecond: bigInt(pricePerSecond),
+	}, &res)
+	return res.Bid
+}
+
+// OpenSession opens a session with the best rated bid of the model
+func (n *Node) OpenSession(t testing.TB, modelID common.Hash, duration time.Duration, failover bool) common.Hash {
+	var res structs.OpenSessionRes
+	n.call(t, http.MethodPost, "/blockchain/models/"+modelID.Hex()+"/session", structs.OpenSessionWithFailover{
+		SessionDuration: bigInt(big.NewInt(int64(duration / time.Second))),
+		Failover:        failover,
+	}, &res)
+	return res.SessionID
+}
+
+func (n *Node) CloseSession(t testing.TB, sessionID common.Hash) {
+	n.call(t, http.MethodPost, "/blockchain/sessions/"+sessionID.Hex()+"/close", nil, nil)
+}
+
+func (n *Node) GetSession(t testing.TB, sessionID common.Hash) *structs.Session {
+	var res structs.SessionRes
+	n.call(t, http.MethodGet, "/blockchain/sessions/"+sessionID.Hex(), nil, &res)
+	return res.Session
+}
+
+// SessionsForUser lists the sessions opened by the node, newest first
+func (n *Node) SessionsForUser(t testing.TB) []*structs.Session {
+	var res structs.SessionsRes
+	n.call(t, http.MethodGet, "/blockchain/sessions/user?order=desc&user="+n.Account.Address.Hex(), nil, &res)
+	return res.Sessions
+}
+
+// PromptResult is the streamed completion
+type PromptResult struct {
+	Text string
+	// ControlMessages are sent by the node, e.g. about the failover
+	ControlMessages []string
+}
+
+// Prompt sends a streaming chat completion request in the session and collects the response
+func (n *Node) Prompt(t testing.TB, sessionID common.Hash, prompt string) *PromptResult {
+	res, err := n.TryPrompt(sessionID, prompt)
+	require.NoError(t, err)
+	return res
+}
+
+// TryPrompt is Prompt, which returns the error of the node instead of failing the test
+func (n *Node) TryPrompt(sessionID common.Hash, prompt string) (*PromptResult, error) {
+	return n.TryPromptRequest(sessionID, openai.ChatCompletionRequest{
+		Messages: []openai.ChatCompletionMessage{{Role: openai.ChatMessageRoleUser, Content: prompt}},
+	})
+}
+
+// TryPromptRequest sends the request as a streaming chat completion request in the session
+func (n *Node) TryPromptRequest(sessionID common.Hash, request openai.ChatCompletionRequest) (*PromptResult, error) {
+	request.Stream = true
+	body, err := json.Marshal(request)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), apiTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.ApiURL+"/v1/chat/completions", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("session_id", sessionID.Hex())
+
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return nil, readError(res)
+	}
+
+	// the node streams the chunks as "data: <json>" lines, an error after the stream has
+	// started is appended as a json object
+	result := &PromptResult{}
+	var text strings.Builder
+	scanner := bufio.NewScanner(res.Body)
+	scanner.Buffer(make([]byte, 0, 64*1024), 10*1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" {
+			continue
+		}
+		data, isData := strings.CutPrefix(line, "data: ")
+		if !isData {
+			var errRes structs.ErrRes
+			if json.Unmarshal([]byte(line), &errRes) == nil && errRes.Error != "" {
+				return result, fmt.Errorf("prompt failed: %s", errRes.Error)
+			}
+			continue
+		}
+
+		var message string
+		if json.Unmarshal([]byte(data), &message) == nil {
+			result.ControlMessages = append(result.ControlMessages, message)
+			continue
+		}
+		var chunk openai.ChatCompletionStreamResponse
+		err := json.Unmarshal([]byte(data), &chunk)
+		if err != nil {
+			return result, fmt.Errorf("invalid chunk %s: %w", data, err)
+		}
+		for _, choice := range chunk.Choices {
+			text.WriteString(choice.Delta.Content)
+		}
+	}
+	result.Text = text.String()
+	return result, scanner.Err()
+}
+
+// call sends the api request and decodes the response into result, the test fails on errors
+func (n *Node) call(t testing.TB, method string, path string, body interface{}, result interface{}) {
+	var reqBody io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		require.NoError(t, err)
+		reqBody = bytes.NewReader(data)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), apiTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, method, n.ApiURL+path, reqBody)
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", "application/json")
+
+	res, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer res.Body.Close()
+
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		require.NoError(t, readError(res), "%s %s on %s", method, path, n.Name)
+	}
+	if result != nil {
+		require.NoError(t, json.NewDecoder(res.Body).Decode(result))
+	}
+}
+
+func readError(res *http.Response) error {
+	data, _ := io.ReadAll(res.Body)
+	var errRes structs.ErrRes
+	if json.Unmarshal(data, &errRes) == nil && errRes.Error != "" {
+		return fmt.Errorf("status %d: %s", res.StatusCode, errRes.Error)
+	}
+	return fmt.Errorf("status %d: %s", res.StatusCode, data)
+}
+
+func bigInt(n *big.Int) *lib.BigInt {
+	return &lib.BigInt{Int: *n}
+}
@@ -0,0 +1,211 @@
+package e2e
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// ArtifactsDirEnv sets a folder with the hardhat artifacts to deploy from instead of the bundle
+const ArtifactsDirEnv = "E2E_ARTIFACTS_DIR"
+
+// BundleFile is the bundle of the contracts the harness deploys, written by `make e2e-artifacts`
+const BundleFile = "testdata/contracts.json"
+
+// BundleContracts are the contracts and interfaces DeployContracts needs from the artifacts
+var BundleContracts = []string{
+	"MorpheusToken", "DelegateRegistry", "LumerinDiamond", "LinearDistributionIntervalDecrease",
+	"ProviderRegistry", "ModelRegistry", "Marketplace", "SessionRouter", "Delegation",
+	"IProviderRegistry", "IModelRegistry", "IMarketplace", "IBidStorage", "ISessionRouter", "IStatsStorage", "IDelegation",
+}
+
+var (
+	ErrArtifactsNotFound = errors.New("compiled contracts not found, run `make e2e-artifacts` or set " + ArtifactsDirEnv)
+	ErrArtifactNotFound  = errors.New("contract artifact not found")
+	ErrArtifactAmbiguous = errors.New("contract name is ambiguous")
+	ErrUnlinkedLibrary   = errors.New("library address is not provided")
+)
+
+// Artifact is a contract compiled by hardhat. The go bindings have no bytecode, so the
+// contracts are deployed from the artifacts of `yarn compile`
+type Artifact struct {
+	ContractName   string                                `json:"contractName"`
+	SourceName     string                                `json:"sourceName"`
+	ABI            json.RawMessage                       `json:"abi"`
+	Bytecode       string                                `json:"bytecode"`
+	LinkReferences map[string]map[string][]LinkReference `json:"linkReferences"`
+}
+
+type LinkReference struct {
+	Start  int `json:"start"`
+	Length int `json:"length"`
+}
+
+func (a *Artifact) ParseABI() (abi.ABI, error) {
+	return abi.JSON(strings.NewReader(string(a.ABI)))
+}
+
+// Link returns the bytecode with the library placeholders replaced by the addresses
+func (a *Artifact) Link(libraries map[string]common.Address) ([]byte, error) {
+	code, err := hexutil.Decode(a.Bytecode)
+	if err != nil {
+		return nil, fmt.Errorf("invalid bytecode of %s: %w", a.ContractName, err)
+	}
+	for _, refs := range a.LinkReferences {
+		for name, positions := range refs {
+			addr, ok := libraries[name]
+			if !ok {
+				return nil, fmt.Errorf("%w: %s", ErrUnlinkedLibrary, name)
+			}
+			for _, pos := range positions {
+				if pos.Length != common.AddressLength || pos.Start+pos.Length > len(code) {
+					return nil, fmt.Errorf("invalid link reference of %s in %s", name, a.ContractName)
+				}
+				copy(code[pos.Start:pos.Start+pos.Length], addr.Bytes())
+			}
+		}
+	}
+	return code, nil
+}
+
+// Artifacts indexes the hardhat artifacts or the bundle by contract name
+type Artifacts struct {
+	dir    string
+	paths  map[string][]string
+	bundle map[string]*Artifact
+}
+
+// DefaultArtifacts loads the folder of ArtifactsDirEnv if it is set and the BundleFile otherwise
+func DefaultArtifacts() (*Artifacts, error) {
+	if dir := os.Getenv(ArtifactsDirEnv); dir != "" {
+		return LoadArtifacts(dir)
+	}
+	_, file, _, _ := runtime.Caller(0)
+	return LoadBundle(filepath.Join(filepath.Dir(file), BundleFile))
+}
+
+// LoadBundle loads the contracts written by WriteBundle
+func LoadBundle(path string) (*Artifacts, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrArtifactsNotFound, err)
+	}
+	var bundle map[string]*Artifact
+	err = json.Unmarshal(data, &bundle)
+	if err != nil {
+		return nil, fmt.Errorf("invalid bundle %s: %w", path, err)
+	}
+	return &Artifacts{dir: path, bundle: bundle}, nil
+}
+
+// WriteBundle writes the given contracts to a single file, which is checked in as BundleFile
+func (a *Artifacts) WriteBundle(path string, contractNames []string) error {
+	bundle := make(map[string]*Artifact, len(contractNames))
+	for _, name := range contractNames {
+		artifact, err := a.Get(name)
+		if err != nil {
+			return err
+		}
+		bundle[name] = artifact
+	}
+
+	data, err := json.MarshalIndent(bundle, "", "  ")
+	if err != nil {
+		return err
+	}
+	err = os.MkdirAll(filepath.Dir(path), 0o755)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0o644)
+}
+
+func LoadArtifacts(dir string) (*Artifacts, error) {
+	_, err := os.Stat(dir)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrArtifactsNotFound, err)
+	}
+
+	paths := make(map[string][]string)
+	err = filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() && d.Name() == "build-info" {
+			return filepath.SkipDir
+		}
+		name := d.Name()
+		if d.IsDir() || !strings.HasSuffix(name, ".json") || strings.HasSuffix(name, ".dbg.json") {
+			return nil
+		}
+		contract := strings.TrimSuffix(name, ".json")
+		paths[contract] = append(paths[contract], path)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("%w: %s is empty", ErrArtifactsNotFound, dir)
+	}
+	return &Artifacts{dir: dir, paths: paths}, nil
+}
+
+func (a *Artifacts) Get(contractName string) (*Artifact, error) {
+	if a.bundle != nil {
+		artifact, ok := a.bundle[contractName]
+		if !ok {
+			return nil, fmt.Errorf("%w: %s in %s", ErrArtifactNotFound, contractName, a.dir)
+		}
+		copied := *artifact
+		return &copied, nil
+	}
+
+	paths := a.paths[contractName]
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("%w: %s in %s", ErrArtifactNotFound, contractName, a.dir)
+	}
+	if len(paths) > 1 {
+		return nil, fmt.Errorf("%w: %s", ErrArtifactAmbiguous, strings.Join(paths, ", "))
+	}
+
+	data, err := os.ReadFile(paths[0])
+	if err != nil {
+		return nil, err
+	}
+	var artifact Artifact
+	err = json.Unmarshal(data, &artifact)
+	if err != nil {
+		return nil, fmt.Errorf("invalid artifact %s: %w", paths[0], err)
+	}
+	return &artifact, nil
+}
+
+// Selectors returns the function selectors of the contract or interface
+func (a *Artifacts) Selectors(contractName string) ([][4]byte, error) {
+	artifact, err := a.Get(contractName)
+	if err != nil {
+		return nil, err
+	}
+	parsed, err := artifact.ParseABI()
+	if err != nil {
+		return nil, err
+	}
+
+	selectors := make([][4]byte, 0, len(parsed.Methods))
+	for _, method := range parsed.Methods {
+		var selector [4]byte
+		copy(selector[:], method.ID)
+		selectors = append(selectors, selector)
+	}
+	return selectors, nil
+}
@@ -0,0 +1,220 @@
+package e2e
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"fmt"
+	"math/big"
+	"net"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/lib"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/eth/ethconfig"
+	"github.com/ethereum/go-ethereum/ethclient/simulated"
+	"github.com/ethereum/go-ethereum/node"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	// ChainID of the simulated backend
+	ChainID = 1337
+
+	// minePollInterval is the interval of checking the tx pool for transactions to mine
+	minePollInterval = 20 * time.Millisecond
+
+	txTimeout = 30 * time.Second
+)
+
+var (
+	// AccountETH is the balance of the accounts created by the chain
+	AccountETH = new(big.Int).Mul(big.NewInt(100), lib.Exp10(18))
+
+	deployerETH = new(big.Int).Mul(big.NewInt(1_000_000), lib.Exp10(18))
+)
+
+// Account is a funded account of the simulated chain
+type Account struct {
+	Key        *ecdsa.PrivateKey
+	Address    common.Address
+	PrivateKey lib.HexString
+}
+
+func NewAccount() (*Account, error) {
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		return nil, err
+	}
+	return &Account{
+		Key:        key,
+		Address:    crypto.PubkeyToAddress(key.PublicKey),
+		PrivateKey: crypto.FromECDSA(key),
+	}, nil
+}
+
+// Chain is a simulated EVM chain, served over websocket like a real node. A block is
+// mined as soon as there are pending transactions
+type Chain struct {
+	URL      string // websocket url of the node
+	Deployer *Account
+
+	backend *simulated.Backend
+	client  simulated.Client
+	rpc     *rpc.Client
+	mineMu  sync.Mutex
+	stop    chan struct{}
+	stopped chan struct{}
+}
+
+// NewChain starts a simulated chain, it is stopped on the test cleanup
+func NewChain(t testing.TB) *Chain {
+	deployer, err := NewAccount()
+	require.NoError(t, err)
+
+	port, err := freePort()
+	require.NoError(t, err)
+
+	backend := simulated.NewBackend(types.GenesisAlloc{
+		deployer.Address: {Balance: deployerETH},
+	}, func(nodeConf *node.Config, ethConf *ethconfig.Config) {
+		nodeConf.WSHost = "127.0.0.1"
+		nodeConf.WSPort = port
+		nodeConf.WSModules = []string{"eth", "net", "web3", "txpool"}
+	})
+
+	url := "ws://127.0.0.1:" + strconv.Itoa(port)
+	rpcClient, err := rpc.Dial(url)
+	if err != nil {
+		_ = backend.Close()
+		require.NoError(t, err)
+	}
+
+	c := &Chain{
+		URL:      url,
+		Deployer: deployer,
+		backend:  backend,
+		client:   backend.Client(),
+		rpc:      rpcClient,
+		stop:     make(chan struct{}),
+		stopped:  make(chan struct{}),
+	}
+	go c.mine()
+
+	t.Cleanup(func() {
+		close(c.stop)
+		<-c.stopped
+		rpcClient.Close()
+		_ = backend.Close()
+	})
+	return c
+}
+
+// Client is connected to the simulated chain in-process
+func (c *Chain) Client() simulated.Client {
+	return c.client
+}
+
+// NewAccount creates an account funded with AccountETH
+func (c *Chain) NewAccount(t testing.TB) *Account {
+	acc, err := NewAccount()
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), txTimeout)
+	defer cancel()
+
+	nonce, err := c.client.PendingNonceAt(ctx, c.Deployer.Address)
+	require.NoError(t, err)
+	gasPrice, err := c.client.SuggestGasPrice(ctx)
+	require.NoError(t, err)
+
+	tx, err := types.SignTx(
+		types.NewTransaction(nonce, acc.Address, AccountETH, 21000, gasPrice, nil),
+		types.LatestSignerForChainID(big.NewInt(ChainID)),
+		c.Deployer.Key,
+	)
+	require.NoError(t, err)
+	require.NoError(t, c.client.SendTransaction(ctx, tx))
+	c.WaitMined(t, tx)
+
+	return acc
+}
+
+// TransactOpts of the account, the transactions are sent by the in-process client
+func (c *Chain) TransactOpts(t testing.TB, acc *Account) *bind.TransactOpts {
+	opts, err := bind.NewKeyedTransactorWithChainID(acc.Key, big.NewInt(ChainID))
+	require.NoError(t, err)
+	return opts
+}
+
+// WaitMined waits for the transaction and fails the test if it reverted
+func (c *Chain) WaitMined(t testing.TB, tx *types.Transaction) *types.Receipt {
+	ctx, cancel := context.WithTimeout(context.Background(), txTimeout)
+	defer cancel()
+
+	receipt, err := bind.WaitMined(ctx, c.client, tx)
+	require.NoError(t, err)
+	require.Equal(t, types.ReceiptStatusSuccessful, receipt.Status, "transaction %s reverted", tx.Hash().Hex())
+	return receipt
+}
+
+// AdjustTime mines an empty block moved forward in time, e.g. to let sessions expire
+func (c *Chain) AdjustTime(t testing.TB, d time.Duration) {
+	c.mineMu.Lock()
+	defer c.mineMu.Unlock()
+
+	err := c.backend.AdjustTime(d)
+	require.NoError(t, err)
+}
+
+// Now is the time of the latest block
+func (c *Chain) Now(t testing.TB) time.Time {
+	header, err := c.client.HeaderByNumber(context.Background(), nil)
+	require.NoError(t, err)
+	return time.Unix(int64(header.Time), 0)
+}
+
+func (c *Chain) mine() {
+	defer close(c.stopped)
+
+	ticker := time.NewTicker(minePollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-c.stop:
+			return
+		case <-ticker.C:
+		}
+
+		var status map[string]hexutil.Uint
+		err := c.rpc.Call(&status, "txpool_status")
+		if err != nil || status["pending"] == 0 {
+			continue
+		}
+		c.mineMu.Lock()
+		c.backend.Commit()
+		c.mineMu.Unlock()
+	}
+}
+
+func freePort() (int, error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return 0, err
+	}
+	defer listener.Close()
+
+	addr, ok := listener.Addr().(*net.TCPAddr)
+	if !ok {
+		return 0, fmt.Errorf("unexpected address %s", listener.Addr())
+	}
+	return addr.Port, nil
+}
@@ -0,0 +1,226 @@
+package e2e
+
+import (
+	"errors"
+	"math/big"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/lib"
+	"github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/repositories/contracts/bindings/delegation"
+	"github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/repositories/contracts/bindings/marketplace"
+	"github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/repositories/contracts/bindings/modelregistry"
+	"github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/repositories/contracts/bindings/morpheustoken"
+	"github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/repositories/contracts/bindings/providerregistry"
+	"github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/repositories/contracts/bindings/sessionrouter"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/stretchr/testify/require"
+)
+
+// diamondCutSig is the diamondCut overload without the init call
+const diamondCutSig = "diamondCut((address,uint8,bytes4[])[])"
+
+// facetActionAdd is FacetAction.Add of the diamond
+const facetActionAdd = 0
+
+// ContractsConfig is the marketplace configuration, the defaults follow deploy/data of smart-contracts
+type ContractsConfig struct {
+	ProviderMinStake   *big.Int
+	ModelMinStake      *big.Int
+	BidFee             *big.Int
+	BidMinPricePerSec  *big.Int
+	BidMaxPricePerSec  *big.Int
+	MaxSessionDuration time.Duration
+}
+
+func DefaultContractsConfig() ContractsConfig {
+	return ContractsConfig{
+		ProviderMinStake:   milliMOR(200),
+		ModelMinStake:      milliMOR(100),
+		BidFee:             milliMOR(300),
+		BidMinPricePerSec:  milliMOR(5),
+		BidMaxPricePerSec:  milliMOR(20_000),
+		MaxSessionDuration: 7 * 24 * time.Hour,
+	}
+}
+
+// Contracts are the marketplace contracts deployed to the simulated chain
+type Contracts struct {
+	Diamond          common.Address
+	MOR              common.Address
+	DelegateRegistry common.Address
+
+	// FundingAccount pays the provider rewards, it is the deployer
+	FundingAccount common.Address
+}
+
+// facetCut is IDiamond.Facet
+type facetCut struct {
+	FacetAddress      common.Address
+	Action            uint8
+	FunctionSelectors [][4]byte
+}
+
+// DeployContracts deploys the MOR token, the delegate registry and the diamond with its facets
+// like the 1_full_protocol migration of smart-contracts, from the artifacts of DefaultArtifacts.
+// The test is skipped if there is neither the bundle nor ArtifactsDirEnv
+func DeployContracts(t testing.TB, chain *Chain, cfg ContractsConfig) *Contracts {
+	artifacts, err := DefaultArtifacts()
+	if errors.Is(err, ErrArtifactsNotFound) && os.Getenv(ArtifactsDirEnv) == "" {
+		t.Skipf("%s is missing, run `make e2e-artifacts` in proxy-router or set %s", BundleFile, ArtifactsDirEnv)
+	}
+	require.NoError(t, err)
+
+	deployer := chain.Deployer
+	opts := chain.TransactOpts(t, deployer)
+
+	morAddr := deploy(t, chain, artifacts, "MorpheusToken", nil)
+	registryAddr := deploy(t, chain, artifacts, "DelegateRegistry", nil)
+	diamondAddr := deploy(t, chain, artifacts, "LumerinDiamond", nil)
+
+	diamondArtifact, err := artifacts.Get("LumerinDiamond")
+	require.NoError(t, err)
+	diamondABI, err := diamondArtifact.ParseABI()
+	require.NoError(t, err)
+	diamond := bind.NewBoundContract(diamondAddr, diamondABI, chain.Client(), chain.Client(), chain.Client())
+	chain.WaitMined(t, transact(t, diamond, diamondABI, opts, "__LumerinDiamond_init()"))
+
+	ldid := deploy(t, chain, artifacts, "LinearDistributionIntervalDecrease", nil)
+	libraries := map[string]common.Address{"LinearDistributionIntervalDecrease": ldid}
+
+	facets := []struct {
+		contract   string
+		interfaces []string
+	}{
+		{"ProviderRegistry", []string{"IProviderRegistry"}},
+		{"ModelRegistry", []string{"IModelRegistry"}},
+		{"Marketplace", []string{"IMarketplace", "IBidStorage"}},
+		{"SessionRouter", []string{"ISessionRouter", "IStatsStorage"}},
+		{"Delegation", []string{"IDelegation"}},
+	}
+	var cuts []facetCut
+	for _, facet := range facets {
+		addr := deploy(t, chain, artifacts, facet.contract, libraries)
+		for _, iface := range facet.interfaces {
+			selectors, err := artifacts.Selectors(iface)
+			require.NoError(t, err)
+			cuts = append(cuts, facetCut{FacetAddress: addr, Action: facetActionAdd, FunctionSelectors: selectors})
+		}
+	}
+	chain.WaitMined(t, transact(t, diamond, diamondABI, opts, diamondCutSig, cuts))
+
+	client := chain.Client()
+	providerRegistry, err := providerregistry.NewProviderRegistry(diamondAddr, client)
+	require.NoError(t, err)
+	modelRegistry, err := modelregistry.NewModelRegistry(diamondAddr, client)
+	require.NoError(t, err)
+	market, err := marketplace.NewMarketplace(diamondAddr, client)
+	require.NoError(t, err)
+	sessionRouter, err := sessionrouter.NewSessionRouter(diamondAddr, client)
+	require.NoError(t, err)
+	delegationFacet, err := delegation.NewDelegation(diamondAddr, client)
+	require.NoError(t, err)
+
+	wait := func(tx *types.Transaction, err error) {
+		require.NoError(t, err)
+		chain.WaitMined(t, tx)
+	}
+	wait(providerRegistry.ProviderRegistryInit(opts))
+	wait(modelRegistry.ModelRegistryInit(opts))
+	wait(market.MarketplaceInit(opts, morAddr, cfg.BidMinPricePerSec, cfg.BidMaxPricePerSec))
+	wait(sessionRouter.SessionRouterInit(opts, deployer.Address, big.NewInt(int64(cfg.MaxSessionDuration/time.Second)), defaultPools(chain.Now(t))))
+	wait(delegationFacet.DelegationInit(opts, registryAddr))
+	wait(providerRegistry.ProviderSetMinStake(opts, cfg.ProviderMinStake))
+	wait(modelRegistry.ModelSetMinStake(opts, cfg.ModelMinStake))
+	wait(market.SetMarketplaceBidFee(opts, cfg.BidFee))
+
+	// provider rewards are transferred from the funding account
+	token, err := morpheustoken.NewMorpheusToken(morAddr, client)
+	require.NoError(t, err)
+	wait(token.Approve(opts, diamondAddr, abi.MaxUint256))
+
+	return &Contracts{
+		Diamond:          diamondAddr,
+		MOR:              morAddr,
+		DelegateRegistry: registryAddr,
+		FundingAccount:   deployer.Address,
+	}
+}
+
+// FundMOR transfers MOR from the deployer, who holds the whole supply
+func (c *Contracts) FundMOR(t testing.TB, chain *Chain, to common.Address, amount *big.Int) {
+	token, err := morpheustoken.NewMorpheusToken(c.MOR, chain.Client())
+	require.NoError(t, err)
+	tx, err := token.Transfer(chain.TransactOpts(t, chain.Deployer), to, amount)
+	require.NoError(t, err)
+	chain.WaitMined(t, tx)
+}
+
+func deploy(t testing.TB, chain *Chain, artifacts *Artifacts, contractName string, libraries map[string]common.Address) common.Address {
+	artifact, err := artifacts.Get(contractName)
+	require.NoError(t, err)
+	parsed, err := artifact.ParseABI()
+	require.NoError(t, err)
+	code, err := artifact.Link(libraries)
+	require.NoError(t, err)
+
+	addr, tx, _, err := bind.DeployContract(chain.TransactOpts(t, chain.Deployer), parsed, code, chain.Client())
+	require.NoError(t, err, "deploy %s", contractName)
+	chain.WaitMined(t, tx)
+	return addr
+}
+
+// transact calls the method by its signature, go-ethereum renames overloaded methods
+func transact(t testing.TB, contract *bind.BoundContract, contractABI abi.ABI, opts *bind.TransactOpts, sig string, params ...interface{}) *types.Transaction {
+	for name, method := range contractABI.Methods {
+		if method.Sig == sig {
+			tx, err := contract.Transact(opts, name, params...)
+			require.NoError(t, err, sig)
+			return tx
+		}
+	}
+	require.FailNow(t, "method not found", sig)
+	return nil
+}
+
+// defaultPools are the reward pools of deploy/data, the payouts started a year ago
+func defaultPools(now time.Time) []sessionrouter.ISessionStoragePool {
+	start := big.NewInt(now.Add(-365 * 24 * time.Hour).Unix())
+	day := big.NewInt(86400)
+	pool := func(initialReward, rewardDecrease string) sessionrouter.ISessionStoragePool {
+		return sessionrouter.ISessionStoragePool{
+			InitialReward:    bigString(initialReward),
+			RewardDecrease:   bigString(rewardDecrease),
+			PayoutStart:      start,
+			DecreaseInterval: day,
+		}
+	}
+	return []sessionrouter.ISessionStoragePool{
+		pool("3456000000000000000000", "592558728240000000"),
+		pool("3456000000000000000000", "592558728240000000"),
+		pool("3456000000000000000000", "592558728240000000"),
+		pool("3456000000000000000000", "592558728240000000"),
+		pool("576000000000000000000", "98759788040000000"),
+	}
+}
+
+func bigString(s string) *big.Int {
+	n, ok := new(big.Int).SetString(s, 10)
+	if !ok {
+		panic("invalid number " + s)
+	}
+	return n
+}
+
+// MOR converts MOR to wei
+func MOR(amount int64) *big.Int {
+	return new(big.Int).Mul(big.NewInt(amount), lib.Exp10(18))
+}
+
+func milliMOR(amount int64) *big.Int {
+	return new(big.Int).Mul(big.NewInt(amount), lib.Exp10(15))
+}
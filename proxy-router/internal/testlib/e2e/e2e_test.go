@@ -0,0 +1,233 @@
+package e2e
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/config"
+	"github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/lib"
+	msgs "github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/proxyapi/morrpcmessage"
+	mc3 "github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/repositories/contracts/bindings/multicall3"
+	"github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/repositories/ethclient"
+	"github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/testlib/mockai"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/sashabaranov/go-openai"
+	"github.com/stretchr/testify/require"
+)
+
+// identityPrecompile returns the call data, it stands in for a contract in the multicall test
+var identityPrecompile = common.BytesToAddress([]byte{4})
+
+func TestChain(t *testing.T) {
+	chain := NewChain(t)
+	acc := chain.NewAccount(t)
+
+	balance, err := chain.Client().BalanceAt(context.Background(), acc.Address, nil)
+	require.NoError(t, err)
+	require.Equal(t, AccountETH, balance)
+
+	before := chain.Now(t)
+	chain.AdjustTime(t, time.Hour)
+	require.GreaterOrEqual(t, chain.Now(t).Sub(before), time.Hour)
+}
+
+func TestMulticall(t *testing.T) {
+	chain := NewChain(t)
+	rpcClient, err := ethclient.NewRPCClientMultiple([]string{chain.URL}, ethclient.RPCClientConfig{}, lib.NewTestLogger())
+	require.NoError(t, err)
+	defer rpcClient.Close()
+	mc := NewMulticall(ethclient.NewClient(rpcClient))
+
+	results, err := mc.Aggregate3(context.Background(), []mc3.Multicall3Call3{
+		{Target: identityPrecompile, CallData: []byte("first")},
+		{Target: identityPrecompile, CallData: []byte("second")},
+	})
+	require.NoError(t, err)
+	require.Equal(t, []mc3.Multicall3Result{
+		{Success: true, ReturnData: []byte("first")},
+		{Success: true, ReturnData: []byte("second")},
+	}, results)
+
+	blockNumber, data, err := mc.Aggregate(context.Background(), []mc3.Multicall3Call{{Target: identityPrecompile, CallData: []byte("third")}})
+	require.NoError(t, err)
+	require.NotNil(t, blockNumber)
+	require.Equal(t, [][]byte{[]byte("third")}, data)
+}
+
+func TestArtifacts(t *testing.T) {
+	dir := t.TempDir()
+	libAddr := common.HexToAddress("0x1111111111111111111111111111111111111111")
+	artifact := `{
+		"contractName": "Router",
+		"abi": [{"type": "function", "name": "route", "inputs": [], "outputs": [], "stateMutability": "nonpayable"}],
+		"bytecode": "0x60__$0123456789abcdef0123456789abcdef01$__60",
+		"linkReferences": {"contracts/Lib.sol": {"Lib": [{"start": 1, "length": 20}]}}
+	}`
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "contracts/Router.sol"), 0o700))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "contracts/Router.sol/Router.json"), []byte(artifact), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "contracts/Router.sol/Router.dbg.json"), []byte(`{}`), 0o600))
+
+	artifacts, err := LoadArtifacts(dir)
+	require.NoError(t, err)
+
+	_, err = artifacts.Get("Missing")
+	require.ErrorIs(t, err, ErrArtifactNotFound)
+
+	router, err := artifacts.Get("Router")
+	require.NoError(t, err)
+
+	// hardhat placeholders are not valid hex, they are replaced before decoding
+	router.Bytecode = "0x60" + common.Bytes2Hex(make([]byte, 20)) + "60"
+	_, err = router.Link(nil)
+	require.ErrorIs(t, err, ErrUnlinkedLibrary)
+	code, err := router.Link(map[string]common.Address{"Lib": libAddr})
+	require.NoError(t, err)
+	require.Equal(t, append(append([]byte{0x60}, libAddr.Bytes()...), 0x60), code)
+
+	selectors, err := artifacts.Selectors("Router")
+	require.NoError(t, err)
+	require.Equal(t, [][4]byte{[4]byte(crypto.Keccak256([]byte("route()")))}, selectors)
+
+	bundlePath := filepath.Join(dir, BundleFile)
+	require.ErrorIs(t, artifacts.WriteBundle(bundlePath, []string{"Router", "Missing"}), ErrArtifactNotFound)
+	require.NoError(t, artifacts.WriteBundle(bundlePath, []string{"Router"}))
+
+	bundle, err := LoadBundle(bundlePath)
+	require.NoError(t, err)
+	bundled, err := bundle.Get("Router")
+	require.NoError(t, err)
+	expected, err := artifacts.Get("Router")
+	require.NoError(t, err)
+	require.Equal(t, expected.Bytecode, bundled.Bytecode)
+	require.Equal(t, expected.LinkReferences, bundled.LinkReferences)
+	require.JSONEq(t, string(expected.ABI), string(bundled.ABI))
+
+	_, err = LoadBundle(filepath.Join(dir, "missing.json"))
+	require.ErrorIs(t, err, ErrArtifactsNotFound)
+}
+
+func TestMarketplace(t *testing.T) {
+	m := NewMarket(t)
+
+	p := m.NewProvider(t, "provider", mockai.Config{})
+	p.Register(t)
+	modelID := p.RegisterModel(t, "mock")
+	p.Offer(t, modelID)
+
+	sessionID := m.Consumer.OpenSession(t, modelID, SessionDuration, false)
+	session := m.Consumer.GetSession(t, sessionID)
+	require.Equal(t, p.Account.Address, session.Provider)
+
+	res := m.Consumer.Prompt(t, sessionID, "hello")
+	require.Equal(t, "You said: hello", res.Text)
+	require.Len(t, p.Model.Requests(), 1)
+
+	m.Consumer.CloseSession(t, sessionID)
+	session = m.Consumer.GetSession(t, sessionID)
+	require.NotZero(t, session.ClosedAt.Sign())
+}
+
+func TestFailover(t *testing.T) {
+	m := NewMarket(t)
+
+	failing := m.NewProvider(t, "failing", mockai.Config{ErrorRate: 1, ErrorStatus: http.StatusServiceUnavailable})
+	failing.Register(t)
+	modelID := failing.RegisterModel(t, "mock")
+	failing.Offer(t, modelID)
+
+	// the higher price rates the backup provider lower, so the session is opened with the failing one
+	backup := m.NewProvider(t, "backup", mockai.Config{})
+	backup.Register(t)
+	backup.OfferWithPrice(t, modelID, new(big.Int).Mul(m.Config.BidMinPricePerSec, big.NewInt(2)))
+
+	sessionID := m.Consumer.OpenSession(t, modelID, SessionDuration, true)
+	require.Equal(t, failing.Account.Address, m.Consumer.GetSession(t, sessionID).Provider)
+
+	res := m.Consumer.Prompt(t, sessionID, "hello")
+	require.Equal(t, "You said: hello", res.Text)
+	require.Contains(t, res.ControlMessages, "provider failed, failover enabled")
+	require.Len(t, backup.Model.Requests(), 1)
+
+	// the failed session is closed and replaced by one with the backup provider
+	require.NotZero(t, m.Consumer.GetSession(t, sessionID).ClosedAt.Sign())
+	sessions := m.Consumer.SessionsForUser(t)
+	require.Len(t, sessions, 2)
+	require.Equal(t, backup.Account.Address, sessions[0].Provider)
+}
+
+func TestModeration(t *testing.T) {
+	m := NewMarket(t)
+
+	p := m.NewProvider(t, "provider", mockai.Config{})
+	p.Register(t)
+	modelID := p.RegisterModel(t, "mock")
+	modelCfg := p.Model.ModelConfig()
+	modelCfg.Moderation = &config.ModerationConfig{Keywords: []string{"forbidden"}}
+	p.ServeModel(t, modelID, modelCfg)
+	p.CreateBid(t, modelID, m.Config.BidMinPricePerSec)
+
+	sessionID := m.Consumer.OpenSession(t, modelID, SessionDuration, true)
+
+	_, err := m.Consumer.TryPrompt(sessionID, "a forbidden prompt")
+	require.ErrorContains(t, err, "status 400")
+	require.Empty(t, p.Model.Requests())
+
+	// the refusal doesn't trigger the failover
+	res := m.Consumer.Prompt(t, sessionID, "hello")
+	require.Equal(t, "You said: hello", res.Text)
+	require.Empty(t, res.ControlMessages)
+}
+
+func TestResponseSchemaCapability(t *testing.T) {
+	m := NewMarket(t)
+	consumer := m.NewNode(t, NodeConfig{Name: "schema-consumer", SchemaRetries: 1})
+	request := openai.ChatCompletionRequest{
+		Messages: []openai.ChatCompletionMessage{{Role: openai.ChatMessageRoleUser, Content: "who are you?"}},
+		ResponseFormat: &openai.ChatCompletionResponseFormat{
+			Type: openai.ChatCompletionResponseFormatTypeJSONSchema,
+			JSONSchema: &openai.ChatCompletionResponseFormatJSONSchema{
+				Name:   "person",
+				Schema: json.RawMessage(`{"type":"object","required":["name"],"properties":{"name":{"type":"string"}}}`),
+			},
+		},
+	}
+
+	// the provider advertising the schema support gets the retry
+	enforcing := m.NewProvider(t, "enforcing", mockai.Config{
+		DefaultResponse: `{"name":"Alice"}`,
+		Responses:       []mockai.Response{{Content: "Alice", Times: 1}},
+	})
+	enforcing.Register(t)
+	modelID := enforcing.RegisterModel(t, "enforcing")
+	modelCfg := enforcing.Model.ModelConfig()
+	modelCfg.Capabilities = []string{msgs.CapabilityJSONSchema}
+	enforcing.ServeModel(t, modelID, modelCfg)
+	enforcing.CreateBid(t, modelID, m.Config.BidMinPricePerSec)
+
+	sessionID := consumer.OpenSession(t, modelID, SessionDuration, false)
+	res, err := consumer.TryPromptRequest(sessionID, request)
+	require.NoError(t, err)
+	require.Equal(t, `{"name":"Alice"}`, res.Text)
+	require.Empty(t, res.ControlMessages)
+	require.Len(t, enforcing.Model.Requests(), 2)
+
+	// the other provider is not retried, the consumer is warned before the prompt
+	other := m.NewProvider(t, "other", mockai.Config{DefaultResponse: "Bob"})
+	other.Register(t)
+	modelID = other.RegisterModel(t, "other")
+	other.Offer(t, modelID)
+
+	sessionID = consumer.OpenSession(t, modelID, SessionDuration, false)
+	res, err = consumer.TryPromptRequest(sessionID, request)
+	require.ErrorContains(t, err, "response does not match the requested schema")
+	require.Len(t, res.ControlMessages, 1)
+	require.Contains(t, res.ControlMessages[0], "doesn't advertise")
+	require.Len(t, other.Model.Requests(), 1)
+}
@@ -0,0 +1,30 @@
+// Command gen bundles the hardhat artifacts of smart-contracts into the testdata of the e2e harness
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/testlib/e2e"
+)
+
+func main() {
+	dir := flag.String("artifacts", "../../../../smart-contracts/artifacts", "hardhat artifacts folder")
+	out := flag.String("out", e2e.BundleFile, "bundle file")
+	flag.Parse()
+
+	err := bundle(*dir, *out)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func bundle(dir, out string) error {
+	artifacts, err := e2e.LoadArtifacts(dir)
+	if err != nil {
+		return err
+	}
+	return artifacts.WriteBundle(out, e2e.BundleContracts)
+}
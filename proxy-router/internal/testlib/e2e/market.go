@@ -0,0 +1,99 @@
+// Package e2e simulates the marketplace locally: a simulated chain with the diamond contracts,
+// in-process proxy-routers as consumer and providers and mock models. A test scripts the flow
+// through the api of the nodes:
+//
+//	m := e2e.NewMarket(t)
+//	p := m.NewProvider(t, "provider", mockai.Config{})
+//	p.Register(t)
+//	modelID := p.RegisterModel(t, "llama")
+//	p.Offer(t, modelID)
+//	sessionID := m.Consumer.OpenSession(t, modelID, e2e.SessionDuration, false)
+//	res := m.Consumer.Prompt(t, sessionID, "hello")
+//	m.Consumer.CloseSession(t, sessionID)
+//
+// The contracts are deployed from the artifacts of `yarn compile` in smart-contracts, the tests are
+// skipped if they are missing
+package e2e
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/testlib/mockai"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/require"
+)
+
+// SessionDuration is the minimal session duration of the contracts
+const SessionDuration = 5 * time.Minute
+
+var (
+	// NodeMOR is the MOR balance of the nodes created by the market
+	NodeMOR = MOR(1000)
+)
+
+// Market is a local marketplace with a consumer node
+type Market struct {
+	Chain     *Chain
+	Contracts *Contracts
+	Config    ContractsConfig
+	Consumer  *Node
+}
+
+// NewMarket starts the chain, deploys the contracts and starts the consumer node
+func NewMarket(t testing.TB) *Market {
+	chain := NewChain(t)
+	cfg := DefaultContractsConfig()
+	m := &Market{
+		Chain:     chain,
+		Contracts: DeployContracts(t, chain, cfg),
+		Config:    cfg,
+	}
+	m.Consumer = m.NewNode(t, NodeConfig{Name: "consumer", StoreChatContext: true})
+	return m
+}
+
+// NewNode starts a node with an account funded with ETH and NodeMOR
+func (m *Market) NewNode(t testing.TB, cfg NodeConfig) *Node {
+	account := m.Chain.NewAccount(t)
+	m.Contracts.FundMOR(t, m.Chain, account.Address, NodeMOR)
+	return NewNode(t, m.Chain, m.Contracts, account, cfg)
+}
+
+// Provider is a provider node, its models are served by a mock model
+type Provider struct {
+	*Node
+	Model  *mockai.TestServer
+	market *Market
+}
+
+// NewProvider starts a provider node with its mock model, the provider is not registered yet
+func (m *Market) NewProvider(t testing.TB, name string, modelCfg mockai.Config) *Provider {
+	return &Provider{
+		Node:   m.NewNode(t, NodeConfig{Name: name}),
+		Model:  mockai.NewTestServer(t, modelCfg),
+		market: m,
+	}
+}
+
+// Register registers the provider with the minimal stake
+func (p *Provider) Register(t testing.TB) {
+	provider := p.RegisterProvider(t, p.market.Config.ProviderMinStake)
+	require.Equal(t, p.Account.Address, provider.Address)
+}
+
+// RegisterModel registers a model with the minimal stake and returns its id
+func (p *Provider) RegisterModel(t testing.TB, name string) common.Hash {
+	return p.Node.RegisterModel(t, name, p.market.Config.ModelMinStake).Id
+}
+
+// Offer serves the model with the mock model and creates a bid with the minimal price
+func (p *Provider) Offer(t testing.TB, modelID common.Hash) common.Hash {
+	return p.OfferWithPrice(t, modelID, p.market.Config.BidMinPricePerSec)
+}
+
+func (p *Provider) OfferWithPrice(t testing.TB, modelID common.Hash, pricePerSecond *big.Int) common.Hash {
+	p.ServeModel(t, modelID, p.Model.ModelConfig())
+	return p.CreateBid(t, modelID, pricePerSecond).Id
+}
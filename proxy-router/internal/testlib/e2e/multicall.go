@@ -0,0 +1,70 @@
+package e2e
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+
+	"github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/interfaces"
+	mc3 "github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/repositories/contracts/bindings/multicall3"
+	"github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/repositories/multicall"
+	ethereum "github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// Multicall executes the calls one by one at the same block. The simulated chain has no
+// Multicall3 contract and the bindings have no bytecode to deploy it
+type Multicall struct {
+	client interfaces.EthClient
+}
+
+func NewMulticall(client interfaces.EthClient) *Multicall {
+	return &Multicall{client: client}
+}
+
+func (m *Multicall) Aggregate(ctx context.Context, calls []mc3.Multicall3Call) (*big.Int, [][]byte, error) {
+	blockNumber, err := m.blockNumber(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	returnData := make([][]byte, len(calls))
+	for i, call := range calls {
+		returnData[i], err = m.call(ctx, call.Target, call.CallData, blockNumber)
+		if err != nil {
+			return nil, nil, fmt.Errorf("call %d failed: %w", i, err)
+		}
+	}
+	return blockNumber, returnData, nil
+}
+
+func (m *Multicall) Aggregate3(ctx context.Context, calls []mc3.Multicall3Call3) ([]mc3.Multicall3Result, error) {
+	blockNumber, err := m.blockNumber(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]mc3.Multicall3Result, len(calls))
+	for i, call := range calls {
+		data, err := m.call(ctx, call.Target, call.CallData, blockNumber)
+		if err != nil && !call.AllowFailure {
+			return nil, fmt.Errorf("call %d failed: %w", i, err)
+		}
+		results[i] = mc3.Multicall3Result{Success: err == nil, ReturnData: data}
+	}
+	return results, nil
+}
+
+func (m *Multicall) blockNumber(ctx context.Context) (*big.Int, error) {
+	number, err := m.client.BlockNumber(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return new(big.Int).SetUint64(number), nil
+}
+
+func (m *Multicall) call(ctx context.Context, target common.Address, data []byte, blockNumber *big.Int) ([]byte, error) {
+	return m.client.CallContract(ctx, ethereum.CallMsg{To: &target, Data: data}, blockNumber)
+}
+
+var _ multicall.MulticallBackend = &Multicall{}
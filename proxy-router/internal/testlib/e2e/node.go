@@ -0,0 +1,204 @@
+package e2e
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/aiengine"
+	"github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/apibus"
+	"github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/blockchainapi"
+	"github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/chatstorage"
+	"github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/config"
+	"github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/handlers/httphandlers"
+	"github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/interfaces"
+	"github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/lib"
+	"github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/proxyapi"
+	"github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/proxyctl"
+	"github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/repositories/contracts"
+	"github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/repositories/ethclient"
+	"github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/repositories/registries"
+	sessionrepo "github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/repositories/session"
+	"github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/repositories/txmanager"
+	wlt "github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/repositories/wallet"
+	"github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/storages"
+	"github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/walletapi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	pollingInterval    = 100 * time.Millisecond
+	maxReconnects      = 30
+	txStuckTimeout     = time.Minute
+	txMaxGasBumps      = 5
+	sessionRenewBefore = time.Minute
+	nodeStartTimeout   = 30 * time.Second
+)
+
+// NodeConfig configures a proxy-router of the simulation
+type NodeConfig struct {
+	Name               string // used as the log name
+	StoreChatContext   bool
+	ForwardChatContext bool
+	OpenAIStrict       bool
+	SchemaRetries      int // PROXY_RESPONSE_SCHEMA_RETRIES
+}
+
+// Node is an in-process proxy-router wired like cmd/main.go with an env wallet,
+// polling for the blockchain events and without auth, webhooks and the indexer
+type Node struct {
+	Name      string
+	Account   *Account
+	ApiURL    string // http api
+	ProxyAddr string // MOR-RPC address, registered as the provider endpoint
+
+	modelsConfigPath  string
+	modelConfigLoader *config.ModelConfigLoader
+	modelsMu          sync.Mutex
+	models            []modelEntry
+}
+
+// modelEntry is an entry of the models config
+type modelEntry struct {
+	ID string `json:"modelId"`
+	config.ModelConfig
+}
+
+// NewNode starts a proxy-router for the account, it is stopped on the test cleanup
+func NewNode(t testing.TB, chain *Chain, contractsCfg *Contracts, account *Account, cfg NodeConfig) *Node {
+	dir := t.TempDir()
+	log := lib.NewTestLogger()
+	appLog := log.Named(cfg.Name)
+
+	port, err := freePort()
+	require.NoError(t, err)
+
+	n := &Node{
+		Name:             cfg.Name,
+		Account:          account,
+		ProxyAddr:        net.JoinHostPort("127.0.0.1", strconv.Itoa(port)),
+		modelsConfigPath: filepath.Join(dir, "models-config.json"),
+	}
+	require.NoError(t, n.writeModelsConfig())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var wg sync.WaitGroup
+	run := func(name string, f func(ctx context.Context) error) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := f(ctx)
+			if err != nil && !errors.Is(err, context.Canceled) {
+				appLog.Errorf("%s stopped: %s", name, err)
+			}
+		}()
+	}
+
+	rpcClient, err := ethclient.NewRPCClientMultiple([]string{chain.URL}, ethclient.RPCClientConfig{}, appLog.Named("RPC"))
+	require.NoError(t, err)
+	ethClient := ethclient.NewClient(rpcClient)
+	chainID := big.NewInt(ChainID)
+
+	storage := storages.NewStorage(appLog, filepath.Join(dir, "data"))
+	sessionStorage := storages.NewSessionStorage(storage)
+	require.NoError(t, sessionStorage.IndexUserSessions())
+
+	wallet := wlt.NewEnvWallet(account.PrivateKey)
+	valid, err := config.NewValidator()
+	require.NoError(t, err)
+	scorer, err := config.LoadRating("", appLog)
+	require.NoError(t, err)
+
+	logWatcher := contracts.NewLogWatcherPolling(ethClient, pollingInterval, maxReconnects, 0, storages.NewCheckpointStorage(storage), appLog)
+	chatStorage := chatstorage.NewChatStorage(filepath.Join(dir, "chats"))
+
+	txManager := txmanager.NewTxManager(ethClient, storages.NewTxQueueStorage(storage), wallet, chainID, txStuckTimeout, txMaxGasBumps, appLog)
+	run("tx manager", txManager.Run)
+
+	multicallBackend := NewMulticall(ethClient)
+	sessionRouter := registries.NewSessionRouter(contractsCfg.Diamond, ethClient, multicallBackend, appLog)
+	marketplace := registries.NewMarketplace(contractsCfg.Diamond, ethClient, multicallBackend, appLog)
+	sessionRepo := sessionrepo.NewSessionRepositoryCached(sessionStorage, sessionRouter, marketplace)
+	proxyRouterApi := proxyapi.NewProxySender(chainID, wallet, lib.NewCollection[*interfaces.LogStorage](), sessionStorage, sessionRepo, appLog)
+	explorer := blockchainapi.NewExplorerClient("", contractsCfg.MOR, time.Second, 0)
+	blockchainApi := blockchainapi.NewBlockchainService(txManager.Client(), multicallBackend, contractsCfg.Diamond, contractsCfg.MOR, explorer, wallet, proxyRouterApi, sessionRepo, scorer, appLog, appLog, false)
+	proxyRouterApi.SetSessionService(blockchainApi)
+	proxyRouterApi.SetOpenAIStrict(cfg.OpenAIStrict)
+	blockchainApi.SetTxManager(txManager)
+
+	n.modelConfigLoader = config.NewModelConfigLoader(n.modelsConfigPath, valid, blockchainApi, &aiengine.ConnectionChecker{}, appLog)
+	require.NoError(t, n.modelConfigLoader.Init())
+
+	aiEngine := aiengine.NewAiEngine(proxyRouterApi, chatStorage, n.modelConfigLoader, appLog)
+	eventListener := blockchainapi.NewEventsListener(sessionRepo, sessionRouter, wallet, logWatcher, appLog)
+	sessionExpiryHandler := blockchainapi.NewSessionExpiryHandler(blockchainApi, sessionStorage, wallet, appLog)
+	sessionRenewer := blockchainapi.NewSessionRenewer(blockchainApi, storages.NewVirtualSessionStorage(storage), sessionRenewBefore, appLog)
+	proxyRouterApi.SetVirtualSessions(sessionRenewer)
+	run("session renewer", sessionRenewer.Run)
+
+	blockchainController := blockchainapi.NewBlockchainController(blockchainApi, appLog)
+	blockchainController.SetSessionRenewer(sessionRenewer)
+	proxyController := proxyapi.NewProxyController(proxyRouterApi, aiEngine, chatStorage, cfg.StoreChatContext, cfg.ForwardChatContext, appLog)
+	proxyController.SetOpenAIStrict(cfg.OpenAIStrict)
+	proxyController.SetResponseSchemaRetries(cfg.SchemaRetries)
+	walletController := walletapi.NewWalletController(wallet)
+
+	apiBus := apibus.NewApiBus(blockchainController, proxyController, walletController)
+	httpServer := httptest.NewServer(httphandlers.CreateHTTPServer(appLog, nil, nil, apiBus))
+	n.ApiURL = httpServer.URL
+
+	proxy := proxyctl.NewProxyCtl(eventListener, wallet, chainID, appLog, log, n.ProxyAddr, sessionStorage, n.modelConfigLoader, valid, aiEngine, blockchainApi, sessionRepo, sessionExpiryHandler)
+	proxy.SetResponseCache(storages.NewResponseCacheStorage(storage))
+	run("proxy", proxy.Run)
+
+	t.Cleanup(func() {
+		cancel()
+		wg.Wait()
+		httpServer.Close()
+		rpcClient.Close()
+		storage.Close()
+	})
+
+	require.Eventually(t, func() bool {
+		conn, err := net.Dial("tcp", n.ProxyAddr)
+		if err != nil {
+			return false
+		}
+		_ = conn.Close()
+		return true
+	}, nodeStartTimeout, pollingInterval, "node %s has not started", cfg.Name)
+
+	return n
+}
+
+// ServeModel adds the model to the models config of the node and reloads it
+func (n *Node) ServeModel(t testing.TB, modelID common.Hash, modelCfg config.ModelConfig) {
+	n.modelsMu.Lock()
+	defer n.modelsMu.Unlock()
+
+	n.models = append(n.models, modelEntry{ID: modelID.Hex(), ModelConfig: modelCfg})
+	require.NoError(t, n.writeModelsConfig())
+	require.NoError(t, n.modelConfigLoader.Init())
+}
+
+func (n *Node) writeModelsConfig() error {
+	models := n.models
+	if models == nil {
+		models = []modelEntry{}
+	}
+	data, err := json.Marshal(map[string][]modelEntry{"models": models})
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(n.modelsConfigPath, data, 0o600)
+}
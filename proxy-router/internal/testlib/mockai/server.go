@@ -0,0 +1,393 @@
+// Package mockai is an OpenAI compatible model server for tests and local development without GPUs or network.
+// It serves chat completions, streamed and not, and image generation with configurable latency, token rate,
+// error injection and scripted responses
+package mockai
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"math/rand"
+	"net/http"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+const (
+	DefaultModel       = "mock-model"
+	DefaultErrorStatus = http.StatusInternalServerError
+)
+
+type Config struct {
+	Latency          time.Duration // delay of the response or the first chunk
+	TokensPerSecond  float64       // rate of the streamed chunks, 0 sends them at once
+	ErrorRate        float64       // share of the requests failed with ErrorStatus, from 0 to 1
+	ErrorStatus      int           // DefaultErrorStatus if not set
+	AbortAfterTokens int           // drops the connection in the middle of the stream after so many tokens, 0 disables
+	Responses        []Response    // scripted responses, the first matching one is used
+	DefaultResponse  string        // the response if no scripted one matches, echoes the prompt if empty
+}
+
+// Response is a scripted response, an error response if Status is set
+type Response struct {
+	Match   string `json:"match"`   // regexp matched against the last user message, empty matches all
+	Content string `json:"content"` // completion text or image prompt result
+	Status  int    `json:"status"`  // http status of an error response
+	Error   string `json:"error"`   // message of an error response
+	Times   int    `json:"times"`   // the response is used so many times, 0 is unlimited
+}
+
+type script struct {
+	Response
+	match *regexp.Regexp
+	used  int
+}
+
+// Server is the mock model server, it is safe for concurrent use
+type Server struct {
+	mutex    sync.Mutex
+	config   Config
+	script   []*script
+	requests []openai.ChatCompletionRequest
+	rand     *rand.Rand
+}
+
+func NewServer(config Config) (*Server, error) {
+	s := &Server{rand: rand.New(rand.NewSource(time.Now().UnixNano()))}
+	err := s.SetConfig(config)
+	if err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// SetConfig replaces the config, the use counts of the scripted responses are reset
+func (s *Server) SetConfig(config Config) error {
+	scripts := make([]*script, len(config.Responses))
+	for i, response := range config.Responses {
+		match, err := regexp.Compile(response.Match)
+		if err != nil {
+			return fmt.Errorf("response %d: %w", i, err)
+		}
+		scripts[i] = &script{Response: response, match: match}
+	}
+	if config.ErrorStatus == 0 {
+		config.ErrorStatus = DefaultErrorStatus
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.config = config
+	s.script = scripts
+	return nil
+}
+
+// Requests returns the received chat completion and image generation requests, image prompts are user messages
+func (s *Server) Requests() []openai.ChatCompletionRequest {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return append([]openai.ChatCompletionRequest{}, s.requests...)
+}
+
+// ServeHTTP serves the endpoints with or without the /v1 prefix:
+//
+//	POST /chat/completions     OpenAI chat completion, streamed if requested
+//	POST /images/generations   OpenAI image generation
+//	POST /image/generation     Hyperbolic image generation, the hyperbolic-sd api type
+//	GET  /models               lists the mock model
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	path := strings.TrimPrefix(r.URL.Path, "/v1")
+	switch {
+	case path == "/chat/completions" && r.Method == http.MethodPost:
+		s.chatCompletion(w, r)
+	case path == "/images/generations" && r.Method == http.MethodPost:
+		s.imageGeneration(w, r, false)
+	case path == "/image/generation" && r.Method == http.MethodPost:
+		s.imageGeneration(w, r, true)
+	case path == "/models" && r.Method == http.MethodGet:
+		writeJSON(w, http.StatusOK, openai.ModelsList{Models: []openai.Model{{ID: DefaultModel, Object: "model", OwnedBy: "mockai"}}})
+	default:
+		writeError(w, http.StatusNotFound, "not found")
+	}
+}
+
+// chatCompletionRequest skips the response format, openai can't decode the json schema
+type chatCompletionRequest struct {
+	openai.ChatCompletionRequest
+	ResponseFormat json.RawMessage `json:"response_format,omitempty"`
+}
+
+func (s *Server) chatCompletion(w http.ResponseWriter, r *http.Request) {
+	var req chatCompletionRequest
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	response, config := s.respond(req.ChatCompletionRequest)
+	if !sleep(r, config.Latency) {
+		return
+	}
+	if response.Status != 0 {
+		writeError(w, response.Status, response.Error)
+		return
+	}
+
+	model := req.Model
+	if model == "" {
+		model = DefaultModel
+	}
+	tokens := tokenize(response.Content)
+	finishReason := openai.FinishReasonStop
+	if req.MaxTokens > 0 && len(tokens) > req.MaxTokens {
+		tokens, finishReason = tokens[:req.MaxTokens], openai.FinishReasonLength
+	}
+	usage := openai.Usage{PromptTokens: promptTokens(req.Messages), CompletionTokens: len(tokens)}
+	usage.TotalTokens = usage.PromptTokens + usage.CompletionTokens
+	id := fmt.Sprintf("chatcmpl-mock%d", time.Now().UnixNano())
+
+	if !req.Stream {
+		writeJSON(w, http.StatusOK, openai.ChatCompletionResponse{
+			ID:      id,
+			Object:  "chat.completion",
+			Created: time.Now().Unix(),
+			Model:   model,
+			Choices: []openai.ChatCompletionChoice{{
+				Message:      openai.ChatCompletionMessage{Role: openai.ChatMessageRoleAssistant, Content: strings.Join(tokens, "")},
+				FinishReason: finishReason,
+			}},
+			Usage: usage,
+		})
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+
+	chunk := func(delta openai.ChatCompletionStreamChoiceDelta, finishReason openai.FinishReason) openai.ChatCompletionStreamResponse {
+		return openai.ChatCompletionStreamResponse{
+			ID:      id,
+			Object:  "chat.completion.chunk",
+			Created: time.Now().Unix(),
+			Model:   model,
+			Choices: []openai.ChatCompletionStreamChoice{{Delta: delta, FinishReason: finishReason}},
+		}
+	}
+
+	writeEvent(w, chunk(openai.ChatCompletionStreamChoiceDelta{Role: openai.ChatMessageRoleAssistant}, ""))
+	var interval time.Duration
+	if config.TokensPerSecond > 0 {
+		interval = time.Duration(float64(time.Second) / config.TokensPerSecond)
+	}
+	for i, token := range tokens {
+		if config.AbortAfterTokens > 0 && i >= config.AbortAfterTokens {
+			// closes the connection without ending the stream
+			panic(http.ErrAbortHandler)
+		}
+		if i > 0 && !sleep(r, interval) {
+			return
+		}
+		writeEvent(w, chunk(openai.ChatCompletionStreamChoiceDelta{Content: token}, ""))
+	}
+	writeEvent(w, chunk(openai.ChatCompletionStreamChoiceDelta{}, finishReason))
+	if req.StreamOptions != nil && req.StreamOptions.IncludeUsage {
+		writeEvent(w, openai.ChatCompletionStreamResponse{
+			ID:      id,
+			Object:  "chat.completion.chunk",
+			Created: time.Now().Unix(),
+			Model:   model,
+			Choices: []openai.ChatCompletionStreamChoice{},
+			Usage:   &usage,
+		})
+	}
+	fmt.Fprint(w, "data: [DONE]\n\n")
+	flush(w)
+}
+
+func (s *Server) imageGeneration(w http.ResponseWriter, r *http.Request, hyperbolic bool) {
+	var req struct {
+		Prompt         string `json:"prompt"`
+		N              int    `json:"n"`
+		ResponseFormat string `json:"response_format"`
+	}
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	response, config := s.respond(openai.ChatCompletionRequest{
+		Messages: []openai.ChatCompletionMessage{{Role: openai.ChatMessageRoleUser, Content: req.Prompt}},
+	})
+	if !sleep(r, config.Latency) {
+		return
+	}
+	if response.Status != 0 {
+		writeError(w, response.Status, response.Error)
+		return
+	}
+
+	img := base64.StdEncoding.EncodeToString(pngImage(response.Content))
+	if hyperbolic {
+		writeJSON(w, http.StatusOK, map[string]interface{}{"images": []map[string]string{{"image": img}}})
+		return
+	}
+
+	n := req.N
+	if n == 0 {
+		n = 1
+	}
+	data := make([]openai.ImageResponseDataInner, n)
+	for i := range data {
+		if req.ResponseFormat == openai.CreateImageResponseFormatB64JSON {
+			data[i].B64JSON = img
+		} else {
+			data[i].URL = "data:image/png;base64," + img
+		}
+		data[i].RevisedPrompt = response.Content
+	}
+	writeJSON(w, http.StatusOK, openai.ImageResponse{Created: time.Now().Unix(), Data: data})
+}
+
+// respond records the request and selects the response: an injected error, a scripted response or the default one
+func (s *Server) respond(req openai.ChatCompletionRequest) (Response, Config) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.requests = append(s.requests, req)
+	config := s.config
+
+	if config.ErrorRate > 0 && s.rand.Float64() < config.ErrorRate {
+		return Response{Status: config.ErrorStatus, Error: "injected error"}, config
+	}
+
+	prompt := lastUserMessage(req.Messages)
+	for _, sc := range s.script {
+		if sc.Times > 0 && sc.used >= sc.Times {
+			continue
+		}
+		if !sc.match.MatchString(prompt) {
+			continue
+		}
+		sc.used++
+		response := sc.Response
+		if response.Status != 0 && response.Error == "" {
+			response.Error = http.StatusText(response.Status)
+		}
+		return response, config
+	}
+
+	if config.DefaultResponse != "" {
+		return Response{Content: config.DefaultResponse}, config
+	}
+	return Response{Content: "You said: " + prompt}, config
+}
+
+func lastUserMessage(messages []openai.ChatCompletionMessage) string {
+	for i := len(messages) - 1; i >= 0; i-- {
+		if messages[i].Role != openai.ChatMessageRoleUser {
+			continue
+		}
+		if messages[i].Content != "" {
+			return messages[i].Content
+		}
+		var parts []string
+		for _, part := range messages[i].MultiContent {
+			if part.Type == openai.ChatMessagePartTypeText {
+				parts = append(parts, part.Text)
+			}
+		}
+		return strings.Join(parts, " ")
+	}
+	return ""
+}
+
+var tokenRegexp = regexp.MustCompile(`\s*\S+`)
+
+// tokenize splits the text into words with their leading whitespace, a word is a token
+func tokenize(text string) []string {
+	tokens := tokenRegexp.FindAllString(text, -1)
+	if trailing := text[len(strings.Join(tokens, "")):]; trailing != "" {
+		tokens = append(tokens, trailing)
+	}
+	return tokens
+}
+
+func promptTokens(messages []openai.ChatCompletionMessage) int {
+	count := 0
+	for _, message := range messages {
+		count += len(tokenize(message.Content))
+	}
+	return count
+}
+
+// pngImage returns a small png with a color derived from the text, so different prompts give different images
+func pngImage(text string) []byte {
+	var sum byte
+	for _, b := range []byte(text) {
+		sum += b
+	}
+	img := image.NewRGBA(image.Rect(0, 0, 8, 8))
+	for x := 0; x < 8; x++ {
+		for y := 0; y < 8; y++ {
+			img.Set(x, y, color.RGBA{R: sum, G: byte(x * 32), B: byte(y * 32), A: 255})
+		}
+	}
+	var buf bytes.Buffer
+	_ = png.Encode(&buf, img)
+	return buf.Bytes()
+}
+
+// sleep waits for the duration, it returns false if the client has gone
+func sleep(r *http.Request, d time.Duration) bool {
+	if d <= 0 {
+		return true
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-r.Context().Done():
+		return false
+	}
+}
+
+func writeEvent(w http.ResponseWriter, v interface{}) {
+	data, _ := json.Marshal(v)
+	fmt.Fprintf(w, "data: %s\n\n", data)
+	flush(w)
+}
+
+func flush(w http.ResponseWriter) {
+	if f, ok := w.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// writeError responds with an error in the OpenAI format
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]interface{}{
+		"error": map[string]interface{}{
+			"message": message,
+			"type":    "mock_error",
+			"code":    status,
+		},
+	})
+}
@@ -0,0 +1,200 @@
+package mockai
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sashabaranov/go-openai"
+	"github.com/stretchr/testify/require"
+)
+
+func newClient(t *testing.T, cfg Config) (*TestServer, *openai.Client) {
+	server := NewTestServer(t, cfg)
+	clientCfg := openai.DefaultConfig("")
+	clientCfg.BaseURL = server.URL
+	return server, openai.NewClientWithConfig(clientCfg)
+}
+
+func userPrompt(text string) openai.ChatCompletionRequest {
+	return openai.ChatCompletionRequest{
+		Model:    DefaultModel,
+		Messages: []openai.ChatCompletionMessage{{Role: openai.ChatMessageRoleUser, Content: text}},
+	}
+}
+
+func TestChatCompletion(t *testing.T) {
+	server, client := newClient(t, Config{})
+
+	res, err := client.CreateChatCompletion(context.Background(), userPrompt("hello there"))
+	require.NoError(t, err)
+	require.Equal(t, "You said: hello there", res.Choices[0].Message.Content)
+	require.Equal(t, openai.FinishReasonStop, res.Choices[0].FinishReason)
+	require.Equal(t, openai.Usage{PromptTokens: 2, CompletionTokens: 4, TotalTokens: 6}, res.Usage)
+	require.Len(t, server.Requests(), 1)
+}
+
+func TestChatCompletionJSONSchema(t *testing.T) {
+	_, client := newClient(t, Config{DefaultResponse: `{"ok":true}`})
+
+	req := userPrompt("answer in json")
+	req.ResponseFormat = &openai.ChatCompletionResponseFormat{
+		Type: openai.ChatCompletionResponseFormatTypeJSONSchema,
+		JSONSchema: &openai.ChatCompletionResponseFormatJSONSchema{
+			Name:   "answer",
+			Schema: json.RawMessage(`{"type":"object"}`),
+		},
+	}
+	res, err := client.CreateChatCompletion(context.Background(), req)
+	require.NoError(t, err)
+	require.Equal(t, `{"ok":true}`, res.Choices[0].Message.Content)
+}
+
+func TestChatCompletionStream(t *testing.T) {
+	_, client := newClient(t, Config{DefaultResponse: "one two three four", TokensPerSecond: 100})
+
+	req := userPrompt("count")
+	req.StreamOptions = &openai.StreamOptions{IncludeUsage: true}
+	start := time.Now()
+	stream, err := client.CreateChatCompletionStream(context.Background(), req)
+	require.NoError(t, err)
+	defer stream.Close()
+
+	var content []string
+	var finishReason openai.FinishReason
+	var usage *openai.Usage
+	for {
+		chunk, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		require.NoError(t, err)
+		if chunk.Usage != nil {
+			usage = chunk.Usage
+		}
+		if len(chunk.Choices) == 0 {
+			continue
+		}
+		if chunk.Choices[0].Delta.Content != "" {
+			content = append(content, chunk.Choices[0].Delta.Content)
+		}
+		if chunk.Choices[0].FinishReason != "" {
+			finishReason = chunk.Choices[0].FinishReason
+		}
+	}
+
+	require.Equal(t, []string{"one", " two", " three", " four"}, content)
+	require.Equal(t, openai.FinishReasonStop, finishReason)
+	require.NotNil(t, usage)
+	require.Equal(t, 4, usage.CompletionTokens)
+	require.GreaterOrEqual(t, time.Since(start), 30*time.Millisecond)
+}
+
+func TestChatCompletionMaxTokens(t *testing.T) {
+	_, client := newClient(t, Config{DefaultResponse: "one two three"})
+
+	req := userPrompt("count")
+	req.MaxTokens = 2
+	res, err := client.CreateChatCompletion(context.Background(), req)
+	require.NoError(t, err)
+	require.Equal(t, "one two", res.Choices[0].Message.Content)
+	require.Equal(t, openai.FinishReasonLength, res.Choices[0].FinishReason)
+}
+
+func TestScriptedResponses(t *testing.T) {
+	_, client := newClient(t, Config{Responses: []Response{
+		{Match: "(?i)weather", Content: "sunny", Times: 1},
+		{Match: "fail", Status: http.StatusTooManyRequests, Error: "rate limited"},
+		{Content: "fallback"},
+	}})
+
+	res, err := client.CreateChatCompletion(context.Background(), userPrompt("What is the Weather?"))
+	require.NoError(t, err)
+	require.Equal(t, "sunny", res.Choices[0].Message.Content)
+
+	// used once
+	res, err = client.CreateChatCompletion(context.Background(), userPrompt("weather again"))
+	require.NoError(t, err)
+	require.Equal(t, "fallback", res.Choices[0].Message.Content)
+
+	_, err = client.CreateChatCompletion(context.Background(), userPrompt("please fail"))
+	var apiErr *openai.APIError
+	require.ErrorAs(t, err, &apiErr)
+	require.Equal(t, http.StatusTooManyRequests, apiErr.HTTPStatusCode)
+	require.Equal(t, "rate limited", apiErr.Message)
+}
+
+func TestErrorInjection(t *testing.T) {
+	server, client := newClient(t, Config{ErrorRate: 1, ErrorStatus: http.StatusServiceUnavailable})
+
+	_, err := client.CreateChatCompletion(context.Background(), userPrompt("hello"))
+	var apiErr *openai.APIError
+	require.ErrorAs(t, err, &apiErr)
+	require.Equal(t, http.StatusServiceUnavailable, apiErr.HTTPStatusCode)
+
+	require.NoError(t, server.SetConfig(Config{}))
+	_, err = client.CreateChatCompletion(context.Background(), userPrompt("hello"))
+	require.NoError(t, err)
+}
+
+func TestStreamAbort(t *testing.T) {
+	_, client := newClient(t, Config{DefaultResponse: "one two three", AbortAfterTokens: 1})
+
+	stream, err := client.CreateChatCompletionStream(context.Background(), userPrompt("count"))
+	require.NoError(t, err)
+	defer stream.Close()
+
+	for {
+		_, err = stream.Recv()
+		if err != nil {
+			break
+		}
+	}
+	require.Error(t, err)
+	require.NotErrorIs(t, err, io.EOF)
+}
+
+func TestLatency(t *testing.T) {
+	_, client := newClient(t, Config{Latency: 200 * time.Millisecond})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err := client.CreateChatCompletion(ctx, userPrompt("hello"))
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestImageGeneration(t *testing.T) {
+	server, client := newClient(t, Config{})
+
+	res, err := client.CreateImage(context.Background(), openai.ImageRequest{
+		Prompt:         "a cat",
+		ResponseFormat: openai.CreateImageResponseFormatB64JSON,
+	})
+	require.NoError(t, err)
+	require.Len(t, res.Data, 1)
+	img, err := base64.StdEncoding.DecodeString(res.Data[0].B64JSON)
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(string(img), "\x89PNG"))
+
+	httpRes, err := http.Post(server.URL+"/image/generation", "application/json", strings.NewReader(`{"prompt":"a dog"}`))
+	require.NoError(t, err)
+	defer httpRes.Body.Close()
+	var hyperbolic struct {
+		Images []struct {
+			Image string `json:"image"`
+		} `json:"images"`
+	}
+	require.NoError(t, json.NewDecoder(httpRes.Body).Decode(&hyperbolic))
+	require.Len(t, hyperbolic.Images, 1)
+	require.NotEqual(t, res.Data[0].B64JSON, hyperbolic.Images[0].Image)
+
+	requests := server.Requests()
+	require.Len(t, requests, 2)
+	require.Equal(t, "a dog", requests[1].Messages[0].Content)
+}
@@ -0,0 +1,34 @@
+package mockai
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/config"
+)
+
+// TestServer is the mock server listening on a local port, it is closed when the test ends
+type TestServer struct {
+	*Server
+	URL string // base url with the /v1 prefix, as configured for the OpenAI api
+}
+
+func NewTestServer(t testing.TB, cfg Config) *TestServer {
+	server, err := NewServer(cfg)
+	if err != nil {
+		t.Fatalf("mockai: %s", err)
+	}
+	httpServer := httptest.NewServer(server)
+	t.Cleanup(httpServer.Close)
+	return &TestServer{Server: server, URL: httpServer.URL + "/v1"}
+}
+
+// ModelConfig returns the config of a local chat model served by the mock
+func (s *TestServer) ModelConfig() config.ModelConfig {
+	return config.ModelConfig{ModelName: DefaultModel, ApiType: "openai", ApiURL: s.URL, ConcurrentSlots: 10}
+}
+
+// ImageModelConfig returns the config of a local image generation model served by the mock
+func (s *TestServer) ImageModelConfig() config.ModelConfig {
+	return config.ModelConfig{ModelName: DefaultModel, ApiType: "hyperbolic-sd", ApiURL: s.URL, ConcurrentSlots: 10}
+}
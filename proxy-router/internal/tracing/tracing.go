@@ -0,0 +1,105 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/lib"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	tracerName = "github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router"
+
+	// traceParentKey is the W3C trace context header, also used as the key in the MOR-RPC message
+	traceParentKey = "traceparent"
+)
+
+var (
+	ErrExporter = fmt.Errorf("failed to create otlp exporter")
+
+	propagator = propagation.TraceContext{}
+	// propagate enables sending and accepting trace context in MOR-RPC messages
+	propagate = false
+)
+
+type Config struct {
+	Endpoint    string  // otlp/http collector url, e.g. http://localhost:4318, tracing is disabled if empty
+	ServiceName string  // defaults to proxy-router
+	SampleRatio float64 // ratio of the sampled root spans, 0-1
+	Propagate   bool    // carry trace context in MOR-RPC messages, the peer must have it enabled too
+}
+
+// Init sets up the global tracer provider exporting spans to the collector. The returned function flushes
+// and stops the exporter. If the endpoint is not set spans are not recorded
+func Init(ctx context.Context, cfg Config, log lib.ILogger) (shutdown func(context.Context) error, err error) {
+	noop := func(context.Context) error { return nil }
+	if cfg.Endpoint == "" {
+		return noop, nil
+	}
+
+	exporter, err := otlptracehttp.New(ctx, otlptracehttp.WithEndpointURL(cfg.Endpoint))
+	if err != nil {
+		return noop, lib.WrapError(ErrExporter, err)
+	}
+
+	serviceName := cfg.ServiceName
+	if serviceName == "" {
+		serviceName = "proxy-router"
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.SampleRatio))),
+		sdktrace.WithResource(resource.NewSchemaless(semconv.ServiceName(serviceName))),
+	)
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagator)
+	otel.SetErrorHandler(otel.ErrorHandlerFunc(func(err error) {
+		log.Debugf("tracing error: %s", err)
+	}))
+	propagate = cfg.Propagate
+
+	return provider.Shutdown, nil
+}
+
+// Start starts the span using the global tracer provider
+func Start(ctx context.Context, name string, kind trace.SpanKind, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return otel.Tracer(tracerName).Start(ctx, name, trace.WithSpanKind(kind), trace.WithAttributes(attrs...))
+}
+
+// End records the error, if any, and ends the span
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// TraceParent returns the W3C traceparent of the span in the context to be sent in the MOR-RPC message.
+// It is empty if propagation is disabled or the span is not sampled
+func TraceParent(ctx context.Context) string {
+	if !propagate {
+		return ""
+	}
+	carrier := propagation.MapCarrier{}
+	propagator.Inject(ctx, carrier)
+	return carrier.Get(traceParentKey)
+}
+
+// WithTraceParent joins the remote trace received in the MOR-RPC message if propagation is enabled
+func WithTraceParent(ctx context.Context, traceParent string) context.Context {
+	if !propagate || traceParent == "" {
+		return ctx
+	}
+	return propagator.Extract(ctx, propagation.MapCarrier{traceParentKey: traceParent})
+}
@@ -0,0 +1,53 @@
+package tracing
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func setupTestProvider(t *testing.T, propagation bool) *tracetest.SpanRecorder {
+	recorder := tracetest.NewSpanRecorder()
+	prevProvider, prevPropagate := otel.GetTracerProvider(), propagate
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	propagate = propagation
+	t.Cleanup(func() {
+		otel.SetTracerProvider(prevProvider)
+		propagate = prevPropagate
+	})
+	return recorder
+}
+
+func TestTraceParentJoinsRemoteTrace(t *testing.T) {
+	recorder := setupTestProvider(t, true)
+
+	consumerCtx, consumerSpan := Start(context.Background(), "consumer", trace.SpanKindClient)
+	traceParent := TraceParent(consumerCtx)
+	require.NotEmpty(t, traceParent)
+
+	_, providerSpan := Start(WithTraceParent(context.Background(), traceParent), "provider", trace.SpanKindServer)
+	providerSpan.End()
+	consumerSpan.End()
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 2)
+	require.Equal(t, spans[1].SpanContext().TraceID(), spans[0].SpanContext().TraceID())
+	require.Equal(t, spans[1].SpanContext().SpanID(), spans[0].Parent().SpanID())
+	require.True(t, spans[0].Parent().IsRemote())
+}
+
+func TestTraceParentDisabled(t *testing.T) {
+	setupTestProvider(t, false)
+
+	ctx, span := Start(context.Background(), "consumer", trace.SpanKindClient)
+	defer span.End()
+	require.Empty(t, TraceParent(ctx))
+
+	remote := "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	require.False(t, trace.SpanContextFromContext(WithTraceParent(context.Background(), remote)).IsValid())
+}
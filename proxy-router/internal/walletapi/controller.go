@@ -1,15 +1,21 @@
 package walletapi
 
 import (
+	"context"
+	"fmt"
 	"net/http"
 
 	"github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/interfaces"
 	"github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/lib"
+	"github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/repositories/wallet"
 	"github.com/gin-gonic/gin"
 )
 
+var ErrAccountsUnavailable = fmt.Errorf("named accounts are not available for this wallet")
+
 type WalletController struct {
-	service interfaces.Wallet
+	service  interfaces.Wallet
+	accounts interfaces.Accounts
 }
 
 func NewWalletController(service interfaces.Wallet) *WalletController {
@@ -20,10 +26,18 @@ func NewWalletController(service interfaces.Wallet) *WalletController {
 	return c
 }
 
+// SetAccounts enables selecting the named account with the X-Account header
+func (s *WalletController) SetAccounts(accounts interfaces.Accounts) {
+	s.accounts = accounts
+}
+
 func (s *WalletController) RegisterRoutes(r interfaces.Router) {
 	r.GET("/wallet", s.GetWallet)
+	r.GET("/wallet/accounts", s.GetAccounts)
 	r.POST("/wallet/privateKey", s.SetupWalletPrivateKey)
 	r.POST("/wallet/mnemonic", s.SetupWalletMnemonic)
+	r.POST("/wallet/keystore/import", s.ImportKeystore)
+	r.POST("/wallet/keystore/export", s.ExportKeystore)
 	r.DELETE("/wallet", s.DeleteWallet)
 }
 
@@ -36,12 +50,12 @@ func (s *WalletController) RegisterRoutes(r interfaces.Router) {
 //	@Success		200	{WalletRes}	WalletRes
 //	@Router			/wallet [get]
 func (s *WalletController) GetWallet(ctx *gin.Context) {
-	prKey, err := s.service.GetPrivateKey()
+	wallet, err := s.getWallet(ctx)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-	addr, err := lib.PrivKeyBytesToAddr(prKey)
+	addr, err := interfaces.GetAddressCtx(ctx, wallet)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -66,13 +80,19 @@ func (s *WalletController) SetupWalletPrivateKey(ctx *gin.Context) {
 		return
 	}
 
-	err = s.service.SetPrivateKey(req.PrivateKey)
+	wallet, err := s.getWallet(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	err = wallet.SetPrivateKey(req.PrivateKey)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	prKey, err := s.service.GetPrivateKey()
+	prKey, err := wallet.GetPrivateKey()
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -104,13 +124,19 @@ func (s *WalletController) SetupWalletMnemonic(ctx *gin.Context) {
 		return
 	}
 
-	err = s.service.SetMnemonic(req.Mnemonic, req.DerivationPath)
+	wallet, err := s.getWallet(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	err = wallet.SetMnemonic(req.Mnemonic, req.DerivationPath)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	prKey, err := s.service.GetPrivateKey()
+	prKey, err := wallet.GetPrivateKey()
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -124,6 +150,96 @@ func (s *WalletController) SetupWalletMnemonic(ctx *gin.Context) {
 	ctx.JSON(http.StatusOK, WalletRes{Address: addr})
 }
 
+// ImportKeystore godoc
+//
+//	@Summary		Setup wallet with keystore
+//	@Description	Setup wallet with the private key of an Ethereum V3 keystore (Web3 Secret Storage) json
+//	@Tags			wallet
+//	@Accept			json
+//	@Produce		json
+//	@Param			keystore	body		ImportKeystoreReqBody	true	"Keystore and its passphrase"
+//	@Success		200			{object}	WalletRes
+//	@Router			/wallet/keystore/import [post]
+func (s *WalletController) ImportKeystore(ctx *gin.Context) {
+	var req ImportKeystoreReqBody
+	err := ctx.ShouldBindJSON(&req)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	prKey, err := wallet.DecryptKeystore(req.Keystore, req.Passphrase)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	w, err := s.getWallet(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	err = w.SetPrivateKey(prKey)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	addr, err := lib.PrivKeyBytesToAddr(prKey)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, WalletRes{Address: addr})
+}
+
+// ExportKeystore godoc
+//
+//	@Summary		Export wallet as keystore
+//	@Description	Export the wallet private key as an Ethereum V3 keystore (Web3 Secret Storage) json encrypted with the passphrase
+//	@Tags			wallet
+//	@Accept			json
+//	@Produce		json
+//	@Param			passphrase	body		ExportKeystoreReqBody	true	"Passphrase of the exported keystore"
+//	@Success		200			{object}	object
+//	@Router			/wallet/keystore/export [post]
+func (s *WalletController) ExportKeystore(ctx *gin.Context) {
+	var req ExportKeystoreReqBody
+	err := ctx.ShouldBindJSON(&req)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	w, err := s.getWallet(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// the key of the remote signer wallet is the MOR-RPC transport key, not the wallet one
+	if _, ok := w.(interfaces.Signer); ok {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": wallet.ErrRemoteSignerExport.Error()})
+		return
+	}
+
+	prKey, err := w.GetPrivateKey()
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	data, err := wallet.EncryptKeystore(prKey, req.Passphrase)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.Data(http.StatusOK, "application/json", data)
+}
+
 // DeleteWallet godoc
 //
 //	@Summary		Remove wallet from proxy
@@ -133,7 +249,13 @@ func (s *WalletController) SetupWalletMnemonic(ctx *gin.Context) {
 //	@Success		200	{statusRes}	res
 //	@Router			/wallet [delete]
 func (s *WalletController) DeleteWallet(ctx *gin.Context) {
-	err := s.service.DeleteWallet()
+	wallet, err := s.getWallet(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	err = wallet.DeleteWallet()
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -141,3 +263,61 @@ func (s *WalletController) DeleteWallet(ctx *gin.Context) {
 
 	ctx.JSON(http.StatusOK, OkRes())
 }
+
+// GetAccounts godoc
+//
+//	@Summary		Get Accounts
+//	@Description	Get named wallet accounts with their addresses, an account is selected with the X-Account header
+//	@Tags			wallet
+//	@Produce		json
+//	@Success		200	{object}	AccountsRes
+//	@Router			/wallet/accounts [get]
+func (s *WalletController) GetAccounts(ctx *gin.Context) {
+	if s.accounts == nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": ErrAccountsUnavailable.Error()})
+		return
+	}
+
+	names, err := s.accounts.ListAccounts()
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	res := AccountsRes{Accounts: make([]AccountRes, 0, len(names))}
+	for _, name := range names {
+		wallet, err := s.accounts.GetAccount(name)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		prKey, err := wallet.GetPrivateKey()
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		addr, err := lib.PrivKeyBytesToAddr(prKey)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		res.Accounts = append(res.Accounts, AccountRes{Name: name, Address: addr})
+	}
+
+	ctx.JSON(http.StatusOK, res)
+}
+
+// getWallet returns the wallet of the account selected by the request
+func (s *WalletController) getWallet(ctx context.Context) (interfaces.Wallet, error) {
+	name := lib.AccountFromContext(ctx)
+	if name == "" {
+		return s.service, nil
+	}
+	if s.accounts == nil {
+		if name == lib.DefaultAccount {
+			return s.service, nil
+		}
+		return nil, ErrAccountsUnavailable
+	}
+	return s.accounts.GetAccount(name)
+}
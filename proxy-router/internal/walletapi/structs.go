@@ -1,6 +1,8 @@
 package walletapi
 
 import (
+	"encoding/json"
+
 	"github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/lib"
 	"github.com/ethereum/go-ethereum/common"
 )
@@ -14,10 +16,28 @@ type SetupWalletMnemonicReqBody struct {
 	DerivationPath string `json:"derivationPath" binding:"required" validate:"required"`
 }
 
+type ImportKeystoreReqBody struct {
+	Keystore   json.RawMessage `json:"keystore" binding:"required" validate:"required" swaggertype:"object"`
+	Passphrase string          `json:"passphrase" binding:"required" validate:"required"`
+}
+
+type ExportKeystoreReqBody struct {
+	Passphrase string `json:"passphrase" binding:"required" validate:"required"`
+}
+
 type WalletRes struct {
 	Address common.Address `json:"address" example:"0x1234"`
 }
 
+type AccountRes struct {
+	Name    string         `json:"name" example:"default"`
+	Address common.Address `json:"address" example:"0x1234"`
+}
+
+type AccountsRes struct {
+	Accounts []AccountRes `json:"accounts"`
+}
+
 type statusRes struct {
 	Status string `json:"status" example:"ok"`
 }
@@ -0,0 +1,81 @@
+package webhooks
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+
+	"github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/lib"
+)
+
+const (
+	DefaultMaxRetries           = 5
+	DefaultTimeoutSeconds       = 10
+	DefaultBalanceCheckInterval = 300
+)
+
+var (
+	ErrInvalidConfig = errors.New("invalid webhooks config")
+)
+
+// Config is the webhooks config file format
+type Config struct {
+	Endpoints []EndpointConfig `json:"endpoints"`
+	Balance   BalanceConfig    `json:"balance"`
+}
+
+type EndpointConfig struct {
+	URL            string   `json:"url"`
+	Secret         string   `json:"secret"`         // HMAC-SHA256 key, requests are not signed if empty
+	Events         []string `json:"events"`         // event filter, all events are sent if empty
+	MaxRetries     int      `json:"maxRetries"`     // delivery attempts after the first failed one, 0 disables retries
+	TimeoutSeconds int      `json:"timeoutSeconds"` // timeout of a single delivery attempt
+}
+
+// BalanceConfig sets the thresholds in wei, a balance.low event is sent when the wallet balance drops below them
+type BalanceConfig struct {
+	ETHThreshold         *lib.BigInt `json:"ethThreshold"`
+	MORThreshold         *lib.BigInt `json:"morThreshold"`
+	CheckIntervalSeconds int         `json:"checkIntervalSeconds"`
+}
+
+// UnmarshalJSON sets the default of the fields missing in the config, so zero values set explicitly are kept
+func (c *EndpointConfig) UnmarshalJSON(data []byte) error {
+	type endpointConfig EndpointConfig
+	cfg := endpointConfig{MaxRetries: DefaultMaxRetries}
+	err := json.Unmarshal(data, &cfg)
+	if err != nil {
+		return err
+	}
+	*c = EndpointConfig(cfg)
+	return nil
+}
+
+func ParseConfig(data []byte) (*Config, error) {
+	var cfg Config
+	err := json.Unmarshal(data, &cfg)
+	if err != nil {
+		return nil, lib.WrapError(ErrInvalidConfig, err)
+	}
+
+	for i := range cfg.Endpoints {
+		ep := &cfg.Endpoints[i]
+		u, err := url.Parse(ep.URL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return nil, lib.WrapError(ErrInvalidConfig, fmt.Errorf("endpoint %d: invalid url %s", i, ep.URL))
+		}
+		if ep.MaxRetries < 0 {
+			return nil, lib.WrapError(ErrInvalidConfig, fmt.Errorf("endpoint %d: negative maxRetries %d", i, ep.MaxRetries))
+		}
+		if ep.TimeoutSeconds == 0 {
+			ep.TimeoutSeconds = DefaultTimeoutSeconds
+		}
+	}
+
+	if cfg.Balance.CheckIntervalSeconds == 0 {
+		cfg.Balance.CheckIntervalSeconds = DefaultBalanceCheckInterval
+	}
+
+	return &cfg, nil
+}
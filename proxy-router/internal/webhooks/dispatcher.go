@@ -0,0 +1,214 @@
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/lib"
+)
+
+const (
+	HeaderEvent     = "X-Morpheus-Event"
+	HeaderDelivery  = "X-Morpheus-Delivery"
+	HeaderTimestamp = "X-Morpheus-Timestamp"
+	HeaderSignature = "X-Morpheus-Signature"
+
+	queueSize = 100
+)
+
+var (
+	ErrDeliveryStatus = errors.New("unexpected webhook response status")
+	ErrQueueFull      = errors.New("webhook queue is full, event dropped")
+)
+
+// Dispatcher delivers node events to the configured webhook endpoints. Each endpoint has its own
+// queue, so a slow or failing endpoint doesn't delay the others. Delivery is best effort, the queues
+// are kept in memory and dropped on shutdown. A nil Dispatcher is valid and ignores all events, so
+// components can emit without checking if webhooks are enabled
+type Dispatcher struct {
+	endpoints []*endpoint
+	balance   BalanceConfig
+	log       lib.ILogger
+
+	minBackoff time.Duration
+	maxBackoff time.Duration
+}
+
+type endpoint struct {
+	cfg    EndpointConfig
+	queue  chan *Event
+	client *http.Client
+}
+
+func NewDispatcher(cfg *Config, log lib.ILogger) *Dispatcher {
+	d := &Dispatcher{
+		balance:    cfg.Balance,
+		log:        log.Named("WEBHOOKS"),
+		minBackoff: time.Second,
+		maxBackoff: time.Minute,
+	}
+	for _, ep := range cfg.Endpoints {
+		d.endpoints = append(d.endpoints, &endpoint{
+			cfg:    ep,
+			queue:  make(chan *Event, queueSize),
+			client: &http.Client{Timeout: time.Duration(ep.TimeoutSeconds) * time.Second},
+		})
+	}
+	return d
+}
+
+// Emit queues the event for delivery to the endpoints which filters match it, it never blocks
+func (d *Dispatcher) Emit(eventType EventType, data interface{}) {
+	if d == nil {
+		return
+	}
+
+	event := &Event{
+		ID:        newEventID(),
+		Type:      eventType,
+		Timestamp: time.Now().UTC(),
+		Data:      data,
+	}
+
+	for _, ep := range d.endpoints {
+		if !matchEvent(ep.cfg.Events, eventType) {
+			continue
+		}
+		select {
+		case ep.queue <- event:
+		default:
+			d.log.Warnf("%s: %s %s", ErrQueueFull, ep.cfg.URL, eventType)
+		}
+	}
+}
+
+// Balance returns the balance thresholds config, zero value if webhooks are disabled
+func (d *Dispatcher) Balance() BalanceConfig {
+	if d == nil {
+		return BalanceConfig{}
+	}
+	return d.balance
+}
+
+// Run delivers queued events until the context is cancelled
+func (d *Dispatcher) Run(ctx context.Context) error {
+	d.log.Infof("webhooks started, endpoints: %d", len(d.endpoints))
+
+	wg := sync.WaitGroup{}
+	for _, ep := range d.endpoints {
+		wg.Add(1)
+		go func(ep *endpoint) {
+			defer wg.Done()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case event := <-ep.queue:
+					err := d.deliver(ctx, ep, event)
+					if err != nil {
+						d.log.Warnf("failed to deliver %s event %s to %s: %s", event.Type, event.ID, ep.cfg.URL, err)
+					}
+				}
+			}
+		}(ep)
+	}
+	wg.Wait()
+
+	return ctx.Err()
+}
+
+func (d *Dispatcher) deliver(ctx context.Context, ep *endpoint, event *Event) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	for attempt := 0; ; attempt++ {
+		retry, err := d.post(ctx, ep, event, body)
+		if err == nil {
+			d.log.Debugf("delivered %s event %s to %s", event.Type, event.ID, ep.cfg.URL)
+			return nil
+		}
+		if !retry || attempt >= ep.cfg.MaxRetries {
+			return err
+		}
+
+		backoff := d.backoff(attempt)
+		d.log.Debugf("webhook delivery to %s failed, retrying in %s (%d/%d): %s", ep.cfg.URL, backoff, attempt+1, ep.cfg.MaxRetries, err)
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff):
+		}
+	}
+}
+
+// post sends the event once, returns true if the delivery can be retried
+func (d *Dispatcher) post(ctx context.Context, ep *endpoint, event *Event, body []byte) (bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, ep.cfg.URL, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+
+	timestamp := time.Now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderEvent, string(event.Type))
+	req.Header.Set(HeaderDelivery, event.ID)
+	req.Header.Set(HeaderTimestamp, strconv.FormatInt(timestamp, 10))
+	if ep.cfg.Secret != "" {
+		req.Header.Set(HeaderSignature, Sign(ep.cfg.Secret, timestamp, body))
+	}
+
+	res, err := ep.client.Do(req)
+	if err != nil {
+		return true, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode >= 200 && res.StatusCode < 300 {
+		return false, nil
+	}
+
+	err = lib.WrapError(ErrDeliveryStatus, fmt.Errorf("%d", res.StatusCode))
+	retry := res.StatusCode >= 500 || res.StatusCode == http.StatusTooManyRequests || res.StatusCode == http.StatusRequestTimeout
+	return retry, err
+}
+
+func (d *Dispatcher) backoff(attempt int) time.Duration {
+	if attempt > 30 {
+		return d.maxBackoff
+	}
+	backoff := d.minBackoff << attempt
+	if backoff <= 0 || backoff > d.maxBackoff {
+		return d.maxBackoff
+	}
+	return backoff
+}
+
+// Sign returns the signature header value: hex encoded HMAC-SHA256 of "<timestamp>.<body>".
+// Receivers should recompute it with the shared secret and reject stale timestamps
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func newEventID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,207 @@
+package webhooks
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/MorpheusAIs/Morpheus-Lumerin-Node/proxy-router/internal/lib"
+	"github.com/stretchr/testify/require"
+)
+
+type stubRequest struct {
+	header http.Header
+	body   []byte
+}
+
+// webhookStub is a local HTTP server replying with the given status codes in order, then 200
+type webhookStub struct {
+	mu       sync.Mutex
+	statuses []int
+	requests []stubRequest
+	received chan struct{}
+	srv      *httptest.Server
+}
+
+func newWebhookStub(t *testing.T, statuses ...int) *webhookStub {
+	stub := &webhookStub{statuses: statuses, received: make(chan struct{}, 100)}
+	stub.srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+
+		stub.mu.Lock()
+		stub.requests = append(stub.requests, stubRequest{header: r.Header.Clone(), body: body})
+		status := http.StatusOK
+		if len(stub.statuses) > 0 {
+			status, stub.statuses = stub.statuses[0], stub.statuses[1:]
+		}
+		stub.mu.Unlock()
+
+		w.WriteHeader(status)
+		stub.received <- struct{}{}
+	}))
+	t.Cleanup(stub.srv.Close)
+	return stub
+}
+
+func (s *webhookStub) Requests() []stubRequest {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]stubRequest{}, s.requests...)
+}
+
+func (s *webhookStub) waitRequests(t *testing.T, n int) {
+	for i := 0; i < n; i++ {
+		select {
+		case <-s.received:
+		case <-time.After(5 * time.Second):
+			require.FailNow(t, "timeout waiting for webhook request")
+		}
+	}
+}
+
+func newTestDispatcher(t *testing.T, endpoints ...EndpointConfig) *Dispatcher {
+	data, err := json.Marshal(Config{Endpoints: endpoints})
+	require.NoError(t, err)
+	cfg, err := ParseConfig(data)
+	require.NoError(t, err)
+
+	d := NewDispatcher(cfg, lib.NewTestLogger())
+	d.minBackoff = time.Millisecond
+	d.maxBackoff = 10 * time.Millisecond
+	return d
+}
+
+func runDispatcher(t *testing.T, d *Dispatcher) {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		_ = d.Run(ctx)
+		close(done)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+}
+
+func TestDispatcherSignsEvents(t *testing.T) {
+	stub := newWebhookStub(t)
+	d := newTestDispatcher(t, EndpointConfig{URL: stub.srv.URL, Secret: "secret"})
+	runDispatcher(t, d)
+
+	d.Emit(EventSessionOpened, &SessionEventData{SessionID: "0x01"})
+	stub.waitRequests(t, 1)
+
+	req := stub.Requests()[0]
+	require.Equal(t, string(EventSessionOpened), req.header.Get(HeaderEvent))
+
+	timestamp, err := strconv.ParseInt(req.header.Get(HeaderTimestamp), 10, 64)
+	require.NoError(t, err)
+	require.Equal(t, Sign("secret", timestamp, req.body), req.header.Get(HeaderSignature))
+	require.NotEqual(t, Sign("other", timestamp, req.body), req.header.Get(HeaderSignature))
+
+	var event struct {
+		ID   string
+		Type EventType
+		Data SessionEventData
+	}
+	require.NoError(t, json.Unmarshal(req.body, &event))
+	require.Equal(t, req.header.Get(HeaderDelivery), event.ID)
+	require.Equal(t, EventSessionOpened, event.Type)
+	require.Equal(t, "0x01", event.Data.SessionID)
+}
+
+func TestDispatcherRetriesWithBackoff(t *testing.T) {
+	stub := newWebhookStub(t, http.StatusInternalServerError, http.StatusTooManyRequests)
+	d := newTestDispatcher(t, EndpointConfig{URL: stub.srv.URL, MaxRetries: 3})
+
+	err := d.deliver(context.Background(), d.endpoints[0], &Event{ID: "1", Type: EventTest})
+	require.NoError(t, err)
+
+	reqs := stub.Requests()
+	require.Len(t, reqs, 3)
+	// the retried request is the same delivery
+	require.Equal(t, reqs[0].header.Get(HeaderDelivery), reqs[2].header.Get(HeaderDelivery))
+	require.Equal(t, reqs[0].body, reqs[2].body)
+}
+
+func TestDispatcherStopsRetrying(t *testing.T) {
+	t.Run("retries exhausted", func(t *testing.T) {
+		stub := newWebhookStub(t, 500, 500, 500, 500)
+		d := newTestDispatcher(t, EndpointConfig{URL: stub.srv.URL, MaxRetries: 2})
+
+		err := d.deliver(context.Background(), d.endpoints[0], &Event{ID: "1", Type: EventTest})
+		require.ErrorIs(t, err, ErrDeliveryStatus)
+		require.Len(t, stub.Requests(), 3)
+	})
+
+	t.Run("retries disabled", func(t *testing.T) {
+		stub := newWebhookStub(t, 500, 500)
+		d := newTestDispatcher(t, EndpointConfig{URL: stub.srv.URL, MaxRetries: 0})
+
+		err := d.deliver(context.Background(), d.endpoints[0], &Event{ID: "1", Type: EventTest})
+		require.ErrorIs(t, err, ErrDeliveryStatus)
+		require.Len(t, stub.Requests(), 1)
+	})
+
+	t.Run("client error", func(t *testing.T) {
+		stub := newWebhookStub(t, http.StatusBadRequest)
+		d := newTestDispatcher(t, EndpointConfig{URL: stub.srv.URL, MaxRetries: 2})
+
+		err := d.deliver(context.Background(), d.endpoints[0], &Event{ID: "1", Type: EventTest})
+		require.ErrorIs(t, err, ErrDeliveryStatus)
+		require.Len(t, stub.Requests(), 1)
+	})
+}
+
+func TestDispatcherFiltersEvents(t *testing.T) {
+	sessions := newWebhookStub(t)
+	all := newWebhookStub(t)
+	d := newTestDispatcher(t,
+		EndpointConfig{URL: sessions.srv.URL, Events: []string{"session.*", string(EventBalanceLow)}},
+		EndpointConfig{URL: all.srv.URL},
+	)
+	runDispatcher(t, d)
+
+	d.Emit(EventBidPosted, nil)
+	d.Emit(EventSessionClosed, nil)
+	d.Emit(EventBalanceLow, nil)
+
+	all.waitRequests(t, 3)
+	sessions.waitRequests(t, 2)
+
+	reqs := sessions.Requests()
+	require.Equal(t, string(EventSessionClosed), reqs[0].header.Get(HeaderEvent))
+	require.Equal(t, string(EventBalanceLow), reqs[1].header.Get(HeaderEvent))
+}
+
+func TestNilDispatcher(t *testing.T) {
+	var d *Dispatcher
+	d.Emit(EventTest, nil)
+	require.Equal(t, BalanceConfig{}, d.Balance())
+}
+
+func TestParseConfig(t *testing.T) {
+	cfg, err := ParseConfig([]byte(`{"endpoints":[{"url":"https://example.com/hook"}],"balance":{"morThreshold":"1000"}}`))
+	require.NoError(t, err)
+	require.Equal(t, DefaultMaxRetries, cfg.Endpoints[0].MaxRetries)
+	require.Equal(t, DefaultTimeoutSeconds, cfg.Endpoints[0].TimeoutSeconds)
+	require.Equal(t, "1000", cfg.Balance.MORThreshold.String())
+	require.Nil(t, cfg.Balance.ETHThreshold)
+
+	cfg, err = ParseConfig([]byte(`{"endpoints":[{"url":"https://example.com/hook","maxRetries":0}]}`))
+	require.NoError(t, err)
+	require.Equal(t, 0, cfg.Endpoints[0].MaxRetries)
+
+	_, err = ParseConfig([]byte(`{"endpoints":[{"url":"ftp://example.com"}]}`))
+	require.ErrorIs(t, err, ErrInvalidConfig)
+
+	_, err = ParseConfig([]byte(`{"endpoints":[{"url":"https://example.com/hook","maxRetries":-1}]}`))
+	require.ErrorIs(t, err, ErrInvalidConfig)
+}
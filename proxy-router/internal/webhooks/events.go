@@ -0,0 +1,99 @@
+package webhooks
+
+import (
+	"strings"
+	"time"
+)
+
+type EventType string
+
+const (
+	EventSessionOpened       EventType = "session.opened"
+	EventSessionClosed       EventType = "session.closed"
+	EventSessionCloseFailed  EventType = "session.close_failed"
+	EventSessionRenewed      EventType = "session.renewed"
+	EventSessionRenewFailed  EventType = "session.renew_failed"
+	EventClaimFailed         EventType = "claim.failed"
+	EventBalanceLow          EventType = "balance.low"
+	EventBidPosted           EventType = "bid.posted"
+	EventBidDeleted          EventType = "bid.deleted"
+	EventFailoverTriggered   EventType = "failover.triggered"
+	EventProviderUnreachable EventType = "provider.unreachable"
+	EventConfigReloaded      EventType = "config.reloaded"
+	EventTest                EventType = "test"
+)
+
+// Event is the payload posted to the webhook endpoints
+type Event struct {
+	ID        string      `json:"id"`
+	Type      EventType   `json:"type"`
+	Timestamp time.Time   `json:"timestamp"`
+	Data      interface{} `json:"data"`
+}
+
+// matchEvent checks the event type against the filter, empty filter matches all events.
+// Filter entries can end with "*" to match a prefix, e.g. "session.*"
+func matchEvent(filter []string, eventType EventType) bool {
+	if len(filter) == 0 {
+		return true
+	}
+	for _, pattern := range filter {
+		if pattern == "*" || pattern == string(eventType) {
+			return true
+		}
+		if prefix, ok := strings.CutSuffix(pattern, "*"); ok && strings.HasPrefix(string(eventType), prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+type SessionEventData struct {
+	SessionID string `json:"sessionId"`
+	User      string `json:"user"`
+	Provider  string `json:"provider"`
+	ModelID   string `json:"modelId,omitempty"`
+	TxHash    string `json:"txHash,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
+
+// SessionRenewedEventData describes the renewal of a virtual session, NewSessionID is empty if it failed
+type SessionRenewedEventData struct {
+	VirtualSessionID string `json:"virtualSessionId"`
+	OldSessionID     string `json:"oldSessionId"`
+	NewSessionID     string `json:"newSessionId,omitempty"`
+	Error            string `json:"error,omitempty"`
+}
+
+type BalanceEventData struct {
+	Address   string `json:"address"`
+	Token     string `json:"token"`
+	Balance   string `json:"balance"`
+	Threshold string `json:"threshold"`
+}
+
+type BidEventData struct {
+	BidID          string `json:"bidId"`
+	ModelID        string `json:"modelId,omitempty"`
+	Provider       string `json:"provider,omitempty"`
+	PricePerSecond string `json:"pricePerSecond,omitempty"`
+	TxHash         string `json:"txHash,omitempty"`
+}
+
+// FailoverEventData describes the failed session, the replacement session emits its own session.opened event
+type FailoverEventData struct {
+	SessionID string `json:"sessionId"`
+	ModelID   string `json:"modelId"`
+	Provider  string `json:"provider"`
+	Reason    string `json:"reason"`
+}
+
+type ProviderEventData struct {
+	Provider string `json:"provider"`
+	Endpoint string `json:"endpoint"`
+	Reason   string `json:"reason"`
+}
+
+type ConfigEventData struct {
+	Section string `json:"section"`
+}
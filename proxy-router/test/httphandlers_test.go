@@ -52,7 +52,7 @@ func TestChat(t *testing.T) {
 	log := lib.NewTestLogger()
 
 	// Create a new instance of the HTTPHandler.
-	handler := httphandlers.CreateHTTPServer(log, apiBus)
+	handler := httphandlers.CreateHTTPServer(log, nil, nil, apiBus)
 
 	server := httptest.NewServer(handler)
 	defer server.Close()